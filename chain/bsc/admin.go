@@ -0,0 +1,158 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bsc
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/common/admin"
+	"github.com/icon-project/btp/common/errors"
+)
+
+var _ admin.Link = (*SimpleChain)(nil)
+var _ admin.FeeReporter = (*SimpleChain)(nil)
+
+// isPaused returns true if it's paused by admin or low balance of the relay account.
+func (s *SimpleChain) isPaused() bool {
+	return atomic.LoadInt32(&s.paused) == 1 || s.fa.IsLow()
+}
+
+func isEmpty(rm *chain.RelayMessage) bool {
+	return len(rm.BlockUpdates) == 0 && len(rm.ReceiptProofs) == 0
+}
+
+func (s *SimpleChain) requestRelay() {
+	if s.relayCh != nil {
+		go s.notifyRelay()
+	}
+}
+
+func (s *SimpleChain) Status() (*admin.LinkStatus, error) {
+	s.rmsMtx.RLock()
+	defer s.rmsMtx.RUnlock()
+	ls := &admin.LinkStatus{
+		Src:         s.src.String(),
+		Dst:         s.dst.String(),
+		Paused:      s.isPaused(),
+		Quarantined: len(s.qrms),
+		Balance:     s.fa.Balance(),
+		LowBalance:  s.fa.IsLow(),
+	}
+	for _, rm := range s.rms {
+		if !isEmpty(rm) {
+			ls.Queued++
+		}
+	}
+	if bs := s.status(); bs != nil {
+		ls.TxSeq = bs.TxSeq.String()
+		ls.RxSeq = bs.RxSeq.String()
+		ls.VerifierHeight = bs.Verifier.Height
+		ls.CurrentHeight = bs.CurrentHeight
+	}
+	return ls, nil
+}
+
+func (s *SimpleChain) RelayMessages() ([]*admin.RelayMessage, error) {
+	s.rmsMtx.RLock()
+	defer s.rmsMtx.RUnlock()
+	l := make([]*admin.RelayMessage, 0, len(s.rms))
+	for _, rm := range s.rms {
+		if isEmpty(rm) {
+			continue
+		}
+		arm := &admin.RelayMessage{
+			ID:       strconv.FormatUint(rm.Seq, 10),
+			Segments: make([]*admin.Segment, 0, len(rm.Segments)),
+		}
+		if len(rm.BlockUpdates) > 0 {
+			arm.Height = rm.BlockUpdates[len(rm.BlockUpdates)-1].Height
+		}
+		for _, segment := range rm.Segments {
+			if segment == nil {
+				continue
+			}
+			as := &admin.Segment{
+				Height:        segment.Height,
+				NumberOfEvent: segment.NumberOfEvent,
+			}
+			if segment.EventSequence != nil {
+				as.EventSequence = segment.EventSequence.String()
+			}
+			if p, ok := segment.GetResultParam.(*TransactionHashParam); ok {
+				as.TxHash = p.Hash.Hex()
+			}
+			arm.Segments = append(arm.Segments, as)
+		}
+		l = append(l, arm)
+	}
+	return l, nil
+}
+
+func (s *SimpleChain) Pause() {
+	atomic.StoreInt32(&s.paused, 1)
+	s.l.Infof("paused")
+}
+
+// Resume resumes relaying paused by admin, it's still paused while the balance is low.
+func (s *SimpleChain) Resume() {
+	if atomic.CompareAndSwapInt32(&s.paused, 1, 0) {
+		s.l.Infof("resumed")
+		s.requestRelay()
+	}
+}
+
+func (s *SimpleChain) Fees() (*admin.FeeReport, error) {
+	if s.fa == nil {
+		return nil, errors.InvalidStateError.New("not served")
+	}
+	return s.fa.Report()
+}
+
+func (s *SimpleChain) Refresh() error {
+	if err := s.RefreshStatus(); err != nil {
+		return err
+	}
+	bs := s.status()
+	if err := s.updateRelayMessage(bs.Verifier.Height, bs.RxSeq.Int64()); err != nil {
+		return err
+	}
+	s.requestRelay()
+	return nil
+}
+
+func (s *SimpleChain) Drop(id string) error {
+	v, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return errors.IllegalArgumentError.Errorf("invalid id:%s", id)
+	}
+	s.rmsMtx.Lock()
+	defer s.rmsMtx.Unlock()
+	for i, rm := range s.rms {
+		if rm.Seq == v && !isEmpty(rm) {
+			s.rms = append(s.rms[:i], s.rms[i+1:]...)
+			if len(s.rms) == 0 {
+				s._rm()
+			}
+			s.m.Queued.Set(float64(len(s.rms)))
+			s.l.Warnf("drop rm:%d", rm.Seq)
+			return nil
+		}
+	}
+	return errors.NotFoundError.Errorf("not found relay message id:%s", id)
+}
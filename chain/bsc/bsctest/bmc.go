@@ -0,0 +1,326 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bsctest
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/icon-project/btp/chain/bsc/binding"
+)
+
+const (
+	BMCRelayMethod       = "handleRelayMessage"
+	BMCGetStatusMethod   = "getStatus"
+	BMCSendMessageMethod = "sendMessage"
+	BMCGetRoutesMethod   = "getRoutes"
+	BMCGetLinksMethod    = "getLinks"
+	BMCMessageEvent      = "Message"
+)
+
+// revert codes of BMC and BMV, refer chain/bsc/error.go
+const (
+	BMCRevert              = 10
+	BMCRevertUnauthorized  = 11
+	BMCRevertNotExistsLink = 18
+	BMVUnknown             = 25
+	BMVNotVerifiable       = 26
+	BMVAlreadyVerified     = 27
+)
+
+var (
+	bmcABI        = mustParseABI(binding.BMCABI)
+	bmcmABI       = mustParseABI(binding.BMCManagementABI)
+	revertReasons = map[int]string{
+		BMCRevert:              "bmc: Revert",
+		BMCRevertUnauthorized:  "bmc: Unauthorized",
+		BMCRevertNotExistsLink: "bmc: NotExistsLink",
+		BMVUnknown:             "bmv: Unknown",
+		BMVNotVerifiable:       "bmv: NotVerifiable",
+		BMVAlreadyVerified:     "bmv: AlreadyVerified",
+	}
+)
+
+func mustParseABI(s string) abi.ABI {
+	a, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// RevertReason returns the revert message of the code in the same format with BMC and BMV of solidity,
+// the code follows the last '|'.
+func RevertReason(code int) string {
+	name, ok := revertReasons[code]
+	if !ok {
+		name = "Revert"
+	}
+	return fmt.Sprintf("%s|%d", name, code)
+}
+
+// Link is the status of BMC for the link.
+type Link struct {
+	RxSeq    int64
+	TxSeq    int64
+	Height   int64
+	Extra    []byte
+	Messages [][]byte
+}
+
+func (l *Link) clone() *Link {
+	nl := *l
+	nl.Extra = append([]byte{}, l.Extra...)
+	nl.Messages = append([][]byte{}, l.Messages...)
+	return &nl
+}
+
+// RelayHandler handles the relay message from the previous BMC, it returns the revert code or zero on success.
+// changes of l are applied only if it returns zero.
+type RelayHandler func(l *Link, msg []byte) int
+
+// AcceptRelayMessage accepts every relay message without changing status.
+func AcceptRelayMessage(l *Link, msg []byte) int {
+	return 0
+}
+
+// AddLink adds the link of previous BMC with BMV which is initialized at the height.
+func (n *Node) AddLink(prev string, height int64) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.links[prev] = &Link{Height: height}
+}
+
+// AddRoute adds the route to the destination via the next BMC, it's returned by getRoutes of BMCManagement
+// which is served at the address of BMC.
+func (n *Node) AddRoute(dst, next string) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.routes[dst] = next
+}
+
+// Link returns the copy of status of the link, it returns nil if there is no link.
+func (n *Node) Link(link string) *Link {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if l, ok := n.links[link]; ok {
+		return l.clone()
+	}
+	return nil
+}
+
+// Messages returns relay messages accepted from the previous BMC.
+func (n *Node) Messages(prev string) [][]byte {
+	if l := n.Link(prev); l != nil {
+		return l.Messages
+	}
+	return nil
+}
+
+// SetRelayHandler sets the handler of handleRelayMessage, the default is AcceptRelayMessage.
+func (n *Node) SetRelayHandler(h RelayHandler) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.handler = h
+}
+
+// SendMessages adds the block which has a transaction for each message,
+// each transaction emits Message event for the next with the sequence of the link.
+// it returns the height of the block.
+func (n *Node) SendMessages(next string, msgs ...[]byte) int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	signer := types.LatestSignerForChainID(n.chainID)
+	from := crypto.PubkeyToAddress(n.key.PublicKey)
+	txs := make(types.Transactions, 0, len(msgs))
+	receipts := make(types.Receipts, 0, len(msgs))
+	for i, msg := range msgs {
+		data, err := bmcABI.Pack(BMCSendMessageMethod, next, "bsctest", big.NewInt(int64(i)), msg)
+		if err != nil {
+			panic(err)
+		}
+		tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    n.nonces[from],
+			To:       &n.bmc,
+			Gas:      DefaultGasLimit,
+			GasPrice: big.NewInt(DefaultGasPrice),
+			Data:     data,
+		}), signer, n.key)
+		if err != nil {
+			panic(err)
+		}
+		n.nonces[from]++
+		n.txs[tx.Hash()] = tx
+		txs = append(txs, tx)
+		receipts = append(receipts, n._execute(tx, from))
+	}
+	return n._newBlock(txs, receipts)
+}
+
+// intrinsicGas returns the gas for the transaction data without execution.
+func intrinsicGas(data []byte) uint64 {
+	gas := uint64(21000)
+	for _, b := range data {
+		if b == 0 {
+			gas += 4
+		} else {
+			gas += 16
+		}
+	}
+	return gas
+}
+
+// _execute runs the transaction and returns the receipt, the block of receipt is not decided. mtx should be locked.
+func (n *Node) _execute(tx *types.Transaction, from common.Address) *types.Receipt {
+	r := &types.Receipt{
+		Type:    tx.Type(),
+		Status:  types.ReceiptStatusSuccessful,
+		TxHash:  tx.Hash(),
+		GasUsed: intrinsicGas(tx.Data()),
+		Logs:    []*types.Log{},
+	}
+	if to := tx.To(); to == nil || *to != n.bmc {
+		return r
+	}
+	if logs, _, code := n._callBMC(tx.Data(), true); code != 0 {
+		r.Status = types.ReceiptStatusFailed
+		n.reverted[string(tx.Data())] = code
+	} else {
+		r.Logs = append(r.Logs, logs...)
+		delete(n.reverted, string(tx.Data()))
+	}
+	return r
+}
+
+// _callBMC runs BMC method, the changes are applied only if commit is true.
+// it returns event logs, output and the revert code. mtx should be locked.
+func (n *Node) _callBMC(data []byte, commit bool) ([]*types.Log, []byte, int) {
+	if len(data) < 4 {
+		return nil, nil, BMCRevert
+	}
+	method, err := bmcABI.MethodById(data[:4])
+	if err != nil {
+		if method, err = bmcmABI.MethodById(data[:4]); err != nil {
+			return nil, nil, BMCRevert
+		}
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, nil, BMCRevert
+	}
+	if !commit {
+		if code, ok := n.reverted[string(data)]; ok {
+			return nil, nil, code
+		}
+	} else {
+		n.calls[method.Name]++
+	}
+	switch method.Name {
+	case BMCRelayMethod:
+		if commit && len(n.reverts) > 0 {
+			code := n.reverts[0]
+			n.reverts = n.reverts[1:]
+			return nil, nil, code
+		}
+		prev, msg := args[0].(string), args[1].([]byte)
+		l, ok := n.links[prev]
+		if !ok {
+			return nil, nil, BMCRevertNotExistsLink
+		}
+		nl := l.clone()
+		if code := n.handler(nl, msg); code != 0 {
+			return nil, nil, code
+		}
+		if commit {
+			nl.Messages = append(nl.Messages, msg)
+			n.links[prev] = nl
+		}
+		return nil, nil, 0
+	case BMCSendMessageMethod:
+		next, msg := args[0].(string), args[3].([]byte)
+		l, ok := n.links[next]
+		if !ok {
+			l = &Link{}
+		}
+		seq := l.TxSeq + 1
+		evt := bmcABI.Events[BMCMessageEvent]
+		b, err := evt.Inputs.NonIndexed().Pack(next, big.NewInt(seq), msg)
+		if err != nil {
+			return nil, nil, BMCRevert
+		}
+		if commit {
+			l.TxSeq = seq
+			n.links[next] = l
+		}
+		return []*types.Log{{
+			Address: n.bmc,
+			Topics:  []common.Hash{evt.ID},
+			Data:    b,
+		}}, nil, 0
+	case BMCGetStatusMethod:
+		l, ok := n.links[args[0].(string)]
+		if !ok {
+			return nil, nil, BMCRevertNotExistsLink
+		}
+		b, err := method.Outputs.Pack(binding.TypesLinkStats{
+			RxSeq: big.NewInt(l.RxSeq),
+			TxSeq: big.NewInt(l.TxSeq),
+			Verifier: binding.TypesVerifierStats{
+				Height: big.NewInt(l.Height),
+				Extra:  l.Extra,
+			},
+			CurrentHeight: big.NewInt(int64(len(n.blocks) - 1)),
+		})
+		if err != nil {
+			return nil, nil, BMCRevert
+		}
+		return nil, b, 0
+	case BMCGetLinksMethod:
+		links := make([]string, 0, len(n.links))
+		for l := range n.links {
+			links = append(links, l)
+		}
+		sort.Strings(links)
+		b, err := method.Outputs.Pack(links)
+		if err != nil {
+			return nil, nil, BMCRevert
+		}
+		return nil, b, 0
+	case BMCGetRoutesMethod:
+		routes := make([]binding.TypesRoute, 0, len(n.routes))
+		for dst, next := range n.routes {
+			routes = append(routes, binding.TypesRoute{Dst: dst, Next: next})
+		}
+		sort.Slice(routes, func(i, j int) bool {
+			return routes[i].Dst < routes[j].Dst
+		})
+		b, err := method.Outputs.Pack(routes)
+		if err != nil {
+			return nil, nil, BMCRevert
+		}
+		return nil, b, 0
+	default:
+		return nil, nil, BMCRevert
+	}
+}
@@ -0,0 +1,382 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package bsctest provides in-process EVM node which serves JSON-RPC with BMC mock,
+// it's used by tests of chain/bsc and evmbridge instead of the real network.
+package bsctest
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	DefaultChainID    = 97
+	DefaultBMCAddress = "0x0000000000000000000000000000000000000b7c"
+	DefaultGasPrice   = 10000000000
+	DefaultGasLimit   = 30000000
+	DefaultBlockTime  = 3
+	// DefaultBalance is the balance of accounts in ether, the fee of each transaction is charged from it
+	DefaultBalance = 1000000
+	// ExtraSealLength is length of the signature in extra-data of header, BSC(parlia) requires it
+	ExtraSealLength   = 65
+	ExtraVanityLength = 32
+)
+
+type Config struct {
+	ChainID    int64
+	BMCAddress string
+}
+
+type block struct {
+	b        *types.Block
+	receipts types.Receipts
+}
+
+// Node is EVM node which executes each transaction in the new block right after it's received,
+// the contract at BMCAddress works as BMC.
+type Node struct {
+	cfg     Config
+	chainID *big.Int
+	bmc     common.Address
+	key     *ecdsa.PrivateKey
+	srv     *httptest.Server
+	rpc     *rpc.Server
+	ws      http.Handler
+
+	mtx      sync.Mutex
+	blocks   []*block
+	txs      map[common.Hash]*types.Transaction
+	nonces   map[common.Address]uint64
+	balances map[common.Address]*big.Int
+	notify   chan struct{}
+	calls    map[string]int
+	errs     map[string][]error
+	reverts  []int
+	pendings int
+	holds    int
+	held     map[common.Hash]bool
+	pool     map[common.Address]map[uint64]*types.Transaction
+	reverted map[string]int
+	links    map[string]*Link
+	routes   map[string]string
+	handler  RelayHandler
+}
+
+// NewNode starts the node with genesis block.
+func NewNode(cfg Config) *Node {
+	if cfg.ChainID == 0 {
+		cfg.ChainID = DefaultChainID
+	}
+	if cfg.BMCAddress == "" {
+		cfg.BMCAddress = DefaultBMCAddress
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	n := &Node{
+		cfg:      cfg,
+		chainID:  big.NewInt(cfg.ChainID),
+		bmc:      common.HexToAddress(cfg.BMCAddress),
+		key:      key,
+		rpc:      rpc.NewServer(),
+		txs:      make(map[common.Hash]*types.Transaction),
+		nonces:   make(map[common.Address]uint64),
+		balances: make(map[common.Address]*big.Int),
+		notify:   make(chan struct{}),
+		calls:    make(map[string]int),
+		errs:     make(map[string][]error),
+		held:     make(map[common.Hash]bool),
+		pool:     make(map[common.Address]map[uint64]*types.Transaction),
+		reverted: make(map[string]int),
+		links:    make(map[string]*Link),
+		routes:   make(map[string]string),
+		handler:  AcceptRelayMessage,
+	}
+	if err = n.rpc.RegisterName("eth", &ethAPI{n: n}); err != nil {
+		panic(err)
+	}
+	n.ws = n.rpc.WebsocketHandler([]string{"*"})
+	n._newBlock(nil, nil)
+	n.srv = httptest.NewServer(n)
+	return n
+}
+
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		n.ws.ServeHTTP(w, r)
+		return
+	}
+	n.rpc.ServeHTTP(w, r)
+}
+
+// URL returns the endpoint for JSON-RPC over http.
+func (n *Node) URL() string {
+	return n.srv.URL
+}
+
+// WSURL returns the endpoint for JSON-RPC over websocket, it supports eth_subscribe of newHeads.
+func (n *Node) WSURL() string {
+	return "ws" + strings.TrimPrefix(n.srv.URL, "http")
+}
+
+func (n *Node) Close() {
+	n.rpc.Stop()
+	n.srv.CloseClientConnections()
+	n.srv.Close()
+}
+
+func (n *Node) ChainID() *big.Int {
+	return new(big.Int).Set(n.chainID)
+}
+
+func (n *Node) BMCAddress() common.Address {
+	return n.bmc
+}
+
+func (n *Node) Height() int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return int64(len(n.blocks) - 1)
+}
+
+// Block returns the block at the height, it returns nil if there is no block.
+func (n *Node) Block(height int64) *types.Block {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if bl := n._block(height); bl != nil {
+		return bl.b
+	}
+	return nil
+}
+
+// Receipts returns receipts of the block at the height.
+func (n *Node) Receipts(height int64) types.Receipts {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if bl := n._block(height); bl != nil {
+		return append(types.Receipts{}, bl.receipts...)
+	}
+	return nil
+}
+
+// AddBlock adds the empty block and returns the height of it.
+func (n *Node) AddBlock() int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n._newBlock(nil, nil)
+}
+
+// InjectError makes the next call of JSON-RPC method fails with the error code.
+func (n *Node) InjectError(method string, code int, msg string) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.errs[method] = append(n.errs[method], &rpcError{code: code, msg: msg})
+}
+
+// InjectRevert makes the next transaction to BMC reverts with the code.
+func (n *Node) InjectRevert(code int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.reverts = append(n.reverts, code)
+}
+
+// InjectPending makes the next count of eth_getTransactionByHash return the transaction as pending.
+func (n *Node) InjectPending(count int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.pendings += count
+}
+
+// _balance returns the balance of the account, mtx should be locked.
+func (n *Node) _balance(addr common.Address) *big.Int {
+	if v, ok := n.balances[addr]; ok {
+		return v
+	}
+	return new(big.Int).Mul(big.NewInt(DefaultBalance), big.NewInt(1e18))
+}
+
+// SetBalance sets the balance of the account in wei.
+func (n *Node) SetBalance(addr common.Address, v *big.Int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.balances[addr] = new(big.Int).Set(v)
+}
+
+// Balance returns the balance of the account in wei.
+func (n *Node) Balance(addr common.Address) *big.Int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return new(big.Int).Set(n._balance(addr))
+}
+
+// HoldTransactions makes the next count of transactions stay in the pool without mining,
+// following transactions of the sender are queued until it's mined.
+// the held transaction is replaced by the transaction with the same nonce and higher gas price.
+func (n *Node) HoldTransactions(count int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.holds += count
+}
+
+// MinePending mines the transactions in the pool including held ones in a block,
+// it returns the height of the block.
+func (n *Node) MinePending() int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	var txs types.Transactions
+	var receipts types.Receipts
+	for from := range n.pool {
+		ptxs, prs := n._promote(from, true)
+		txs = append(txs, ptxs...)
+		receipts = append(receipts, prs...)
+	}
+	return n._newBlock(txs, receipts)
+}
+
+// DropPending removes the held transactions from the pool as if they were evicted,
+// it returns the number of dropped transactions.
+func (n *Node) DropPending() int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	dropped := 0
+	for from, q := range n.pool {
+		for nonce, tx := range q {
+			if n.held[tx.Hash()] {
+				delete(q, nonce)
+				delete(n.held, tx.Hash())
+				delete(n.txs, tx.Hash())
+				dropped++
+			}
+		}
+		if len(q) == 0 {
+			delete(n.pool, from)
+		}
+	}
+	return dropped
+}
+
+// _promote executes the transactions of the sender in the pool in order of nonce until the gap,
+// held transaction stops it unless force is true. mtx should be locked.
+func (n *Node) _promote(from common.Address, force bool) (types.Transactions, types.Receipts) {
+	var txs types.Transactions
+	var receipts types.Receipts
+	q := n.pool[from]
+	for {
+		tx, ok := q[n.nonces[from]]
+		if !ok || (!force && n.held[tx.Hash()]) {
+			break
+		}
+		delete(q, tx.Nonce())
+		delete(n.held, tx.Hash())
+		n.nonces[from]++
+		txs = append(txs, tx)
+		r := n._execute(tx, from)
+		fee := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(r.GasUsed))
+		n.balances[from] = fee.Sub(n._balance(from), fee)
+		receipts = append(receipts, r)
+	}
+	if len(q) == 0 {
+		delete(n.pool, from)
+	}
+	return txs, receipts
+}
+
+// Calls returns the number of calls of JSON-RPC method or BMC method.
+func (n *Node) Calls(method string) int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.calls[method]
+}
+
+// _enter counts the call, and returns the injected error. mtx should be locked.
+func (n *Node) _enter(method string) error {
+	n.calls[method]++
+	if errs := n.errs[method]; len(errs) > 0 {
+		n.errs[method] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+func (n *Node) _block(height int64) *block {
+	if height < 0 || height >= int64(len(n.blocks)) {
+		return nil
+	}
+	return n.blocks[height]
+}
+
+func (n *Node) _blockByHash(hash common.Hash) *block {
+	for _, bl := range n.blocks {
+		if bl.b.Hash() == hash {
+			return bl
+		}
+	}
+	return nil
+}
+
+// _newBlock makes the block with transactions and receipts, then notifies it. mtx should be locked.
+func (n *Node) _newBlock(txs types.Transactions, receipts types.Receipts) int64 {
+	height := int64(len(n.blocks))
+	h := &types.Header{
+		Number:     big.NewInt(height),
+		Difficulty: big.NewInt(2),
+		GasLimit:   DefaultGasLimit,
+		Time:       uint64(height * DefaultBlockTime),
+		Root:       crypto.Keccak256Hash(big.NewInt(height).Bytes()),
+		Extra:      make([]byte, ExtraVanityLength+ExtraSealLength),
+	}
+	if height > 0 {
+		h.ParentHash = n.blocks[height-1].b.Hash()
+	}
+	var logIndex uint
+	for _, r := range receipts {
+		h.GasUsed += r.GasUsed
+		r.CumulativeGasUsed = h.GasUsed
+		for _, l := range r.Logs {
+			l.Index = logIndex
+			logIndex++
+		}
+		r.Bloom = types.CreateBloom(types.Receipts{r})
+	}
+	b := types.NewBlock(h, txs, nil, receipts, trie.NewStackTrie(nil))
+	for i, r := range receipts {
+		r.BlockHash = b.Hash()
+		r.BlockNumber = b.Number()
+		r.TransactionIndex = uint(i)
+		for _, l := range r.Logs {
+			l.BlockHash = r.BlockHash
+			l.BlockNumber = uint64(height)
+			l.TxHash = r.TxHash
+			l.TxIndex = r.TransactionIndex
+		}
+	}
+	n.blocks = append(n.blocks, &block{b: b, receipts: receipts})
+	close(n.notify)
+	n.notify = make(chan struct{})
+	return height
+}
@@ -0,0 +1,513 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bsctest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	ErrorCodeServer   = -32000
+	ErrorCodeReverted = 3
+)
+
+// priceBump is the minimum percentage of gas price increase to replace the transaction in the pool
+const priceBump = 10
+
+var (
+	revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	// code of contract is not used for execution, it's only for checking existence.
+	bmcCode = []byte{0x60, 0x80, 0x60, 0x40, 0x52}
+)
+
+// rpcError implements rpc.Error and rpc.DataError.
+type rpcError struct {
+	code int
+	msg  string
+	data interface{}
+}
+
+func (e *rpcError) Error() string {
+	return e.msg
+}
+
+func (e *rpcError) ErrorCode() int {
+	return e.code
+}
+
+func (e *rpcError) ErrorData() interface{} {
+	return e.data
+}
+
+// newRevertError returns the error as same as go-ethereum for the reverted call.
+func newRevertError(code int) *rpcError {
+	reason := RevertReason(code)
+	b, _ := abi.Arguments{{Type: mustNewType("string")}}.Pack(reason)
+	return &rpcError{
+		code: ErrorCodeReverted,
+		msg:  "execution reverted: " + reason,
+		data: hexutil.Encode(append(append([]byte{}, revertSelector...), b...)),
+	}
+}
+
+func mustNewType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+type callArgs struct {
+	From     *common.Address `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *hexutil.Uint64 `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     *hexutil.Bytes  `json:"data"`
+}
+
+func (a *callArgs) data() []byte {
+	if a.Data == nil {
+		return nil
+	}
+	return *a.Data
+}
+
+type filterArgs struct {
+	BlockHash *common.Hash     `json:"blockHash"`
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+	Addresses []common.Address `json:"address"`
+	Topics    [][]common.Hash  `json:"topics"`
+}
+
+func (f *filterArgs) match(l *types.Log) bool {
+	if len(f.Addresses) > 0 {
+		found := false
+		for _, addr := range f.Addresses {
+			if addr == l.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Topics) > len(l.Topics) {
+		return false
+	}
+	for i, topics := range f.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range topics {
+			if topic == l.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ethAPI serves methods of 'eth' namespace which are used by go-ethereum ethclient and bind.
+type ethAPI struct {
+	n *Node
+}
+
+func (api *ethAPI) ChainId() (*hexutil.Big, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_chainId"); err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(n.chainID), nil
+}
+
+func (api *ethAPI) BlockNumber() (hexutil.Uint64, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(len(n.blocks) - 1), nil
+}
+
+func (api *ethAPI) GasPrice() (*hexutil.Big, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(big.NewInt(DefaultGasPrice)), nil
+}
+
+func (api *ethAPI) GetBalance(addr common.Address, _ rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_getBalance"); err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(new(big.Int).Set(n._balance(addr))), nil
+}
+
+// GetTransactionCount returns the nonce of mined transactions,
+// executable transactions in the pool are counted for pending.
+func (api *ethAPI) GetTransactionCount(addr common.Address, bn rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_getTransactionCount"); err != nil {
+		return 0, err
+	}
+	nonce := n.nonces[addr]
+	if number, ok := bn.Number(); ok && number == rpc.PendingBlockNumber {
+		for q := n.pool[addr]; q[nonce] != nil; nonce++ {
+		}
+	}
+	return hexutil.Uint64(nonce), nil
+}
+
+func (api *ethAPI) GetCode(addr common.Address, _ rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_getCode"); err != nil {
+		return nil, err
+	}
+	if addr == n.bmc {
+		return bmcCode, nil
+	}
+	return hexutil.Bytes{}, nil
+}
+
+// _blockByNumber returns the block, latest one for the negative number. mtx should be locked.
+func (n *Node) _blockByNumber(number rpc.BlockNumber) *block {
+	if number < 0 {
+		return n.blocks[len(n.blocks)-1]
+	}
+	return n._block(number.Int64())
+}
+
+func (api *ethAPI) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_getBlockByNumber"); err != nil {
+		return nil, err
+	}
+	return marshalBlock(n._blockByNumber(number), fullTx)
+}
+
+func (api *ethAPI) GetBlockByHash(hash common.Hash, fullTx bool) (map[string]interface{}, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_getBlockByHash"); err != nil {
+		return nil, err
+	}
+	return marshalBlock(n._blockByHash(hash), fullTx)
+}
+
+func (api *ethAPI) GetTransactionByHash(hash common.Hash) (map[string]interface{}, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_getTransactionByHash"); err != nil {
+		return nil, err
+	}
+	tx, ok := n.txs[hash]
+	if !ok {
+		return nil, nil
+	}
+	bl, idx := n._blockOfTransaction(hash)
+	if n.pendings > 0 {
+		n.pendings--
+		bl = nil
+	}
+	return marshalTransaction(tx, bl, idx)
+}
+
+func (api *ethAPI) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_getTransactionReceipt"); err != nil {
+		return nil, err
+	}
+	if bl, idx := n._blockOfTransaction(hash); bl != nil {
+		return bl.receipts[idx], nil
+	}
+	return nil, nil
+}
+
+func (api *ethAPI) SendRawTransaction(input hexutil.Bytes) (common.Hash, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_sendRawTransaction"); err != nil {
+		return common.Hash{}, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, &rpcError{code: ErrorCodeServer, msg: err.Error()}
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(n.chainID), tx)
+	if err != nil {
+		return common.Hash{}, &rpcError{code: ErrorCodeServer, msg: fmt.Sprintf("invalid sender: %v", err)}
+	}
+	if _, ok := n.txs[tx.Hash()]; ok {
+		return common.Hash{}, &rpcError{code: ErrorCodeServer, msg: "already known"}
+	}
+	if tx.Nonce() < n.nonces[from] {
+		return common.Hash{}, &rpcError{code: ErrorCodeServer, msg: "nonce too low"}
+	}
+	if gas := intrinsicGas(tx.Data()); tx.Gas() < gas {
+		return common.Hash{}, &rpcError{code: ErrorCodeServer, msg: "intrinsic gas too low"}
+	}
+	q, ok := n.pool[from]
+	if !ok {
+		q = make(map[uint64]*types.Transaction)
+		n.pool[from] = q
+	}
+	if old, ok := q[tx.Nonce()]; ok {
+		min := new(big.Int).Mul(old.GasPrice(), big.NewInt(100+priceBump))
+		if min.Div(min, big.NewInt(100)); tx.GasPrice().Cmp(min) < 0 {
+			return common.Hash{}, &rpcError{code: ErrorCodeServer, msg: "replacement transaction underpriced"}
+		}
+		delete(n.txs, old.Hash())
+		delete(n.held, old.Hash())
+	}
+	q[tx.Nonce()] = tx
+	n.txs[tx.Hash()] = tx
+	if n.holds > 0 {
+		n.holds--
+		n.held[tx.Hash()] = true
+	}
+	if txs, receipts := n._promote(from, false); len(txs) > 0 {
+		n._newBlock(txs, receipts)
+	}
+	return tx.Hash(), nil
+}
+
+func (api *ethAPI) Call(args callArgs, _ rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_call"); err != nil {
+		return nil, err
+	}
+	if args.To == nil || *args.To != n.bmc {
+		return hexutil.Bytes{}, nil
+	}
+	_, out, code := n._callBMC(args.data(), false)
+	if code != 0 {
+		return nil, newRevertError(code)
+	}
+	return out, nil
+}
+
+func (api *ethAPI) EstimateGas(args callArgs) (hexutil.Uint64, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_estimateGas"); err != nil {
+		return 0, err
+	}
+	if args.To != nil && *args.To == n.bmc {
+		if _, _, code := n._callBMC(args.data(), false); code != 0 {
+			return 0, newRevertError(code)
+		}
+	}
+	return hexutil.Uint64(intrinsicGas(args.data())), nil
+}
+
+func (api *ethAPI) GetLogs(f filterArgs) ([]*types.Log, error) {
+	n := api.n
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if err := n._enter("eth_getLogs"); err != nil {
+		return nil, err
+	}
+	var bls []*block
+	if f.BlockHash != nil {
+		if bl := n._blockByHash(*f.BlockHash); bl != nil {
+			bls = append(bls, bl)
+		}
+	} else {
+		from, to := rpc.BlockNumber(0), rpc.LatestBlockNumber
+		if f.FromBlock != nil {
+			from = *f.FromBlock
+		}
+		if f.ToBlock != nil {
+			to = *f.ToBlock
+		}
+		if from < 0 {
+			from = rpc.BlockNumber(len(n.blocks) - 1)
+		}
+		if to < 0 {
+			to = rpc.BlockNumber(len(n.blocks) - 1)
+		}
+		for h := from; h <= to; h++ {
+			if bl := n._block(h.Int64()); bl != nil {
+				bls = append(bls, bl)
+			}
+		}
+	}
+	logs := make([]*types.Log, 0)
+	for _, bl := range bls {
+		for _, r := range bl.receipts {
+			for _, l := range r.Logs {
+				if f.match(l) {
+					logs = append(logs, l)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+// NewHeads notifies headers of new blocks, it's the subscription of 'newHeads'.
+func (api *ethAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	n := api.n
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	n.mtx.Lock()
+	err := n._enter("eth_subscribe")
+	height := len(n.blocks)
+	n.mtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for {
+			n.mtx.Lock()
+			var headers []*types.Header
+			for ; height < len(n.blocks); height++ {
+				headers = append(headers, n.blocks[height].b.Header())
+			}
+			notify := n.notify
+			n.mtx.Unlock()
+			for _, h := range headers {
+				if err := notifier.Notify(sub.ID, h); err != nil {
+					return
+				}
+			}
+			select {
+			case <-notify:
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// _blockOfTransaction returns the block which has the transaction and the index in the block. mtx should be locked.
+func (n *Node) _blockOfTransaction(hash common.Hash) (*block, int) {
+	for i := len(n.blocks) - 1; i >= 0; i-- {
+		for j, tx := range n.blocks[i].b.Transactions() {
+			if tx.Hash() == hash {
+				return n.blocks[i], j
+			}
+		}
+	}
+	return nil, 0
+}
+
+// toFields returns JSON fields of v, so that extra fields could be added.
+func toFields(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// marshalTransaction returns JSON fields of the transaction in the block, bl is nil for pending one.
+func marshalTransaction(tx *types.Transaction, bl *block, idx int) (map[string]interface{}, error) {
+	fields, err := toFields(tx)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.LatestSignerForChainID(tx.ChainId())
+	if from, err := types.Sender(signer, tx); err == nil {
+		fields["from"] = from
+	}
+	if bl != nil {
+		fields["blockHash"] = bl.b.Hash()
+		fields["blockNumber"] = (*hexutil.Big)(bl.b.Number())
+		fields["transactionIndex"] = hexutil.Uint64(idx)
+	}
+	return fields, nil
+}
+
+func marshalBlock(bl *block, fullTx bool) (map[string]interface{}, error) {
+	if bl == nil {
+		return nil, nil
+	}
+	fields, err := toFields(bl.b.Header())
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]interface{}, 0, len(bl.b.Transactions()))
+	for i, tx := range bl.b.Transactions() {
+		if fullTx {
+			v, err := marshalTransaction(tx, bl, i)
+			if err != nil {
+				return nil, err
+			}
+			txs = append(txs, v)
+		} else {
+			txs = append(txs, tx.Hash())
+		}
+	}
+	fields["transactions"] = txs
+	fields["uncles"] = []common.Hash{}
+	fields["size"] = hexutil.Uint64(bl.b.Size())
+	fields["totalDifficulty"] = (*hexutil.Big)(new(big.Int).Mul(bl.b.Number(), bl.b.Difficulty()))
+	return fields, nil
+}
@@ -20,7 +20,6 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
-	"math/big"
 	"path/filepath"
 	"sync"
 	"sync/atomic"
@@ -30,8 +29,12 @@ import (
 	"github.com/icon-project/btp/common/codec"
 	"github.com/icon-project/btp/common/db"
 	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/health"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
 	"github.com/icon-project/btp/common/mta"
+	"github.com/icon-project/btp/common/policy"
+	"github.com/icon-project/btp/common/segment"
 )
 
 const (
@@ -51,15 +54,29 @@ type SimpleChain struct {
 	acc     *mta.ExtAccumulator
 	dst     chain.BtpAddress
 	bs      *chain.BMCLinkStatus //getstatus(dst.src)
+	bsMtx   sync.RWMutex
 	relayCh chan *chain.RelayMessage
 	l       log.Logger
 	cfg     *chain.Config
 
 	rms             []*chain.RelayMessage
+	qrms            []*chain.RelayMessage
 	rmsMtx          sync.RWMutex
 	rmSeq           uint64
 	heightOfDst     int64
 	lastBlockUpdate *chain.BlockUpdate
+	paused          int32
+	pe              *policy.Engine
+	errCh           chan error
+	m               *metrics.LinkMetrics
+	wd              *health.Watchdog
+	restartCh       chan error
+	loopGen         int32
+	database        db.Database
+	stopCh          chan struct{}
+	stopOnce        sync.Once
+	tr              *chain.MessageTracker
+	fa              *chain.FeeAccount
 }
 
 func (s *SimpleChain) _hasWait(rm *chain.RelayMessage) bool {
@@ -93,6 +110,9 @@ func (s *SimpleChain) _log(prefix string, rm *chain.RelayMessage, segment *chain
 }
 
 func (s *SimpleChain) _relay() {
+	if s.isPaused() {
+		return
+	}
 	s.rmsMtx.RLock()
 	defer s.rmsMtx.RUnlock()
 	var err error
@@ -101,7 +121,7 @@ func (s *SimpleChain) _relay() {
 			break
 		} else {
 			if len(rm.Segments) == 0 {
-				if rm.Segments, err = s.Segment(rm, s.bs.Verifier.Height); err != nil {
+				if rm.Segments, err = s.Segment(rm, s.status().Verifier.Height); err != nil {
 					s.l.Panicf("fail to segment err:%+v", err)
 				}
 			}
@@ -114,11 +134,20 @@ func (s *SimpleChain) _relay() {
 				reSegment = false
 
 				if segment.GetResultParam == nil {
+					if !s.verify(rm, segment) {
+						return
+					}
 					segment.TransactionResult = nil
 					if segment.GetResultParam, err = s.s.Relay(segment); err != nil {
-						s.l.Panicf("fail to Relay err:%+v", err)
+						s.l.Debugf("fail to Relay err:%+v", err)
+						d := s.pe.Decide(rm.Seq, err)
+						s.m.Failed(d.Code)
+						//handle locks rmsMtx for some actions
+						go s.handle(rm, segment, d)
+						return
 					}
 					s._log("after relay", rm, segment, j)
+					s.m.Sent.Inc()
 					go s.result(rm, segment)
 				}
 			}
@@ -133,136 +162,58 @@ func (s *SimpleChain) isOverLimit(size int) bool {
 	return s.s.TxSizeLimit() < size
 }
 
+// strategy returns Strategy of segmentation, RelayMessage is segmented entirely at once,
+// so only the limit of events is applied.
+func (s *SimpleChain) strategy() segment.Strategy {
+	if s.cfg == nil {
+		return segment.Greedy
+	}
+	return s.cfg.Strategy()
+}
+
 func (s *SimpleChain) Segment(rm *chain.RelayMessage, height int64) ([]*chain.Segment, error) {
-	segments := make([]*chain.Segment, 0)
-	var err error
-	msg := &RelayMessage{
-		BlockUpdates:  make([][]byte, 0),
-		ReceiptProofs: make([][]byte, 0),
+	bp, err := codec.RLP.MarshalToBytes(rm.BlockProof)
+	if err != nil {
+		return nil, err
+	}
+	if s.isOverLimit(len(bp)) {
+		return nil, fmt.Errorf("invalid BlockProof size")
 	}
-	size := 0
+	p := newRelayMessagePacker(bp, rm.BlockProof.BlockWitness.Height)
+	sg := segment.New(p, func(size int) bool { return !s.isOverLimit(size) }, s.strategy())
 	//TODO rm.BlockUpdates[len(rm.BlockUpdates)-1].Height <= s.bmcStatus.Verifier.Height
 	//	using only rm.BlockProof
 	for _, bu := range rm.BlockUpdates {
 		if bu.Height <= height {
 			continue
 		}
-		buSize := len(bu.Proof)
-		if s.isOverLimit(buSize) {
+		if s.isOverLimit(len(bu.Proof)) {
 			return nil, fmt.Errorf("invalid BlockUpdate.StorageProof size")
 		}
-		size += buSize
-		if s.isOverLimit(size) {
-			segment := &chain.Segment{
-				Height:              msg.GetHeight(),
-				NumberOfBlockUpdate: msg.GetNumberOfBlockUpdate(),
-			}
-			b, err := codec.RLP.MarshalToBytes(msg)
-			if err != nil {
-				return nil, err
-			}
-			segment.TransactionParam = b
-
-			segments = append(segments, segment)
-			msg = &RelayMessage{
-				BlockUpdates:  make([][]byte, 0),
-				ReceiptProofs: make([][]byte, 0),
-			}
-			size = buSize
+		if err = sg.Add(&blockUpdateItem{bu: bu}); err != nil {
+			return nil, err
 		}
-		msg.BlockUpdates = append(msg.BlockUpdates, bu.Proof)
-		msg.SetHeight(bu.Height)
-		msg.SetNumberOfBlockUpdate(msg.GetNumberOfBlockUpdate() + 1)
-	}
-
-	var bp []byte
-	if bp, err = codec.RLP.MarshalToBytes(rm.BlockProof); err != nil {
-		return nil, err
-	}
-	if s.isOverLimit(len(bp)) {
-		return nil, fmt.Errorf("invalid BlockProof size")
 	}
-
-	var b []byte
 	for _, rp := range rm.ReceiptProofs {
 		if s.isOverLimit(len(rp.Proof)) {
 			return nil, fmt.Errorf("invalid ReceiptProof.Proof size")
 		}
-		if len(msg.BlockUpdates) == 0 {
-			size += len(bp)
-			msg.BlockProof = bp
-			msg.SetHeight(rm.BlockProof.BlockWitness.Height)
-		}
-		size += len(rp.Proof)
-		trp := &ReceiptProof{
-			Index:       rp.Index,
-			Proof:       rp.Proof,
-			EventProofs: make([]*chain.EventProof, 0),
-		}
 		for j, ep := range rp.EventProofs {
 			if s.isOverLimit(len(ep.Proof)) {
 				return nil, fmt.Errorf("invalid EventProof.Proof size")
 			}
-			size += len(ep.Proof)
-			if s.isOverLimit(size) {
-				if j == 0 && len(msg.BlockUpdates) == 0 {
-					return nil, fmt.Errorf("BlockProof + ReceiptProof + EventProof > limit")
-				}
-				//
-				segment := &chain.Segment{
-					Height:              msg.GetHeight(),
-					NumberOfBlockUpdate: msg.GetNumberOfBlockUpdate(),
-					EventSequence:       big.NewInt(msg.GetEventSequence()),
-					NumberOfEvent:       msg.GetNumberOfEvent(),
-				}
-
-				b, err := codec.RLP.MarshalToBytes(msg)
-				if err != nil {
-					return nil, err
-				}
-				segment.TransactionParam = b
-				segments = append(segments, segment)
-
-				msg = &RelayMessage{
-					BlockUpdates:  make([][]byte, 0),
-					ReceiptProofs: make([][]byte, 0),
-					BlockProof:    bp,
-				}
-				size = len(ep.Proof)
-				size += len(rp.Proof)
-				size += len(bp)
-
-				trp = &ReceiptProof{
-					Index:       rp.Index,
-					Proof:       rp.Proof,
-					EventProofs: make([]*chain.EventProof, 0),
-				}
+			if s.isOverLimit(len(bp) + len(rp.Proof) + len(ep.Proof)) {
+				return nil, fmt.Errorf("BlockProof + ReceiptProof + EventProof > limit")
+			}
+			if err = sg.Add(&eventProofItem{rp: rp, ep: ep, seq: rp.Events[j].Sequence.Int64()}); err != nil {
+				return nil, err
 			}
-			trp.EventProofs = append(trp.EventProofs, ep)
-			msg.SetEventSequence(rp.Events[j].Sequence.Int64())
-			msg.SetNumberOfEvent(msg.GetNumberOfEvent() + 1)
-		}
-
-		if b, err = codec.RLP.MarshalToBytes(trp); err != nil {
-			return nil, err
 		}
-		msg.ReceiptProofs = append(msg.ReceiptProofs, b)
 	}
-	//
-	segment := &chain.Segment{
-		Height:              msg.GetHeight(),
-		NumberOfBlockUpdate: msg.GetNumberOfBlockUpdate(),
-		EventSequence:       big.NewInt(msg.GetEventSequence()),
-		NumberOfEvent:       msg.GetNumberOfEvent(),
-	}
-
-	b, err = codec.RLP.MarshalToBytes(msg)
-	if err != nil {
+	if err = sg.Flush(); err != nil {
 		return nil, err
 	}
-	segment.TransactionParam = b
-	segments = append(segments, segment)
-	return segments, nil
+	return p.segments, nil
 }
 
 func (s *SimpleChain) UpdateSegment(bp *chain.BlockProof, segment *chain.Segment) error {
@@ -289,29 +240,84 @@ func (s *SimpleChain) result(rm *chain.RelayMessage, segment *chain.Segment) {
 	var err error
 	segment.TransactionResult, err = s.s.GetResult(segment.GetResultParam)
 	if err != nil {
-		if ec, ok := errors.CoderOf(err); ok {
-			s.l.Debugf("fail to GetResult GetResultParam:%v ErrorCoder:%+v",
-				segment.GetResultParam, ec)
-			switch ec.ErrorCode() {
-			case BMVUnknown:
-				//TODO panic??
-			case BMVNotVerifiable:
-				segment.GetResultParam = nil
-			case BMVAlreadyVerified:
-				segment.GetResultParam = nil
-			case BMCRevertUnauthorized:
-				segment.GetResultParam = nil
-			default:
-				s.l.Panicf("fail to GetResult GetResultParam:%v ErrorCoder:%+v",
-					segment.GetResultParam, ec)
-			}
+		s.l.Debugf("fail to GetResult GetResultParam:%v err:%+v",
+			segment.GetResultParam, err)
+		//attempts are counted by RelayMessage, segments are replaced on resegment
+		d := s.pe.Decide(rm.Seq, err)
+		s.m.Failed(d.Code)
+		s.handle(rm, segment, d)
+	} else {
+		s.m.Confirmed.Inc()
+		s.wd.OnRelay()
+		s.pe.Reset(rm.Seq)
+	}
+}
+
+func (s *SimpleChain) handle(rm *chain.RelayMessage, segment *chain.Segment, d *policy.Decision) {
+	switch d.Action {
+	case policy.ActionRetry:
+		s.resend(d.Delay, func() {
+			segment.GetResultParam = nil
+		})
+	case policy.ActionResegment:
+		s.resend(d.Delay, func() {
+			rm.Segments = rm.Segments[:0]
+		})
+	case policy.ActionRefresh:
+		if err := s.RefreshStatus(); err != nil {
+			s.l.Warnf("fail to RefreshStatus err:%+v", err)
 		} else {
-			//TODO: commented temporarily to keep the relayer running
-			//s.l.Panicf("fail to GetResult GetResultParam:%v err:%+v",
-			//	segment.GetResultParam, err)
-			s.l.Debugf("fail to GetResult GetResultParam:%v err:%+v", segment.GetResultParam, err)
+			bs := s.status()
+			if err = s.updateRelayMessage(bs.Verifier.Height, bs.RxSeq.Int64()); err != nil {
+				s.l.Warnf("fail to updateRelayMessage err:%+v", err)
+			}
+		}
+		s.resend(d.Delay, func() {
+			segment.GetResultParam = nil
+		})
+	case policy.ActionQuarantine:
+		s.rmsMtx.Lock()
+		defer s.rmsMtx.Unlock()
+		s._quarantine(rm, segment)
+	case policy.ActionShutdown:
+		s.shutdown(d.Err)
+	}
+}
+
+// _quarantine removes rm from relay queue and keeps it aside, rmsMtx should be locked.
+func (s *SimpleChain) _quarantine(rm *chain.RelayMessage, segment *chain.Segment) {
+	for i, v := range s.rms {
+		if v == rm {
+			s.rms = append(s.rms[:i], s.rms[i+1:]...)
+			s.qrms = append(s.qrms, rm)
+			s.l.Errorf("quarantine rm:%d [h:%d,seq:%d,txh:%v]",
+				rm.Seq, segment.Height, segment.EventSequence, segment.GetResultParam)
+			break
 		}
 	}
+	if len(s.rms) == 0 {
+		s._rm()
+	}
+}
+
+// resend calls reset with locked rmsMtx after delay, then requests relay.
+func (s *SimpleChain) resend(delay time.Duration, reset func()) {
+	time.AfterFunc(delay, func() {
+		if reset != nil {
+			s.rmsMtx.Lock()
+			reset()
+			s.rmsMtx.Unlock()
+		}
+		s.notifyRelay()
+	})
+}
+
+func (s *SimpleChain) shutdown(err error) {
+	s.l.Errorf("shutdown err:%+v", err)
+	select {
+	case s.errCh <- err:
+	default:
+	}
 }
 
 func (s *SimpleChain) _rm() *chain.RelayMessage {
@@ -322,6 +328,7 @@ func (s *SimpleChain) _rm() *chain.RelayMessage {
 	}
 	s.rms = append(s.rms, rm)
 	s.rmSeq += 1
+	s.m.Queued.Set(float64(len(s.rms)))
 	return rm
 }
 
@@ -347,7 +354,7 @@ func (s *SimpleChain) addRelayMessage(bu *chain.BlockUpdate, rps []*chain.Receip
 		s.l.Debugf("addRelayMessage rms:%d bu:%d rps:%d HeightOfDst:%d", len(s.rms), bu.Height, len(rps), rm.HeightOfDst)
 		rm = s._rm()
 	} else {
-		if bu.Height <= s.bs.Verifier.Height {
+		if bu.Height <= s.status().Verifier.Height {
 			return
 		}
 		rm.BlockUpdates = append(rm.BlockUpdates, bu)
@@ -438,6 +445,7 @@ rmLoop:
 		if len(s.rms) == 0 {
 			s._rm()
 		}
+		s.m.Queued.Set(float64(len(s.rms)))
 	}
 	return nil
 }
@@ -460,38 +468,59 @@ func (s *SimpleChain) updateMTA(bu *chain.BlockUpdate) {
 func (s *SimpleChain) OnBlockOfDst(height int64) error {
 	s.l.Tracef("OnBlockOfDst height:%d", height)
 	atomic.StoreInt64(&s.heightOfDst, height)
-	h, seq := s.bs.Verifier.Height, s.bs.RxSeq
+	s.wd.OnDst(height)
+	bs := s.status()
+	h, seq := bs.Verifier.Height, bs.RxSeq
 	if err := s.RefreshStatus(); err != nil {
 		return err
 	}
-	if h != s.bs.Verifier.Height || seq != s.bs.RxSeq {
-		h, seq = s.bs.Verifier.Height, s.bs.RxSeq
+	if bs = s.status(); h != bs.Verifier.Height || seq != bs.RxSeq {
+		h, seq = bs.Verifier.Height, bs.RxSeq
 		if err := s.updateRelayMessage(h, seq.Int64()); err != nil {
 			return err
 		}
-		s.relayCh <- nil
+		s.tr.OnDeliver(s.src.NetworkAddress(), s.dst.NetworkAddress(), seq.Int64(), s.l)
+		s.notifyRelay()
 	}
 	return nil
 }
 
 func (s *SimpleChain) OnBlockOfSrc(bu *chain.BlockUpdate, rps []*chain.ReceiptProof) {
+	if s.isStopped() {
+		//the database could be closed
+		return
+	}
 	s.l.Tracef("OnBlockOfSrc height:%d, bu.Height:%d", s.acc.Height(), bu.Height)
+	if last := s.wd.SrcHeight(); last != 0 && bu.Height <= last {
+		//duplicated by restarted ReceiveLoop
+		s.l.Debugf("skip OnBlockOfSrc height:%d last:%d", bu.Height, last)
+		return
+	}
+	s.m.SrcHeight.Set(float64(bu.Height))
+	for _, rp := range rps {
+		for _, e := range rp.Events {
+			s.m.TxSeq.Set(metrics.BigFloat(e.Sequence))
+			s.tr.OnReceive(s.src.NetworkAddress(), s.dst.NetworkAddress(), e.Sequence.Int64(), e.Message, s.l)
+		}
+	}
 	s.updateMTA(bu)
 	s.addRelayMessage(bu, rps)
-	s.relayCh <- nil
+	s.wd.OnSrc(bu.Height)
+	s.notifyRelay()
 }
 
 func (s *SimpleChain) newBlockProof(height int64, header []byte) (*chain.BlockProof, error) {
 	//at := s.bs.Verifier.Height
 	//w, err := s.acc.WitnessForWithAccLength(height-s.acc.Offset(), at-s.bs.Verifier.Offset)
 	//TODO refactoring Duplicate rlp decode
+	bs := s.status()
 	vs := &VerifierStatus_v1{}
-	_, err := codec.RLP.UnmarshalFromBytes(s.bs.Verifier.Extra, vs)
+	_, err := codec.RLP.UnmarshalFromBytes(bs.Verifier.Extra, vs)
 	if err != nil {
 		return nil, err
 	}
 
-	at, w, err := s.acc.WitnessForAt(height, s.bs.Verifier.Height, vs.Offset)
+	at, w, err := s.acc.WitnessForAt(height, bs.Verifier.Height, vs.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -517,6 +546,8 @@ func (s *SimpleChain) prepareDatabase(offset int64) error {
 	defer func() {
 		if err != nil {
 			database.Close()
+		} else {
+			s.database = database
 		}
 	}()
 	var bk db.Bucket
@@ -555,15 +586,26 @@ func (s *SimpleChain) RefreshStatus() error {
 	if err != nil {
 		return err
 	}
+	s.bsMtx.Lock()
 	s.bs = bmcStatus
+	s.bsMtx.Unlock()
+	s.m.SetStatus(bmcStatus.CurrentHeight, bmcStatus.Verifier.Height, bmcStatus.RxSeq)
 	return nil
 }
 
+// status returns the last BMCLinkStatus, it's refreshed by result goroutines as well as MonitorLoop.
+func (s *SimpleChain) status() *chain.BMCLinkStatus {
+	s.bsMtx.RLock()
+	defer s.bsMtx.RUnlock()
+	return s.bs
+}
+
 func (s *SimpleChain) init() error {
 	if err := s.RefreshStatus(); err != nil {
 		return err
 	}
-	atomic.StoreInt64(&s.heightOfDst, s.bs.CurrentHeight)
+	atomic.StoreInt64(&s.heightOfDst, s.status().CurrentHeight)
+	s.tr.LoadRouteTables(s.src, s.dst, s.r, s.s, s.l)
 	if s.relayCh == nil {
 		s.relayCh = make(chan *chain.RelayMessage, 2)
 		go func() {
@@ -578,12 +620,15 @@ func (s *SimpleChain) init() error {
 						return
 					}
 					s._relay()
+				case <-s.stopCh:
+					return
 				}
 			}
 		}()
 	}
+	bs := s.status()
 	s.l.Debugf("_init height:%d, dst(%s, height:%d, seq:%d, last:%d), receive:%d",
-		s.acc.Height(), s.dst, s.bs.Verifier.Height, s.bs.RxSeq, s.bs.Verifier.Height, s.receiveHeight())
+		s.acc.Height(), s.dst, bs.Verifier.Height, bs.RxSeq, bs.Verifier.Height, s.receiveHeight())
 	return nil
 }
 
@@ -591,7 +636,7 @@ func (s *SimpleChain) receiveHeight() int64 {
 	//min(max(s.acc.Height(), s.bs.Verifier.Offset), s.bs.Verifier.LastHeight)
 	//TODO refactoring Duplicate rlp decode
 	vs := &VerifierStatus_v1{}
-	_, err := codec.RLP.UnmarshalFromBytes(s.bs.Verifier.Extra, vs)
+	_, err := codec.RLP.UnmarshalFromBytes(s.status().Verifier.Extra, vs)
 	if err != nil {
 		return 0
 	}
@@ -614,11 +659,29 @@ func (s *SimpleChain) monitorHeight() int64 {
 
 func (s *SimpleChain) Serve(sender chain.Sender) error {
 	s.s = sender
-	s.r = NewReceiver(s.src, s.dst, s.cfg.Src.Endpoint, s.cfg.Src.Options, s.l)
+	s.r = NewReceiver(s.src, s.dst, s.cfg.Src.EndpointList(), s.cfg.Src.Options, s.l)
+	if c, ok := s.r.(chain.Closer); ok {
+		defer c.Close()
+	}
+
+	var err error
+	if s.pe, err = policy.NewEngine(s.cfg.Policy, DefaultPolicyRules, s.l); err != nil {
+		return err
+	}
 
 	if err := s.prepareDatabase(s.cfg.Offset); err != nil {
 		return err
 	}
+	defer s.database.Close()
+
+	if s.fa, err = chain.NewFeeAccount(s.database, sender, s.cfg.Src.Balance, s.m, s.requestRelay, s.l); err != nil {
+		return err
+	}
+	s.fa.Start()
+	defer s.fa.Stop()
+
+	s.tr.Register(s.src.NetworkAddress(), s.dst.NetworkAddress())
+	defer s.tr.Unregister(s.src.NetworkAddress(), s.dst.NetworkAddress())
 
 	if err := s.Monitoring(); err != nil {
 		return err
@@ -627,46 +690,136 @@ func (s *SimpleChain) Serve(sender chain.Sender) error {
 	return nil
 }
 
+// Stop stops Serve which returns nil, the database is closed on return of Serve.
+// the watchdog of the direction is removed from probes.
+func (s *SimpleChain) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		health.Unregister(s.wd)
+	})
+}
+
+func (s *SimpleChain) isStopped() bool {
+	select {
+	case <-s.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// notifyRelay requests relay to relayLoop, it doesn't block after Stop.
+func (s *SimpleChain) notifyRelay() {
+	select {
+	case s.relayCh <- nil:
+	case <-s.stopCh:
+	}
+}
+
 func (s *SimpleChain) Monitoring() error {
 	if err := s.init(); err != nil {
 		return err
 	}
-	errCh := make(chan error)
+	s.startLoops(s.receiveHeight(), s.status().CurrentHeight)
+	s.wd.Start()
+	defer s.wd.Stop()
+	for {
+		select {
+		case err := <-s.errCh:
+			if err != nil {
+				s.stopLoops()
+				return err
+			}
+		case err := <-s.restartCh:
+			s.restartLoops(err)
+		case <-s.stopCh:
+			s.stopLoops()
+			s.l.Debugln("stopped")
+			return nil
+		}
+	}
+}
+
+// startLoops runs MonitorLoop from dh and ReceiveLoop from h,
+// errors of the loops stopped by stopLoops are ignored.
+func (s *SimpleChain) startLoops(h, dh int64) {
+	rxSeq := s.status().RxSeq
+	gen := atomic.AddInt32(&s.loopGen, 1)
+	notify := func(err error) {
+		if atomic.LoadInt32(&s.loopGen) != gen {
+			return
+		}
+		select {
+		case s.errCh <- err:
+		default:
+		}
+	}
 	go func() {
-		err := s.s.MonitorLoop(
-			s.bs.CurrentHeight,
+		notify(s.s.MonitorLoop(
+			dh,
 			s.OnBlockOfDst,
 			func() {
 				s.l.Debugf("Connect MonitorLoop")
-				errCh <- nil
-			})
-		select {
-		case errCh <- err:
-		default:
-		}
+				notify(nil)
+			}))
 	}()
 	go func() {
-		err := s.r.ReceiveLoop(
-			s.receiveHeight(),
-			s.bs.RxSeq,
+		notify(s.r.ReceiveLoop(
+			h,
+			rxSeq,
 			s.OnBlockOfSrc,
 			func() {
 				s.l.Debugf("Connect ReceiveLoop")
-				errCh <- nil
-			})
-		select {
-		case errCh <- err:
-		default:
-		}
+				notify(nil)
+			}))
 	}()
-	for {
-		select {
-		case err := <-errCh:
-			if err != nil {
-				return err
-			}
+}
+
+func (s *SimpleChain) stopLoops() {
+	atomic.AddInt32(&s.loopGen, 1)
+	s.s.StopMonitorLoop()
+	s.r.StopReceiveLoop()
+}
+
+// restartLoops restarts loops from the last heights,
+// Poll of Client doesn't return error, it just stops after BlockRetryLimit.
+func (s *SimpleChain) restartLoops(err error) {
+	h := s.receiveHeight()
+	if sh := s.wd.SrcHeight(); sh != 0 {
+		h = sh + 1
+	}
+	dh := s.monitorHeight()
+	s.l.Warnf("restart loops receive:%d monitor:%d err:%+v", h, dh, err)
+	s.stopLoops()
+	s.startLoops(h, dh)
+	s.requestRelay()
+}
+
+// onStall restarts loops in Monitoring, or shutdown if restart is false.
+func (s *SimpleChain) onStall(err error, restart bool) {
+	if !restart {
+		s.shutdown(err)
+		return
+	}
+	select {
+	case s.restartCh <- err:
+	default:
+	}
+}
+
+// pending returns whether there is relay message to relay.
+func (s *SimpleChain) pending() bool {
+	if s.isPaused() {
+		return false
+	}
+	s.rmsMtx.RLock()
+	defer s.rmsMtx.RUnlock()
+	for _, rm := range s.rms {
+		if !isEmpty(rm) {
+			return true
 		}
 	}
+	return false
 }
 
 func NewChain(cfg *chain.Config, l log.Logger) *SimpleChain {
@@ -676,10 +829,16 @@ func NewChain(cfg *chain.Config, l log.Logger) *SimpleChain {
 		l:   l.WithFields(log.Fields{log.FieldKeyChain:
 		//fmt.Sprintf("%s->%s", cfg.Src.Address.NetworkAddress(), cfg.Dst.Address.NetworkAddress())}),
 		fmt.Sprintf("%s", cfg.Dst.Address.NetworkID())}),
-		cfg: cfg,
-		rms: make([]*chain.RelayMessage, 0),
+		cfg:       cfg,
+		rms:       make([]*chain.RelayMessage, 0),
+		errCh:     make(chan error),
+		m:         metrics.NewLinkMetrics(cfg.Src.Address.NetworkAddress(), cfg.Dst.Address.NetworkAddress()),
+		restartCh: make(chan error, 1),
+		stopCh:    make(chan struct{}),
+		tr:        chain.DefaultMessageTracker(),
 	}
 	s._rm()
+	s.wd = health.NewWatchdog(cfg.DirectionName(), cfg.Watchdog, s.pending, s.onStall, s.l)
 	return s
 }
 
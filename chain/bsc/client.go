@@ -20,7 +20,6 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"fmt"
-	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -30,14 +29,19 @@ import (
 	"github.com/icon-project/btp/chain/bsc/systemcontracts"
 	"github.com/icon-project/btp/common/wallet"
 	"math/big"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/icon-project/btp/common/endpoint"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
 )
 
 const (
+	MetricsClientName                          = "bsc"
 	DefaultSendTransactionRetryInterval        = 3 * time.Second         //3sec
 	DefaultGetTransactionResultPollingInterval = 1500 * time.Millisecond //1.5sec
 	DefaultTimeout                             = 10 * time.Second        //
@@ -70,7 +74,40 @@ type Client struct {
 	rpcClient             *rpc.Client
 	chainID               *big.Int
 	tendermintLightClient *systemcontracts.Tendermintlightclient
-	stop                  <-chan bool
+	mtx                   sync.Mutex
+	stop                  chan bool
+	es                    *endpoint.Selector
+}
+
+// dial connects to the current endpoint of es, http requests are sent to the other endpoint on failure.
+// websocket is connected to the first available endpoint, it's not rotated after connected.
+func dial(es *endpoint.Selector) (*rpc.Client, error) {
+	if uri := es.Current(); strings.HasPrefix(uri, "http") {
+		hc := &http.Client{Transport: metrics.NewRPCTransport(MetricsClientName, es.Transport(nil))}
+		return rpc.DialHTTPWithClient(uri, hc)
+	}
+	var err error
+	for i := 0; i < es.Len(); i++ {
+		uri := es.Current()
+		var rc *rpc.Client
+		if rc, err = rpc.Dial(uri); err == nil {
+			return rc, nil
+		}
+		es.Fail(uri, err)
+	}
+	return nil, err
+}
+
+func blockNumberOf(uri string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), endpoint.DefaultCheckTimeout)
+	defer cancel()
+	rc, err := rpc.DialContext(ctx, uri)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	n, err := ethclient.NewClient(rc).BlockNumber(ctx)
+	return int64(n), err
 }
 
 func toBlockNumArg(number *big.Int) string {
@@ -95,37 +132,12 @@ func newTransaction(nonce uint64, to common.Address, amount *big.Int, gasLimit u
 	})
 }
 
-func (c *Client) GetRevertMessage(hash common.Hash) (string, error) {
-	tx, _, err := c.ethClient.TransactionByHash(context.Background(), hash)
-	if err != nil {
-		return "", err
-	}
-
-	from, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
-	if err != nil {
-		return "", err
-	}
-
-	msg := ethereum.CallMsg{
-		From:     from,
-		To:       tx.To(),
-		Gas:      tx.Gas(),
-		GasPrice: tx.GasPrice(),
-		Value:    tx.Value(),
-		Data:     tx.Data(),
-	}
-
-	_, err = c.ethClient.CallContract(context.Background(), msg, nil)
-	return err.Error(), nil
-
-}
-
 func (c *Client) newTransactOpts(w Wallet) (*bind.TransactOpts, error) {
-	txo, err := bind.NewKeyedTransactorWithChainID(w.(*wallet.EvmWallet).Skey, c.chainID)
-	if err != nil {
-		return nil, err
+	ts, ok := w.(wallet.TransactionSigner)
+	if !ok {
+		return nil, fmt.Errorf("not supported wallet %T", w)
 	}
-	txo.GasPrice, _ = c.ethClient.SuggestGasPrice(context.Background())
+	txo := wallet.NewTransactor(ts, c.chainID)
 	txo.GasLimit = uint64(DefaultGasLimit)
 	return txo, nil
 }
@@ -164,6 +176,12 @@ func (c *Client) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error)
 	return tr, nil
 }
 
+func (c *Client) GetBalance(addr common.Address) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	return c.ethClient.BalanceAt(ctx, addr, nil)
+}
+
 func (c *Client) GetTransaction(hash common.Hash) (*types.Transaction, bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
 	defer cancel()
@@ -246,12 +264,19 @@ func (c *Client) MonitorBlock(p *BlockRequest, cb func(b *BlockNotification) err
 }
 
 func (c *Client) Poll(p *BlockRequest, cb func(b *BlockNotification) error) error {
+	c.mtx.Lock()
+	if c.stop == nil {
+		c.stop = make(chan bool)
+	}
+	stop := c.stop
+	c.mtx.Unlock()
 	go func() {
 		current := p.Height
 		var retry = BlockRetryLimit
 		for {
 			select {
-			case <-c.stop:
+			case <-stop:
+				c.log.Debugf("Polling stopped at %v", current)
 				return
 			default:
 				// Exhausted all error retries
@@ -319,11 +344,19 @@ func (c *Client) Monitor(cb func(b *BlockNotification) error) error {
 	return nil
 }
 
+// CloseMonitor stops polling and subscription, the client is kept to be used by next Poll.
 func (c *Client) CloseMonitor() {
 	c.log.Debugf("CloseMonitor %s", c.rpcClient)
-	c.subscription.Unsubscribe()
-	c.ethClient.Close()
-	c.rpcClient.Close()
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	if c.subscription != nil {
+		c.subscription.Unsubscribe()
+		c.subscription = nil
+	}
 }
 
 func (c *Client) CloseAllMonitor() {
@@ -331,9 +364,18 @@ func (c *Client) CloseAllMonitor() {
 	c.CloseMonitor()
 }
 
-func NewClient(uri string, log log.Logger) *Client {
+// Close stops monitors and the watcher of endpoints, then closes the connection.
+func (c *Client) Close() {
+	c.CloseAllMonitor()
+	c.es.Stop()
+	c.rpcClient.Close()
+}
+
+// NewClient returns Client which uses endpoints in order, it rotates to the next endpoint on failure.
+func NewClient(endpoints []string, log log.Logger) *Client {
 	//TODO options {MaxRetrySendTx, MaxRetryGetResult, MaxIdleConnsPerHost, Debug, Dump} }
-	rpcClient, err := rpc.Dial(uri)
+	es := endpoint.NewSelector(endpoints, log)
+	rpcClient, err := dial(es)
 	if err != nil {
 		log.Fatal("Error creating client", err)
 	}
@@ -341,8 +383,12 @@ func NewClient(uri string, log log.Logger) *Client {
 		rpcClient: rpcClient,
 		ethClient: ethclient.NewClient(rpcClient),
 		log:       log,
+		es:        es,
 	}
 	c.chainID, _ = c.GetChainID()
+	if strings.HasPrefix(es.Current(), "http") {
+		es.Watch(endpoint.DefaultCheckInterval, blockNumberOf)
+	}
 	log.Tracef("Client Connected Chain ID: ", c.chainID)
 	c.tendermintLightClient, err = systemcontracts.NewTendermintlightclient(tendermintLightClientContractAddr, c.ethClient)
 	if err != nil {
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bsc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/chain/bsc/binding"
+	"github.com/icon-project/btp/common/codec"
+)
+
+const (
+	BMCRelayMethod = "handleRelayMessage"
+)
+
+var _ chain.FeeEstimator = (*sender)(nil)
+
+// EstimateFee returns the fee of handleRelayMessage with estimated gas and gas price of the strategy.
+func (s *sender) EstimateFee(segment *chain.Segment) (*big.Int, error) {
+	p, ok := segment.TransactionParam.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("fail to cast []byte %T", segment.TransactionParam)
+	}
+	parsed, err := abi.JSON(strings.NewReader(binding.BMCABI))
+	if err != nil {
+		return nil, err
+	}
+	data, err := parsed.Pack(BMCRelayMethod, s.src.String(), p)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	to := HexToAddress(s.dst.ContractAddress())
+	gas, err := s.c.ethClient.EstimateGas(ctx, ethereum.CallMsg{
+		From: HexToAddress(s.w.Address()),
+		To:   &to,
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	price, err := s.gp.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return price.Mul(price, new(big.Int).SetUint64(gas)), nil
+}
+
+type blockUpdateRecord struct {
+	Height uint64 `json:"height"`
+	Hash   string `json:"hash"`
+	Size   int    `json:"size"`
+}
+
+type blockProofRecord struct {
+	Height  uint64 `json:"height"`
+	Hash    string `json:"hash"`
+	At      int64  `json:"at"`
+	Witness int    `json:"witness"`
+}
+
+type receiptProofRecord struct {
+	Index       int `json:"index"`
+	Size        int `json:"size"`
+	EventProofs int `json:"event_proofs"`
+}
+
+type relayMessageRecord struct {
+	BlockUpdates  []*blockUpdateRecord  `json:"block_updates"`
+	BlockProof    *blockProofRecord     `json:"block_proof,omitempty"`
+	ReceiptProofs []*receiptProofRecord `json:"receipt_proofs"`
+}
+
+// DecodeRelayMessage returns the readable form of RelayMessage, it's used by dry-run.
+func DecodeRelayMessage(b []byte) (interface{}, error) {
+	msg := &RelayMessage{}
+	if _, err := codec.RLP.UnmarshalFromBytes(b, msg); err != nil {
+		return nil, err
+	}
+	r := &relayMessageRecord{
+		BlockUpdates:  make([]*blockUpdateRecord, 0, len(msg.BlockUpdates)),
+		ReceiptProofs: make([]*receiptProofRecord, 0, len(msg.ReceiptProofs)),
+	}
+	for _, bub := range msg.BlockUpdates {
+		bu := &BlockUpdate{}
+		if _, err := codec.RLP.UnmarshalFromBytes(bub, bu); err != nil {
+			return nil, err
+		}
+		h := &Header{}
+		if _, err := codec.RLP.UnmarshalFromBytes(bu.BlockHeader, h); err != nil {
+			return nil, err
+		}
+		r.BlockUpdates = append(r.BlockUpdates, &blockUpdateRecord{
+			Height: h.Number,
+			Hash:   h.Hash().Hex(),
+			Size:   len(bub),
+		})
+	}
+	if len(msg.BlockProof) > 0 {
+		bp := &chain.BlockProof{}
+		if _, err := codec.RLP.UnmarshalFromBytes(msg.BlockProof, bp); err != nil {
+			return nil, err
+		}
+		h := &Header{}
+		if _, err := codec.RLP.UnmarshalFromBytes(bp.Header, h); err != nil {
+			return nil, err
+		}
+		r.BlockProof = &blockProofRecord{Height: h.Number, Hash: h.Hash().Hex()}
+		if bp.BlockWitness != nil {
+			r.BlockProof.At = bp.BlockWitness.Height
+			r.BlockProof.Witness = len(bp.BlockWitness.Witness)
+		}
+	}
+	for _, rpb := range msg.ReceiptProofs {
+		rp := &ReceiptProof{}
+		if _, err := codec.RLP.UnmarshalFromBytes(rpb, rp); err != nil {
+			return nil, err
+		}
+		r.ReceiptProofs = append(r.ReceiptProofs, &receiptProofRecord{
+			Index:       rp.Index,
+			Size:        len(rpb),
+			EventProofs: len(rp.EventProofs),
+		})
+	}
+	return r, nil
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/policy"
 )
 
 const (
@@ -74,6 +75,18 @@ var (
 	}
 )
 
+var (
+	DefaultPolicyRules = []policy.Rule{
+		{Code: fmt.Sprint(BMVUnknown), Action: policy.ActionQuarantine},
+		{Code: fmt.Sprint(BMVNotVerifiable), Action: policy.ActionRefresh},
+		{Code: fmt.Sprint(BMVAlreadyVerified), Action: policy.ActionRefresh},
+		{Code: fmt.Sprint(BMCRevertUnauthorized), Action: policy.ActionRetry},
+		{Code: policy.CodeTransport, Action: policy.ActionRetry},
+		{Code: policy.CodeUnknown, Action: policy.ActionRetry},
+		{Code: policy.CodeAny, Action: policy.ActionShutdown},
+	}
+)
+
 func NewRevertError(code int) error {
 	c := errors.Code(code)
 	if c >= CodeBTP {
@@ -33,7 +33,7 @@ type LogProof struct {
 }
 
 func GenerateReceiptProof_(height int64) ([]*chain.ReceiptProof, error) {
-	client := NewClient("http://localhost:8545", log.New())
+	client := NewClient([]string{"http://localhost:8545"}, log.New())
 
 	rps := make([]*chain.ReceiptProof, 0)
 
@@ -113,7 +113,7 @@ func GenerateReceiptProof_(height int64) ([]*chain.ReceiptProof, error) {
 }
 
 func GenerateReceiptProof1(height int64) error {
-	client := NewClient("http://localhost:8545", log.New())
+	client := NewClient([]string{"http://localhost:8545"}, log.New())
 
 	trieDB := trie.NewDatabase(memorydb.New())
 	trieObj, _ := trie.New(common.Hash{}, trieDB) // empty trie
@@ -151,7 +151,7 @@ func GenerateReceiptProof1(height int64) error {
 }
 
 func GenerateReceiptProof(height int64) (*LogProof, error) {
-	client := NewClient("http://localhost:8545", log.New())
+	client := NewClient([]string{"http://localhost:8545"}, log.New())
 
 	block, err := client.GetBlockByHeight(big.NewInt(height))
 	if err != nil {
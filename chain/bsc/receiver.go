@@ -36,6 +36,7 @@ import (
 	"github.com/icon-project/btp/chain"
 	"github.com/icon-project/btp/chain/bsc/binding"
 	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/metrics"
 
 	"github.com/icon-project/btp/common/log"
 )
@@ -50,6 +51,7 @@ type receiver struct {
 	dst chain.BtpAddress
 	log log.Logger
 	opt struct {
+		BMCManagement string `json:"bmc_management,omitempty"` //address of BMCManagement, BMC is used if it's empty
 	}
 	consensusStates    ConsensusStates
 	evtReq             *BlockRequest
@@ -159,6 +161,10 @@ func (r *receiver) newReceiptProofs(v *BlockNotification) ([]*chain.ReceiptProof
 			}
 
 			if bmcMsg, err := binding.UnpackEventLog(eventLog.Data); err == nil {
+				if next := chain.BtpAddress(bmcMsg.Next); next.NetworkAddress() != r.dst.NetworkAddress() {
+					r.skipEvent(next, bmcMsg.Seq)
+					continue
+				}
 				rp.Events = append(rp.Events, &chain.Event{
 					Message:  bmcMsg.Msg,
 					Next:     chain.BtpAddress(bmcMsg.Next),
@@ -194,6 +200,18 @@ func (r *receiver) newReceiptProofs(v *BlockNotification) ([]*chain.ReceiptProof
 	return rps, nil
 }
 
+// skipEvent logs the message for the other BMC which is not relayed to the destination,
+// it's counted as unserved if the direction to the next is not served by the relay.
+func (r *receiver) skipEvent(next chain.BtpAddress, seq *big.Int) {
+	src := r.src.NetworkAddress()
+	if chain.DefaultMessageTracker().Serves(src, next.NetworkAddress()) {
+		r.log.Tracef("skip message seq:%d for next:%s", seq, next)
+		return
+	}
+	metrics.UnservedMessage(src, next.NetworkAddress())
+	r.log.Infof("skip message seq:%d for next:%s which is not served by the relay", seq, next)
+}
+
 func trieFromReceipts(receipts []*types.Receipt) (*trie.Trie, error) {
 	tr, _ := trie.New(common.Hash{}, trie.NewDatabase(memorydb.New()))
 
@@ -273,7 +291,11 @@ func (r *receiver) StopReceiveLoop() {
 	r.c.CloseAllMonitor()
 }
 
-func NewReceiver(src, dst chain.BtpAddress, endpoint string, opt map[string]interface{}, l log.Logger) chain.Receiver {
+func (r *receiver) Close() {
+	r.c.Close()
+}
+
+func NewReceiver(src, dst chain.BtpAddress, endpoints []string, opt map[string]interface{}, l log.Logger) chain.Receiver {
 	r := &receiver{
 		src: src,
 		dst: dst,
@@ -286,7 +308,7 @@ func NewReceiver(src, dst chain.BtpAddress, endpoint string, opt map[string]inte
 	if err = json.Unmarshal(b, &r.opt); err != nil {
 		l.Panicf("fail to unmarshal opt:%#v err:%+v", opt, err)
 	}
-	r.c = NewClient(endpoint, l)
+	r.c = NewClient(endpoints, l)
 	return r
 }
 
@@ -1,43 +1,171 @@
 package bsc
 
 import (
+	"bytes"
 	"fmt"
-	"math/big"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/chain/bsc/bsctest"
 	"github.com/icon-project/btp/common/codec"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/segment"
 )
 
+func newTestReceiver(node *bsctest.Node) *receiver {
+	return NewReceiver(testBscAddress, testIconAddress, []string{node.URL()}, nil, log.New()).(*receiver)
+}
+
+func blockNotificationOf(node *bsctest.Node, height int64) *BlockNotification {
+	b := node.Block(height)
+	return &BlockNotification{Hash: b.Hash(), Height: b.Number(), Header: b.Header()}
+}
+
 func TestReceiver_GetReceiptProofs(t *testing.T) {
-	var src, dst chain.BtpAddress
-	err := src.Set("btp://0x97.icon/0xAaFc8EeaEE8d9C8bD3262CCE3D73E56DeE3FB776")
-	err = dst.Set("btp://0xf8aac3.icon/cxea19a7d6e9a926767d1d05eea467299fe461c0eb")
-	if err != nil {
-		fmt.Println(err)
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	r := newTestReceiver(node)
+
+	msgs := [][]byte{[]byte("message0"), []byte("message1"), []byte("message2")}
+	height := node.SendMessages(testIconAddress.String(), msgs...)
+	receiptRoot := node.Block(height).ReceiptHash()
+
+	rps, err := r.newReceiptProofs(blockNotificationOf(node, height))
+	assert.NoError(t, err)
+	assert.Equal(t, len(msgs), len(rps))
+	for i, rp := range rps {
+		assert.Equal(t, i, rp.Index)
+		assert.Equal(t, 1, len(rp.Events))
+		assert.Equal(t, msgs[i], rp.Events[0].Message)
+		assert.Equal(t, int64(i+1), rp.Events[0].Sequence.Int64())
+		assert.Equal(t, testIconAddress, rp.Events[0].Next)
+		assert.NoError(t, verifyReceiptProof(receiptRoot, &ReceiptProof{Index: rp.Index, Proof: rp.Proof}))
 	}
 
-	r := NewReceiver(src, dst, "http://localhost:8545", nil, log.New())
+	//block without transaction
+	rps, err = r.newReceiptProofs(blockNotificationOf(node, node.AddBlock()))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(rps))
+}
+
+func TestReceiver_SkipOtherNext(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	r := newTestReceiver(node)
+
+	other := "btp://0x2.icon/cx0000000000000000000000000000000000000002"
+	height := node.SendMessages(other, []byte("message0"))
+	rps, err := r.newReceiptProofs(blockNotificationOf(node, height))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(rps))
 
-	blockNotification := &BlockNotification{Height: big.NewInt(191)}
-	receiptProofs, err := r.(*receiver).newReceiptProofs(blockNotification)
+	height = node.SendMessages(testIconAddress.String(), []byte("message1"))
+	rps, err = r.newReceiptProofs(blockNotificationOf(node, height))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(rps))
+	assert.Equal(t, 1, len(rps[0].EventProofs))
+	assert.Equal(t, int64(1), rps[0].Events[0].Sequence.Int64())
+}
+
+func TestReceiver_GetRouteTable(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	r := newTestReceiver(node)
 
-	//fmt.Println(receiptProofs[0].Proof)
+	other := "btp://0x2.icon/cx0000000000000000000000000000000000000002"
+	node.AddLink(testIconAddress.String(), 0)
+	node.AddRoute("0x2.icon", testIconAddress.String())
+	rt, err := r.GetRouteTable()
+	assert.NoError(t, err)
+	assert.Equal(t, []chain.BtpAddress{testIconAddress}, rt.Links)
+	assert.Equal(t, testIconAddress, rt.Next(chain.BtpAddress(other).NetworkAddress()))
+	assert.True(t, rt.HasLink(testIconAddress.NetworkAddress()))
+}
 
-	var bytes [][]byte
-	_, err = codec.RLP.UnmarshalFromBytes(receiptProofs[0].Proof, &bytes)
+func TestReceiver_newBlockUpdate(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	r := newTestReceiver(node)
 
-	if err != nil {
-		return
+	height := node.SendMessages(testIconAddress.String(), []byte("message0"))
+	bu, err := r.newBlockUpdate(blockNotificationOf(node, height))
+	assert.NoError(t, err)
+	assert.Equal(t, height, bu.Height)
+	assert.Equal(t, node.Block(height).Hash().Bytes(), bu.BlockHash)
+	h := &Header{}
+	_, err = codec.RLP.UnmarshalFromBytes(bu.Header, h)
+	assert.NoError(t, err)
+	assert.Equal(t, node.Block(height).Hash(), h.Hash())
+}
+
+// relayMessageOf returns RelayMessage for the block, BlockProof is made without witness.
+func relayMessageOf(t *testing.T, r *receiver, node *bsctest.Node, height int64) *chain.RelayMessage {
+	v := blockNotificationOf(node, height)
+	bu, err := r.newBlockUpdate(v)
+	assert.NoError(t, err)
+	rps, err := r.newReceiptProofs(v)
+	assert.NoError(t, err)
+	return &chain.RelayMessage{
+		BlockUpdates:  []*chain.BlockUpdate{bu},
+		BlockProof:    &chain.BlockProof{Header: bu.Header, BlockWitness: &chain.BlockWitness{Height: height}},
+		ReceiptProofs: rps,
+	}
+}
+
+func TestSimpleChain_Segment(t *testing.T) {
+	src := bsctest.NewNode(bsctest.Config{})
+	defer src.Close()
+	dst := bsctest.NewNode(bsctest.Config{})
+	defer dst.Close()
+	s := newTestSender(t, dst, nil)
+	sc := &SimpleChain{s: s}
+
+	//large messages which couldn't be sent at once
+	msgs := make([][]byte, 3)
+	for i := range msgs {
+		msgs[i] = bytes.Repeat([]byte(fmt.Sprintf("message%d", i)), s.TxSizeLimit()/32)
 	}
+	height := src.SendMessages(testIconAddress.String(), msgs...)
+	rm := relayMessageOf(t, newTestReceiver(src), src, height)
 
-	block, err := r.(*receiver).c.GetBlockByHeight(big.NewInt(191))
-	fmt.Println(block.ReceiptHash())
-	//fmt.Println(block.Hash())
-	//fmt.Println(receiptProofs[0])
-	//fmt.Println(len(bytes))
-	//for _, proof := range bytes {
-	//	fmt.Println(hexutil.Encode(proof))
-	//}
+	segments, err := sc.Segment(rm, height-1)
+	assert.NoError(t, err)
+	assert.True(t, len(segments) > 1)
+	numberOfEvent := 0
+	for _, segment := range segments {
+		numberOfEvent += segment.NumberOfEvent
+		assert.NoError(t, relay(s, segment.TransactionParam.([]byte)))
+	}
+	assert.Equal(t, len(msgs), numberOfEvent)
+	assert.Equal(t, int64(len(msgs)), segments[len(segments)-1].EventSequence.Int64())
+	assert.Equal(t, len(segments), len(dst.Messages(testIconAddress.String())))
+	assert.Equal(t, height, segments[0].Height)
+}
+
+func TestSimpleChain_SegmentMaxEvents(t *testing.T) {
+	src := bsctest.NewNode(bsctest.Config{})
+	defer src.Close()
+	dst := bsctest.NewNode(bsctest.Config{})
+	defer dst.Close()
+	s := newTestSender(t, dst, nil)
+	sc := &SimpleChain{s: s, cfg: &chain.Config{Config: segment.Config{MaxEventsTx: 2}}}
+
+	msgs := [][]byte{[]byte("message0"), []byte("message1"), []byte("message2")}
+	height := src.SendMessages(testIconAddress.String(), msgs...)
+	rm := relayMessageOf(t, newTestReceiver(src), src, height)
+
+	segments, err := sc.Segment(rm, height-1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(segments))
+	assert.Equal(t, 1, segments[0].NumberOfBlockUpdate)
+	assert.Equal(t, 2, segments[0].NumberOfEvent)
+	assert.Equal(t, int64(2), segments[0].EventSequence.Int64())
+	assert.Equal(t, 1, segments[1].NumberOfEvent)
+	assert.Equal(t, int64(3), segments[1].EventSequence.Int64())
+	for _, segment := range segments {
+		assert.NoError(t, relay(s, segment.TransactionParam.([]byte)))
+	}
+	assert.Equal(t, len(segments), len(dst.Messages(testIconAddress.String())))
 }
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bsc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/chain/bsc/binding"
+)
+
+// GetRouteTable returns the route table by getLinks and getRoutes of BMCManagement at addr.
+func (c *Client) GetRouteTable(addr common.Address) (*chain.RouteTable, error) {
+	parsed, err := abi.JSON(strings.NewReader(binding.BMCManagementABI))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	bc := bind.NewBoundContract(addr, parsed, c.ethClient, nil, nil)
+	var out []interface{}
+	if err = bc.Call(&bind.CallOpts{Context: ctx}, &out, "getLinks"); err != nil {
+		return nil, err
+	}
+	links := *abi.ConvertType(out[0], new([]string)).(*[]string)
+	out = nil
+	if err = bc.Call(&bind.CallOpts{Context: ctx}, &out, "getRoutes"); err != nil {
+		return nil, err
+	}
+	routes := make(map[string]string)
+	for _, r := range *abi.ConvertType(out[0], new([]binding.TypesRoute)).(*[]binding.TypesRoute) {
+		routes[r.Dst] = r.Next
+	}
+	return chain.NewRouteTable(links, routes), nil
+}
+
+// bmcManagementOf returns the address of BMCManagement, it's the address of BMC if the option is empty.
+func bmcManagementOf(bmc chain.BtpAddress, opt string) common.Address {
+	if opt != "" {
+		return HexToAddress(opt)
+	}
+	return HexToAddress(bmc.ContractAddress())
+}
+
+// GetRouteTable returns the route table of BMC of the destination.
+func (s *sender) GetRouteTable() (*chain.RouteTable, error) {
+	return s.c.GetRouteTable(bmcManagementOf(s.dst, s.opt.BMCManagement))
+}
+
+// GetRouteTable returns the route table of BMC of the source.
+func (r *receiver) GetRouteTable() (*chain.RouteTable, error) {
+	return r.c.GetRouteTable(bmcManagementOf(r.src, r.opt.BMCManagement))
+}
+
+var _ chain.RouteLoader = (*sender)(nil)
+var _ chain.RouteLoader = (*receiver)(nil)
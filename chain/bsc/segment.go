@@ -0,0 +1,175 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bsc
+
+import (
+	"math/big"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/segment"
+)
+
+type blockUpdateItem struct {
+	bu *chain.BlockUpdate
+}
+
+func (i *blockUpdateItem) Size() int {
+	return len(i.bu.Proof)
+}
+
+func (i *blockUpdateItem) Sequence() (int64, int64) {
+	return 1, 0
+}
+
+type eventProofItem struct {
+	rp  *chain.ReceiptProof
+	ep  *chain.EventProof
+	seq int64
+}
+
+func (i *eventProofItem) Size() int {
+	return len(i.ep.Proof)
+}
+
+func (i *eventProofItem) Sequence() (int64, int64) {
+	return i.seq, i.seq
+}
+
+// relayMessagePacker packs block updates and event proofs into RelayMessage,
+// BlockProof is added to RelayMessage which has events without block updates.
+type relayMessagePacker struct {
+	bp       []byte
+	bpHeight int64
+
+	msg      *RelayMessage
+	size     int
+	trp      *ReceiptProof
+	rp       *chain.ReceiptProof //source of trp
+	segments []*chain.Segment
+}
+
+func newRelayMessagePacker(bp []byte, bpHeight int64) *relayMessagePacker {
+	return &relayMessagePacker{
+		bp:       bp,
+		bpHeight: bpHeight,
+		msg:      newRelayMessage(),
+	}
+}
+
+func newRelayMessage() *RelayMessage {
+	return &RelayMessage{
+		BlockUpdates:  make([][]byte, 0),
+		ReceiptProofs: make([][]byte, 0),
+	}
+}
+
+func (p *relayMessagePacker) needBlockProof() bool {
+	return len(p.msg.BlockUpdates) == 0 && len(p.msg.BlockProof) == 0
+}
+
+func (p *relayMessagePacker) SizeWith(item segment.Item) (int, error) {
+	size := p.size
+	switch i := item.(type) {
+	case *blockUpdateItem:
+		size += i.Size()
+	case *eventProofItem:
+		if p.needBlockProof() {
+			size += len(p.bp)
+		}
+		if p.rp != i.rp {
+			size += len(i.rp.Proof)
+		}
+		size += i.Size()
+	}
+	return size, nil
+}
+
+func (p *relayMessagePacker) Add(item segment.Item) error {
+	switch i := item.(type) {
+	case *blockUpdateItem:
+		p.msg.BlockUpdates = append(p.msg.BlockUpdates, i.bu.Proof)
+		p.msg.SetHeight(i.bu.Height)
+		p.msg.SetNumberOfBlockUpdate(p.msg.GetNumberOfBlockUpdate() + 1)
+		p.size += i.Size()
+	case *eventProofItem:
+		if p.needBlockProof() {
+			p.msg.BlockProof = p.bp
+			p.msg.SetHeight(p.bpHeight)
+			p.size += len(p.bp)
+		}
+		if p.rp != i.rp {
+			if err := p.appendReceiptProof(); err != nil {
+				return err
+			}
+			p.trp = &ReceiptProof{
+				Index:       i.rp.Index,
+				Proof:       i.rp.Proof,
+				EventProofs: make([]*chain.EventProof, 0),
+			}
+			p.rp = i.rp
+			p.size += len(i.rp.Proof)
+		}
+		p.trp.EventProofs = append(p.trp.EventProofs, i.ep)
+		p.msg.SetEventSequence(i.seq)
+		p.msg.SetNumberOfEvent(p.msg.GetNumberOfEvent() + 1)
+		p.size += i.Size()
+	}
+	return nil
+}
+
+func (p *relayMessagePacker) appendReceiptProof() error {
+	if p.trp == nil {
+		return nil
+	}
+	b, err := codec.RLP.MarshalToBytes(p.trp)
+	if err != nil {
+		return err
+	}
+	p.msg.ReceiptProofs = append(p.msg.ReceiptProofs, b)
+	p.trp, p.rp = nil, nil
+	return nil
+}
+
+func (p *relayMessagePacker) Empty() bool {
+	return len(p.msg.BlockUpdates) == 0 && p.msg.GetNumberOfEvent() == 0
+}
+
+// Flush builds the segment of pending ones, the following events of the last receipt
+// are continued in the next segment with BlockProof.
+func (p *relayMessagePacker) Flush() error {
+	if err := p.appendReceiptProof(); err != nil {
+		return err
+	}
+	b, err := codec.RLP.MarshalToBytes(p.msg)
+	if err != nil {
+		return err
+	}
+	s := &chain.Segment{
+		TransactionParam:    b,
+		Height:              p.msg.GetHeight(),
+		NumberOfBlockUpdate: p.msg.GetNumberOfBlockUpdate(),
+		NumberOfEvent:       p.msg.GetNumberOfEvent(),
+	}
+	if s.NumberOfEvent > 0 {
+		s.EventSequence = big.NewInt(p.msg.GetEventSequence())
+	}
+	p.segments = append(p.segments, s)
+	p.msg = newRelayMessage()
+	p.size = 0
+	return nil
+}
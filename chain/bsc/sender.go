@@ -17,23 +17,28 @@
 package bsc
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math"
 	"math/big"
-	"strconv"
-	"strings"
-	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/icon-project/btp/chain/bsc/binding"
 
 	"github.com/icon-project/btp/chain"
 	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/gas"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
+	"github.com/icon-project/btp/common/nonce"
+	"github.com/icon-project/btp/common/revert"
 )
 
 const (
@@ -50,9 +55,14 @@ type sender struct {
 	w   Wallet
 	l   log.Logger
 	opt struct {
+		gas.Config
+		BMCManagement string `json:"bmc_management,omitempty"` //address of BMCManagement, BMC is used if it's empty
 	}
 
 	bmc *binding.BMC
+	gp  *gas.Pricer
+	nm  *nonce.Manager
+	rd  *revert.Decoder
 
 	evtLogRawFilter struct {
 		addr      []byte
@@ -63,10 +73,13 @@ type sender struct {
 	evtReq             *BlockRequest
 	isFoundOffsetBySeq bool
 	cb                 chain.ReceiveCallback
-
-	mutex sync.Mutex
+	m                  *metrics.LinkMetrics
+	fl                 chain.FeeListener
 }
 
+var _ chain.FeeNotifier = (*sender)(nil)
+var _ chain.BalanceGetter = (*sender)(nil)
+
 func (s *sender) newTransactionParam(prev string, rm *RelayMessage) (*TransactionParam, error) {
 	b, err := codec.RLP.MarshalToBytes(rm)
 	if err != nil {
@@ -100,18 +113,32 @@ func (s *sender) UpdateSegment(bp *chain.BlockProof, segment *chain.Segment) err
 }
 
 func (s *sender) Relay(segment *chain.Segment) (chain.GetResultParam, error) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
 	p := segment.TransactionParam.([]byte)
 
 	t, err := s.c.newTransactOpts(s.w)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	if t.GasPrice, err = s.gp.GasPrice(ctx); err != nil {
+		return nil, err
+	}
+	nonce, err := s.nm.Allocate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.Nonce = new(big.Int).SetUint64(nonce)
+	t.NoSend = true
 
 	var tx *types.Transaction
 	tx, err = s.bmc.HandleRelayMessage(t, s.src.String(), p[:])
 	if err != nil {
+		s.nm.Release(nonce, err)
+		s.l.Errorf("handleRelayMessage: ", err.Error())
+		return nil, err
+	}
+	if err = s.nm.Send(ctx, tx); err != nil {
 		s.l.Errorf("handleRelayMessage: ", err.Error())
 		return nil, err
 	}
@@ -121,32 +148,119 @@ func (s *sender) Relay(segment *chain.Segment) (chain.GetResultParam, error) {
 	return thp, nil
 }
 
+// transactionOf returns the mined one of the transaction and its replacements,
+// if all of them are pending, it returns the latest pending one.
+func (s *sender) transactionOf(hashes []common.Hash) (*types.Transaction, bool, error) {
+	var latest *types.Transaction
+	var err error
+	for i := len(hashes) - 1; i >= 0; i-- {
+		t, pending, e := s.c.GetTransaction(hashes[i])
+		if e != nil {
+			//replaced transaction could be dropped
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		if !pending {
+			return t, false, nil
+		}
+		if latest == nil {
+			latest = t
+		}
+	}
+	if latest == nil {
+		return nil, false, err
+	}
+	return latest, true, nil
+}
+
+// revertOf returns the error of the failed transaction which has the code of BMC or BMV.
+func (s *sender) revertOf(tx *types.Transaction) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	return s.rd.ErrorOfTransaction(ctx, s.c.ethClient, tx, nil)
+}
+
+func (s *sender) recover() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	return s.nm.Recover(ctx)
+}
+
+// replace sends the transaction which has the same nonce with tx and bumped gas price.
+func (s *sender) replace(tx *types.Transaction) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	ntx, err := s.gp.Replacement(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.c.newTransactOpts(s.w)
+	if err != nil {
+		return nil, err
+	}
+	if ntx, err = t.Signer(t.From, ntx); err != nil {
+		return nil, err
+	}
+	if err = s.nm.Send(ctx, ntx); err != nil {
+		return nil, err
+	}
+	return ntx, nil
+}
+
 func (s *sender) GetResult(p chain.GetResultParam) (chain.TransactionResult, error) {
 	if txh, ok := p.(*TransactionHashParam); ok {
+		hashes := []common.Hash{txh.Hash}
+		nonce, tracking := s.nm.NonceOf(txh.Hash)
+		sent := time.Now()
 		for {
-			_, pending, err := s.c.GetTransaction(txh.Hash)
+			t, pending, err := s.transactionOf(hashes)
+			if err == ethereum.NotFound && tracking {
+				//dropped transaction is resent, unless the nonce is used by another
+				if err = s.recover(); err != nil {
+					return nil, err
+				}
+				if !s.nm.Tracking(nonce) {
+					return nil, ethereum.NotFound
+				}
+				<-time.After(DefaultGetRelayResultInterval)
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
 			if pending {
+				if d := s.gp.ReplaceTimeout(); d > 0 && time.Since(sent) > d {
+					//transaction could be stuck by the gap of previous nonce
+					if err = s.recover(); err != nil {
+						s.l.Warnf("fail to recover nonce err:%+v", err)
+					}
+					if nt, err := s.replace(t); err != nil {
+						s.l.Warnf("fail to replace tx:%s err:%+v", t.Hash(), err)
+					} else {
+						s.l.Infof("replace tx:%s to tx:%s gasPrice:%s", t.Hash(), nt.Hash(), nt.GasPrice())
+						hashes = append(hashes, nt.Hash())
+					}
+					sent = time.Now()
+				}
 				<-time.After(DefaultGetRelayResultInterval)
 				continue
 			}
+			s.nm.Done(t.Nonce())
+			txh.Hash = t.Hash()
 			tx, err := s.c.GetTransactionReceipt(txh.Hash)
 			if err != nil {
 				return nil, err
 			}
+			fee := new(big.Int).Mul(t.GasPrice(), new(big.Int).SetUint64(tx.GasUsed))
+			s.m.Fee.Add(metrics.BigFloat(fee))
+			if s.fl != nil {
+				s.fl(fee)
+			}
 
-			if tx.Status == 0 {
-				revertMsg, err := s.c.GetRevertMessage(txh.Hash)
-				if err != nil {
-					return nil, err
-				}
-				code, err := strconv.Atoi(strings.Split(revertMsg, "|")[1])
-				if err != nil {
-					return nil, err
-				}
-				return tx, NewRevertError(code)
+			if tx.Status == types.ReceiptStatusFailed {
+				return tx, s.revertOf(t)
 			}
 			return tx, nil
 		}
@@ -155,6 +269,15 @@ func (s *sender) GetResult(p chain.GetResultParam) (chain.TransactionResult, err
 	}
 }
 
+// SetFeeListener should be called before Relay.
+func (s *sender) SetFeeListener(l chain.FeeListener) {
+	s.fl = l
+}
+
+func (s *sender) Balance() (*big.Int, error) {
+	return s.c.GetBalance(HexToAddress(s.w.Address()))
+}
+
 func (s *sender) GetStatus() (*chain.BMCLinkStatus, error) {
 	var status binding.TypesLinkStats
 	status, err := s.bmc.GetStatus(nil, s.src.String())
@@ -186,6 +309,11 @@ func (s *sender) MonitorLoop(height int64, cb chain.MonitorCallback, scb func())
 func (s *sender) StopMonitorLoop() {
 	s.c.CloseAllMonitor()
 }
+
+func (s *sender) Close() {
+	s.c.Close()
+}
+
 func (s *sender) FinalizeLatency() int {
 	//on-the-next
 	return 1
@@ -195,23 +323,39 @@ func (s *sender) TxSizeLimit() int {
 	return int(math.Round(float64(txSizeLimit)))
 }
 
-func NewSender(src, dst chain.BtpAddress, w Wallet, endpoint string, opt map[string]interface{}, l log.Logger) chain.Sender {
+// NewSender returns the sender to BMC of dst, it fails if opt is invalid.
+func NewSender(src, dst chain.BtpAddress, w Wallet, endpoints []string, opt map[string]interface{}, l log.Logger) (chain.Sender, error) {
 	s := &sender{
 		src: src,
 		dst: dst,
 		w:   w,
 		l:   l,
+		m:   metrics.NewLinkMetrics(src.NetworkAddress(), dst.NetworkAddress()),
 	}
 	b, err := json.Marshal(opt)
 	if err != nil {
-		l.Panicf("fail to marshal opt:%#v err:%+v", opt, err)
+		return nil, errors.Wrapf(err, "fail to marshal opt:%#v", opt)
 	}
 	if err = json.Unmarshal(b, &s.opt); err != nil {
-		l.Panicf("fail to unmarshal opt:%#v err:%+v", opt, err)
+		return nil, errors.Wrapf(err, "fail to unmarshal opt:%#v", opt)
+	}
+	s.c = NewClient(endpoints, l)
+	if s.gp, err = gas.NewPricer(s.opt.Config, s.c.rpcClient); err != nil {
+		s.c.Close()
+		return nil, errors.Wrap(err, "fail to create gas pricer")
+	}
+	t, err := s.c.newTransactOpts(s.w)
+	if err != nil {
+		s.c.Close()
+		return nil, errors.Wrap(err, "fail to create transact opts")
+	}
+	s.nm = nonce.NewManager(s.c.ethClient, t.From, t.Signer, s.gp, l)
+	if s.rd, err = revert.NewDecoder(NewRevertError, binding.BMCABI); err != nil {
+		s.c.Close()
+		return nil, errors.Wrap(err, "fail to create revert decoder")
 	}
-	s.c = NewClient(endpoint, l)
 
 	s.bmc, _ = binding.NewBMC(HexToAddress(s.dst.ContractAddress()), s.c.ethClient)
 
-	return s
+	return s, nil
 }
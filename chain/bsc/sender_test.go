@@ -0,0 +1,239 @@
+package bsc
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/chain/bsc/bsctest"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/wallet"
+)
+
+const (
+	testIconAddress = chain.BtpAddress("btp://0x1.icon/cx0000000000000000000000000000000000000001")
+	testBscAddress  = chain.BtpAddress("btp://0x61.bsc/" + bsctest.DefaultBMCAddress)
+)
+
+// newTestSender returns the sender to BMC of the in-process node, the link from ICON is added.
+func newTestSender(t *testing.T, node *bsctest.Node, opt map[string]interface{}) *sender {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	w, err := wallet.NewEvmWalletFromPrivateKey(key)
+	assert.NoError(t, err)
+	node.AddLink(testIconAddress.String(), 0)
+	s, err := NewSender(testIconAddress, testBscAddress, w, []string{node.URL()}, opt, log.New())
+	assert.NoError(t, err)
+	return s.(*sender)
+}
+
+func relay(s *sender, msg []byte) error {
+	p, err := s.Relay(&chain.Segment{TransactionParam: msg})
+	if err != nil {
+		return err
+	}
+	_, err = s.GetResult(p)
+	return err
+}
+
+func TestSender_Relay(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	s := newTestSender(t, node, nil)
+
+	node.SetRelayHandler(func(l *bsctest.Link, msg []byte) int {
+		l.RxSeq++
+		l.Height = 10
+		return 0
+	})
+	msgs := [][]byte{[]byte("message0"), []byte("message1")}
+	for _, msg := range msgs {
+		assert.NoError(t, relay(s, msg))
+	}
+	assert.Equal(t, msgs, node.Messages(testIconAddress.String()))
+	assert.Equal(t, len(msgs), node.Calls(bsctest.BMCRelayMethod))
+
+	bs, err := s.GetStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(msgs)), bs.RxSeq.Int64())
+	assert.Equal(t, int64(10), bs.Verifier.Height)
+	assert.Equal(t, node.Height(), bs.CurrentHeight)
+}
+
+func TestSender_GetResult(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	s := newTestSender(t, node, nil)
+
+	//reverted by BMV, the code is parsed from revert message
+	node.InjectRevert(bsctest.BMVNotVerifiable)
+	err := relay(s, []byte("message0"))
+	assert.Error(t, err)
+	assert.Equal(t, BMVNotVerifiable, errors.CodeOf(err))
+	assert.Equal(t, 0, len(node.Messages(testIconAddress.String())))
+
+	//pending transaction is waited
+	node.InjectPending(1)
+	assert.NoError(t, relay(s, []byte("message0")))
+	assert.Equal(t, 1, len(node.Messages(testIconAddress.String())))
+
+	//reverted by BMC
+	node.SetRelayHandler(func(l *bsctest.Link, msg []byte) int {
+		return bsctest.BMCRevertUnauthorized
+	})
+	err = relay(s, []byte("message1"))
+	assert.Equal(t, BMCRevertUnauthorized, errors.CodeOf(err))
+}
+
+func TestNewSender_InvalidOption(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	w, err := wallet.NewEvmWalletFromPrivateKey(key)
+	assert.NoError(t, err)
+
+	//the link fails to start instead of panic
+	_, err = NewSender(testIconAddress, testBscAddress, w, []string{node.URL()},
+		map[string]interface{}{"gas_strategy": "unknown"}, log.New())
+	assert.True(t, errors.IllegalArgumentError.Equals(err))
+}
+
+func TestSender_Replace(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	s := newTestSender(t, node, map[string]interface{}{
+		"gas_strategy":    "fixed",
+		"gas_price":       "1gwei",
+		"gas_ceiling":     "2gwei",
+		"replace_timeout": "1ms",
+	})
+
+	//stuck transaction is replaced with bumped gas price
+	node.HoldTransactions(1)
+	p, err := s.Relay(&chain.Segment{TransactionParam: []byte("message0")})
+	assert.NoError(t, err)
+	hash := p.(*TransactionHashParam).Hash
+	_, err = s.GetResult(p)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, p.(*TransactionHashParam).Hash)
+	assert.Equal(t, 2, node.Calls("eth_sendRawTransaction"))
+	assert.Equal(t, 1, len(node.Messages(testIconAddress.String())))
+	tx, _, err := s.c.GetTransaction(p.(*TransactionHashParam).Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1100000000), tx.GasPrice())
+}
+
+func TestSender_ReplaceOverCeiling(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	s := newTestSender(t, node, map[string]interface{}{
+		"gas_strategy":    "fixed",
+		"gas_price":       "1gwei",
+		"gas_ceiling":     "1gwei",
+		"replace_timeout": "1ms",
+	})
+
+	//gas price couldn't be bumped, then the original one is waited
+	node.HoldTransactions(1)
+	p, err := s.Relay(&chain.Segment{TransactionParam: []byte("message0")})
+	assert.NoError(t, err)
+	hash := p.(*TransactionHashParam).Hash
+	time.AfterFunc(2*DefaultGetRelayResultInterval, func() {
+		node.MinePending()
+	})
+	_, err = s.GetResult(p)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, p.(*TransactionHashParam).Hash)
+	assert.Equal(t, 1, node.Calls("eth_sendRawTransaction"))
+	assert.Equal(t, 1, len(node.Messages(testIconAddress.String())))
+}
+
+func TestSender_RelayPipelined(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	s := newTestSender(t, node, map[string]interface{}{"replace_timeout": "1ms"})
+
+	//segments are sent without waiting result, the nonce is allocated locally
+	node.HoldTransactions(1)
+	msgs := [][]byte{[]byte("message0"), []byte("message1"), []byte("message2")}
+	ps := make([]chain.GetResultParam, 0, len(msgs))
+	for _, msg := range msgs {
+		p, err := s.Relay(&chain.Segment{TransactionParam: msg})
+		assert.NoError(t, err)
+		ps = append(ps, p)
+	}
+	assert.Equal(t, 1, node.Calls("eth_getTransactionCount"))
+	assert.Equal(t, 0, len(node.Messages(testIconAddress.String())))
+
+	//the first one is dropped, then it's resent for the following ones
+	assert.Equal(t, 1, node.DropPending())
+	for i := len(ps) - 1; i >= 0; i-- {
+		_, err := s.GetResult(ps[i])
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, msgs, node.Messages(testIconAddress.String()))
+
+	//sending failure releases the nonce
+	node.InjectError("eth_sendRawTransaction", bsctest.ErrorCodeServer, "transient")
+	_, err := s.Relay(&chain.Segment{TransactionParam: []byte("message3")})
+	assert.Error(t, err)
+	assert.NoError(t, relay(s, []byte("message3")))
+	assert.Equal(t, 4, len(node.Messages(testIconAddress.String())))
+}
+
+func TestSender_RemoteSigner(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	w, err := wallet.NewEvmWalletFromPrivateKey(key)
+	assert.NoError(t, err)
+	signer, err := wallet.NewSigner(w)
+	assert.NoError(t, err)
+	srv := httptest.NewServer(signer)
+	defer srv.Close()
+
+	//transactions are signed by the signer, the key is not given to the sender
+	rw, err := wallet.NewRemoteWallet(&wallet.RemoteConfig{Endpoint: srv.URL})
+	assert.NoError(t, err)
+	defer rw.Close()
+	node.AddLink(testIconAddress.String(), 0)
+	cs, err := NewSender(testIconAddress, testBscAddress, rw, []string{node.URL()}, nil, log.New())
+	assert.NoError(t, err)
+	s := cs.(*sender)
+	assert.NoError(t, relay(s, []byte("message0")))
+	assert.Equal(t, 1, len(node.Messages(testIconAddress.String())))
+}
+
+func TestSender_Fee(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	s := newTestSender(t, node, nil)
+	addr := HexToAddress(s.w.Address())
+
+	initial := big.NewInt(1e18)
+	node.SetBalance(addr, initial)
+	balance, err := s.Balance()
+	assert.NoError(t, err)
+	assert.Equal(t, initial, balance)
+
+	var fees []*big.Int
+	s.SetFeeListener(func(fee *big.Int) {
+		fees = append(fees, fee)
+	})
+	assert.NoError(t, relay(s, []byte("message0")))
+	assert.Equal(t, 1, len(fees))
+	assert.Equal(t, 1, fees[0].Sign())
+
+	//the fee is charged from the balance
+	balance, err = s.Balance()
+	assert.NoError(t, err)
+	assert.Equal(t, new(big.Int).Sub(initial, fees[0]), balance)
+}
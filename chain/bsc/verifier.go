@@ -0,0 +1,168 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bsc
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/mta"
+	"github.com/icon-project/btp/common/policy"
+)
+
+// Hash returns the block hash of the header.
+func (h *Header) Hash() common.Hash {
+	return (&types.Header{
+		ParentHash:  h.ParentHash,
+		UncleHash:   h.UncleHash,
+		Coinbase:    h.Coinbase,
+		Root:        h.Root,
+		TxHash:      h.TxHash,
+		ReceiptHash: h.ReceiptHash,
+		Bloom:       types.BytesToBloom(h.Bloom),
+		Difficulty:  new(big.Int).SetUint64(h.Difficulty),
+		Number:      new(big.Int).SetUint64(h.Number),
+		GasLimit:    h.GasLimit,
+		GasUsed:     h.GasUsed,
+		Time:        h.Time,
+		Extra:       h.Extra,
+		MixDigest:   h.MixDigest,
+		Nonce:       h.Nonce,
+	}).Hash()
+}
+
+// WitnessVerifier verifies the witness of the block hash, the witness is made at the height of accumulator.
+type WitnessVerifier func(w *chain.BlockWitness, height int64, hash []byte) error
+
+// VerifyRelayMessage checks ReceiptProofs of the relay message with receipt root of the last header,
+// and BlockWitness of BlockProof with verifyWitness.
+func VerifyRelayMessage(b []byte, verifyWitness WitnessVerifier) error {
+	msg := &RelayMessage{}
+	if _, err := codec.RLP.UnmarshalFromBytes(b, msg); err != nil {
+		return errors.Wrap(err, "fail to decode RelayMessage")
+	}
+	var h *Header
+	for i, bub := range msg.BlockUpdates {
+		bu := &BlockUpdate{}
+		if _, err := codec.RLP.UnmarshalFromBytes(bub, bu); err != nil {
+			return errors.Wrapf(err, "fail to decode BlockUpdate block_updates[%d]", i)
+		}
+		h = &Header{}
+		if _, err := codec.RLP.UnmarshalFromBytes(bu.BlockHeader, h); err != nil {
+			return errors.Wrapf(err, "fail to decode Header block_updates[%d]", i)
+		}
+	}
+	if len(msg.BlockProof) > 0 {
+		bp := &chain.BlockProof{}
+		if _, err := codec.RLP.UnmarshalFromBytes(msg.BlockProof, bp); err != nil {
+			return errors.Wrap(err, "fail to decode BlockProof")
+		}
+		bh := &Header{}
+		if _, err := codec.RLP.UnmarshalFromBytes(bp.Header, bh); err != nil {
+			return errors.Wrap(err, "fail to decode Header of BlockProof")
+		}
+		if bp.BlockWitness == nil {
+			return errors.InvalidStateError.New("no BlockWitness of BlockProof")
+		}
+		if err := verifyWitness(bp.BlockWitness, int64(bh.Number), bh.Hash().Bytes()); err != nil {
+			return errors.InvalidStateError.Wrapf(err, "invalid BlockWitness height:%d at:%d",
+				bh.Number, bp.BlockWitness.Height)
+		}
+		if h == nil {
+			h = bh
+		}
+	}
+	if len(msg.ReceiptProofs) > 0 && h == nil {
+		return errors.InvalidStateError.New("no header for ReceiptProofs")
+	}
+	for i, rpb := range msg.ReceiptProofs {
+		rp := &ReceiptProof{}
+		if _, err := codec.RLP.UnmarshalFromBytes(rpb, rp); err != nil {
+			return errors.Wrapf(err, "fail to decode ReceiptProof receipt_proofs[%d]", i)
+		}
+		if err := verifyReceiptProof(h.ReceiptHash, rp); err != nil {
+			return errors.InvalidStateError.Wrapf(err, "invalid ReceiptProof receipt_proofs[%d] height:%d index:%d",
+				i, h.Number, rp.Index)
+		}
+	}
+	return nil
+}
+
+func verifyReceiptProof(root common.Hash, rp *ReceiptProof) error {
+	var nodes [][]byte
+	if _, err := codec.RLP.UnmarshalFromBytes(rp.Proof, &nodes); err != nil {
+		return err
+	}
+	db := memorydb.New()
+	for _, n := range nodes {
+		if err := db.Put(crypto.Keccak256(n), n); err != nil {
+			return err
+		}
+	}
+	key, err := rlp.EncodeToBytes(uint(rp.Index))
+	if err != nil {
+		return err
+	}
+	v, err := trie.VerifyProof(root, key, db)
+	if err != nil {
+		return err
+	}
+	if len(v) == 0 {
+		return errors.NotFoundError.New("receipt not found")
+	}
+	return nil
+}
+
+// verify returns whether the segment can be sent, unverified one is held back with the report.
+// rmsMtx should be locked.
+func (s *SimpleChain) verify(rm *chain.RelayMessage, segment *chain.Segment) bool {
+	if !s.cfg.Verify {
+		return true
+	}
+	b, ok := segment.TransactionParam.([]byte)
+	if !ok {
+		return true
+	}
+	err := VerifyRelayMessage(b, s.verifyWitness)
+	if err == nil {
+		return true
+	}
+	s.l.Errorf("fail to verify rm:%d [h:%d,seq:%d] err:%+v", rm.Seq, segment.Height, segment.EventSequence, err)
+	s.m.Failed(policy.CodeUnverified)
+	s.resend(0, func() {
+		s._quarantine(rm, segment)
+	})
+	return false
+}
+
+func (s *SimpleChain) verifyWitness(bw *chain.BlockWitness, height int64, hash []byte) error {
+	vs := &VerifierStatus_v1{}
+	if _, err := codec.RLP.UnmarshalFromBytes(s.status().Verifier.Extra, vs); err != nil {
+		return err
+	}
+	w := mta.HashesToWitness(bw.Witness, height-1-s.acc.Offset())
+	return s.acc.VerifyAt(w, hash, bw.Height, vs.Offset)
+}
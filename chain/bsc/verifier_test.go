@@ -0,0 +1,91 @@
+package bsc
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/db"
+	"github.com/icon-project/btp/common/mta"
+)
+
+func TestVerifyRelayMessage(t *testing.T) {
+	receipts := []*types.Receipt{
+		{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*types.Log{}},
+		{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 42000, Logs: []*types.Log{}},
+		{Status: types.ReceiptStatusFailed, CumulativeGasUsed: 63000, Logs: []*types.Log{}},
+	}
+	tr, err := trieFromReceipts(receipts)
+	assert.NoError(t, err)
+	key, _ := codec.RLP.MarshalToBytes(uint(1))
+	nodes, err := receiptProof(tr, key)
+	assert.NoError(t, err)
+	proof, err := codec.RLP.MarshalToBytes(nodes)
+	assert.NoError(t, err)
+	rp := codec.RLP.MustMarshalToBytes(&ReceiptProof{Index: 1, Proof: proof})
+
+	//block 1 ~ 4 in accumulator, block 3 has receipts
+	database := db.NewMapDB()
+	defer database.Close()
+	bk, err := database.GetBucket("Accumulator")
+	assert.NoError(t, err)
+	acc := mta.NewExtAccumulator([]byte("Accumulator"), bk, 0)
+	headers := make([]*Header, 4)
+	for i := range headers {
+		headers[i] = &Header{Number: uint64(i + 1), Extra: []byte{}, Bloom: []byte{}}
+		if i == 2 {
+			headers[i].ReceiptHash = tr.Hash()
+		}
+		acc.AddHash(headers[i].Hash().Bytes())
+	}
+	assert.NoError(t, acc.Flush())
+	verifyWitness := func(w *chain.BlockWitness, height int64, hash []byte) error {
+		return acc.VerifyAt(mta.HashesToWitness(w.Witness, height-1-acc.Offset()), hash, w.Height, 0)
+	}
+	newBlockProof := func(h *Header) []byte {
+		at, w, err := acc.WitnessForAt(int64(h.Number), 4, 0)
+		assert.NoError(t, err)
+		return codec.RLP.MustMarshalToBytes(&chain.BlockProof{
+			Header:       codec.RLP.MustMarshalToBytes(h),
+			BlockWitness: &chain.BlockWitness{Height: at, Witness: mta.WitnessesToHashes(w)},
+		})
+	}
+	newBlockUpdate := func(h *Header) []byte {
+		return codec.RLP.MustMarshalToBytes(&BlockUpdate{BlockHeader: codec.RLP.MustMarshalToBytes(h)})
+	}
+	verify := func(msg *RelayMessage) error {
+		return VerifyRelayMessage(codec.RLP.MustMarshalToBytes(msg), verifyWitness)
+	}
+
+	//receipts with BlockUpdate
+	assert.NoError(t, verify(&RelayMessage{
+		BlockUpdates:  [][]byte{newBlockUpdate(headers[1]), newBlockUpdate(headers[2])},
+		ReceiptProofs: [][]byte{rp},
+	}))
+	//receipts with BlockProof
+	assert.NoError(t, verify(&RelayMessage{
+		BlockUpdates:  [][]byte{},
+		BlockProof:    newBlockProof(headers[2]),
+		ReceiptProofs: [][]byte{rp},
+	}))
+	//receipts of other block
+	assert.Error(t, verify(&RelayMessage{
+		BlockUpdates:  [][]byte{newBlockUpdate(headers[3])},
+		ReceiptProofs: [][]byte{rp},
+	}))
+	//BlockProof with the header which is not in accumulator
+	h := *headers[2]
+	h.GasUsed = 1
+	bp := &chain.BlockProof{}
+	_, err = codec.RLP.UnmarshalFromBytes(newBlockProof(headers[2]), bp)
+	assert.NoError(t, err)
+	bp.Header = codec.RLP.MustMarshalToBytes(&h)
+	assert.Error(t, verify(&RelayMessage{
+		BlockUpdates:  [][]byte{},
+		BlockProof:    codec.RLP.MustMarshalToBytes(bp),
+		ReceiptProofs: [][]byte{rp},
+	}))
+}
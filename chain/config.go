@@ -20,23 +20,61 @@ import (
 	"encoding/json"
 
 	"github.com/icon-project/btp/common/config"
+	"github.com/icon-project/btp/common/fee"
+	"github.com/icon-project/btp/common/health"
+	"github.com/icon-project/btp/common/policy"
+	"github.com/icon-project/btp/common/segment"
+	"github.com/icon-project/btp/common/wallet"
 )
 
 type BaseConfig struct {
 	Address      BtpAddress             `json:"address"`
 	Endpoint     string                 `json:"endpoint"`
+	Endpoints    []string               `json:"endpoints,omitempty"`
 	Nid          int64                  `json:"nid"`
 	KeyStoreData json.RawMessage        `json:"key_store"`
 	KeyStorePass string                 `json:"key_password,omitempty"`
 	KeySecret    string                 `json:"key_secret,omitempty"`
+	Signer       *wallet.RemoteConfig   `json:"signer,omitempty"`  //KeyStore is not used if it's given
+	PKCS11       *wallet.PKCS11Config   `json:"pkcs11,omitempty"`  //KeyStore is not used if it's given
+	Balance      *fee.Config            `json:"balance,omitempty"` //thresholds of the balance of the wallet, it pays for the direction from this chain
 	Options      map[string]interface{} `json:"options,omitempty"`
 }
 
+// EndpointList returns Endpoint followed by Endpoints for failover, in order of preference.
+func (c *BaseConfig) EndpointList() []string {
+	l := make([]string, 0, len(c.Endpoints)+1)
+	if c.Endpoint != "" {
+		l = append(l, c.Endpoint)
+	}
+	for _, e := range c.Endpoints {
+		if e != c.Endpoint {
+			l = append(l, e)
+		}
+	}
+	return l
+}
+
 type Config struct {
 	config.FileConfig `json:",squash"` //instead of `mapstructure:",squash"`
-	Src               BaseConfig `json:"src"`
-	Dst               BaseConfig `json:"dst"`
-	MaxSizeTx         bool       `json:"maxSizeTx"`
-	Direction         string     `json:"direction"`
-	Offset            int64      `json:"offset"`
+	segment.Config    `json:",squash"`
+	Name              string         `json:"name,omitempty"` //name of link, it's required to serve multiple links in a process
+	Src               BaseConfig     `json:"src"`
+	Dst               BaseConfig     `json:"dst"`
+	Direction         string         `json:"direction"`
+	Offset            int64          `json:"offset"`
+	Policy            *policy.Config `json:"policy,omitempty"`
+	Watchdog          *health.Config `json:"watchdog,omitempty"`
+	Verify            bool           `json:"verify,omitempty"`         //verify relay messages locally before sending
+	DryRun            string         `json:"dry_run,omitempty"`        //output path of dry-run records, dry-run is disabled if empty
+	DryRunStatus      string         `json:"dry_run_status,omitempty"` //path of status file for dry-run confirmation
+}
+
+// DirectionName returns the name of the direction from Src, it's used for probes and admin.
+// network address of Src is used if Name is empty, otherwise it's suffixed by '@' and Name.
+func (c *Config) DirectionName() string {
+	if c.Name == "" {
+		return c.Src.Address.NetworkAddress()
+	}
+	return c.Src.Address.NetworkAddress() + "@" + c.Name
 }
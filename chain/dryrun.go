@@ -0,0 +1,151 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/icon-project/btp/common/dryrun"
+)
+
+// FeeEstimator is implemented by Sender which could estimate the fee of the segment without sending.
+type FeeEstimator interface {
+	EstimateFee(segment *Segment) (*big.Int, error)
+}
+
+// DecodeFunc returns the readable form of TransactionParam of the segment.
+type DecodeFunc func(b []byte) (interface{}, error)
+
+type dryRunSender struct {
+	Sender
+	src    BtpAddress
+	dst    BtpAddress
+	method string
+	decode DecodeFunc
+	r      *dryrun.Recorder
+	t      *dryrun.Tracker
+}
+
+type dryRunResult struct {
+	Height        int64
+	EventSequence int64
+}
+
+// progressOf returns height and sequence which would be reflected by the segment.
+func progressOf(segment *Segment) (int64, int64) {
+	if segment.NumberOfEvent > 0 && segment.EventSequence != nil {
+		return segment.Height, segment.EventSequence.Int64()
+	}
+	return segment.Height, 0
+}
+
+func (s *dryRunSender) Relay(segment *Segment) (GetResultParam, error) {
+	height, seq := progressOf(segment)
+	rec := &dryrun.Record{
+		Src:           s.src.String(),
+		Dst:           s.dst.String(),
+		Method:        s.method,
+		Height:        height,
+		EventSequence: seq,
+		NumberOfEvent: segment.NumberOfEvent,
+	}
+	var errs []error
+	if b, ok := segment.TransactionParam.([]byte); ok {
+		rec.Size = len(b)
+		if s.decode != nil {
+			var err error
+			if rec.Message, err = s.decode(b); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if fe, ok := s.Sender.(FeeEstimator); ok {
+		if fee, err := fe.EstimateFee(segment); err != nil {
+			errs = append(errs, err)
+		} else {
+			rec.Fee = fee.String()
+		}
+	}
+	if len(errs) > 0 {
+		rec.Error = fmt.Sprint(errs)
+	}
+	if err := s.r.Record(rec); err != nil {
+		return nil, err
+	}
+	return &dryRunResult{Height: height, EventSequence: seq}, nil
+}
+
+// GetResult returns after the segment is reflected to the status of dryrun.Tracker.
+func (s *dryRunSender) GetResult(p GetResultParam) (TransactionResult, error) {
+	dr, ok := p.(*dryRunResult)
+	if !ok {
+		return nil, fmt.Errorf("fail to cast *dryRunResult %T", p)
+	}
+	s.t.Confirm(dr.Height, dr.EventSequence)
+	if err := s.t.Wait(dr.Height, dr.EventSequence); err != nil {
+		return nil, err
+	}
+	return dr, nil
+}
+
+// GetStatus returns the status of the destination with the progress of dryrun.Tracker.
+func (s *dryRunSender) GetStatus() (*BMCLinkStatus, error) {
+	bs, err := s.Sender.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+	s.t.Init(dryrun.Status{Height: bs.Verifier.Height, RxSeq: bs.RxSeq.Int64()})
+	st, err := s.t.Status()
+	if err != nil {
+		return nil, err
+	}
+	ds := *bs
+	ds.Verifier.Height = st.Height
+	ds.RxSeq = big.NewInt(st.RxSeq)
+	return &ds, nil
+}
+
+// GetRouteTable returns the route table of the destination if s implements RouteLoader.
+func (s *dryRunSender) GetRouteTable() (*RouteTable, error) {
+	if rl, ok := s.Sender.(RouteLoader); ok {
+		return rl.GetRouteTable()
+	}
+	return nil, fmt.Errorf("not supported GetRouteTable %T", s.Sender)
+}
+
+// NewDryRunSender returns Sender which records segments by r instead of sending with s,
+// the method is the name of BMC method to be called and decode is used to record readable message.
+// Close closes the wrapped Sender if it's Closer.
+func (s *dryRunSender) Close() {
+	if c, ok := s.Sender.(Closer); ok {
+		c.Close()
+	}
+}
+
+func NewDryRunSender(s Sender, src, dst BtpAddress, method string, decode DecodeFunc,
+	r *dryrun.Recorder, t *dryrun.Tracker) Sender {
+	return &dryRunSender{
+		Sender: s,
+		src:    src,
+		dst:    dst,
+		method: method,
+		decode: decode,
+		r:      r,
+		t:      t,
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/icon-project/btp/common/admin"
+	"github.com/icon-project/btp/common/db"
+	"github.com/icon-project/btp/common/fee"
+	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
+)
+
+// FeeAccount records fees of the relay account of a direction in the ledger,
+// and watches its balance if Sender implements BalanceGetter.
+// relaying should be paused while IsLow returns true.
+type FeeAccount struct {
+	fl        *fee.Ledger
+	fm        *fee.Monitor
+	low       int32
+	m         *metrics.LinkMetrics
+	onRecover func()
+	l         log.Logger
+}
+
+func (a *FeeAccount) onFee(v *big.Int) {
+	if err := a.fl.Add(time.Now(), v); err != nil {
+		a.l.Warnf("fail to add fee:%s err:%+v", v, err)
+	}
+	if a.fm != nil {
+		a.fm.Spend(v)
+	}
+}
+
+func (a *FeeAccount) onBalance(balance *big.Int, lv, prev fee.Level) {
+	a.m.Balance.Set(metrics.BigFloat(balance))
+	switch lv {
+	case fee.LevelStop:
+		if atomic.CompareAndSwapInt32(&a.low, 0, 1) {
+			a.l.Errorf("relaying is paused by low balance:%s", balance)
+		}
+		return
+	case fee.LevelWarn:
+		if lv != prev {
+			a.l.Warnf("low balance:%s", balance)
+		}
+	}
+	if atomic.CompareAndSwapInt32(&a.low, 1, 0) {
+		a.l.Infof("relaying is resumed by balance:%s", balance)
+		if a.onRecover != nil {
+			a.onRecover()
+		}
+	}
+}
+
+// IsLow returns whether the balance is under the stop threshold, it's safe on nil.
+func (a *FeeAccount) IsLow() bool {
+	return a != nil && atomic.LoadInt32(&a.low) == 1
+}
+
+// Balance returns the last known balance in string, empty if it's unknown. it's safe on nil.
+func (a *FeeAccount) Balance() string {
+	if a == nil || a.fm == nil {
+		return ""
+	}
+	if balance, _ := a.fm.Balance(); balance != nil {
+		return balance.String()
+	}
+	return ""
+}
+
+func (a *FeeAccount) Report() (*admin.FeeReport, error) {
+	days, err := a.fl.Days()
+	if err != nil {
+		return nil, err
+	}
+	cnt, total := fee.Total(days)
+	r := &admin.FeeReport{
+		Balance: a.Balance(),
+		Level:   fee.LevelNormal,
+		Count:   cnt,
+		Total:   total.String(),
+		Days:    days,
+	}
+	if a.fm != nil {
+		_, r.Level = a.fm.Balance()
+	}
+	return r, nil
+}
+
+// Start starts polling of the balance, it does nothing without BalanceGetter.
+func (a *FeeAccount) Start() {
+	if a.fm != nil {
+		a.fm.Start()
+	}
+}
+
+func (a *FeeAccount) Stop() {
+	if a.fm != nil {
+		a.fm.Stop()
+	}
+}
+
+// NewFeeAccount returns FeeAccount with the ledger in the database of the link,
+// onRecover is called when the balance is recovered over the stop threshold.
+func NewFeeAccount(database db.Database, s Sender, cfg *fee.Config, m *metrics.LinkMetrics, onRecover func(), l log.Logger) (*FeeAccount, error) {
+	fl, err := fee.NewLedger(database)
+	if err != nil {
+		return nil, err
+	}
+	a := &FeeAccount{
+		fl:        fl,
+		m:         m,
+		onRecover: onRecover,
+		l:         l,
+	}
+	if fn, ok := s.(FeeNotifier); ok {
+		fn.SetFeeListener(a.onFee)
+	}
+	if bg, ok := s.(BalanceGetter); ok {
+		a.fm = fee.NewMonitor(cfg, bg.Balance, a.onBalance, l)
+	}
+	return a, nil
+}
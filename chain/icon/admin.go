@@ -0,0 +1,159 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icon
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/common/admin"
+	"github.com/icon-project/btp/common/errors"
+)
+
+var _ admin.Link = (*SimpleChain)(nil)
+var _ admin.FeeReporter = (*SimpleChain)(nil)
+
+// isPaused returns true if it's paused by admin or low balance of the relay account.
+func (s *SimpleChain) isPaused() bool {
+	return atomic.LoadInt32(&s.paused) == 1 || s.fa.IsLow()
+}
+
+func (s *SimpleChain) Status() (*admin.LinkStatus, error) {
+	s.rmsMtx.RLock()
+	defer s.rmsMtx.RUnlock()
+	ls := &admin.LinkStatus{
+		Src:         s.src.String(),
+		Dst:         s.dst.String(),
+		Paused:      s.isPaused(),
+		Quarantined: len(s.qrms),
+		Balance:     s.fa.Balance(),
+		LowBalance:  s.fa.IsLow(),
+	}
+	for _, rm := range s.rms {
+		if len(rm.Messages) > 0 {
+			ls.Queued++
+		}
+	}
+	if bs := s.status(); bs != nil {
+		ls.TxSeq = bs.TxSeq.String()
+		ls.RxSeq = bs.RxSeq.String()
+		ls.VerifierHeight = bs.Verifier.Height
+		ls.CurrentHeight = bs.CurrentHeight
+	}
+	return ls, nil
+}
+
+func (s *SimpleChain) RelayMessages() ([]*admin.RelayMessage, error) {
+	s.rmsMtx.RLock()
+	defer s.rmsMtx.RUnlock()
+	l := make([]*admin.RelayMessage, 0, len(s.rms))
+	for _, rm := range s.rms {
+		if len(rm.Messages) == 0 {
+			continue
+		}
+		arm := &admin.RelayMessage{
+			ID:       strconv.FormatUint(rm.id, 10),
+			Height:   rm.Height(),
+			Segments: make([]*admin.Segment, 0),
+		}
+		if segment := rm.Segments(); segment != nil {
+			arm.Segments = append(arm.Segments, adminSegmentOf(segment))
+		}
+		l = append(l, arm)
+	}
+	return l, nil
+}
+
+func adminSegmentOf(segment *chain.Segment) *admin.Segment {
+	as := &admin.Segment{
+		Height:        segment.Height,
+		NumberOfEvent: segment.NumberOfEvent,
+	}
+	if segment.EventSequence != nil {
+		as.EventSequence = segment.EventSequence.String()
+	}
+	switch p := segment.GetResultParam.(type) {
+	case *TransactionHashParam:
+		as.TxHash = string(p.Hash)
+	case *FragmentsParam:
+		for _, f := range p.Fragments() {
+			as.Fragments = append(as.Fragments, &admin.Fragment{Index: f.Index, TxHash: string(f.TxHash), Done: f.Done})
+		}
+	}
+	return as
+}
+
+func (s *SimpleChain) Pause() {
+	atomic.StoreInt32(&s.paused, 1)
+	s.l.Infof("paused")
+}
+
+// Resume resumes relaying paused by admin, it's still paused while the balance is low.
+func (s *SimpleChain) Resume() {
+	if atomic.CompareAndSwapInt32(&s.paused, 1, 0) {
+		s.l.Infof("resumed")
+		s.relayInBackground()
+	}
+}
+
+func (s *SimpleChain) relayInBackground() {
+	go func() {
+		if err := s.relay(); err != nil {
+			s.shutdown(err)
+		}
+	}()
+}
+
+func (s *SimpleChain) Fees() (*admin.FeeReport, error) {
+	if s.fa == nil {
+		return nil, errors.InvalidStateError.New("not served")
+	}
+	return s.fa.Report()
+}
+
+func (s *SimpleChain) Refresh() error {
+	if err := s.RefreshStatus(); err != nil {
+		return err
+	}
+	bs := s.status()
+	s.updateRelayMessage(bs.Verifier.Height, bs.RxSeq)
+	return nil
+}
+
+func (s *SimpleChain) Drop(id string) error {
+	v, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return errors.IllegalArgumentError.Errorf("invalid id:%s", id)
+	}
+	s.rmsMtx.Lock()
+	defer s.rmsMtx.Unlock()
+	for i, rm := range s.rms {
+		if rm.id == v && len(rm.Messages) > 0 {
+			s.rms = append(s.rms[:i], s.rms[i+1:]...)
+			s.m.Queued.Set(float64(len(s.rms)))
+			if s.rs != nil {
+				if err = s.rs.Retain(s.bds, s.rms); err != nil {
+					s.l.Warnf("fail to retain relayStore err:%+v", err)
+				}
+			}
+			s.l.Warnf("drop rm:%d height:%d", rm.id, rm.Height())
+			return nil
+		}
+	}
+	return errors.NotFoundError.Errorf("not found relay message id:%s", id)
+}
@@ -19,14 +19,21 @@ package icon
 import (
 	"fmt"
 	"math/big"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/icon-project/btp/chain"
 	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/db"
 	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/health"
 	"github.com/icon-project/btp/common/log"
 	"github.com/icon-project/btp/common/mbt"
+	"github.com/icon-project/btp/common/metrics"
+	"github.com/icon-project/btp/common/policy"
+	"github.com/icon-project/btp/common/segment"
 )
 
 type chainInfo struct {
@@ -42,18 +49,35 @@ type SimpleChain struct {
 	src chain.BtpAddress
 	dst chain.BtpAddress
 
-	bs  *chain.BMCLinkStatus
-	l   log.Logger
-	cfg *chain.Config
+	bs    *chain.BMCLinkStatus
+	bsMtx sync.RWMutex
+	l     log.Logger
+	cfg   *chain.Config
 
-	bds []*BTPBlockData
-	rms []*BTPRelayMessage
+	bds  []*BTPBlockData
+	rms  []*BTPRelayMessage
+	rs   *relayStore
+	qrms []*BTPRelayMessage
+	sg   *segment.Segmenter
 
 	rmsMtx      sync.RWMutex
 	rmSeq       uint64
 	heightOfDst int64
 
-	relayble bool
+	relayble  bool
+	paused    int32
+	pe        *policy.Engine
+	errCh     chan error
+	m         *metrics.LinkMetrics
+	wd        *health.Watchdog
+	restartCh chan error
+	loopGen   int32
+	database  db.Database
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	tr        *chain.MessageTracker
+	fa        *chain.FeeAccount
+	seqOffset int64
 }
 
 func (s *SimpleChain) _log(prefix string, rm *BTPRelayMessage, segment *chain.Segment, segmentIdx int) {
@@ -76,18 +100,24 @@ func (s *SimpleChain) _log(prefix string, rm *BTPRelayMessage, segment *chain.Se
 	}
 }
 
+// relay sends segments which are not sent yet, rmsMtx is locked exclusively
+// because it's called concurrently and it updates segments and the journal.
 func (s *SimpleChain) relay() error {
-	s.rmsMtx.RLock()
-	defer s.rmsMtx.RUnlock()
-	var rmSize int
-	if s.cfg.MaxSizeTx {
-		rmSize = len(s.rms) - 1
-	} else {
-		rmSize = len(s.rms)
-		s.rms = append(s.rms, NewRelayMessage())
+	if s.isPaused() {
+		return nil
+	}
+	s.rmsMtx.Lock()
+	defer s.rmsMtx.Unlock()
+	//the last one is pending by Strategy
+	if err := s.sg.Commit(); err != nil {
+		return err
 	}
+	rmSize := len(s.rms) - 1
 
 	for i := 0; i < rmSize; i++ {
+		if len(s.rms[i].Messages) == 0 {
+			continue
+		}
 		s._log("before relay", s.rms[i], nil, -1)
 		b, err := codec.RLP.MarshalToBytes(s.rms[i])
 		if err != nil {
@@ -96,14 +126,24 @@ func (s *SimpleChain) relay() error {
 
 		if s.rms[i].Segments() == nil {
 			s.rms[i].SetSegments(s.rms[i].Height(), b, int64(s.rms[i].MessageSeq()))
+			s.persistRelayMessage(s.rms[i])
 		}
 
 		if s.rms[i].Segments().GetResultParam == nil {
+			if !s.verify(s.rms[i]) {
+				return nil
+			}
 			s.rms[i].Segments().TransactionResult = nil
 			if s.rms[i].Segments().GetResultParam, err = s.s.Relay(s.rms[i].Segments()); err != nil {
-				s.l.Panicf("fail to Relay err:%+v", err)
+				s.l.Debugf("fail to Relay err:%+v", err)
+				d := s.pe.Decide(s.rms[i].id, err)
+				s.m.Failed(d.Code)
+				s.handle(s.rms[i].Segments(), d)
+				return nil
 			}
 			s._log("after relay", s.rms[i], s.rms[i].Segments(), -1)
+			s.m.Sent.Inc()
+			s.persistRelayMessage(s.rms[i])
 			go s.result(s.rms[i].Segments())
 		}
 	}
@@ -113,142 +153,179 @@ func (s *SimpleChain) relay() error {
 func (s *SimpleChain) segment() error {
 	s.rmsMtx.Lock()
 	defer s.rmsMtx.Unlock()
-	bdsLen := len(s.bds) - 1
-	if len(s.rms) == 0 {
-		s.rms = append(s.rms, NewRelayMessage())
-	}
+	bd := s.bds[len(s.bds)-1]
 
 	//blockUpdate
-	s.BlockUpdateSegment(s.bds[bdsLen].Bu, s.bds[bdsLen].Height)
+	if err := s.BlockUpdateSegment(bd.Bu, bd.Height); err != nil {
+		return err
+	}
 	//messageProof
-	if s.bds[bdsLen].Mt != nil {
-		s.MessageSegment(s.bds[bdsLen])
+	if bd.Mt != nil {
+		if err := s.MessageSegment(bd); err != nil {
+			return err
+		}
+		s.persistBlockData(bd)
+	}
+	for _, rm := range s.rms {
+		if rm.Segments() == nil || rm.Segments().GetResultParam == nil {
+			s.persistRelayMessage(rm)
+		}
 	}
 	return nil
 }
 
 func (s *SimpleChain) BlockUpdateSegment(bu *BTPBlockUpdate, heightOfSrc int64) error {
-	//blockUpdate
 	//skipped first btp block
-	if heightOfSrc != s.ci.StartHeight {
-		tpm, err := NewTypePrefixedMessage(bu)
-		b, err := codec.RLP.MarshalToBytes(tpm)
-		if err != nil {
-			return err
-		}
-		rmsSize, err := s.rms[len(s.rms)-1].Size()
-		if err != nil {
-			return err
-		}
-		if s.isOverLimit(rmsSize + len(b)) {
-			s.rms = append(s.rms, NewRelayMessage())
-		}
-		s.rms[len(s.rms)-1].SetHeight(heightOfSrc)
-		s.rms[len(s.rms)-1].AppendMessage(tpm)
+	if heightOfSrc == s.ci.StartHeight {
+		return nil
 	}
-	return nil
+	tpm, err := NewTypePrefixedMessage(bu)
+	if err != nil {
+		return err
+	}
+	return s.sg.Add(&blockUpdateItem{tpm: tpm, height: heightOfSrc})
 }
 
+// MessageSegment adds messages of BTP block after PartialOffset, PartialOffset is updated by added ones.
 func (s *SimpleChain) MessageSegment(bd *BTPBlockData) error {
-	var endIndex int
-	for endIndex = bd.PartialOffset + 1; endIndex < bd.Mt.Len(); endIndex++ {
-		//TODO refactoring
-		p, err := bd.Mt.Proof(bd.PartialOffset+1, endIndex)
-		if err != nil {
-			return err
-		}
-		tpm, err := NewTypePrefixedMessage(*p)
-		b, err := codec.RLP.MarshalToBytes(tpm)
-		//if s.isOverLimit(s.rms[len(s.rms)-1].Size() + s.bds[bdIndex].Mt.ProofLength(s.bds[bdIndex].PartialOffset, endIndex)) {
-		rmsSize, err := s.rms[len(s.rms)-1].Size()
-		if err != nil {
-			return err
-		}
-		if s.isOverLimit(rmsSize + len(b)) {
-			s.rms = append(s.rms, NewRelayMessage())
-			if err != nil {
-				return err
-			}
-			bd.PartialOffset = endIndex
-
-			s.rms[len(s.rms)-1].SetHeight(bd.Height)
-			s.rms[len(s.rms)-1].SetMessageSeq(endIndex)
-			s.rms[len(s.rms)-1].AppendMessage(tpm)
-		}
+	bh := &BTPBlockHeader{}
+	if _, err := codec.RLP.UnmarshalFromBytes(bd.Bu.BTPBlockHeader, bh); err != nil {
+		return err
 	}
-	if bd.PartialOffset != bd.Mt.Len() {
-		p, err := bd.Mt.Proof(bd.PartialOffset+1, endIndex)
-		if err != nil {
-			return err
+	//sequences are used for the number of events if the offset is unknown
+	offset, ok := s.sequenceOffset()
+	if !ok {
+		offset = 0
+	}
+	for i := bd.PartialOffset + 1; i <= bd.Mt.Len(); i++ {
+		item := &messageItem{
+			bd:    bd,
+			index: i,
+			seq:   offset + bh.UpdateNumber>>1 + int64(i),
 		}
-
-		tpm, err := NewTypePrefixedMessage(*p)
-		if err != nil {
+		if err := s.sg.Add(item); err != nil {
 			return err
 		}
-
-		s.rms[len(s.rms)-1].SetHeight(bd.Height)
-		s.rms[len(s.rms)-1].SetMessageSeq(endIndex)
-		s.rms[len(s.rms)-1].AppendMessage(tpm)
-		bd.PartialOffset = bd.Mt.Len()
 	}
 	return nil
 }
 
-func (s *SimpleChain) updateSegments(h int64, seq *big.Int) error {
-	if err := s.RefreshStatus(); err != nil {
-		return err
+func (s *SimpleChain) result(segment *chain.Segment) {
+	if fp, ok := segment.GetResultParam.(*FragmentsParam); ok {
+		//journal transactions of fragments which are sent while waiting results
+		fp.onSend = func() {
+			s.rmsMtx.Lock()
+			defer s.rmsMtx.Unlock()
+			s.persistSegment(segment)
+		}
 	}
-	vs := &VerifierStatus{}
-	_, err := codec.RLP.UnmarshalFromBytes(s.bs.Verifier.Extra, vs)
+	txr, err := s.s.GetResult(segment.GetResultParam)
+	s.rmsMtx.Lock()
+	defer s.rmsMtx.Unlock()
+	segment.TransactionResult = txr
 	if err != nil {
-		return err
+		s.l.Debugf("fail to GetResult GetResultParam:%v err:%+v",
+			segment.GetResultParam, err)
+		d := s.pe.Decide(s.policyKeyOf(segment), err)
+		s.m.Failed(d.Code)
+		s.handle(segment, d)
+	} else {
+		s.m.Confirmed.Inc()
+		s.wd.OnRelay()
+		s.pe.Reset(s.policyKeyOf(segment))
 	}
+}
 
-	if !(s.bs.RxSeq.Int64() < s.rms[0].Height()) {
-		for i := 0; i < len(s.rms); i++ {
-			if (s.bs.RxSeq.Int64() <= s.rms[i].Height()) || (s.rms[i].Height() > h) {
-				s.rms[i].Segments().GetResultParam = nil
+// policyKeyOf returns the key of attempts for the segment, it's the id of relay message
+// which is kept while the message is resegmented, rmsMtx should be locked.
+func (s *SimpleChain) policyKeyOf(segment *chain.Segment) interface{} {
+	if rm := s.relayMessageOf(segment); rm != nil {
+		return rm.id
+	}
+	return segment
+}
+
+// handle executes the action of Decision for the segment, rmsMtx should be locked.
+func (s *SimpleChain) handle(segment *chain.Segment, d *policy.Decision) {
+	switch d.Action {
+	case policy.ActionRetry:
+		s.resend(d.Delay, func() {
+			segment.GetResultParam = nil
+			s.persistSegment(segment)
+		})
+	case policy.ActionResegment:
+		s.resend(d.Delay, func() {
+			if rm := s.relayMessageOf(segment); rm != nil {
+				rm.segments = nil
+				s.persistRelayMessage(rm)
 			}
+		})
+	case policy.ActionRefresh:
+		if err := s.RefreshStatus(); err != nil {
+			s.l.Warnf("fail to RefreshStatus err:%+v", err)
+		} else {
+			bs := s.status()
+			s._updateRelayMessage(bs.Verifier.Height, bs.RxSeq)
 		}
-	} else {
-		s.l.Panicf("No relay messages collected.")
+		s.resend(d.Delay, func() {
+			segment.GetResultParam = nil
+			s.persistSegment(segment)
+		})
+	case policy.ActionQuarantine:
+		if rm := s.relayMessageOf(segment); rm != nil {
+			s._quarantine(rm)
+		}
+	case policy.ActionShutdown:
+		s.shutdown(d.Err)
 	}
+}
 
-	return nil
+// _quarantine removes rm from relay queue and keeps it aside, rmsMtx should be locked.
+func (s *SimpleChain) _quarantine(rm *BTPRelayMessage) {
+	for i, v := range s.rms {
+		if v == rm {
+			s.rms = append(s.rms[:i], s.rms[i+1:]...)
+			s.qrms = append(s.qrms, rm)
+			s.m.Queued.Set(float64(len(s.rms)))
+			break
+		}
+	}
+	segment := rm.Segments()
+	s.l.Errorf("quarantine rm:%d [h:%d,seq:%d,txh:%v]",
+		rm.id, segment.Height, segment.EventSequence, segment.GetResultParam)
 }
 
-func (s *SimpleChain) result(segment *chain.Segment) {
-	s.rmsMtx.Lock()
-	defer s.rmsMtx.Unlock()
-	var err error
-	segment.TransactionResult, err = s.s.GetResult(segment.GetResultParam)
-	if err != nil {
-		if ec, ok := errors.CoderOf(err); ok {
-			s.l.Debugf("fail to GetResult GetResultParam:%v ErrorCoder:%+v",
-				segment.GetResultParam, ec)
-			switch ec.ErrorCode() {
-			case BMVUnknown:
-				s.l.Panicf("BMVUnknown Revert :%v ErrorCoder:%+v",
-					segment.GetResultParam, ec)
-			case BMVNotVerifiable:
-				s.updateSegments(segment.Height, segment.EventSequence)
-				segment.GetResultParam = nil
-			case BMVAlreadyVerified:
-				s.updateRelayMessage(segment.Height, segment.EventSequence)
-			case BMCRevertUnauthorized:
-				segment.GetResultParam = nil
-			default:
-				s.l.Panicf("fail to GetResult GetResultParam:%v ErrorCoder:%+v",
-					segment.GetResultParam, ec)
-			}
-		} else {
-			s.l.Panicf("fail to GetResult GetResultParam:%v err:%+v",
-				segment.GetResultParam, err)
+// resend calls reset with locked rmsMtx after delay, then relay.
+func (s *SimpleChain) resend(delay time.Duration, reset func()) {
+	time.AfterFunc(delay, func() {
+		if reset != nil {
+			s.rmsMtx.Lock()
+			reset()
+			s.rmsMtx.Unlock()
 		}
+		if err := s.relay(); err != nil {
+			s.shutdown(err)
+		}
+	})
+}
+
+func (s *SimpleChain) shutdown(err error) {
+	s.l.Errorf("shutdown err:%+v", err)
+	select {
+	case s.errCh <- err:
+	default:
 	}
 }
 
+func (s *SimpleChain) relayMessageOf(segment *chain.Segment) *BTPRelayMessage {
+	for _, rm := range s.rms {
+		if rm.Segments() == segment {
+			return rm
+		}
+	}
+	return nil
+}
+
 func (s *SimpleChain) isOverLimit(size int) bool {
 	return s.s.TxSizeLimit() < size
 }
@@ -271,6 +348,7 @@ func (s *SimpleChain) addRelayMessage(bu *BTPBlockUpdate, bh *BTPBlockHeader) er
 		if err != nil {
 			return err
 		}
+		s.trackMessages(bh, m)
 
 		if mt, err = mbt.NewMerkleBinaryTree(mbt.HashFuncByUID(s.ci.NetworkTypeName), m); err != nil {
 			return err
@@ -284,12 +362,29 @@ func (s *SimpleChain) addRelayMessage(bu *BTPBlockUpdate, bh *BTPBlockHeader) er
 		Height: bh.MainHeight}
 
 	s.bds = append(s.bds, btpBlock)
+	s.persistBlockData(btpBlock)
 	return nil
 }
 
+// trackMessages adds messages of the BTP block to MessageTracker with sequences of the link.
+func (s *SimpleChain) trackMessages(bh *BTPBlockHeader, msgs [][]byte) {
+	offset, ok := s.sequenceOffset()
+	if !ok {
+		return
+	}
+	for i, msg := range msgs {
+		seq := offset + bh.UpdateNumber>>1 + int64(i) + 1
+		s.tr.OnReceive(s.src.NetworkAddress(), s.dst.NetworkAddress(), seq, msg, s.l)
+	}
+}
+
 func (s *SimpleChain) updateRelayMessage(h int64, seq *big.Int) {
 	s.rmsMtx.Lock()
 	defer s.rmsMtx.Unlock()
+	s._updateRelayMessage(h, seq)
+}
+
+func (s *SimpleChain) _updateRelayMessage(h int64, seq *big.Int) {
 	s.l.Debugf("updateRelayMessage h:%d seq:%d monitorHeight:%d", h, seq, s.monitorHeight())
 	bdIndex := 0
 	rmIndex := 0
@@ -301,6 +396,10 @@ func (s *SimpleChain) updateRelayMessage(h int64, seq *big.Int) {
 	}
 
 	for i, rm := range s.rms {
+		//empty one is not relayed yet, it doesn't have height
+		if len(rm.Messages) == 0 {
+			continue
+		}
 		if (rm.Height() == h || big.NewInt(int64(rm.MessageSeq())) == seq) || rm.Height() < h {
 			rmIndex = i
 		}
@@ -308,22 +407,70 @@ func (s *SimpleChain) updateRelayMessage(h int64, seq *big.Int) {
 
 	s.bds = s.bds[bdIndex:]
 	s.rms = s.rms[rmIndex:]
+	s.m.Queued.Set(float64(len(s.rms)))
+	if s.rs != nil {
+		if err := s.rs.Retain(s.bds, s.rms); err != nil {
+			s.l.Warnf("fail to retain relayStore err:%+v", err)
+		}
+	}
+}
+
+func (s *SimpleChain) persistBlockData(bd *BTPBlockData) {
+	if s.rs == nil {
+		return
+	}
+	if err := s.rs.PutBlockData(bd); err != nil {
+		s.l.Warnf("fail to persist BTPBlockData height:%d err:%+v", bd.Height, err)
+	}
+}
+
+func (s *SimpleChain) persistRelayMessage(rm *BTPRelayMessage) {
+	if rm.id == 0 {
+		s.rmSeq++
+		rm.id = s.rmSeq
+	}
+	if s.rs == nil {
+		return
+	}
+	if err := s.rs.PutRelayMessage(rm); err != nil {
+		s.l.Warnf("fail to persist BTPRelayMessage height:%d err:%+v", rm.Height(), err)
+	}
+}
+
+func (s *SimpleChain) persistSegment(segment *chain.Segment) {
+	if rm := s.relayMessageOf(segment); rm != nil {
+		s.persistRelayMessage(rm)
+	}
 }
 
 func (s *SimpleChain) OnBlockOfDst(height int64) error {
 	s.l.Tracef("OnBlockOfDst height:%d", height)
 	atomic.StoreInt64(&s.heightOfDst, height)
-	h, seq := s.bs.Verifier.Height, s.bs.RxSeq
+	s.wd.OnDst(height)
+	bs := s.status()
+	h, seq := bs.Verifier.Height, bs.RxSeq
 	if err := s.RefreshStatus(); err != nil {
 		return err
 	}
-	if h != s.bs.Verifier.Height || seq != s.bs.RxSeq {
-		h, seq = s.bs.Verifier.Height, s.bs.RxSeq
+	if bs = s.status(); h != bs.Verifier.Height || seq != bs.RxSeq {
+		h, seq = bs.Verifier.Height, bs.RxSeq
 		s.updateRelayMessage(h, seq)
+		s.tr.OnDeliver(s.src.NetworkAddress(), s.dst.NetworkAddress(), seq.Int64(), s.l)
+	}
+	if s.due() {
+		//pending messages by latency
+		return s.relay()
 	}
 	return nil
 }
 
+// due returns true if pending messages should be relayed by Strategy.
+func (s *SimpleChain) due() bool {
+	s.rmsMtx.RLock()
+	defer s.rmsMtx.RUnlock()
+	return s.sg.Due()
+}
+
 func (s *SimpleChain) OnBlockOfSrc(bu *BTPBlockUpdate) error {
 	bh := &BTPBlockHeader{}
 	_, err := codec.RLP.UnmarshalFromBytes(bu.BTPBlockHeader, bh)
@@ -332,11 +479,21 @@ func (s *SimpleChain) OnBlockOfSrc(bu *BTPBlockUpdate) error {
 	}
 
 	s.l.Tracef("OnBlockOfSrc height:%d, bu.Height:%d", s.ci.StartHeight, bh.MainHeight)
+	if last := s.wd.SrcHeight(); last != 0 && bh.MainHeight <= last {
+		//duplicated by restarted ReceiveLoop
+		s.l.Debugf("skip OnBlockOfSrc height:%d last:%d", bh.MainHeight, last)
+		return nil
+	}
+	s.m.SrcHeight.Set(float64(bh.MainHeight))
+	if offset, ok := s.sequenceOffset(); ok && bh.MessageCount > 0 {
+		s.m.TxSeq.Set(float64(offset + bh.UpdateNumber>>1 + bh.MessageCount))
+	}
 	if s.ci.StartHeight == 0 {
 		s.SetChainInfo()
 	}
 
-	if s.relayble && bh.MainHeight != s.ci.StartHeight {
+	relayable := s.relayble && bh.MainHeight != s.ci.StartHeight
+	if relayable {
 		if err := s.addRelayMessage(bu, bh); err != nil {
 			return err
 		}
@@ -344,10 +501,11 @@ func (s *SimpleChain) OnBlockOfSrc(bu *BTPBlockUpdate) error {
 		if err := s.segment(); err != nil {
 			return err
 		}
-
-		if err := s.relay(); err != nil {
-			return err
-		}
+	}
+	//the block is skipped as duplicated after it's processed
+	s.wd.OnSrc(bh.MainHeight)
+	if relayable {
+		return s.relay()
 	}
 	return nil
 }
@@ -357,26 +515,51 @@ func (s *SimpleChain) RefreshStatus() error {
 	if err != nil {
 		return err
 	}
+	s.bsMtx.Lock()
 	s.bs = bmcStatus
+	s.bsMtx.Unlock()
+	s.m.SetStatus(bmcStatus.CurrentHeight, bmcStatus.Verifier.Height, bmcStatus.RxSeq)
+	vs := &VerifierStatus{}
+	if _, err = codec.RLP.UnmarshalFromBytes(bmcStatus.Verifier.Extra, vs); err == nil {
+		atomic.StoreInt64(&s.seqOffset, vs.SequenceOffset)
+	}
 	return nil
 }
 
+// status returns the last BMCLinkStatus, it's refreshed by result goroutines as well as MonitorLoop.
+func (s *SimpleChain) status() *chain.BMCLinkStatus {
+	s.bsMtx.RLock()
+	defer s.bsMtx.RUnlock()
+	return s.bs
+}
+
+// sequenceOffset returns SequenceOffset of the verifier on destination,
+// it's used by OnBlockOfSrc which runs concurrently with RefreshStatus.
+func (s *SimpleChain) sequenceOffset() (int64, bool) {
+	v := atomic.LoadInt64(&s.seqOffset)
+	return v, v >= 0
+}
+
 func (s *SimpleChain) init() error {
 	if err := s.RefreshStatus(); err != nil {
 		return err
 	}
-	atomic.StoreInt64(&s.heightOfDst, s.bs.CurrentHeight)
+	atomic.StoreInt64(&s.heightOfDst, s.status().CurrentHeight)
+	s.tr.LoadRouteTables(s.src, s.dst, s.r, s.s, s.l)
 	return nil
 }
 
 func (s *SimpleChain) receiveHeight() (int64, error) {
-	if s.bs.Verifier.Height == s.ci.StartHeight {
-		return s.bs.Verifier.Height, nil
+	bs := s.status()
+	if bs.Verifier.Height == s.ci.StartHeight {
+		return bs.Verifier.Height, nil
 	} else {
+		s.rmsMtx.Lock()
 		if len(s.rms) == 0 {
 			s.rms = append(s.rms, NewRelayMessage())
 		}
-		h, err := s.r.GetBTPBlockHeader(s.bs.Verifier.Height, s.cfg.Src.Nid)
+		s.rmsMtx.Unlock()
+		h, err := s.r.GetBTPBlockHeader(bs.Verifier.Height, s.cfg.Src.Nid)
 		if err != nil {
 			return 0, err
 		}
@@ -387,12 +570,12 @@ func (s *SimpleChain) receiveHeight() (int64, error) {
 		}
 
 		vs := &VerifierStatus{}
-		_, err = codec.RLP.UnmarshalFromBytes(s.bs.Verifier.Extra, vs)
+		_, err = codec.RLP.UnmarshalFromBytes(bs.Verifier.Extra, vs)
 		if err != nil {
 			return 0, err
 		}
 
-		index := (s.bs.RxSeq.Int64() - vs.SequenceOffset) - vs.FirstMessageSn
+		index := (bs.RxSeq.Int64() - vs.SequenceOffset) - vs.FirstMessageSn
 		if index < bh.MessageCount {
 			var mt *mbt.MerkleBinaryTree
 			m, err := s.r.GetBTPMessage(bh.MainHeight, s.cfg.Src.Nid)
@@ -409,32 +592,124 @@ func (s *SimpleChain) receiveHeight() (int64, error) {
 				Height:        bh.MainHeight,
 			}
 
-			s.MessageSegment(bd)
-			s.relay()
+			s.rmsMtx.Lock()
+			err = s.MessageSegment(bd)
+			s.rmsMtx.Unlock()
+			if err != nil {
+				return 0, err
+			}
+			if err = s.relay(); err != nil {
+				return 0, err
+			}
 		}
 		return bh.MainHeight + 1, nil
 	}
 }
 
+// restore loads journaled BTPBlockData and BTPRelayMessage from relayStore,
+// then reconciles them with BMCLinkStatus and tracks in-flight transactions.
+// It returns the height to receive from, or zero if there is nothing to restore.
+func (s *SimpleChain) restore() (int64, error) {
+	if s.rs == nil {
+		return 0, nil
+	}
+	bds, err := s.rs.BlockDatas(mbt.HashFuncByUID(s.ci.NetworkTypeName))
+	if err != nil {
+		return 0, err
+	}
+	rms, err := s.rs.RelayMessages()
+	if err != nil {
+		return 0, err
+	}
+	bs := s.status()
+	if len(bds) == 0 || bds[len(bds)-1].Height < bs.Verifier.Height {
+		s.l.Debugf("discard relayStore bds:%d rms:%d verifier height:%d",
+			len(bds), len(rms), bs.Verifier.Height)
+		return 0, s.rs.Retain(nil, nil)
+	}
+	s.rmSeq = s.rs.LastID()
+	s.bds, s.rms = bds, rms
+	s.updateRelayMessage(bs.Verifier.Height, bs.RxSeq)
+	if len(s.rms) == 0 {
+		s.rms = append(s.rms, NewRelayMessage())
+	}
+	h := s.bds[len(s.bds)-1].Height + 1
+	s.l.Debugf("restore bds:%d rms:%d receive:%d", len(s.bds), len(s.rms), h)
+
+	for _, rm := range s.rms {
+		if rm.Segments() != nil && rm.Segments().GetResultParam != nil {
+			s._log("track", rm, rm.Segments(), -1)
+			go s.result(rm.Segments())
+		}
+	}
+	if err = s.relay(); err != nil {
+		return 0, err
+	}
+	return h, nil
+}
+
+func (s *SimpleChain) prepareDatabase() error {
+	s.l.Debugln("open database", filepath.Join(s.cfg.AbsBaseDir(), s.cfg.Dst.Address.NetworkAddress()))
+	database, err := db.Open(s.cfg.AbsBaseDir(), string(DefaultDBType), s.cfg.Dst.Address.NetworkAddress())
+	if err != nil {
+		return errors.Wrap(err, "fail to open database")
+	}
+	if s.rs, err = newRelayStore(database); err != nil {
+		database.Close()
+		return err
+	}
+	s.database = database
+	return nil
+}
+
 func (s *SimpleChain) monitorHeight() int64 {
 	return atomic.LoadInt64(&s.heightOfDst)
 }
 
 func (s *SimpleChain) Serve(sender chain.Sender) error {
 	s.s = sender
-	s.r = NewReceiver(s.src, s.dst, s.cfg.Src.Endpoint, s.cfg.Src.Options, s.l)
+	s.r = NewReceiver(s.src, s.dst, s.cfg.Src.EndpointList(), s.cfg.Src.Options, s.l)
+	defer s.r.Close()
 	s.ci = &chainInfo{}
 	//TODO Pre rotation settings
 	s.relayble = true
 
 	s.SetChainInfo()
 
+	var err error
+	if s.pe, err = policy.NewEngine(s.cfg.Policy, DefaultPolicyRules, s.l, ErrConnectFail); err != nil {
+		return err
+	}
+
+	if err := s.prepareDatabase(); err != nil {
+		return err
+	}
+	defer s.database.Close()
+
+	if s.fa, err = chain.NewFeeAccount(s.database, sender, s.cfg.Src.Balance, s.m, s.relayInBackground, s.l); err != nil {
+		return err
+	}
+	s.fa.Start()
+	defer s.fa.Stop()
+
+	s.tr.Register(s.src.NetworkAddress(), s.dst.NetworkAddress())
+	defer s.tr.Unregister(s.src.NetworkAddress(), s.dst.NetworkAddress())
+
 	if err := s.Monitoring(); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Stop stops Serve which returns nil, the database is closed on return of Serve.
+// the watchdog of the direction is removed from probes.
+func (s *SimpleChain) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		health.Unregister(s.wd)
+	})
+}
 func (s *SimpleChain) SetChainInfo() error {
 	ni, err := s.r.GetBTPNetworkInfo(s.cfg.Src.Nid)
 	if err != nil {
@@ -451,61 +726,146 @@ func (s *SimpleChain) Monitoring() error {
 		return err
 	}
 
-	h, err := s.receiveHeight()
+	h, err := s.restore()
 	if err != nil {
 		return err
 	}
+	if h == 0 {
+		if h, err = s.receiveHeight(); err != nil {
+			return err
+		}
+	}
 
+	bs := s.status()
 	s.l.Debugf("_init height:%d, dst(%s, src height:%d, seq:%d, last:%d), receive:%d",
-		s.ci.StartHeight, s.dst, s.bs.Verifier.Height, s.bs.RxSeq, s.bs.Verifier.Height, h)
+		s.ci.StartHeight, s.dst, bs.Verifier.Height, bs.RxSeq, bs.Verifier.Height, h)
 
-	errCh := make(chan error)
+	s.startLoops(h, bs.CurrentHeight)
+	s.wd.Start()
+	defer s.wd.Stop()
+	for {
+		select {
+		case err := <-s.errCh:
+			if err != nil {
+				s.stopLoops()
+				return err
+			}
+		case err := <-s.restartCh:
+			s.restartLoops(h, err)
+		case <-s.stopCh:
+			s.stopLoops()
+			s.l.Debugln("stopped")
+			return nil
+		}
+	}
+}
+
+// startLoops runs MonitorLoop from dh and ReceiveLoop from h,
+// errors of the loops stopped by stopLoops are ignored.
+func (s *SimpleChain) startLoops(h, dh int64) {
+	gen := atomic.AddInt32(&s.loopGen, 1)
+	notify := func(err error) {
+		if atomic.LoadInt32(&s.loopGen) != gen {
+			return
+		}
+		select {
+		case s.errCh <- err:
+		default:
+		}
+	}
 	go func() {
-		err := s.s.MonitorLoop(
-			s.bs.CurrentHeight,
+		notify(s.s.MonitorLoop(
+			dh,
 			s.OnBlockOfDst,
 			func() {
 				s.l.Debugf("Connect MonitorLoop")
-				errCh <- nil
-			})
-		select {
-		case errCh <- err:
-		default:
-		}
+				notify(nil)
+			}))
 	}()
 	go func() {
-		err := s.r.ReceiveLoop(
+		notify(s.r.ReceiveLoop(
 			h,
 			s.cfg.Src.Nid,
 			s.OnBlockOfSrc,
 			func() {
 				s.l.Debugf("Connect ReceiveLoop")
-				errCh <- nil
-			})
-		select {
-		case errCh <- err:
-		default:
-		}
+				notify(nil)
+			}))
 	}()
-	for {
-		select {
-		case err := <-errCh:
-			if err != nil {
-				return err
-			}
+}
+
+func (s *SimpleChain) stopLoops() {
+	atomic.AddInt32(&s.loopGen, 1)
+	s.s.StopMonitorLoop()
+	s.r.StopReceiveLoop()
+}
+
+// restartLoops restarts loops from the last heights, h is used if there is no received block.
+func (s *SimpleChain) restartLoops(h int64, err error) {
+	if sh := s.wd.SrcHeight(); sh != 0 {
+		h = sh + 1
+	}
+	dh := s.monitorHeight()
+	s.l.Warnf("restart loops receive:%d monitor:%d err:%+v", h, dh, err)
+	s.stopLoops()
+	s.startLoops(h, dh)
+	s.resend(0, nil)
+}
+
+// onStall restarts loops in Monitoring, or shutdown if restart is false.
+func (s *SimpleChain) onStall(err error, restart bool) {
+	if !restart {
+		s.shutdown(err)
+		return
+	}
+	select {
+	case s.restartCh <- err:
+	default:
+	}
+}
+
+// pending returns whether there is relay message to relay.
+func (s *SimpleChain) pending() bool {
+	if s.isPaused() {
+		return false
+	}
+	s.rmsMtx.RLock()
+	defer s.rmsMtx.RUnlock()
+	for _, rm := range s.rms {
+		if len(rm.Messages) > 0 {
+			return true
 		}
 	}
+	return false
 }
 
 func NewChain(cfg *chain.Config, l log.Logger) *SimpleChain {
 	s := &SimpleChain{
-		src: cfg.Src.Address,
-		dst: cfg.Dst.Address,
-		l:   l.WithFields(log.Fields{log.FieldKeyChain: fmt.Sprintf("%s", cfg.Dst.Address.NetworkID())}),
-		cfg: cfg,
-		bds: make([]*BTPBlockData, 0),
-		rms: make([]*BTPRelayMessage, 0),
+		src:       cfg.Src.Address,
+		dst:       cfg.Dst.Address,
+		l:         l.WithFields(log.Fields{log.FieldKeyChain: fmt.Sprintf("%s", cfg.Dst.Address.NetworkID())}),
+		cfg:       cfg,
+		bds:       make([]*BTPBlockData, 0),
+		rms:       make([]*BTPRelayMessage, 0),
+		errCh:     make(chan error),
+		m:         metrics.NewLinkMetrics(cfg.Src.Address.NetworkAddress(), cfg.Dst.Address.NetworkAddress()),
+		restartCh: make(chan error, 1),
+		stopCh:    make(chan struct{}),
+		tr:        chain.DefaultMessageTracker(),
+		seqOffset: -1,
 	}
-
+	wc := health.Config{}
+	if cfg.Watchdog != nil {
+		wc = *cfg.Watchdog
+	}
+	if wc.SrcTimeout == 0 {
+		//BTP block is produced only if there is a message for the network, src check is disabled by default
+		wc.SrcTimeout = -1
+	}
+	s.wd = health.NewWatchdog(cfg.DirectionName(), &wc, s.pending, s.onStall, s.l)
+	//segment over the limit is sent by fragments
+	s.sg = segment.New(&relayMessagePacker{s: s}, func(size int) bool {
+		return !s.isOverLimit(size)
+	}, cfg.Strategy()).AllowOversize()
 	return s
 }
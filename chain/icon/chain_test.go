@@ -0,0 +1,313 @@
+package icon
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/chain/icon/icontest"
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/config"
+	feepkg "github.com/icon-project/btp/common/fee"
+	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/policy"
+	"github.com/icon-project/btp/common/wallet"
+)
+
+const (
+	testSrcAddress = chain.BtpAddress("btp://0x1.icon/cx0000000000000000000000000000000000000001")
+	testDstAddress = chain.BtpAddress("btp://0x2.icon/cx0000000000000000000000000000000000000002")
+)
+
+type testLink struct {
+	t   *testing.T
+	src *icontest.Node
+	dst *icontest.Node
+	cfg *chain.Config
+	s   *SimpleChain
+	dir string
+	err chan error
+}
+
+// newTestLink serves SimpleChain between in-process nodes, BMC of dst is initialized with the first BTP block of src.
+// opts are applied to the config before serving.
+func newTestLink(t *testing.T, opts ...func(tl *testLink)) *testLink {
+	dir, err := ioutil.TempDir("", "icon")
+	assert.NoError(t, err)
+	tl := &testLink{
+		t:   t,
+		src: icontest.NewNode(icontest.Config{NetworkID: 1}),
+		dst: icontest.NewNode(icontest.Config{NetworkID: 2}),
+		dir: dir,
+		err: make(chan error, 1),
+	}
+	tl.dst.AddLink(testSrcAddress.String(), icontest.DefaultNetworkTypeName, tl.src.BTPHeight())
+
+	tl.cfg = &chain.Config{
+		FileConfig: config.FileConfig{BaseDir: dir},
+		Src:        chain.BaseConfig{Address: testSrcAddress, Endpoint: tl.src.URL(), Nid: 1},
+		Dst:        chain.BaseConfig{Address: testDstAddress, Endpoint: tl.dst.URL()},
+	}
+	for _, opt := range opts {
+		opt(tl)
+	}
+	tl.serve()
+	return tl
+}
+
+func (tl *testLink) serve() {
+	l := log.New()
+	tl.s = NewChain(tl.cfg, l)
+	sender, err := NewSender(testSrcAddress, testDstAddress, wallet.New(), tl.cfg.Dst.EndpointList(), nil, l)
+	assert.NoError(tl.t, err)
+	go func(s *SimpleChain) {
+		tl.err <- s.Serve(sender)
+	}(tl.s)
+}
+
+// stop stops SimpleChain, Serve should return nil.
+func (tl *testLink) stop() {
+	tl.s.Stop()
+	select {
+	case err := <-tl.err:
+		assert.NoError(tl.t, err)
+	case <-time.After(5 * time.Second):
+		tl.t.Error("SimpleChain is not stopped")
+	}
+}
+
+func (tl *testLink) Close() {
+	tl.stop()
+	tl.src.Close()
+	tl.dst.Close()
+	os.RemoveAll(tl.dir)
+}
+
+// waitMessages waits until dst receives messages, it fails if SimpleChain returns error.
+func (tl *testLink) waitMessages(messages ...[]byte) {
+	timeout := time.After(10 * time.Second)
+	for {
+		received := tl.dst.Messages(testSrcAddress.String())
+		if len(received) >= len(messages) {
+			assert.Equal(tl.t, messages, received)
+			return
+		}
+		select {
+		case err := <-tl.err:
+			tl.t.Fatalf("SimpleChain returns err:%+v", err)
+		case <-timeout:
+			tl.t.Fatalf("timeout received:%d expected:%d", len(received), len(messages))
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func testMessages(from, to int) [][]byte {
+	l := make([][]byte, 0, to-from)
+	for i := from; i < to; i++ {
+		l = append(l, []byte(fmt.Sprintf("message%d", i)))
+	}
+	return l
+}
+
+func TestSimpleChain_Relay(t *testing.T) {
+	tl := newTestLink(t)
+	defer tl.Close()
+
+	msgs := testMessages(0, 5)
+	tl.src.AddBTPBlock(msgs[:3]...)
+	tl.waitMessages(msgs[:3]...)
+	tl.src.AddBTPBlock()
+	tl.src.AddBTPBlock(msgs[3:]...)
+	tl.waitMessages(msgs...)
+}
+
+func TestSimpleChain_RelayMaxEvents(t *testing.T) {
+	tl := newTestLink(t, func(tl *testLink) {
+		tl.cfg.MaxEventsTx = 2
+	})
+	defer tl.Close()
+
+	//messages are proven by ranges in relay messages, [bu,1-2],[3-4],[5]
+	msgs := testMessages(0, 5)
+	tl.src.AddBTPBlock(msgs...)
+	tl.waitMessages(msgs...)
+	assert.Equal(t, 3, tl.dst.Calls(BMCRelayMethod))
+}
+
+func TestSimpleChain_Restart(t *testing.T) {
+	tl := newTestLink(t)
+	defer tl.Close()
+
+	msgs := testMessages(0, 4)
+	tl.src.AddBTPBlock(msgs[:2]...)
+	tl.waitMessages(msgs[:2]...)
+
+	//database is released by Stop, so the link could be served again in the process
+	tl.stop()
+	tl.src.AddBTPBlock(msgs[2:]...)
+	tl.serve()
+	tl.waitMessages(msgs...)
+}
+
+func TestSimpleChain_RelayFragments(t *testing.T) {
+	tl := newTestLink(t)
+	defer tl.Close()
+
+	//the block update doesn't fit in with the large message, so it's relayed alone
+	large := bytes.Repeat([]byte{0xff}, txSizeLimit+1024)
+	tl.src.AddBTPBlock(large)
+	tl.waitMessages(large)
+	assert.Equal(t, 2, tl.dst.Calls(BMCFragmentMethod))
+	assert.Equal(t, 1, tl.dst.Calls(BMCRelayMethod))
+}
+
+func TestSimpleChain_RelayFragmentsRetry(t *testing.T) {
+	tl := newTestLink(t)
+	defer tl.Close()
+
+	//the third fragment is reverted, then the last one is reverted by the order
+	//only failed ones are sent again
+	tl.dst.InjectFragmentRevert(1, icontest.BMCRevert)
+	large := bytes.Repeat([]byte{0xff}, txSizeLimit*3+1024)
+	tl.src.AddBTPBlock(large)
+	tl.waitMessages(large)
+	assert.Equal(t, 6, tl.dst.Calls(BMCFragmentMethod))
+	assert.Equal(t, 1, tl.dst.Calls(BMCRelayMethod))
+}
+
+func TestSimpleChain_RelayOnError(t *testing.T) {
+	tl := newTestLink(t)
+	defer tl.Close()
+
+	//rejected by overflow of transaction pool, then resent by sender
+	tl.dst.InjectError("icx_sendTransaction", icontest.ErrorCodeTxPoolOverflow, "TxPoolOverflow")
+	msgs := testMessages(0, 4)
+	tl.src.AddBTPBlock(msgs[:2]...)
+	tl.waitMessages(msgs[:2]...)
+	assert.Equal(t, 1, tl.dst.Calls(BMCRelayMethod))
+
+	//reverted by BMV, then resent after refresh by policy
+	tl.dst.InjectRevert(icontest.BMVNotVerifiable)
+	tl.dst.InjectPending(1)
+	tl.src.AddBTPBlock(msgs[2:]...)
+	tl.waitMessages(msgs...)
+	assert.Equal(t, 3, tl.dst.Calls(BMCRelayMethod))
+}
+
+func TestSimpleChain_ResegmentFallback(t *testing.T) {
+	tl := newTestLink(t, func(tl *testLink) {
+		tl.cfg.Policy = &policy.Config{
+			Rules: []policy.Rule{
+				{Code: fmt.Sprint(icontest.BMCRevert), Action: policy.ActionResegment,
+					MaxRetry: 1, Fallback: policy.ActionQuarantine},
+			},
+			Backoff: common.Duration(10 * time.Millisecond),
+		}
+	})
+	defer tl.Close()
+
+	//attempts are kept while the message is resegmented
+	tl.dst.InjectRevert(icontest.BMCRevert)
+	tl.dst.InjectRevert(icontest.BMCRevert)
+	tl.src.AddBTPBlock(testMessages(0, 2)...)
+	assert.Eventually(t, func() bool {
+		ls, err := tl.s.Status()
+		return err == nil && ls.Quarantined == 1
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, 2, tl.dst.Calls(BMCRelayMethod))
+}
+
+func TestSimpleChain_RelayQuarantine(t *testing.T) {
+	tl := newTestLink(t, func(tl *testLink) {
+		tl.cfg.Policy = &policy.Config{
+			Rules: []policy.Rule{
+				{Code: policy.CodeUnknown, Action: policy.ActionQuarantine},
+			},
+		}
+	})
+	defer tl.Close()
+
+	//failure of sending is handled by the action of policy
+	tl.dst.InjectError("icx_sendTransaction", icontest.ErrorCodeSystem, "SystemError")
+	tl.src.AddBTPBlock(testMessages(0, 2)...)
+	assert.Eventually(t, func() bool {
+		ls, err := tl.s.Status()
+		return err == nil && ls.Quarantined == 1
+	}, 5*time.Second, 50*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, tl.dst.Calls("icx_sendTransaction"))
+}
+
+func TestSimpleChain_Reconnect(t *testing.T) {
+	tl := newTestLink(t)
+	defer tl.Close()
+
+	msgs := testMessages(0, 4)
+	tl.src.AddBTPBlock(msgs[:2]...)
+	tl.waitMessages(msgs[:2]...)
+
+	tl.src.DropConnections()
+	tl.dst.DropConnections()
+	tl.src.AddBTPBlock(msgs[2:]...)
+	tl.waitMessages(msgs...)
+}
+
+func TestSimpleChain_LowBalance(t *testing.T) {
+	//balance is under the stop threshold after the first transaction
+	fee := big.NewInt(icontest.DefaultStepUsed * icontest.DefaultStepPrice)
+	stop := new(big.Int).Mul(fee, big.NewInt(2))
+	tl := newTestLink(t, func(tl *testLink) {
+		tl.dst.SetBalance(stop)
+		tl.cfg.Src.Balance = &feepkg.Config{Interval: 50 * time.Millisecond, Stop: feepkg.NewAmount(stop)}
+	})
+	defer tl.Close()
+
+	msgs := testMessages(0, 3)
+	tl.src.AddBTPBlock(msgs[:1]...)
+	tl.waitMessages(msgs[:1]...)
+	assert.Eventually(t, func() bool {
+		ls, err := tl.s.Status()
+		return err == nil && ls.LowBalance
+	}, 5*time.Second, 50*time.Millisecond)
+	fr, err := tl.s.Fees()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), fr.Count)
+	assert.Equal(t, fee.String(), fr.Total)
+	assert.Equal(t, feepkg.LevelStop, fr.Level)
+
+	//admin resume doesn't resume relaying paused by low balance
+	tl.src.AddBTPBlock(msgs[1:]...)
+	tl.s.Resume()
+	<-time.After(300 * time.Millisecond)
+	assert.Equal(t, msgs[:1], tl.dst.Messages(testSrcAddress.String()))
+
+	//resumed by deposit
+	tl.dst.SetBalance(new(big.Int).Mul(fee, big.NewInt(100)))
+	tl.waitMessages(msgs...)
+	ls, err := tl.s.Status()
+	assert.NoError(t, err)
+	assert.False(t, ls.LowBalance)
+}
+
+func TestGetRouteTable(t *testing.T) {
+	node := icontest.NewNode(icontest.Config{NetworkID: 2})
+	defer node.Close()
+	node.AddLink(testSrcAddress.String(), icontest.DefaultNetworkTypeName, 0)
+	node.AddRoute("btp://0x3.icon/cx0000000000000000000000000000000000000003", testSrcAddress.String())
+
+	s, err := NewSender(testSrcAddress, testDstAddress, wallet.New(), []string{node.URL()}, nil, log.New())
+	assert.NoError(t, err)
+	rt, err := s.(chain.RouteLoader).GetRouteTable()
+	assert.NoError(t, err)
+	assert.Equal(t, []chain.BtpAddress{testSrcAddress}, rt.Links)
+	assert.Equal(t, []chain.Route{{Destination: "0x3.icon", Next: testSrcAddress}}, rt.Routes)
+	assert.Equal(t, testSrcAddress, rt.Next("0x3.icon"))
+}
@@ -0,0 +1,150 @@
+package icon
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/log"
+)
+
+func TestSender_MonitorLoopReconnect(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		requests []int64
+	)
+	upgrader := websocket.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		req := &BlockRequest{}
+		if err = conn.ReadJSON(req); err != nil {
+			return
+		}
+		h, _ := req.Height.Value()
+		mtx.Lock()
+		requests = append(requests, h)
+		first := len(requests) == 1
+		mtx.Unlock()
+		_ = conn.WriteJSON(&WSResponse{})
+		if first {
+			for i := h; i < h+2; i++ {
+				_ = conn.WriteJSON(&BlockNotification{Height: NewHexInt(i)})
+			}
+			//disconnect without close message
+			return
+		}
+		//replay the last block before new blocks
+		for i := h - 1; i < h+2; i++ {
+			_ = conn.WriteJSON(&BlockNotification{Height: NewHexInt(i)})
+		}
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer ts.Close()
+
+	l := log.New()
+	s := &sender{c: NewClient([]string{ts.URL}, l), l: l}
+	var heights []int64
+	done := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.MonitorLoop(10, func(height int64) error {
+			heights = append(heights, height)
+			if height == 13 {
+				close(done)
+			}
+			return nil
+		}, nil)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	s.StopMonitorLoop()
+	select {
+	case <-errCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("MonitorLoop is not stopped")
+	}
+	assert.Equal(t, []int64{10, 11, 12, 13}, heights)
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.Equal(t, []int64{10, 12}, requests)
+}
+
+func TestSender_MonitorLoopCallbackError(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		requests []int64
+	)
+	upgrader := websocket.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		req := &BlockRequest{}
+		if err = conn.ReadJSON(req); err != nil {
+			return
+		}
+		h, _ := req.Height.Value()
+		mtx.Lock()
+		requests = append(requests, h)
+		mtx.Unlock()
+		_ = conn.WriteJSON(&WSResponse{})
+		for i := h; i <= 12; i++ {
+			_ = conn.WriteJSON(&BlockNotification{Height: NewHexInt(i)})
+		}
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer ts.Close()
+
+	l := log.New()
+	s := &sender{c: NewClient([]string{ts.URL}, l), l: l}
+	var heights []int64
+	failed := false
+	done := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.MonitorLoop(10, func(height int64) error {
+			//the failed block is monitored again after reconnect
+			if height == 11 && !failed {
+				failed = true
+				return fmt.Errorf("transient")
+			}
+			heights = append(heights, height)
+			if height == 12 {
+				close(done)
+			}
+			return nil
+		}, nil)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	s.StopMonitorLoop()
+	select {
+	case <-errCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("MonitorLoop is not stopped")
+	}
+	assert.Equal(t, []int64{10, 11, 12}, heights)
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.Equal(t, []int64{10, 11}, requests)
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icon
+
+import (
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/mbt"
+)
+
+type blockUpdateView struct {
+	Type         string   `json:"type"`
+	Height       int64    `json:"height"`
+	NetworkID    int64    `json:"network_id"`
+	UpdateNumber int64    `json:"update_number"`
+	MessageCount int64    `json:"message_count"`
+	MessagesRoot HexBytes `json:"messages_root"`
+	ProofSize    int      `json:"proof_size"`
+}
+
+type messageProofView struct {
+	Type         string     `json:"type"`
+	ProofInLeft  int        `json:"proof_in_left"`
+	Contents     []HexBytes `json:"contents"`
+	ProofInRight int        `json:"proof_in_right"`
+}
+
+// DecodeRelayMessage returns the readable form of BTPRelayMessage, it's used by dry-run.
+func DecodeRelayMessage(b []byte) (interface{}, error) {
+	rm := NewRelayMessage()
+	if _, err := codec.RLP.UnmarshalFromBytes(b, rm); err != nil {
+		return nil, err
+	}
+	l := make([]interface{}, 0, len(rm.Messages))
+	for i, tpm := range rm.Messages {
+		switch tpm.Type {
+		case RelayMessageTypeBlockUpdate:
+			bu := &BTPBlockUpdate{}
+			if _, err := codec.RLP.UnmarshalFromBytes(tpm.Payload, bu); err != nil {
+				return nil, err
+			}
+			bh := &BTPBlockHeader{}
+			if _, err := codec.RLP.UnmarshalFromBytes(bu.BTPBlockHeader, bh); err != nil {
+				return nil, err
+			}
+			l = append(l, &blockUpdateView{
+				Type:         "BlockUpdate",
+				Height:       bh.MainHeight,
+				NetworkID:    bh.NetworkID,
+				UpdateNumber: bh.UpdateNumber,
+				MessageCount: bh.MessageCount,
+				MessagesRoot: NewHexBytes(bh.MessagesRoot),
+				ProofSize:    len(bu.BTPBlockProof),
+			})
+		case RelayMessageTypeMessageProof:
+			p := &mbt.MerkleBinaryTreeProof{}
+			if _, err := codec.RLP.UnmarshalFromBytes(tpm.Payload, p); err != nil {
+				return nil, err
+			}
+			v := &messageProofView{
+				Type:         "MessageProof",
+				ProofInLeft:  len(p.ProofInLeft),
+				Contents:     make([]HexBytes, len(p.Contents)),
+				ProofInRight: len(p.ProofInRight),
+			}
+			for j, c := range p.Contents {
+				v.Contents[j] = NewHexBytes(c)
+			}
+			l = append(l, v)
+		default:
+			return nil, errors.InvalidStateError.Errorf("invalid type:%d messages[%d]", tpm.Type, i)
+		}
+	}
+	return map[string]interface{}{"messages": l}, nil
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icon
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/jsonrpc"
+)
+
+const (
+	DefaultFragmentWindow = 4
+	DefaultFragmentRetry  = 3
+)
+
+// Fragment is the transaction of a part of the relay message which is sent by BMCFragmentMethod,
+// the first one has negative index of the last one and the last one has zero index.
+type Fragment struct {
+	Index  int
+	TxHash HexBytes
+	Done   bool
+}
+
+// FragmentsParam is GetResultParam of the relay message which is sent by fragments,
+// each fragment is tracked with its own transaction, so that the failed one could be sent again alone.
+type FragmentsParam struct {
+	mtx       sync.Mutex
+	msg       []byte
+	limit     int
+	fragments []*Fragment
+	attempts  []int
+	// onSend is called after fragments are sent by GetResult, it's called without lock.
+	onSend func()
+}
+
+func newFragmentsParam(msg []byte, limit int) *FragmentsParam {
+	last := len(msg) / limit
+	p := &FragmentsParam{
+		msg:       msg,
+		limit:     limit,
+		fragments: make([]*Fragment, last+1),
+		attempts:  make([]int, last+1),
+	}
+	for i := range p.fragments {
+		p.fragments[i] = &Fragment{Index: last - i}
+	}
+	p.fragments[0].Index = -last
+	return p
+}
+
+// data returns the part of the message for i-th fragment.
+func (p *FragmentsParam) data(i int) []byte {
+	begin, end := i*p.limit, (i+1)*p.limit
+	if i == len(p.fragments)-1 || end > len(p.msg) {
+		end = len(p.msg)
+	}
+	return p.msg[begin:end]
+}
+
+// Fragments returns the copy of fragments in order of sending.
+func (p *FragmentsParam) Fragments() []Fragment {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	l := make([]Fragment, len(p.fragments))
+	for i, f := range p.fragments {
+		l[i] = *f
+	}
+	return l
+}
+
+// restore applies the state of fragments which are journaled, it returns false if they don't match.
+func (p *FragmentsParam) restore(fs []Fragment) bool {
+	if len(fs) != len(p.fragments) {
+		return false
+	}
+	for i, f := range fs {
+		if f.Index != p.fragments[i].Index {
+			return false
+		}
+	}
+	for i, f := range fs {
+		*p.fragments[i] = f
+	}
+	return true
+}
+
+func (p *FragmentsParam) setTxHash(f *Fragment, txh HexBytes) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	f.TxHash = txh
+}
+
+func (p *FragmentsParam) setDone(f *Fragment) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	f.Done = true
+}
+
+func (p *FragmentsParam) String() string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	done := 0
+	for _, f := range p.fragments {
+		if f.Done {
+			done++
+		}
+	}
+	return fmt.Sprintf("fragments[%d/%d]", done, len(p.fragments))
+}
+
+// sendFragments sends fragments which are not sent in order,
+// while the number of fragments waiting for the result is less than the window.
+// it returns true if any fragment is sent.
+func (s *sender) sendFragments(p *FragmentsParam) (bool, error) {
+	pending := 0
+	for _, f := range p.fragments {
+		if !f.Done && len(f.TxHash) > 0 {
+			pending++
+		}
+	}
+	sent := false
+	for i, f := range p.fragments {
+		if pending >= s.opt.FragmentWindow {
+			break
+		}
+		if f.Done || len(f.TxHash) > 0 {
+			continue
+		}
+		fmp := &BMCFragmentMethodParams{
+			Prev:     s.src.String(),
+			Messages: base64.URLEncoding.EncodeToString(p.data(i)),
+			Index:    NewHexInt(int64(f.Index)),
+		}
+		ret, err := s.sendTransaction(s.newTransactionParam(BMCFragmentMethod, fmp))
+		if err != nil {
+			return sent, err
+		}
+		p.setTxHash(f, ret.(*TransactionHashParam).Hash)
+		pending++
+		sent = true
+	}
+	return sent, nil
+}
+
+// isFragmentRetryable returns true if the fragment could be sent again alone,
+// BMC reverts the fragment which is out of order with BMCRevert.
+func isFragmentRetryable(err error) bool {
+	if je, ok := err.(*jsonrpc.Error); ok {
+		return je.Code == JsonrpcErrorCodeNotFound
+	}
+	if coder, ok := errors.CoderOf(err); ok {
+		return coder.ErrorCode() == BMCRevert
+	}
+	return false
+}
+
+// getFragmentsResult sends the rest of fragments and waits the results of all fragments,
+// it returns the result of the last fragment. the failed fragment is sent again alone
+// up to the retry limit, and the other errors are returned without retry.
+func (s *sender) getFragmentsResult(p *FragmentsParam) (chain.TransactionResult, error) {
+	var last *TransactionResult
+	for {
+		sent, err := s.sendFragments(p)
+		if err != nil {
+			return nil, err
+		}
+		if sent && p.onSend != nil {
+			p.onSend()
+		}
+		changed, done := false, 0
+		for i, f := range p.fragments {
+			if f.Done {
+				done++
+				continue
+			}
+			if len(f.TxHash) == 0 {
+				continue
+			}
+			txr, err := s.c.GetTransactionResult(&TransactionHashParam{Hash: f.TxHash})
+			if je, ok := err.(*jsonrpc.Error); ok &&
+				(je.Code == JsonrpcErrorCodePending || je.Code == JsonrpcErrorCodeExecuting) {
+				continue
+			}
+			if txr != nil {
+				s.addFee(txr)
+			}
+			changed = true
+			if err = mapErrorWithTransactionResult(txr, err); err == nil {
+				p.setDone(f)
+				done++
+				if f.Index == 0 {
+					last = txr
+				}
+				continue
+			}
+			if p.attempts[i]++; !isFragmentRetryable(err) || p.attempts[i] > s.opt.FragmentRetry {
+				return txr, err
+			}
+			s.l.Debugf("retry fragment idx:%d txh:%s attempts:%d err:%+v", f.Index, f.TxHash, p.attempts[i], err)
+			p.setTxHash(f, "")
+		}
+		if done == len(p.fragments) {
+			return last, nil
+		}
+		if !changed {
+			<-time.After(DefaultGetRelayResultInterval)
+		}
+	}
+}
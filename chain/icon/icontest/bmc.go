@@ -0,0 +1,189 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icontest
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/mbt"
+)
+
+// link is the status of BMC and BMV for the previous BMC,
+// BMV accepts BlockUpdate of the next BTP block after all messages of the last one are received.
+type link struct {
+	hashFunc mbt.HashFunc
+	height   int64
+	firstSN  int64
+	count    int64
+	root     []byte
+	rxSeq    int64
+	messages [][]byte
+}
+
+func (l *link) status(height int64) *bmcStatus {
+	bs := &bmcStatus{
+		TxSeq:         newHexInt(0),
+		RxSeq:         newHexInt(l.rxSeq),
+		BMRs:          []interface{}{},
+		CurrentHeight: newHexInt(height),
+	}
+	bs.Verifier.Height = newHexInt(l.height)
+	bs.Verifier.Extra = newHexBytes(codec.RLP.MustMarshalToBytes(&verifierStatus{
+		FirstMessageSn: l.firstSN,
+		MessageCount:   l.count,
+	}))
+	return bs
+}
+
+func (l *link) blockUpdate(b []byte) int {
+	bu := &BTPBlockUpdate{}
+	if _, err := codec.RLP.UnmarshalFromBytes(b, bu); err != nil {
+		return BMVUnknown
+	}
+	bh := &BTPBlockHeader{}
+	if _, err := codec.RLP.UnmarshalFromBytes(bu.BTPBlockHeader, bh); err != nil {
+		return BMVUnknown
+	}
+	if bh.MainHeight <= l.height {
+		return BMVAlreadyVerified
+	}
+	if l.rxSeq != l.firstSN+l.count || bh.UpdateNumber>>1 != l.rxSeq {
+		return BMVNotVerifiable
+	}
+	l.height, l.firstSN, l.count, l.root = bh.MainHeight, bh.UpdateNumber>>1, bh.MessageCount, bh.MessagesRoot
+	return 0
+}
+
+func (l *link) messageProof(b []byte) int {
+	p := &mbt.MerkleBinaryTreeProof{}
+	if _, err := codec.RLP.UnmarshalFromBytes(b, p); err != nil {
+		return BMVUnknown
+	}
+	p.SetHashFunc(l.hashFunc)
+	root, left, total, err := p.Root()
+	if err != nil {
+		return BMVUnknown
+	}
+	if int64(total) != l.count || !bytes.Equal(root, l.root) {
+		return BMVNotVerifiable
+	}
+	if offset := l.rxSeq - l.firstSN; int64(left) != offset {
+		if int64(left) < offset {
+			return BMVAlreadyVerified
+		}
+		return BMVNotVerifiable
+	}
+	l.messages = append(l.messages, p.Contents...)
+	l.rxSeq += int64(len(p.Contents))
+	return 0
+}
+
+type fragment struct {
+	next int64
+	data []byte
+}
+
+// AddLink adds the link of previous BMC with BMV which is initialized at the height of BTP block,
+// typeName is the network type name of the previous.
+func (n *Node) AddLink(prev, typeName string, height int64) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.links[prev] = &link{
+		hashFunc: mbt.HashFuncByUID(typeName),
+		height:   height,
+	}
+}
+
+// AddRoute adds the route to the destination via the link, it's returned by getRoutes.
+func (n *Node) AddRoute(dst, link string) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.routes[dst] = link
+}
+
+// _linkList returns BMCs of links in order, mtx should be locked.
+func (n *Node) _linkList() []string {
+	links := make([]string, 0, len(n.links))
+	for l := range n.links {
+		links = append(links, l)
+	}
+	sort.Strings(links)
+	return links
+}
+
+// Messages returns the messages received from the previous BMC.
+func (n *Node) Messages(prev string) [][]byte {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if l, ok := n.links[prev]; ok {
+		return append([][]byte{}, l.messages...)
+	}
+	return nil
+}
+
+// _handleRelayMessage applies the relay message to the link only if all of messages are accepted.
+// mtx should be locked.
+func (n *Node) _handleRelayMessage(prev string, msg []byte) int {
+	l, ok := n.links[prev]
+	if !ok {
+		return BMCRevertNotExistsLink
+	}
+	rm := &relayMessage{}
+	if _, err := codec.RLP.UnmarshalFromBytes(msg, rm); err != nil {
+		return BMVUnknown
+	}
+	nl := *l
+	nl.messages = append([][]byte{}, l.messages...)
+	for _, tpm := range rm.Messages {
+		var code int
+		switch tpm.Type {
+		case RelayMessageTypeBlockUpdate:
+			code = nl.blockUpdate(tpm.Payload)
+		case RelayMessageTypeMessageProof:
+			code = nl.messageProof(tpm.Payload)
+		default:
+			code = BMVUnknown
+		}
+		if code != 0 {
+			return code
+		}
+	}
+	n.links[prev] = &nl
+	return 0
+}
+
+// _handleFragment collects fragments, the first one has negative index of the last one
+// and the last one has zero index. mtx should be locked.
+func (n *Node) _handleFragment(prev string, msg []byte, idx int64) int {
+	if idx < 0 {
+		n.fragments[prev] = &fragment{next: -idx - 1, data: append([]byte{}, msg...)}
+		return 0
+	}
+	f, ok := n.fragments[prev]
+	if !ok || f.next != idx {
+		return BMCRevert
+	}
+	f.data = append(f.data, msg...)
+	if idx > 0 {
+		f.next--
+		return 0
+	}
+	delete(n.fragments, prev)
+	return n._handleRelayMessage(prev, f.data)
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icontest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/icon-project/btp/common/crypto"
+	"github.com/icon-project/btp/common/intconv"
+	"github.com/icon-project/btp/common/jsonrpc"
+)
+
+func newError(code jsonrpc.ErrorCode, format string, args ...interface{}) *jsonrpc.Error {
+	return &jsonrpc.Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func invalidParams(err error) *jsonrpc.Error {
+	return newError(jsonrpc.ErrorCodeInvalidParams, "InvalidParams(%v)", err)
+}
+
+func (n *Node) serveJSONRPC(w http.ResponseWriter, r *http.Request) {
+	req := &jsonrpc.Request{}
+	resp := &jsonrpc.Response{Version: jsonrpc.Version}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		resp.Error = newError(jsonrpc.ErrorCodeJsonParse, "ParseError(%v)", err)
+	} else {
+		resp.ID = req.ID
+		//debug API is served only on its own path
+		if strings.HasPrefix(req.Method, "debug_") != (r.URL.Path == debugApiPath) {
+			resp.Error = newError(jsonrpc.ErrorCodeMethodNotFound, "MethodNotFound(%s)", req.Method)
+		} else {
+			resp.Result, resp.Error = n.handle(req.Method, req.Params)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (n *Node) handle(method string, params json.RawMessage) (interface{}, *jsonrpc.Error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.calls[method]++
+	if errs := n.errs[method]; len(errs) > 0 {
+		n.errs[method] = errs[1:]
+		return nil, errs[0]
+	}
+	switch method {
+	case "icx_getLastBlock":
+		return &lastBlock{Height: n.height, BlockHash: string(newHexBytes(blockHash(n.height)))}, nil
+	case "icx_getBalance":
+		return hexInt(intconv.FormatBigInt(n.balance)), nil
+	case "icx_call":
+		p := &callParam{}
+		if err := json.Unmarshal(params, p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return n._call(p)
+	case "debug_estimateStep":
+		p := &transactionParam{}
+		if err := json.Unmarshal(params, p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return newHexInt(DefaultStepUsed), nil
+	case "icx_sendTransaction":
+		p := &transactionParam{}
+		if err := json.Unmarshal(params, p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return n._sendTransaction(p)
+	case "icx_getTransactionResult":
+		p := &transactionHashParam{}
+		if err := json.Unmarshal(params, p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if n.pendings > 0 {
+			n.pendings--
+			return nil, newError(ErrorCodePending, "Pending")
+		}
+		txr, ok := n.results[string(p.Hash)]
+		if !ok {
+			return nil, newError(ErrorCodeNotFound, "NotFound: no transaction %s", p.Hash)
+		}
+		return txr, nil
+	case "btp_getNetworkInfo":
+		p := &btpNetworkInfoParam{}
+		if err := json.Unmarshal(params, p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if err := n._checkNetworkID(p.ID); err != nil {
+			return nil, err
+		}
+		last := n.btpBlocks[len(n.btpBlocks)-1]
+		return &networkInfo{
+			StartHeight:     newHexInt(n.startHeight),
+			NetworkTypeID:   newHexInt(1),
+			NetworkTypeName: n.cfg.NetworkTypeName,
+			NetworkID:       newHexInt(n.cfg.NetworkID),
+			NextMessageSN:   newHexInt(n.nextSN),
+			LastNSHash:      newHexBytes(crypto.SHA3Sum256(last.header)),
+		}, nil
+	case "btp_getHeader", "btp_getMessages", "btp_getProof":
+		p := &btpBlockParam{}
+		if err := json.Unmarshal(params, p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if err := n._checkNetworkID(p.NetworkID); err != nil {
+			return nil, err
+		}
+		height, err := p.Height.Value()
+		if err != nil {
+			return nil, invalidParams(err)
+		}
+		bb := n._btpBlock(height)
+		if bb == nil {
+			return nil, newError(ErrorCodeNotFound, "NotFound: no BTP block at %d", height)
+		}
+		switch method {
+		case "btp_getHeader":
+			return base64.StdEncoding.EncodeToString(bb.header), nil
+		case "btp_getProof":
+			return base64.StdEncoding.EncodeToString(bb.proof), nil
+		default:
+			l := make([]string, len(bb.messages))
+			for i, m := range bb.messages {
+				l[i] = base64.StdEncoding.EncodeToString(m)
+			}
+			return l, nil
+		}
+	default:
+		return nil, newError(jsonrpc.ErrorCodeMethodNotFound, "MethodNotFound(%s)", method)
+	}
+}
+
+func (n *Node) _checkNetworkID(id hexInt) *jsonrpc.Error {
+	v, err := id.Value()
+	if err != nil {
+		return invalidParams(err)
+	}
+	if v != n.cfg.NetworkID {
+		return newError(ErrorCodeNotFound, "NotFound: no network %d", v)
+	}
+	return nil
+}
+
+func (n *Node) _call(p *callParam) (interface{}, *jsonrpc.Error) {
+	switch p.Data.Method {
+	case BMCGetStatusMethod:
+		l, ok := n.links[p.Data.Params.Link]
+		if !ok {
+			return nil, newError(ErrorCodeScore-BMCRevertNotExistsLink, "Reverted(%d)", BMCRevertNotExistsLink)
+		}
+		return l.status(n.height), nil
+	case BMCGetLinksMethod:
+		return n._linkList(), nil
+	case BMCGetRoutesMethod:
+		routes := make(map[string]string, len(n.routes))
+		for dst, link := range n.routes {
+			routes[dst] = link
+		}
+		return routes, nil
+	default:
+		return nil, newError(ErrorCodeScore-1, "MethodNotFound(%s)", p.Data.Method)
+	}
+}
+
+// _sendTransaction executes the transaction in the new block, mtx should be locked.
+func (n *Node) _sendTransaction(p *transactionParam) (interface{}, *jsonrpc.Error) {
+	if txh, ok := n.signatures[p.Signature]; ok {
+		return nil, newError(ErrorCodeSystem, "E%d:DuplicateTransaction(%s)", DuplicateTransactionError, txh)
+	}
+	txh := newHexBytes(crypto.SHA3Sum256([]byte(p.Signature)))
+	n.signatures[p.Signature] = txh
+	if v, err := p.StepLimit.Value(); err == nil {
+		n.stepLimit = v
+	}
+	n.calls[p.Data.Method]++
+
+	height := n._newBlock()
+	txr := &transactionResult{
+		To:                 p.To,
+		CumulativeStepUsed: newHexInt(DefaultStepUsed),
+		StepUsed:           newHexInt(DefaultStepUsed),
+		StepPrice:          newHexInt(DefaultStepPrice),
+		EventLogs:          []interface{}{},
+		Status:             ResultStatusSuccess,
+		BlockHash:          newHexBytes(blockHash(height)),
+		BlockHeight:        newHexInt(height),
+		TxIndex:            newHexInt(0),
+		TxHash:             txh,
+	}
+	if code := n._execute(p); code != 0 {
+		txr.Status = ResultStatusFailure
+		txr.Failure = &failure{
+			Code:    newHexInt(int64(ResultStatusFailureCodeRevert + code)),
+			Message: fmt.Sprintf("Reverted(%d)", code),
+		}
+	}
+	n.results[string(txh)] = txr
+	n.balance.Sub(n.balance, big.NewInt(DefaultStepUsed*DefaultStepPrice))
+	return txh, nil
+}
+
+// _execute calls BMC method, and returns the revert code if it fails. mtx should be locked.
+func (n *Node) _execute(p *transactionParam) int {
+	if len(n.reverts) > 0 {
+		code := n.reverts[0]
+		n.reverts = n.reverts[1:]
+		return code
+	}
+	prm := p.Data.Params
+	msg, err := base64.URLEncoding.DecodeString(prm.Messages)
+	if err != nil {
+		return BMCRevert
+	}
+	switch p.Data.Method {
+	case BMCRelayMethod:
+		return n._handleRelayMessage(prm.Prev, msg)
+	case BMCFragmentMethod:
+		idx, err := prm.Index.Value()
+		if err != nil {
+			return BMCRevert
+		}
+		if code, ok := n.fragReverts[idx]; ok {
+			delete(n.fragReverts, idx)
+			return code
+		}
+		return n._handleFragment(prm.Prev, msg, idx)
+	default:
+		return BMCRevert
+	}
+}
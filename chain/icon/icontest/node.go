@@ -0,0 +1,304 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package icontest provides in-process ICON node for tests of relay.
+// The node serves the subset of JSON-RPC and websocket API which is used by chain/icon.Client,
+// BTP blocks of the node are scripted by tests, and the transactions to BMC are
+// executed by simple BMC and BMV which keep link status and received messages.
+package icontest
+
+import (
+	"encoding/binary"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/crypto"
+	"github.com/icon-project/btp/common/jsonrpc"
+	"github.com/icon-project/btp/common/mbt"
+)
+
+const (
+	DefaultNetworkID       = 1
+	DefaultNetworkTypeName = "eth"
+	DefaultStepPrice       = 12500000000
+	DefaultStepUsed        = 100000
+	// DefaultBalance is the balance of accounts in ICX, the fee of each transaction is charged from it
+	DefaultBalance = 1000000
+
+	apiPath      = "/api/v3/icon_dex"
+	debugApiPath = "/api/v3d/icon_dex"
+)
+
+type Config struct {
+	//NetworkID is the id of BTP network which is produced by the node
+	NetworkID int64
+	//NetworkTypeName is the name of network type, it decides the hash function of messages
+	NetworkTypeName string
+}
+
+type btpBlock struct {
+	height   int64
+	header   []byte
+	proof    []byte
+	messages [][]byte
+}
+
+type Node struct {
+	cfg      Config
+	srv      *httptest.Server
+	hashFunc mbt.HashFunc
+	upgrader websocket.Upgrader
+
+	mtx         sync.Mutex
+	height      int64
+	startHeight int64
+	nextSN      int64
+	btpBlocks   []*btpBlock
+	results     map[string]*transactionResult
+	signatures  map[string]hexBytes
+	links       map[string]*link
+	routes      map[string]string
+	fragments   map[string]*fragment
+	errs        map[string][]*jsonrpc.Error
+	reverts     []int
+	fragReverts map[int64]int
+	pendings    int
+	balance     *big.Int
+	stepLimit   int64
+	calls       map[string]int
+	notify      chan struct{}
+	conns       map[*websocket.Conn]bool
+}
+
+// URL returns the endpoint of JSON-RPC, the websocket API is served under it.
+func (n *Node) URL() string {
+	return n.srv.URL + apiPath
+}
+
+func (n *Node) Close() {
+	n.DropConnections()
+	n.srv.Close()
+}
+
+// Height returns the height of the last block.
+func (n *Node) Height() int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.height
+}
+
+// StartHeight returns the height which BTP network is opened.
+func (n *Node) StartHeight() int64 {
+	return n.startHeight
+}
+
+// BTPHeight returns the height of the last BTP block.
+func (n *Node) BTPHeight() int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.btpBlocks[len(n.btpBlocks)-1].height
+}
+
+func blockHash(height int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(height))
+	return crypto.SHA3Sum256(b)
+}
+
+// _newBlock makes new block and notifies to monitors, monitors read it after mtx is unlocked.
+// mtx should be locked.
+func (n *Node) _newBlock() int64 {
+	n.height++
+	close(n.notify)
+	n.notify = make(chan struct{})
+	return n.height
+}
+
+// AddBlock makes new block without BTP block, and returns its height.
+func (n *Node) AddBlock() int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n._newBlock()
+}
+
+// AddBTPBlock makes new block with BTP block of messages, and returns its height.
+func (n *Node) AddBTPBlock(messages ...[]byte) int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	h := n._newBlock()
+	n._addBTPBlock(messages)
+	return h
+}
+
+// _addBTPBlock makes BTP block at the current height, mtx should be locked.
+func (n *Node) _addBTPBlock(messages [][]byte) {
+	bh := &BTPBlockHeader{
+		MainHeight:   n.height,
+		NetworkID:    n.cfg.NetworkID,
+		UpdateNumber: n.nextSN << 1,
+		MessageCount: int64(len(messages)),
+	}
+	if l := len(n.btpBlocks); l > 0 {
+		bh.PrevNetworkSectionHash = crypto.SHA3Sum256(n.btpBlocks[l-1].header)
+	}
+	if len(messages) > 0 {
+		mt, err := mbt.NewMerkleBinaryTree(n.hashFunc, messages)
+		if err != nil {
+			panic(err)
+		}
+		bh.MessagesRoot = mt.Root()
+	}
+	h := codec.RLP.MustMarshalToBytes(bh)
+	n.btpBlocks = append(n.btpBlocks, &btpBlock{
+		height:   n.height,
+		header:   h,
+		proof:    crypto.SHA3Sum256(h),
+		messages: messages,
+	})
+	n.nextSN += int64(len(messages))
+}
+
+func (n *Node) _btpBlock(height int64) *btpBlock {
+	for _, bb := range n.btpBlocks {
+		if bb.height == height {
+			return bb
+		}
+	}
+	return nil
+}
+
+// InjectError makes the next call of JSON-RPC method fail with code and message,
+// it's queued if it's called several times.
+func (n *Node) InjectError(method string, code jsonrpc.ErrorCode, message string) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.errs[method] = append(n.errs[method], &jsonrpc.Error{Code: code, Message: message})
+}
+
+// InjectRevert makes the next transaction to BMC fail with revert code,
+// refer chain/icon/error.go for the codes of BMC and BMV.
+func (n *Node) InjectRevert(code int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.reverts = append(n.reverts, code)
+}
+
+// InjectFragmentRevert makes the next fragment of the index fail with revert code.
+func (n *Node) InjectFragmentRevert(idx int64, code int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.fragReverts[idx] = code
+}
+
+// InjectPending makes the next count calls of icx_getTransactionResult return pending.
+func (n *Node) InjectPending(count int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.pendings += count
+}
+
+// SetBalance sets the balance of accounts in loop, the node doesn't distinguish accounts.
+func (n *Node) SetBalance(v *big.Int) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.balance = new(big.Int).Set(v)
+}
+
+// Balance returns the balance of accounts in loop.
+func (n *Node) Balance() *big.Int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return new(big.Int).Set(n.balance)
+}
+
+// StepLimit returns the step limit of the last transaction.
+func (n *Node) StepLimit() int64 {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.stepLimit
+}
+
+// Calls returns the number of calls of JSON-RPC method, or transactions of BMC method.
+func (n *Node) Calls(method string) int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return n.calls[method]
+}
+
+// DropConnections closes websocket connections without close message, so that clients reconnect.
+func (n *Node) DropConnections() {
+	n.mtx.Lock()
+	conns := make([]*websocket.Conn, 0, len(n.conns))
+	for conn := range n.conns {
+		conns = append(conns, conn)
+	}
+	n.mtx.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
+
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/btp"):
+			n.serveBTP(w, r)
+		case strings.HasSuffix(r.URL.Path, "/block"):
+			n.serveBlock(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+	n.serveJSONRPC(w, r)
+}
+
+// NewNode returns Node which is started to serve, BTP network is opened with the first BTP block.
+func NewNode(cfg Config) *Node {
+	if cfg.NetworkID == 0 {
+		cfg.NetworkID = DefaultNetworkID
+	}
+	if cfg.NetworkTypeName == "" {
+		cfg.NetworkTypeName = DefaultNetworkTypeName
+	}
+	n := &Node{
+		cfg:         cfg,
+		hashFunc:    mbt.HashFuncByUID(cfg.NetworkTypeName),
+		height:      1,
+		results:     make(map[string]*transactionResult),
+		signatures:  make(map[string]hexBytes),
+		links:       make(map[string]*link),
+		routes:      make(map[string]string),
+		fragments:   make(map[string]*fragment),
+		fragReverts: make(map[int64]int),
+		errs:        make(map[string][]*jsonrpc.Error),
+		calls:       make(map[string]int),
+		balance:     new(big.Int).Mul(big.NewInt(DefaultBalance), big.NewInt(1e18)),
+		notify:      make(chan struct{}),
+		conns:       make(map[*websocket.Conn]bool),
+	}
+	n.startHeight = n.height
+	n.height++
+	n._addBTPBlock(nil)
+	n.srv = httptest.NewServer(n)
+	return n
+}
@@ -0,0 +1,242 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icontest
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/icon-project/btp/common/intconv"
+	"github.com/icon-project/btp/common/jsonrpc"
+)
+
+//types of this file are the wire format of ICON JSON-RPC v3 and BTP extension,
+//they are declared again instead of using chain/icon, so that it could be used by tests of chain/icon.
+
+const (
+	ErrorCodeSystem         jsonrpc.ErrorCode = -31000
+	ErrorCodeTxPoolOverflow jsonrpc.ErrorCode = -31001
+	ErrorCodePending        jsonrpc.ErrorCode = -31002
+	ErrorCodeExecuting      jsonrpc.ErrorCode = -31003
+	ErrorCodeNotFound       jsonrpc.ErrorCode = -31004
+	ErrorCodeScore          jsonrpc.ErrorCode = -30000
+)
+
+const (
+	DuplicateTransactionError = 2000
+	ExpiredTransactionError   = 2002
+	FutureTransactionError    = 2003
+)
+
+const (
+	ResultStatusSuccess           = "0x1"
+	ResultStatusFailure           = "0x0"
+	ResultStatusFailureCodeRevert = 32
+)
+
+const (
+	BMCRelayMethod     = "handleRelayMessage"
+	BMCFragmentMethod  = "handleFragment"
+	BMCGetStatusMethod = "getStatus"
+	BMCGetRoutesMethod = "getRoutes"
+	BMCGetLinksMethod  = "getLinks"
+)
+
+// revert codes of BMC and BMV, refer chain/icon/error.go
+const (
+	BMCRevert              = 10
+	BMCRevertUnauthorized  = 11
+	BMCRevertNotExistsLink = 18
+	BMVUnknown             = 25
+	BMVNotVerifiable       = 26
+	BMVAlreadyVerified     = 27
+)
+
+const (
+	RelayMessageTypeBlockUpdate = iota + 1
+	RelayMessageTypeMessageProof
+)
+
+type hexInt string
+
+func (i hexInt) Value() (int64, error) {
+	return intconv.ParseInt(string(i), 64)
+}
+
+func newHexInt(v int64) hexInt {
+	return hexInt(intconv.FormatInt(v))
+}
+
+type hexBytes string
+
+func (hs hexBytes) Value() ([]byte, error) {
+	if hs == "" {
+		return nil, nil
+	}
+	return hex.DecodeString(strings.TrimPrefix(string(hs), "0x"))
+}
+
+func newHexBytes(b []byte) hexBytes {
+	return hexBytes("0x" + hex.EncodeToString(b))
+}
+
+type callData struct {
+	Method string `json:"method"`
+	Params struct {
+		Link     string `json:"_link,omitempty"`
+		Prev     string `json:"_prev,omitempty"`
+		Messages string `json:"_msg,omitempty"`
+		Index    hexInt `json:"_idx,omitempty"`
+	} `json:"params"`
+}
+
+type callParam struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	DataType string   `json:"dataType"`
+	Data     callData `json:"data"`
+}
+
+type transactionParam struct {
+	Version   hexInt   `json:"version"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	StepLimit hexInt   `json:"stepLimit"`
+	Timestamp hexInt   `json:"timestamp"`
+	NetworkID hexInt   `json:"nid"`
+	Signature string   `json:"signature"`
+	DataType  string   `json:"dataType"`
+	Data      callData `json:"data"`
+}
+
+type transactionHashParam struct {
+	Hash hexBytes `json:"txHash"`
+}
+
+type failure struct {
+	Code    hexInt `json:"code"`
+	Message string `json:"message"`
+}
+
+type transactionResult struct {
+	To                 string        `json:"to"`
+	CumulativeStepUsed hexInt        `json:"cumulativeStepUsed"`
+	StepUsed           hexInt        `json:"stepUsed"`
+	StepPrice          hexInt        `json:"stepPrice"`
+	EventLogs          []interface{} `json:"eventLogs"`
+	LogsBloom          hexBytes      `json:"logsBloom"`
+	Status             hexInt        `json:"status"`
+	Failure            *failure      `json:"failure,omitempty"`
+	BlockHash          hexBytes      `json:"blockHash"`
+	BlockHeight        hexInt        `json:"blockHeight"`
+	TxIndex            hexInt        `json:"txIndex"`
+	TxHash             hexBytes      `json:"txHash"`
+}
+
+type bmcStatus struct {
+	TxSeq    hexInt `json:"tx_seq"`
+	RxSeq    hexInt `json:"rx_seq"`
+	Verifier struct {
+		Height hexInt   `json:"height"`
+		Extra  hexBytes `json:"extra"`
+	} `json:"verifier"`
+	BMRs          []interface{} `json:"relays"`
+	CurrentHeight hexInt        `json:"cur_height"`
+}
+
+type btpBlockParam struct {
+	Height    hexInt `json:"height"`
+	NetworkID hexInt `json:"networkID"`
+}
+
+type btpNetworkInfoParam struct {
+	Height hexInt `json:"height"`
+	ID     hexInt `json:"id"`
+}
+
+type networkInfo struct {
+	StartHeight     hexInt   `json:"startHeight"`
+	NetworkTypeID   hexInt   `json:"networkTypeID"`
+	NetworkTypeName string   `json:"networkTypeName"`
+	NetworkID       hexInt   `json:"networkID"`
+	NextMessageSN   hexInt   `json:"nextMessageSN"`
+	PrevNSHash      hexBytes `json:"prevNSHash"`
+	LastNSHash      hexBytes `json:"lastNSHash"`
+}
+
+type lastBlock struct {
+	Height    int64  `json:"height"`
+	BlockHash string `json:"block_hash"`
+}
+
+type btpRequest struct {
+	Height    hexInt `json:"height"`
+	NetworkID hexInt `json:"networkID"`
+	ProofFlag bool   `json:"proofFlag"`
+}
+
+type btpNotification struct {
+	Header hexBytes `json:"header"`
+	Proof  string   `json:"proof,omitempty"`
+}
+
+type blockRequest struct {
+	Height hexInt `json:"height"`
+}
+
+type blockNotification struct {
+	Hash   hexBytes `json:"hash"`
+	Height hexInt   `json:"height"`
+}
+
+type wsResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type BTPBlockHeader struct {
+	MainHeight             int64
+	Round                  int32
+	NextProofContextHash   []byte
+	NetworkSectionToRoot   [][]byte
+	NetworkID              int64
+	UpdateNumber           int64
+	PrevNetworkSectionHash []byte
+	MessageCount           int64
+	MessagesRoot           []byte
+	NextProofContext       []byte
+}
+
+type BTPBlockUpdate struct {
+	BTPBlockHeader []byte
+	BTPBlockProof  []byte
+}
+
+type typePrefixedMessage struct {
+	Type    int
+	Payload []byte
+}
+
+type relayMessage struct {
+	Messages []*typePrefixedMessage
+}
+
+type verifierStatus struct {
+	SequenceOffset int64
+	FirstMessageSn int64
+	MessageCount   int64
+}
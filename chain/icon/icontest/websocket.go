@@ -0,0 +1,142 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icontest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// notificationsFunc returns notifications from height and the next height, mtx is locked while it's called.
+type notificationsFunc func(height int64) ([]interface{}, int64)
+
+func (n *Node) upgrade(w http.ResponseWriter, r *http.Request, reqPtr interface{}) (*websocket.Conn, error) {
+	conn, err := n.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.ReadJSON(reqPtr); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	n.mtx.Lock()
+	n.conns[conn] = true
+	n.mtx.Unlock()
+	return conn, nil
+}
+
+func (n *Node) closeConn(conn *websocket.Conn) {
+	n.mtx.Lock()
+	delete(n.conns, conn)
+	n.mtx.Unlock()
+	_ = conn.Close()
+}
+
+// stream writes notifications from height until the connection is closed.
+func (n *Node) stream(conn *websocket.Conn, height int64, nf notificationsFunc) {
+	if err := conn.WriteJSON(&wsResponse{}); err != nil {
+		return
+	}
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		n.mtx.Lock()
+		l, next := nf(height)
+		notify := n.notify
+		n.mtx.Unlock()
+		for _, v := range l {
+			if err := conn.WriteJSON(v); err != nil {
+				return
+			}
+		}
+		height = next
+		select {
+		case <-notify:
+		case <-closed:
+			return
+		}
+	}
+}
+
+func nextHeight(height, last int64) int64 {
+	if height > last {
+		return height
+	}
+	return last + 1
+}
+
+func (n *Node) serveBTP(w http.ResponseWriter, r *http.Request) {
+	req := &btpRequest{}
+	conn, err := n.upgrade(w, r, req)
+	if err != nil {
+		return
+	}
+	defer n.closeConn(conn)
+	height, err := req.Height.Value()
+	if err != nil {
+		_ = conn.WriteJSON(&wsResponse{Code: -1, Message: fmt.Sprintf("invalid height err:%v", err)})
+		return
+	}
+	if nid, err := req.NetworkID.Value(); err != nil || nid != n.cfg.NetworkID {
+		_ = conn.WriteJSON(&wsResponse{Code: -1, Message: fmt.Sprintf("invalid networkID %s", req.NetworkID)})
+		return
+	}
+	n.stream(conn, height, func(height int64) ([]interface{}, int64) {
+		var l []interface{}
+		for _, bb := range n.btpBlocks {
+			if bb.height >= height {
+				v := &btpNotification{Header: newHexBytes(bb.header)}
+				if req.ProofFlag {
+					v.Proof = base64.StdEncoding.EncodeToString(bb.proof)
+				}
+				l = append(l, v)
+			}
+		}
+		return l, nextHeight(height, n.height)
+	})
+}
+
+func (n *Node) serveBlock(w http.ResponseWriter, r *http.Request) {
+	req := &blockRequest{}
+	conn, err := n.upgrade(w, r, req)
+	if err != nil {
+		return
+	}
+	defer n.closeConn(conn)
+	height, err := req.Height.Value()
+	if err != nil {
+		_ = conn.WriteJSON(&wsResponse{Code: -1, Message: fmt.Sprintf("invalid height err:%v", err)})
+		return
+	}
+	n.stream(conn, height, func(height int64) ([]interface{}, int64) {
+		var l []interface{}
+		for h := height; h <= n.height; h++ {
+			l = append(l, &blockNotification{Hash: newHexBytes(blockHash(h)), Height: newHexInt(h)})
+		}
+		return l, nextHeight(height, n.height)
+	})
+}
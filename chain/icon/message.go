@@ -17,6 +17,7 @@ const (
 )
 
 type BTPRelayMessage struct {
+	id         uint64
 	height     int64
 	messageSeq int
 	Messages   []*TypePrefixedMessage
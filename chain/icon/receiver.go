@@ -25,6 +25,7 @@ import (
 	"github.com/gorilla/websocket"
 
 	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/common/codec"
 	"github.com/icon-project/btp/common/intconv"
 
 	"github.com/icon-project/btp/common/log"
@@ -97,54 +98,70 @@ func (r *Receiver) GetBTPNetworkInfo(nid int64) (*NetworkInfo, error) {
 	return b, nil
 }
 
+// ReceiveLoop monitors BTP blocks from height, it reconnects from the next height of
+// the last received block and skips the blocks replayed across the reconnect.
 func (r *Receiver) ReceiveLoop(height int64, networkId int64, cb func(bu *BTPBlockUpdate) error, scb func()) error {
-	//s := r.dst.String()
-	r.req = &BTPRequest{
-		Height:    HexInt(intconv.FormatInt(height)),
-		NetworkID: HexInt(intconv.FormatInt(networkId)),
-		ProofFlag: false,
-	}
-
 	if height < 1 {
 		return fmt.Errorf("cannot catchup from zero height")
 	}
-
-	return r.c.MonitorBTP(r.req,
-		func(conn *websocket.Conn, v *BTPNotification) error {
-			var p []byte
-			h, err := v.Header.Value()
-			if err != nil {
-				return err
-			}
-			if len(v.Proof) > 0 {
-				p, err = base64.StdEncoding.DecodeString(v.Proof)
+	last := height - 1
+	return r.c.MonitorWithReconnect(func(reset func()) error {
+		//s := r.dst.String()
+		r.req = &BTPRequest{
+			Height:    HexInt(intconv.FormatInt(last + 1)),
+			NetworkID: HexInt(intconv.FormatInt(networkId)),
+			ProofFlag: false,
+		}
+		return r.c.MonitorBTP(r.req,
+			func(conn *websocket.Conn, v *BTPNotification) error {
+				var p []byte
+				h, err := v.Header.Value()
 				if err != nil {
 					return err
 				}
-			}
-
-			if err != nil {
-				return err
-			}
-			return cb(&BTPBlockUpdate{BTPBlockHeader: h, BTPBlockProof: p})
-		},
-		func(conn *websocket.Conn) {
-			r.l.Debugf("ReceiveLoop connected %s", conn.LocalAddr().String())
-			if scb != nil {
-				scb()
-			}
-		},
-		func(conn *websocket.Conn, err error) {
-			r.l.Debugf("onError %s err:%+v", conn.LocalAddr().String(), err)
-			_ = conn.Close()
-		})
+				bh := &BTPBlockHeader{}
+				if _, err = codec.RLP.UnmarshalFromBytes(h, bh); err != nil {
+					return err
+				}
+				if bh.MainHeight <= last {
+					r.l.Debugf("ReceiveLoop skip height:%d last:%d", bh.MainHeight, last)
+					return nil
+				}
+				if len(v.Proof) > 0 {
+					p, err = base64.StdEncoding.DecodeString(v.Proof)
+					if err != nil {
+						return err
+					}
+				}
+				if err = cb(&BTPBlockUpdate{BTPBlockHeader: h, BTPBlockProof: p}); err != nil {
+					return err
+				}
+				last = bh.MainHeight
+				return nil
+			},
+			func(conn *websocket.Conn) {
+				r.l.Debugf("ReceiveLoop connected %s height:%d", conn.LocalAddr().String(), last+1)
+				reset()
+				if scb != nil {
+					scb()
+				}
+			},
+			func(conn *websocket.Conn, err error) {
+				r.l.Debugf("onError %s err:%+v", conn.LocalAddr().String(), err)
+				_ = conn.Close()
+			})
+	})
 }
 
 func (r *Receiver) StopReceiveLoop() {
 	r.c.CloseAllMonitor()
 }
 
-func NewReceiver(src, dst chain.BtpAddress, endpoint string, opt map[string]interface{}, l log.Logger) *Receiver {
+func (r *Receiver) Close() {
+	r.c.Close()
+}
+
+func NewReceiver(src, dst chain.BtpAddress, endpoints []string, opt map[string]interface{}, l log.Logger) *Receiver {
 	r := &Receiver{
 		src: src,
 		dst: dst,
@@ -157,6 +174,6 @@ func NewReceiver(src, dst chain.BtpAddress, endpoint string, opt map[string]inte
 	if err = json.Unmarshal(b, &r.opt); err != nil {
 		l.Panicf("fail to unmarshal opt:%#v err:%+v", opt, err)
 	}
-	r.c = NewClient(endpoint, l)
+	r.c = NewClient(endpoints, l)
 	return r
 }
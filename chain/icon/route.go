@@ -0,0 +1,53 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icon
+
+import (
+	"github.com/icon-project/btp/chain"
+)
+
+// getRouteTable returns the route table of BMC by getLinks and getRoutes.
+func getRouteTable(c *Client, bmc chain.BtpAddress) (*chain.RouteTable, error) {
+	p := &CallParam{
+		ToAddress: Address(bmc.Account()),
+		DataType:  "call",
+		Data:      CallData{Method: BMCGetLinksMethod},
+	}
+	var links []string
+	if err := mapError(c.Call(p, &links)); err != nil {
+		return nil, err
+	}
+	p.Data = CallData{Method: BMCGetRoutesMethod}
+	routes := make(map[string]string)
+	if err := mapError(c.Call(p, &routes)); err != nil {
+		return nil, err
+	}
+	return chain.NewRouteTable(links, routes), nil
+}
+
+// GetRouteTable returns the route table of BMC of the destination.
+func (s *sender) GetRouteTable() (*chain.RouteTable, error) {
+	return getRouteTable(s.c, s.dst)
+}
+
+// GetRouteTable returns the route table of BMC of the source.
+func (r *Receiver) GetRouteTable() (*chain.RouteTable, error) {
+	return getRouteTable(r.c, r.src)
+}
+
+var _ chain.RouteLoader = (*sender)(nil)
+var _ chain.RouteLoader = (*Receiver)(nil)
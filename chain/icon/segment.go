@@ -0,0 +1,152 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icon
+
+import (
+	"github.com/icon-project/btp/common/segment"
+)
+
+type blockUpdateItem struct {
+	tpm    *TypePrefixedMessage
+	height int64
+}
+
+func (i *blockUpdateItem) Size() int {
+	return len(i.tpm.Payload)
+}
+
+func (i *blockUpdateItem) Sequence() (int64, int64) {
+	return 1, 0
+}
+
+// messageItem is the message of BTP block at index which starts from one.
+type messageItem struct {
+	bd    *BTPBlockData
+	index int
+	seq   int64
+}
+
+func (i *messageItem) Size() int {
+	b, _ := i.bd.Mt.Get(i.index)
+	return len(b)
+}
+
+func (i *messageItem) Sequence() (int64, int64) {
+	return i.seq, i.seq
+}
+
+// relayMessagePacker packs block updates and messages into the last one of SimpleChain.rms,
+// consecutive messages of BTP block in a relay message are proven by a proof of the range.
+// it's used with rmsMtx of SimpleChain.
+type relayMessagePacker struct {
+	s     *SimpleChain
+	bd    *BTPBlockData //BTP block of the proof which is the last message of the relay message
+	begin int
+
+	item segment.Item //cache of tpmOf
+	tpm  *TypePrefixedMessage
+}
+
+func (p *relayMessagePacker) last() *BTPRelayMessage {
+	if len(p.s.rms) == 0 {
+		p.s.rms = append(p.s.rms, NewRelayMessage())
+	}
+	return p.s.rms[len(p.s.rms)-1]
+}
+
+// tpmOf returns TypePrefixedMessage of the item, it replaces the last message of
+// the relay message if the item extends the range of the proof.
+func (p *relayMessagePacker) tpmOf(item segment.Item) (*TypePrefixedMessage, bool, error) {
+	switch i := item.(type) {
+	case *blockUpdateItem:
+		return i.tpm, false, nil
+	case *messageItem:
+		extend := p.bd == i.bd
+		if p.item != item {
+			begin := i.index
+			if extend {
+				begin = p.begin
+			}
+			mp, err := i.bd.Mt.Proof(begin, i.index)
+			if err != nil {
+				return nil, false, err
+			}
+			if p.tpm, err = NewTypePrefixedMessage(*mp); err != nil {
+				return nil, false, err
+			}
+			p.item = item
+		}
+		return p.tpm, extend, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func (p *relayMessagePacker) SizeWith(item segment.Item) (int, error) {
+	rm := p.last()
+	if item == nil {
+		return rm.Size()
+	}
+	tpm, replace, err := p.tpmOf(item)
+	if err != nil {
+		return 0, err
+	}
+	msgs := rm.Messages
+	if replace {
+		msgs = msgs[:len(msgs)-1]
+	}
+	t := &BTPRelayMessage{Messages: append(msgs[:len(msgs):len(msgs)], tpm)}
+	return t.Size()
+}
+
+func (p *relayMessagePacker) Add(item segment.Item) error {
+	rm := p.last()
+	tpm, replace, err := p.tpmOf(item)
+	if err != nil {
+		return err
+	}
+	if replace {
+		rm.Messages = rm.Messages[:len(rm.Messages)-1]
+	}
+	rm.AppendMessage(tpm)
+	switch i := item.(type) {
+	case *blockUpdateItem:
+		rm.SetHeight(i.height)
+		p.bd = nil
+	case *messageItem:
+		rm.SetHeight(i.bd.Height)
+		rm.SetMessageSeq(i.index)
+		i.bd.PartialOffset = i.index
+		if !replace {
+			p.bd, p.begin = i.bd, i.index
+		}
+	}
+	p.item, p.tpm = nil, nil
+	return nil
+}
+
+func (p *relayMessagePacker) Empty() bool {
+	return len(p.s.rms) == 0 || len(p.last().Messages) == 0
+}
+
+// Flush closes the last relay message, then it's relayed.
+func (p *relayMessagePacker) Flush() error {
+	p.s.rms = append(p.s.rms, NewRelayMessage())
+	p.bd = nil
+	p.item, p.tpm = nil, nil
+	return nil
+}
@@ -21,16 +21,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
 	"net/url"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 
 	"github.com/icon-project/btp/chain"
 	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/errors"
 	"github.com/icon-project/btp/common/jsonrpc"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
 )
 
 const (
@@ -39,6 +43,7 @@ const (
 	DefaultGetRelayResultInterval = time.Second
 	DefaultRelayReSendInterval    = time.Second
 	DefaultStepLimit              = 0x9502f900 //maxStepLimit(invoke), refer https://www.icondev.io/docs/step-estimation
+	DefaultStepMargin             = 10         //percent of estimated step
 )
 
 var (
@@ -52,12 +57,27 @@ type sender struct {
 	w   Wallet
 	l   log.Logger
 	opt struct {
+		//StepLimit is used if debug_estimateStep is unavailable
 		StepLimit int64
+		//StepMargin is percent which is added to estimated step, zero means DefaultStepMargin
+		StepMargin int64 `json:"step_margin,omitempty"`
+		//StepLimitCap is the max of estimated step limit, zero means StepLimit
+		StepLimitCap int64 `json:"step_limit_cap,omitempty"`
+		//FragmentWindow is the max number of fragments which are waiting for the result
+		FragmentWindow int `json:"fragment_window,omitempty"`
+		//FragmentRetry is the max number of attempts to send the failed fragment again
+		FragmentRetry int `json:"fragment_retry,omitempty"`
 	}
+	noEstimate         int32
 	isFoundOffsetBySeq bool
 	cb                 chain.ReceiveCallback
+	m                  *metrics.LinkMetrics
+	fl                 chain.FeeListener
 }
 
+var _ chain.FeeNotifier = (*sender)(nil)
+var _ chain.BalanceGetter = (*sender)(nil)
+
 func (s *sender) newTransactionParam(method string, params interface{}) *TransactionParam {
 	p := &TransactionParam{
 		Version:     NewHexInt(JsonrpcApiVersion),
@@ -78,45 +98,58 @@ type transactionParamMessage struct {
 	messages string
 }
 
-func (s *sender) sendFragment(msg []byte, idx int) (chain.GetResultParam, error) {
-	fmp := &BMCFragmentMethodParams{
-		Prev:     s.src.String(),
-		Messages: base64.URLEncoding.EncodeToString(msg),
-		Index:    NewHexInt(int64(idx)),
-	}
-	p := s.newTransactionParam(BMCFragmentMethod, fmp)
-	return s.sendTransaction(p)
-}
-
+// Relay sends the message by BMCRelayMethod, or by fragments if it's over the size limit of transaction.
+// fragments are sent up to the window, and the rest of them are sent by GetResult.
 func (s *sender) Relay(segment *chain.Segment) (chain.GetResultParam, error) {
 	msg := segment.TransactionParam.([]byte)
-	idx := len(msg) / txSizeLimit
-	if idx == 0 {
+	if len(msg) < txSizeLimit {
 		rmp := &BMCRelayMethodParams{
 			Prev:     s.src.String(),
 			Messages: base64.URLEncoding.EncodeToString(msg),
 		}
 		return s.sendTransaction(s.newTransactionParam(BMCRelayMethod, rmp))
-	} else {
-		ret, err := s.sendFragment(msg[:txSizeLimit], idx*-1)
-		if err != nil {
-			return nil, err
-		}
-		msg = msg[txSizeLimit:]
-		for idx--; idx > 0; idx-- {
-			if ret, err = s.sendFragment(msg[:txSizeLimit], idx); err != nil {
-				return ret, err
+	}
+	p := newFragmentsParam(msg, txSizeLimit)
+	if _, err := s.sendFragments(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// estimateStepLimit sets stepLimit of p to estimated step with margin which doesn't exceed the cap.
+// the static limit is kept if debug_estimateStep is unavailable,
+// and it returns the revert error if the estimation fails by revert.
+func (s *sender) estimateStepLimit(p *TransactionParam) error {
+	step, err := s.c.EstimateStep(p)
+	if err != nil {
+		if je, ok := err.(*jsonrpc.Error); ok {
+			if re := revertErrorOf(je); re != nil {
+				return re
 			}
-			msg = msg[txSizeLimit:]
 		}
-		if ret, err = s.sendFragment(msg[:], idx); err != nil {
-			return ret, err
+		if atomic.CompareAndSwapInt32(&s.noEstimate, 0, 1) {
+			s.l.Warnf("fail to EstimateStep, use static step limit:%d err:%+v", s.opt.StepLimit, err)
+		} else {
+			s.l.Debugf("fail to EstimateStep, use static step limit:%d err:%+v", s.opt.StepLimit, err)
 		}
-		return ret, err
+		return nil
 	}
+	if atomic.CompareAndSwapInt32(&s.noEstimate, 1, 0) {
+		s.l.Infof("EstimateStep is available")
+	}
+	limit := step + step*s.opt.StepMargin/100
+	if limit > s.opt.StepLimitCap {
+		s.l.Warnf("estimated step limit:%d exceeds the cap:%d", limit, s.opt.StepLimitCap)
+		limit = s.opt.StepLimitCap
+	}
+	p.StepLimit = NewHexInt(limit)
+	return nil
 }
 
 func (s *sender) sendTransaction(p *TransactionParam) (chain.GetResultParam, error) {
+	if err := s.estimateStepLimit(p); err != nil {
+		return nil, err
+	}
 	thp := &TransactionHashParam{}
 SignLoop:
 	for {
@@ -155,6 +188,9 @@ SignLoop:
 }
 
 func (s *sender) GetResult(p chain.GetResultParam) (chain.TransactionResult, error) {
+	if fp, ok := p.(*FragmentsParam); ok {
+		return s.getFragmentsResult(fp)
+	}
 	if txh, ok := p.(*TransactionHashParam); ok {
 		for {
 			txr, err := s.c.GetTransactionResult(txh)
@@ -167,6 +203,9 @@ func (s *sender) GetResult(p chain.GetResultParam) (chain.TransactionResult, err
 					}
 				}
 			}
+			if txr != nil {
+				s.addFee(txr)
+			}
 			return txr, mapErrorWithTransactionResult(txr, err)
 		}
 	} else {
@@ -174,6 +213,31 @@ func (s *sender) GetResult(p chain.GetResultParam) (chain.TransactionResult, err
 	}
 }
 
+func (s *sender) addFee(txr *TransactionResult) {
+	used, err := txr.StepUsed.BigInt()
+	if err != nil {
+		return
+	}
+	price, err := txr.StepPrice.BigInt()
+	if err != nil {
+		return
+	}
+	fee := used.Mul(used, price)
+	s.m.Fee.Add(metrics.BigFloat(fee))
+	if s.fl != nil {
+		s.fl(fee)
+	}
+}
+
+// SetFeeListener should be called before Relay.
+func (s *sender) SetFeeListener(l chain.FeeListener) {
+	s.fl = l
+}
+
+func (s *sender) Balance() (*big.Int, error) {
+	return s.c.GetBalance(&AddressParam{Address: Address(s.w.Address())})
+}
+
 func (s *sender) GetStatus() (*chain.BMCLinkStatus, error) {
 	p := &CallParam{
 		FromAddress: Address(s.w.Address()),
@@ -210,33 +274,51 @@ func (s *sender) GetStatus() (*chain.BMCLinkStatus, error) {
 	return ls, nil
 }
 
+// MonitorLoop monitors blocks from height, it reconnects from the next height of
+// the last monitored block and skips the blocks replayed across the reconnect.
 func (s *sender) MonitorLoop(height int64, cb chain.MonitorCallback, scb func()) error {
-	br := &BlockRequest{
-		Height: NewHexInt(height),
-	}
-	return s.c.MonitorBlock(br,
-		func(conn *websocket.Conn, v *BlockNotification) error {
-			if h, err := v.Height.Value(); err != nil {
-				return err
-			} else {
-				return cb(h)
-			}
-		},
-		func(conn *websocket.Conn) {
-			s.l.Debugf("MonitorLoop connected %s", conn.LocalAddr().String())
-			if scb != nil {
-				scb()
-			}
-		},
-		func(conn *websocket.Conn, err error) {
-			s.l.Debugf("onError %s err:%+v", conn.LocalAddr().String(), err)
-			_ = conn.Close()
-		})
+	last := height - 1
+	return s.c.MonitorWithReconnect(func(reset func()) error {
+		br := &BlockRequest{
+			Height: NewHexInt(last + 1),
+		}
+		return s.c.MonitorBlock(br,
+			func(conn *websocket.Conn, v *BlockNotification) error {
+				h, err := v.Height.Value()
+				if err != nil {
+					return err
+				}
+				if h <= last {
+					return nil
+				}
+				if err = cb(h); err != nil {
+					return err
+				}
+				last = h
+				return nil
+			},
+			func(conn *websocket.Conn) {
+				s.l.Debugf("MonitorLoop connected %s height:%d", conn.LocalAddr().String(), last+1)
+				reset()
+				if scb != nil {
+					scb()
+				}
+			},
+			func(conn *websocket.Conn, err error) {
+				s.l.Debugf("onError %s err:%+v", conn.LocalAddr().String(), err)
+				_ = conn.Close()
+			})
+	})
 }
 
 func (s *sender) StopMonitorLoop() {
 	s.c.CloseAllMonitor()
 }
+
+func (s *sender) Close() {
+	s.c.Close()
+}
+
 func (s *sender) FinalizeLatency() int {
 	//on-the-next
 	return 1
@@ -246,25 +328,41 @@ func (s *sender) TxSizeLimit() int {
 	return txSizeLimit
 }
 
-func NewSender(src, dst chain.BtpAddress, w Wallet, endpoint string, opt map[string]interface{}, l log.Logger) chain.Sender {
+// NewSender returns the sender to BMC of dst, options are validated and filled with defaults.
+func NewSender(src, dst chain.BtpAddress, w Wallet, endpoints []string, opt map[string]interface{}, l log.Logger) (chain.Sender, error) {
 	s := &sender{
 		src: src,
 		dst: dst,
 		w:   w,
 		l:   l,
+		m:   metrics.NewLinkMetrics(src.NetworkAddress(), dst.NetworkAddress()),
 	}
 	b, err := json.Marshal(opt)
 	if err != nil {
-		l.Panicf("fail to marshal opt:%#v err:%+v", opt, err)
+		return nil, errors.Wrapf(err, "fail to marshal opt:%#v", opt)
 	}
 	if err = json.Unmarshal(b, &s.opt); err != nil {
-		l.Panicf("fail to unmarshal opt:%#v err:%+v", opt, err)
+		return nil, errors.Wrapf(err, "fail to unmarshal opt:%#v", opt)
 	}
 	if s.opt.StepLimit <= 0 {
 		s.opt.StepLimit = DefaultStepLimit
 	}
-	s.c = NewClient(endpoint, l)
-	return s
+	if s.opt.StepMargin < 0 {
+		return nil, errors.IllegalArgumentError.Errorf("invalid step_margin:%d", s.opt.StepMargin)
+	} else if s.opt.StepMargin == 0 {
+		s.opt.StepMargin = DefaultStepMargin
+	}
+	if s.opt.StepLimitCap <= 0 {
+		s.opt.StepLimitCap = s.opt.StepLimit
+	}
+	if s.opt.FragmentWindow <= 0 {
+		s.opt.FragmentWindow = DefaultFragmentWindow
+	}
+	if s.opt.FragmentRetry <= 0 {
+		s.opt.FragmentRetry = DefaultFragmentRetry
+	}
+	s.c = NewClient(endpoints, l)
+	return s, nil
 }
 
 func mapError(err error) error {
@@ -303,6 +401,15 @@ func mapError(err error) error {
 	return err
 }
 
+// revertErrorOf returns the revert error of SCORE error, debug_estimateStep returns the failure of execution as it.
+func revertErrorOf(je *jsonrpc.Error) error {
+	fc := int64(JsonrpcErrorCodeScore - je.Code)
+	if fc < ResultStatusFailureCodeRevert || fc > ResultStatusFailureCodeEnd {
+		return nil
+	}
+	return NewRevertError(int(fc - ResultStatusFailureCodeRevert))
+}
+
 func mapErrorWithTransactionResult(txr *TransactionResult, err error) error {
 	err = mapError(err)
 	if err == nil && txr != nil && txr.Status != ResultStatusSuccess {
@@ -0,0 +1,108 @@
+package icon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/chain"
+	"github.com/icon-project/btp/chain/icon/icontest"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/jsonrpc"
+	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/wallet"
+)
+
+func TestSender_EstimateStep(t *testing.T) {
+	n := icontest.NewNode(icontest.Config{NetworkID: 2})
+	defer n.Close()
+	newSender := func(opt map[string]interface{}) *sender {
+		s, err := NewSender(testSrcAddress, testDstAddress, wallet.New(), []string{n.URL()}, opt, log.New())
+		assert.NoError(t, err)
+		return s.(*sender)
+	}
+	relay := func(s *sender) error {
+		_, err := s.Relay(&chain.Segment{TransactionParam: []byte("message")})
+		return err
+	}
+
+	//estimated step with default margin
+	s := newSender(nil)
+	assert.NoError(t, relay(s))
+	assert.Equal(t, int64(icontest.DefaultStepUsed*(100+DefaultStepMargin)/100), n.StepLimit())
+	assert.Equal(t, 1, n.Calls("debug_estimateStep"))
+
+	s = newSender(map[string]interface{}{"step_margin": 50})
+	assert.NoError(t, relay(s))
+	assert.Equal(t, int64(icontest.DefaultStepUsed*3/2), n.StepLimit())
+
+	//capped
+	s = newSender(map[string]interface{}{"step_limit_cap": icontest.DefaultStepUsed})
+	assert.NoError(t, relay(s))
+	assert.Equal(t, int64(icontest.DefaultStepUsed), n.StepLimit())
+
+	//static limit if estimation is unavailable
+	s = newSender(map[string]interface{}{"StepLimit": 0x1000000})
+	n.InjectError("debug_estimateStep", jsonrpc.ErrorCodeMethodNotFound, "MethodNotFound")
+	assert.NoError(t, relay(s))
+	assert.Equal(t, int64(0x1000000), n.StepLimit())
+	assert.NoError(t, relay(s))
+	assert.Equal(t, int64(icontest.DefaultStepUsed*(100+DefaultStepMargin)/100), n.StepLimit())
+
+	//transaction is not sent if the estimation is reverted
+	calls := n.Calls(BMCRelayMethod)
+	n.InjectError("debug_estimateStep", JsonrpcErrorCodeScore-ResultStatusFailureCodeRevert-jsonrpc.ErrorCode(BMVNotVerifiable), "Reverted")
+	err := relay(s)
+	coder, ok := errors.CoderOf(err)
+	assert.True(t, ok)
+	assert.Equal(t, errors.Code(BMVNotVerifiable), coder.ErrorCode())
+	assert.Equal(t, calls, n.Calls(BMCRelayMethod))
+}
+
+func TestNewSender_InvalidStepMargin(t *testing.T) {
+	//the link fails to start instead of panic
+	_, err := NewSender(testSrcAddress, testDstAddress, wallet.New(), []string{"http://localhost:9080/api/v3"},
+		map[string]interface{}{"step_margin": -1}, log.New())
+	assert.True(t, errors.IllegalArgumentError.Equals(err))
+}
+
+func TestSender_RelayFragments(t *testing.T) {
+	n := icontest.NewNode(icontest.Config{NetworkID: 2})
+	defer n.Close()
+	n.AddLink(testSrcAddress.String(), icontest.DefaultNetworkTypeName, 1)
+	cs, err := NewSender(testSrcAddress, testDstAddress, wallet.New(), []string{n.URL()},
+		map[string]interface{}{"fragment_window": 2}, log.New())
+	assert.NoError(t, err)
+	s := cs.(*sender)
+
+	//fragments are sent up to the window by Relay
+	msg := make([]byte, txSizeLimit*4+1024)
+	p, err := s.Relay(&chain.Segment{TransactionParam: msg})
+	assert.NoError(t, err)
+	fp, ok := p.(*FragmentsParam)
+	assert.True(t, ok)
+	assert.Equal(t, 2, n.Calls(BMCFragmentMethod))
+	var indexes []int
+	for _, f := range fp.Fragments() {
+		indexes = append(indexes, f.Index)
+	}
+	assert.Equal(t, []int{-4, 3, 2, 1, 0}, indexes)
+
+	//the failure of the relay message is returned without retry
+	_, err = s.GetResult(p)
+	coder, ok := errors.CoderOf(err)
+	assert.True(t, ok)
+	assert.Equal(t, errors.Code(BMVUnknown), coder.ErrorCode())
+	assert.Equal(t, 5, n.Calls(BMCFragmentMethod))
+	for _, f := range fp.Fragments()[:4] {
+		assert.True(t, f.Done)
+	}
+	assert.False(t, fp.Fragments()[4].Done)
+}
+
+func TestDebugPath(t *testing.T) {
+	assert.Equal(t, "/api/v3d", debugPath("/api/v3"))
+	assert.Equal(t, "/api/v3d/icon_dex", debugPath("/api/v3/icon_dex"))
+	assert.Equal(t, "/api/v3d/icon_dex", debugPath("/api/v3d/icon_dex"))
+	assert.Equal(t, "/rpc", debugPath("/rpc"))
+}
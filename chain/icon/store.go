@@ -0,0 +1,293 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icon
+
+import (
+	"encoding/binary"
+
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/db"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/mbt"
+)
+
+const (
+	DefaultDBType = db.GoLevelDBBackend
+
+	RelayQueueBucket db.BucketID = "RelayQueue"
+)
+
+var (
+	keyOfBlockDataIndex    = []byte("BlockDataIndex")
+	keyOfRelayMessageIndex = []byte("RelayMessageIndex")
+	prefixOfBlockData      = []byte("bd:")
+	prefixOfRelayMessage   = []byte("rm:")
+)
+
+type blockDataRecord struct {
+	Height        int64
+	MessageCnt    int64
+	Bu            *BTPBlockUpdate
+	Messages      [][]byte
+	PartialOffset int
+}
+
+type relayMessageRecord struct {
+	Height     int64
+	MessageSeq int
+	Messages   []*TypePrefixedMessage
+	TxHash     HexBytes
+	Fragments  []Fragment
+}
+
+// relayStore journals queued BTPBlockData and BTPRelayMessage of SimpleChain,
+// so that they can be restored after restart without re-fetching from source.
+type relayStore struct {
+	bk  db.Bucket
+	bds []int64
+	rms []uint64
+}
+
+func keyOf(prefix []byte, v int64) []byte {
+	k := make([]byte, len(prefix)+8)
+	copy(k, prefix)
+	binary.BigEndian.PutUint64(k[len(prefix):], uint64(v))
+	return k
+}
+
+func newRelayStore(database db.Database) (*relayStore, error) {
+	bk, err := database.GetBucket(RelayQueueBucket)
+	if err != nil {
+		return nil, err
+	}
+	rs := &relayStore{
+		bk:  bk,
+		bds: make([]int64, 0),
+		rms: make([]uint64, 0),
+	}
+	if err = rs.getIndex(keyOfBlockDataIndex, &rs.bds); err != nil {
+		return nil, err
+	}
+	if err = rs.getIndex(keyOfRelayMessageIndex, &rs.rms); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (rs *relayStore) getIndex(k []byte, v interface{}) error {
+	b, err := rs.bk.Get(k)
+	if err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	if _, err = codec.RLP.UnmarshalFromBytes(b, v); err != nil {
+		return errors.Wrapf(err, "fail to unmarshal index key:%s", k)
+	}
+	return nil
+}
+
+func (rs *relayStore) setIndex(k []byte, v interface{}) error {
+	b, err := codec.RLP.MarshalToBytes(v)
+	if err != nil {
+		return err
+	}
+	return rs.bk.Set(k, b)
+}
+
+func (rs *relayStore) PutBlockData(bd *BTPBlockData) error {
+	r := &blockDataRecord{
+		Height:        bd.Height,
+		MessageCnt:    bd.MessageCnt,
+		Bu:            bd.Bu,
+		PartialOffset: bd.PartialOffset,
+	}
+	if bd.Mt != nil {
+		r.Messages = make([][]byte, bd.Mt.Len())
+		for i := range r.Messages {
+			m, err := bd.Mt.Get(i + 1)
+			if err != nil {
+				return err
+			}
+			r.Messages[i] = m
+		}
+	}
+	b, err := codec.RLP.MarshalToBytes(r)
+	if err != nil {
+		return err
+	}
+	if err = rs.bk.Set(keyOf(prefixOfBlockData, bd.Height), b); err != nil {
+		return err
+	}
+	for _, h := range rs.bds {
+		if h == bd.Height {
+			return nil
+		}
+	}
+	rs.bds = append(rs.bds, bd.Height)
+	return rs.setIndex(keyOfBlockDataIndex, rs.bds)
+}
+
+func (rs *relayStore) PutRelayMessage(rm *BTPRelayMessage) error {
+	r := &relayMessageRecord{
+		Height:     rm.Height(),
+		MessageSeq: rm.MessageSeq(),
+		Messages:   rm.Messages,
+	}
+	if sg := rm.Segments(); sg != nil {
+		switch p := sg.GetResultParam.(type) {
+		case *TransactionHashParam:
+			r.TxHash = p.Hash
+		case *FragmentsParam:
+			r.Fragments = p.Fragments()
+		}
+	}
+	b, err := codec.RLP.MarshalToBytes(r)
+	if err != nil {
+		return err
+	}
+	if err = rs.bk.Set(keyOf(prefixOfRelayMessage, int64(rm.id)), b); err != nil {
+		return err
+	}
+	for _, id := range rs.rms {
+		if id == rm.id {
+			return nil
+		}
+	}
+	rs.rms = append(rs.rms, rm.id)
+	return rs.setIndex(keyOfRelayMessageIndex, rs.rms)
+}
+
+// Retain removes journaled entries which are not in the given queues.
+func (rs *relayStore) Retain(bds []*BTPBlockData, rms []*BTPRelayMessage) error {
+	bdm := make(map[int64]bool)
+	for _, bd := range bds {
+		bdm[bd.Height] = true
+	}
+	nbds := make([]int64, 0, len(bds))
+	for _, h := range rs.bds {
+		if bdm[h] {
+			nbds = append(nbds, h)
+		} else if err := rs.bk.Delete(keyOf(prefixOfBlockData, h)); err != nil {
+			return err
+		}
+	}
+	rmm := make(map[uint64]bool)
+	for _, rm := range rms {
+		rmm[rm.id] = true
+	}
+	nrms := make([]uint64, 0, len(rms))
+	for _, id := range rs.rms {
+		if rmm[id] {
+			nrms = append(nrms, id)
+		} else if err := rs.bk.Delete(keyOf(prefixOfRelayMessage, int64(id))); err != nil {
+			return err
+		}
+	}
+	if len(nbds) != len(rs.bds) {
+		rs.bds = nbds
+		if err := rs.setIndex(keyOfBlockDataIndex, rs.bds); err != nil {
+			return err
+		}
+	}
+	if len(nrms) != len(rs.rms) {
+		rs.rms = nrms
+		if err := rs.setIndex(keyOfRelayMessageIndex, rs.rms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BlockDatas returns journaled BTPBlockData in order of height,
+// MerkleBinaryTree of each BTPBlockData is rebuilt with given hashFunc.
+func (rs *relayStore) BlockDatas(hashFunc mbt.HashFunc) ([]*BTPBlockData, error) {
+	bds := make([]*BTPBlockData, 0, len(rs.bds))
+	for _, h := range rs.bds {
+		b, err := rs.bk.Get(keyOf(prefixOfBlockData, h))
+		if err != nil {
+			return nil, err
+		}
+		r := &blockDataRecord{}
+		if _, err = codec.RLP.UnmarshalFromBytes(b, r); err != nil {
+			return nil, errors.Wrapf(err, "fail to unmarshal BTPBlockData height:%d", h)
+		}
+		bd := &BTPBlockData{
+			Height:        r.Height,
+			MessageCnt:    r.MessageCnt,
+			Bu:            r.Bu,
+			PartialOffset: r.PartialOffset,
+		}
+		if len(r.Messages) > 0 {
+			if bd.Mt, err = mbt.NewMerkleBinaryTree(hashFunc, r.Messages); err != nil {
+				return nil, err
+			}
+		}
+		bds = append(bds, bd)
+	}
+	return bds, nil
+}
+
+// RelayMessages returns journaled BTPRelayMessage in order of creation,
+// the segment of BTPRelayMessage is restored with the journaled transaction hash or fragments,
+// fragments are dropped if they don't match the message.
+func (rs *relayStore) RelayMessages() ([]*BTPRelayMessage, error) {
+	rms := make([]*BTPRelayMessage, 0, len(rs.rms))
+	for _, id := range rs.rms {
+		b, err := rs.bk.Get(keyOf(prefixOfRelayMessage, int64(id)))
+		if err != nil {
+			return nil, err
+		}
+		r := &relayMessageRecord{}
+		if _, err = codec.RLP.UnmarshalFromBytes(b, r); err != nil {
+			return nil, errors.Wrapf(err, "fail to unmarshal BTPRelayMessage id:%d", id)
+		}
+		rm := NewRelayMessage()
+		rm.id = id
+		rm.SetHeight(r.Height)
+		rm.SetMessageSeq(r.MessageSeq)
+		if r.Messages != nil {
+			rm.Messages = r.Messages
+		}
+		if len(r.TxHash) > 0 || len(r.Fragments) > 0 {
+			b, err := codec.RLP.MarshalToBytes(rm)
+			if err != nil {
+				return nil, err
+			}
+			rm.SetSegments(rm.Height(), b, int64(rm.MessageSeq()))
+			if len(r.TxHash) > 0 {
+				rm.Segments().GetResultParam = &TransactionHashParam{Hash: r.TxHash}
+			} else if fp := newFragmentsParam(b, txSizeLimit); fp.restore(r.Fragments) {
+				rm.Segments().GetResultParam = fp
+			}
+		}
+		rms = append(rms, rm)
+	}
+	return rms, nil
+}
+
+// LastID returns the biggest id of journaled BTPRelayMessage.
+func (rs *relayStore) LastID() uint64 {
+	var id uint64
+	for _, v := range rs.rms {
+		if v > id {
+			id = v
+		}
+	}
+	return id
+}
@@ -0,0 +1,105 @@
+package icon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/db"
+	"github.com/icon-project/btp/common/mbt"
+)
+
+func TestRelayStore(t *testing.T) {
+	database := db.NewMapDB()
+	defer database.Close()
+
+	rs, err := newRelayStore(database)
+	assert.NoError(t, err)
+
+	hashFunc := mbt.HashFuncByUID("icon")
+	mt, err := mbt.NewMerkleBinaryTree(hashFunc, [][]byte{[]byte("a"), []byte("b"), []byte("c")})
+	assert.NoError(t, err)
+	bds := []*BTPBlockData{
+		{
+			Height:     10,
+			MessageCnt: 0,
+			Bu:         &BTPBlockUpdate{BTPBlockHeader: []byte("header10"), BTPBlockProof: []byte("proof10")},
+		},
+		{
+			Height:        11,
+			MessageCnt:    3,
+			Bu:            &BTPBlockUpdate{BTPBlockHeader: []byte("header11"), BTPBlockProof: []byte("proof11")},
+			Mt:            mt,
+			PartialOffset: 3,
+		},
+	}
+	for _, bd := range bds {
+		assert.NoError(t, rs.PutBlockData(bd))
+	}
+
+	rm1 := NewRelayMessage()
+	rm1.id = 1
+	rm1.SetHeight(10)
+	rm1.AppendMessage(&TypePrefixedMessage{Type: RelayMessageTypeBlockUpdate, Payload: []byte("bu10")})
+	rm1.SetSegments(10, []byte("tx"), 0)
+	rm1.Segments().GetResultParam = &TransactionHashParam{Hash: "0x0123"}
+	rm2 := NewRelayMessage()
+	rm2.id = 2
+	rm2.SetHeight(11)
+	rm2.SetMessageSeq(3)
+	rm2.AppendMessage(&TypePrefixedMessage{Type: RelayMessageTypeMessageProof, Payload: []byte("mp11")})
+	rm3 := NewRelayMessage()
+	rm3.id = 3
+	rm3.SetHeight(12)
+	rm3.AppendMessage(&TypePrefixedMessage{Type: RelayMessageTypeBlockUpdate, Payload: make([]byte, txSizeLimit+1)})
+	b, err := codec.RLP.MarshalToBytes(rm3)
+	assert.NoError(t, err)
+	rm3.SetSegments(12, b, 0)
+	fp := newFragmentsParam(b, txSizeLimit)
+	fp.fragments[0].TxHash, fp.fragments[0].Done = "0x01", true
+	fp.fragments[1].TxHash = "0x02"
+	rm3.Segments().GetResultParam = fp
+	for _, rm := range []*BTPRelayMessage{rm1, rm2, rm3} {
+		assert.NoError(t, rs.PutRelayMessage(rm))
+	}
+	//overwrite should not duplicate index
+	assert.NoError(t, rs.PutRelayMessage(rm2))
+
+	//reopen
+	rs, err = newRelayStore(database)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), rs.LastID())
+
+	lbds, err := rs.BlockDatas(hashFunc)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(lbds))
+	assert.Equal(t, bds[0].Bu, lbds[0].Bu)
+	assert.Nil(t, lbds[0].Mt)
+	assert.Equal(t, int64(11), lbds[1].Height)
+	assert.Equal(t, 3, lbds[1].PartialOffset)
+	assert.Equal(t, mt.Root(), lbds[1].Mt.Root())
+
+	lrms, err := rs.RelayMessages()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(lrms))
+	assert.Equal(t, rm1.Messages, lrms[0].Messages)
+	assert.Equal(t, &TransactionHashParam{Hash: "0x0123"}, lrms[0].Segments().GetResultParam)
+	assert.Equal(t, 3, lrms[1].MessageSeq())
+	assert.Nil(t, lrms[1].Segments())
+	lfp, ok := lrms[2].Segments().GetResultParam.(*FragmentsParam)
+	assert.True(t, ok)
+	assert.Equal(t, []Fragment{{Index: -1, TxHash: "0x01", Done: true}, {Index: 0, TxHash: "0x02"}}, lfp.Fragments())
+
+	assert.NoError(t, rs.Retain(lbds[1:], lrms[1:2]))
+	rs, err = newRelayStore(database)
+	assert.NoError(t, err)
+	lbds, err = rs.BlockDatas(hashFunc)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(lbds))
+	assert.Equal(t, int64(11), lbds[0].Height)
+	lrms, err = rs.RelayMessages()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(lrms))
+	assert.Equal(t, uint64(2), lrms[0].id)
+}
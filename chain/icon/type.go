@@ -65,6 +65,8 @@ const (
 	BMCRelayMethod     = "handleRelayMessage"
 	BMCFragmentMethod  = "handleFragment"
 	BMCGetStatusMethod = "getStatus"
+	BMCGetRoutesMethod = "getRoutes"
+	BMCGetLinksMethod  = "getLinks"
 )
 
 type VerifierStatus struct {
@@ -143,6 +145,19 @@ type TransactionParam struct {
 	Data        interface{} `json:"data,omitempty"`
 	TxHash      HexBytes    `json:"-"`
 }
+
+// EstimateStepParam is the parameter of debug_estimateStep, it's TransactionParam without stepLimit and signature.
+type EstimateStepParam struct {
+	Version     HexInt      `json:"version"`
+	FromAddress Address     `json:"from"`
+	ToAddress   Address     `json:"to"`
+	Value       HexInt      `json:"value,omitempty"`
+	Timestamp   HexInt      `json:"timestamp"`
+	NetworkID   HexInt      `json:"nid"`
+	Nonce       HexInt      `json:"nonce,omitempty"`
+	DataType    string      `json:"dataType,omitempty"`
+	Data        interface{} `json:"data,omitempty"`
+}
 type CallData struct {
 	Method string      `json:"method"`
 	Params interface{} `json:"params,omitempty"`
@@ -191,7 +206,7 @@ type BMCRemoveRouteMethodParams struct {
 }
 
 type CallParam struct {
-	FromAddress Address     `json:"from" validate:"optional,t_addr_eoa"`
+	FromAddress Address     `json:"from,omitempty" validate:"optional,t_addr_eoa"`
 	ToAddress   Address     `json:"to" validate:"required,t_addr_score"`
 	DataType    string      `json:"dataType" validate:"required,call"`
 	Data        interface{} `json:"data"`
@@ -229,6 +244,10 @@ type TransactionHashParam struct {
 	Hash HexBytes `json:"txHash" validate:"required,t_hash"`
 }
 
+type AddressParam struct {
+	Address Address `json:"address" validate:"required,t_addr"`
+}
+
 type BlockHeightParam struct {
 	Height HexInt `json:"height" validate:"required,t_int"`
 }
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package icon
+
+import (
+	"bytes"
+
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/mbt"
+	"github.com/icon-project/btp/common/policy"
+)
+
+// HeaderFunc returns BTPBlockHeader of the height, it's used if the relay message doesn't have BlockUpdate.
+type HeaderFunc func(height int64) (*BTPBlockHeader, error)
+
+// VerifyRelayMessage checks MessageProofs of rm with MessagesRoot of BTPBlockHeader,
+// the header is taken from BlockUpdate in rm or headerOf.
+func VerifyRelayMessage(rm *BTPRelayMessage, hashFunc mbt.HashFunc, headerOf HeaderFunc) error {
+	var bh *BTPBlockHeader
+	for i, tpm := range rm.Messages {
+		switch tpm.Type {
+		case RelayMessageTypeBlockUpdate:
+			bu := &BTPBlockUpdate{}
+			if _, err := codec.RLP.UnmarshalFromBytes(tpm.Payload, bu); err != nil {
+				return errors.Wrapf(err, "fail to decode BlockUpdate messages[%d]", i)
+			}
+			bh = &BTPBlockHeader{}
+			if _, err := codec.RLP.UnmarshalFromBytes(bu.BTPBlockHeader, bh); err != nil {
+				return errors.Wrapf(err, "fail to decode BTPBlockHeader messages[%d]", i)
+			}
+		case RelayMessageTypeMessageProof:
+			p := &mbt.MerkleBinaryTreeProof{}
+			if _, err := codec.RLP.UnmarshalFromBytes(tpm.Payload, p); err != nil {
+				return errors.Wrapf(err, "fail to decode MessageProof messages[%d]", i)
+			}
+			if bh == nil || bh.MainHeight != rm.Height() {
+				var err error
+				if bh, err = headerOf(rm.Height()); err != nil {
+					return err
+				}
+			}
+			p.SetHashFunc(hashFunc)
+			root, _, total, err := p.Root()
+			if err != nil {
+				return errors.InvalidStateError.Wrapf(err, "invalid MessageProof messages[%d]", i)
+			}
+			if int64(total) != bh.MessageCount {
+				return errors.InvalidStateError.Errorf(
+					"mismatch number of messages messages[%d] height:%d proof:%d header:%d",
+					i, bh.MainHeight, total, bh.MessageCount)
+			}
+			if !bytes.Equal(root, bh.MessagesRoot) {
+				return errors.InvalidStateError.Errorf(
+					"mismatch MessagesRoot messages[%d] height:%d proof:%x header:%x",
+					i, bh.MainHeight, root, bh.MessagesRoot)
+			}
+		default:
+			return errors.InvalidStateError.Errorf("invalid type:%d messages[%d]", tpm.Type, i)
+		}
+	}
+	return nil
+}
+
+// headerOf returns BTPBlockHeader of the height from BTPBlockData, or from the source chain.
+func (s *SimpleChain) headerOf(height int64) (*BTPBlockHeader, error) {
+	var b []byte
+	for _, bd := range s.bds {
+		if bd.Height == height && bd.Bu != nil {
+			b = bd.Bu.BTPBlockHeader
+			break
+		}
+	}
+	if b == nil {
+		var err error
+		if b, err = s.r.GetBTPBlockHeader(height, s.cfg.Src.Nid); err != nil {
+			return nil, err
+		}
+	}
+	bh := &BTPBlockHeader{}
+	if _, err := codec.RLP.UnmarshalFromBytes(b, bh); err != nil {
+		return nil, err
+	}
+	return bh, nil
+}
+
+// verify returns whether rm can be sent, unverified one is held back with the report.
+// rmsMtx should be locked.
+func (s *SimpleChain) verify(rm *BTPRelayMessage) bool {
+	if !s.cfg.Verify {
+		return true
+	}
+	err := VerifyRelayMessage(rm, mbt.HashFuncByUID(s.ci.NetworkTypeName), s.headerOf)
+	if err == nil {
+		return true
+	}
+	s.l.Errorf("fail to verify rm:%d height:%d seq:%d err:%+v", rm.id, rm.Height(), rm.MessageSeq(), err)
+	s.m.Failed(policy.CodeUnverified)
+	s.resend(0, func() {
+		s._quarantine(rm)
+	})
+	return false
+}
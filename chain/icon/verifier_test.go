@@ -0,0 +1,64 @@
+package icon
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/mbt"
+)
+
+func TestVerifyRelayMessage(t *testing.T) {
+	hashFunc := mbt.HashFuncByUID("icon")
+	mt, err := mbt.NewMerkleBinaryTree(hashFunc,
+		[][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("e")})
+	assert.NoError(t, err)
+	bh := &BTPBlockHeader{MainHeight: 11, MessageCount: 5, MessagesRoot: mt.Root()}
+	bu := &BTPBlockUpdate{BTPBlockHeader: codec.RLP.MustMarshalToBytes(bh)}
+	newRelayMessage := func(withBu bool, begin, end int) *BTPRelayMessage {
+		rm := NewRelayMessage()
+		rm.SetHeight(11)
+		if withBu {
+			tpm, err := NewTypePrefixedMessage(bu)
+			assert.NoError(t, err)
+			rm.AppendMessage(tpm)
+		}
+		p, err := mt.Proof(begin, end)
+		assert.NoError(t, err)
+		tpm, err := NewTypePrefixedMessage(*p)
+		assert.NoError(t, err)
+		rm.AppendMessage(tpm)
+		return rm
+	}
+	var requested []int64
+	headerOf := func(height int64) (*BTPBlockHeader, error) {
+		requested = append(requested, height)
+		if height != bh.MainHeight {
+			return nil, fmt.Errorf("not found height:%d", height)
+		}
+		return bh, nil
+	}
+
+	assert.NoError(t, VerifyRelayMessage(newRelayMessage(true, 1, 5), hashFunc, headerOf))
+	assert.Equal(t, 0, len(requested))
+
+	//partial proof without BlockUpdate
+	assert.NoError(t, VerifyRelayMessage(newRelayMessage(false, 3, 4), hashFunc, headerOf))
+	assert.Equal(t, []int64{11}, requested)
+
+	//tampered message
+	rm := newRelayMessage(true, 2, 3)
+	p := &mbt.MerkleBinaryTreeProof{}
+	_, err = codec.RLP.UnmarshalFromBytes(rm.Messages[1].Payload, p)
+	assert.NoError(t, err)
+	p.Contents[0] = []byte("x")
+	rm.Messages[1].Payload = codec.RLP.MustMarshalToBytes(p)
+	assert.Error(t, VerifyRelayMessage(rm, hashFunc, headerOf))
+
+	//wrong header
+	rm = newRelayMessage(false, 1, 2)
+	rm.SetHeight(12)
+	assert.Error(t, VerifyRelayMessage(rm, hashFunc, headerOf))
+}
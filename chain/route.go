@@ -0,0 +1,91 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"sort"
+)
+
+// Route is the entry of the route table of BMC, messages to Destination are sent to Next.
+type Route struct {
+	Destination string //network address
+	Next        BtpAddress
+}
+
+// RouteTable is the topology known to BMC, Links are BMCs which are linked directly.
+type RouteTable struct {
+	Links  []BtpAddress
+	Routes []Route
+}
+
+// NetworkAddressOf returns the network address of s, s could be BtpAddress or network address.
+func NetworkAddressOf(s string) string {
+	if na := BtpAddress(s).NetworkAddress(); na != "" {
+		return na
+	}
+	return s
+}
+
+// NewRouteTable returns RouteTable from the result of getLinks and getRoutes of BMC,
+// keys of routes are destinations in BtpAddress or network address.
+func NewRouteTable(links []string, routes map[string]string) *RouteTable {
+	t := &RouteTable{}
+	for _, l := range links {
+		t.Links = append(t.Links, BtpAddress(l))
+	}
+	for dst, next := range routes {
+		t.Routes = append(t.Routes, Route{
+			Destination: NetworkAddressOf(dst),
+			Next:        BtpAddress(next),
+		})
+	}
+	sort.Slice(t.Routes, func(i, j int) bool {
+		return t.Routes[i].Destination < t.Routes[j].Destination
+	})
+	return t
+}
+
+// HasLink returns true if BMC is linked to the network directly.
+func (t *RouteTable) HasLink(network string) bool {
+	for _, l := range t.Links {
+		if l.NetworkAddress() == network {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns BMC of the next hop for the network, the route is preferred to the link.
+// it returns empty if the network is unreachable.
+func (t *RouteTable) Next(network string) BtpAddress {
+	for _, r := range t.Routes {
+		if r.Destination == network {
+			return r.Next
+		}
+	}
+	for _, l := range t.Links {
+		if l.NetworkAddress() == network {
+			return l
+		}
+	}
+	return ""
+}
+
+// RouteLoader is implemented by Sender and Receiver which could load the route table of BMC.
+type RouteLoader interface {
+	GetRouteTable() (*RouteTable, error)
+}
@@ -0,0 +1,347 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chain
+
+import (
+	"container/list"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/intconv"
+	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
+)
+
+const (
+	DefaultTrackLimit = 10000
+)
+
+// MessageID identifies BTP message across links, it's decoded from the header of BTP message.
+// Src and Dst are network addresses.
+type MessageID struct {
+	Src string
+	Dst string
+	Svc string
+	Sn  string
+}
+
+func (id MessageID) String() string {
+	return fmt.Sprintf("{src:%s dst:%s svc:%s sn:%s}", id.Src, id.Dst, id.Svc, id.Sn)
+}
+
+// DecodeMessageID returns MessageID of BTP message, [src, dst, svc, sn, ...] in RLP.
+func DecodeMessageID(b []byte) (MessageID, error) {
+	var h struct {
+		Src string
+		Dst string
+		Svc string
+		Sn  []byte
+	}
+	if _, err := codec.RLP.UnmarshalFromBytes(b, &h); err != nil {
+		return MessageID{}, err
+	}
+	return MessageID{
+		Src: NetworkAddressOf(h.Src),
+		Dst: NetworkAddressOf(h.Dst),
+		Svc: h.Svc,
+		Sn:  intconv.BigIntSetBytes(new(big.Int), h.Sn).String(),
+	}, nil
+}
+
+// Hop is the progress of the message over a direction of link, Seq is the sequence of the link.
+type Hop struct {
+	Src       string
+	Dst       string
+	Seq       int64
+	Delivered bool
+}
+
+// Path is hops of the message in the order of receipt.
+type Path struct {
+	ID   MessageID
+	Hops []*Hop
+}
+
+// Complete returns true if the message is delivered to the destination network.
+func (p *Path) Complete() bool {
+	if len(p.Hops) == 0 {
+		return false
+	}
+	last := p.Hops[len(p.Hops)-1]
+	return last.Delivered && last.Dst == p.ID.Dst
+}
+
+func (p *Path) String() string {
+	ss := make([]string, 0, len(p.Hops))
+	for _, h := range p.Hops {
+		s := fmt.Sprintf("%s->%s(seq:%d)", h.Src, h.Dst, h.Seq)
+		if !h.Delivered {
+			s += "*"
+		}
+		ss = append(ss, s)
+	}
+	return strings.Join(ss, ",")
+}
+
+func (p *Path) clone() *Path {
+	np := &Path{ID: p.ID, Hops: make([]*Hop, len(p.Hops))}
+	for i, h := range p.Hops {
+		nh := *h
+		np.Hops[i] = &nh
+	}
+	return np
+}
+
+type direction struct {
+	src, dst string
+}
+
+type pendingHop struct {
+	hop  *Hop
+	path *Path
+}
+
+// MessageTracker correlates messages received and delivered by directions in the process,
+// so the delivery of the message is confirmed across links, it keeps up to limit messages.
+type MessageTracker struct {
+	mtx     sync.Mutex
+	limit   int
+	served  map[direction]int
+	tables  map[string]*RouteTable
+	paths   map[MessageID]*list.Element
+	order   *list.List
+	pending map[direction][]*pendingHop
+}
+
+// NewMessageTracker returns MessageTracker, DefaultTrackLimit is used if limit is not positive.
+func NewMessageTracker(limit int) *MessageTracker {
+	if limit <= 0 {
+		limit = DefaultTrackLimit
+	}
+	return &MessageTracker{
+		limit:   limit,
+		served:  make(map[direction]int),
+		tables:  make(map[string]*RouteTable),
+		paths:   make(map[MessageID]*list.Element),
+		order:   list.New(),
+		pending: make(map[direction][]*pendingHop),
+	}
+}
+
+var defaultMessageTracker = NewMessageTracker(DefaultTrackLimit)
+
+// DefaultMessageTracker returns MessageTracker which is shared by directions in the process.
+func DefaultMessageTracker() *MessageTracker {
+	return defaultMessageTracker
+}
+
+// Register adds the direction served in the process, src and dst are network addresses.
+func (t *MessageTracker) Register(src, dst string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.served[direction{src, dst}]++
+}
+
+// Unregister removes the direction added by Register, pending hops of the direction are dropped
+// if there is no other registration.
+func (t *MessageTracker) Unregister(src, dst string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	d := direction{src, dst}
+	if t.served[d]--; t.served[d] <= 0 {
+		delete(t.served, d)
+		delete(t.pending, d)
+	}
+}
+
+// Serves returns true if the direction is served in the process.
+func (t *MessageTracker) Serves(src, dst string) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.served[direction{src, dst}] > 0
+}
+
+// SetRouteTable sets the route table of BMC in the network.
+func (t *MessageTracker) SetRouteTable(network string, rt *RouteTable) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.tables[network] = rt
+}
+
+// NextOf returns the network of the next hop from the network for dst,
+// it returns empty if the route table of the network is unknown or dst is unreachable.
+func (t *MessageTracker) NextOf(network, dst string) string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	rt, ok := t.tables[network]
+	if !ok || network == dst {
+		return ""
+	}
+	return rt.Next(dst).NetworkAddress()
+}
+
+// Path returns the copy of the path of the message, it returns nil if the message is not tracked.
+func (t *MessageTracker) Path(id MessageID) *Path {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if e, ok := t.paths[id]; ok {
+		return e.Value.(*Path).clone()
+	}
+	return nil
+}
+
+// Receive adds the hop of the message received by the direction with the sequence of the link,
+// the duplicated hop is ignored. it returns the copy of the path.
+func (t *MessageTracker) Receive(src, dst string, seq int64, msg []byte) (*Path, error) {
+	id, err := DecodeMessageID(msg)
+	if err != nil {
+		return nil, err
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	var p *Path
+	if e, ok := t.paths[id]; ok {
+		p = e.Value.(*Path)
+		for _, h := range p.Hops {
+			if h.Src == src && h.Dst == dst && h.Seq == seq {
+				return p.clone(), nil
+			}
+		}
+	} else {
+		p = &Path{ID: id}
+		t.paths[id] = t.order.PushBack(p)
+		for t.order.Len() > t.limit {
+			t._remove(t.order.Front().Value.(*Path))
+		}
+	}
+	h := &Hop{Src: src, Dst: dst, Seq: seq}
+	p.Hops = append(p.Hops, h)
+	d := direction{src, dst}
+	t.pending[d] = append(t.pending[d], &pendingHop{hop: h, path: p})
+	return p.clone(), nil
+}
+
+// _remove stops tracking of the path, mtx should be locked.
+func (t *MessageTracker) _remove(p *Path) {
+	if e, ok := t.paths[p.ID]; ok {
+		t.order.Remove(e)
+		delete(t.paths, p.ID)
+	}
+	for _, h := range p.Hops {
+		if h.Delivered {
+			continue
+		}
+		d := direction{h.Src, h.Dst}
+		phs := t.pending[d]
+		for i, ph := range phs {
+			if ph.hop == h {
+				t.pending[d] = append(phs[:i:i], phs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Deliver marks hops of the direction delivered up to rxSeq, it returns copies of the paths.
+// the path is not tracked any more if it's complete or the next hop is not served in the process.
+func (t *MessageTracker) Deliver(src, dst string, rxSeq int64) []*Path {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	d := direction{src, dst}
+	var ps []*Path
+	var remain []*pendingHop
+	for _, ph := range t.pending[d] {
+		if ph.hop.Seq > rxSeq {
+			remain = append(remain, ph)
+			continue
+		}
+		ph.hop.Delivered = true
+		ps = append(ps, ph.path.clone())
+		if ph.path.Complete() {
+			t._remove(ph.path)
+		} else if rt, ok := t.tables[dst]; ok {
+			if next := rt.Next(ph.path.ID.Dst).NetworkAddress(); next != "" && t.served[direction{dst, next}] == 0 {
+				t._remove(ph.path)
+			}
+		}
+	}
+	if _, ok := t.pending[d]; ok {
+		t.pending[d] = remain
+	}
+	return ps
+}
+
+// OnReceive is Receive which logs the failure to decode the message.
+func (t *MessageTracker) OnReceive(src, dst string, seq int64, msg []byte, l log.Logger) {
+	if p, err := t.Receive(src, dst, seq, msg); err != nil {
+		l.Debugf("fail to decode message seq:%d err:%+v", seq, err)
+	} else {
+		l.Tracef("received message %s seq:%d path:%s", p.ID, seq, p)
+	}
+}
+
+// OnDeliver is Deliver which logs the delivery of the messages, it reports the message end-to-end
+// if it's delivered to the destination network, otherwise the next hop which is not served.
+func (t *MessageTracker) OnDeliver(src, dst string, rxSeq int64, l log.Logger) {
+	for _, p := range t.Deliver(src, dst, rxSeq) {
+		if p.Complete() {
+			metrics.DeliveredMessage(p.ID.Src, p.ID.Dst)
+			if len(p.Hops) > 1 {
+				l.Infof("delivered message %s path:%s", p.ID, p)
+			} else {
+				l.Debugf("delivered message %s path:%s", p.ID, p)
+			}
+			continue
+		}
+		next := t.NextOf(dst, p.ID.Dst)
+		switch {
+		case next == "":
+			l.Debugf("delivered message %s to %s, next is unknown path:%s", p.ID, dst, p)
+		case !t.Serves(dst, next):
+			metrics.UnservedMessage(dst, next)
+			l.Infof("delivered message %s to %s, next %s is not served by the relay path:%s", p.ID, dst, next, p)
+		default:
+			l.Debugf("delivered message %s to %s, next %s path:%s", p.ID, dst, next, p)
+		}
+	}
+}
+
+// LoadRouteTables loads route tables of BMCs of src and dst by loaders which implement RouteLoader,
+// it warns if src and dst are not linked to each other. the failure is logged, BMC could not support it.
+func (t *MessageTracker) LoadRouteTables(src, dst BtpAddress, srcLoader, dstLoader interface{}, l log.Logger) {
+	load := func(addr, peer BtpAddress, loader interface{}) {
+		rl, ok := loader.(RouteLoader)
+		if !ok {
+			return
+		}
+		rt, err := rl.GetRouteTable()
+		if err != nil {
+			l.Warnf("fail to load route table of %s err:%+v", addr, err)
+			return
+		}
+		t.SetRouteTable(addr.NetworkAddress(), rt)
+		if !rt.HasLink(peer.NetworkAddress()) {
+			l.Warnf("%s is not linked to %s", addr, peer)
+		}
+		l.Debugf("route table of %s links:%v routes:%v", addr, rt.Links, rt.Routes)
+	}
+	load(src, dst, srcLoader)
+	load(dst, src, dstLoader)
+}
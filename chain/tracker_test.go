@@ -0,0 +1,134 @@
+package chain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/intconv"
+	"github.com/icon-project/btp/common/log"
+)
+
+const (
+	testNetA = "0x1.icon"
+	testNetB = "0x61.bsc"
+	testNetC = "0x2.icon"
+	testNetD = "0x3.icon"
+)
+
+func testBMC(network string) BtpAddress {
+	return BtpAddress("btp://" + network + "/cx0000000000000000000000000000000000000001")
+}
+
+func testMessage(src, dst string, sn int64) []byte {
+	return codec.RLP.MustMarshalToBytes([]interface{}{
+		src, dst, "svc", intconv.BigIntToBytes(big.NewInt(sn)), []byte("payload"),
+	})
+}
+
+func TestRouteTable(t *testing.T) {
+	rt := NewRouteTable(
+		[]string{testBMC(testNetB).String()},
+		map[string]string{
+			testNetC:                   testBMC(testNetB).String(),
+			testBMC(testNetD).String(): testBMC(testNetB).String(),
+		})
+	assert.True(t, rt.HasLink(testNetB))
+	assert.False(t, rt.HasLink(testNetC))
+	assert.Equal(t, []Route{
+		{Destination: testNetC, Next: testBMC(testNetB)},
+		{Destination: testNetD, Next: testBMC(testNetB)},
+	}, rt.Routes)
+	assert.Equal(t, testBMC(testNetB), rt.Next(testNetB))
+	assert.Equal(t, testBMC(testNetB), rt.Next(testNetD))
+	assert.Equal(t, BtpAddress(""), rt.Next(testNetA))
+}
+
+func TestDecodeMessageID(t *testing.T) {
+	id, err := DecodeMessageID(testMessage(testBMC(testNetA).String(), testNetC, -3))
+	assert.NoError(t, err)
+	assert.Equal(t, MessageID{Src: testNetA, Dst: testNetC, Svc: "svc", Sn: "-3"}, id)
+
+	_, err = DecodeMessageID([]byte("message"))
+	assert.Error(t, err)
+}
+
+func TestMessageTracker(t *testing.T) {
+	tr := NewMessageTracker(0)
+	l := log.New()
+	tr.Register(testNetA, testNetB)
+	tr.Register(testNetB, testNetC)
+	tr.SetRouteTable(testNetB, NewRouteTable(
+		[]string{testBMC(testNetA).String(), testBMC(testNetC).String()},
+		map[string]string{testNetD: testBMC(testNetC).String()}))
+	tr.SetRouteTable(testNetC, NewRouteTable(
+		[]string{testBMC(testNetB).String(), testBMC(testNetD).String()}, nil))
+	assert.True(t, tr.Serves(testNetA, testNetB))
+	assert.False(t, tr.Serves(testNetC, testNetD))
+	assert.Equal(t, testNetC, tr.NextOf(testNetB, testNetD))
+
+	//A->B->C, delivered across links
+	msg := testMessage(testNetA, testNetC, 1)
+	id, _ := DecodeMessageID(msg)
+	_, err := tr.Receive(testNetA, testNetB, 5, msg)
+	assert.NoError(t, err)
+	_, err = tr.Receive(testNetA, testNetB, 5, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(tr.Path(id).Hops))
+	assert.Equal(t, 0, len(tr.Deliver(testNetA, testNetB, 4)))
+	ps := tr.Deliver(testNetA, testNetB, 5)
+	assert.Equal(t, 1, len(ps))
+	assert.False(t, ps[0].Complete())
+
+	p, err := tr.Receive(testNetB, testNetC, 2, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Hop{
+		{Src: testNetA, Dst: testNetB, Seq: 5, Delivered: true},
+		{Src: testNetB, Dst: testNetC, Seq: 2},
+	}, p.Hops)
+	tr.OnDeliver(testNetB, testNetC, 3, l)
+	assert.Nil(t, tr.Path(id))
+
+	//A->B->C->D, the last hop is not served
+	msg = testMessage(testNetA, testNetD, 2)
+	id, _ = DecodeMessageID(msg)
+	tr.OnReceive(testNetA, testNetB, 6, msg, l)
+	tr.OnDeliver(testNetA, testNetB, 6, l)
+	assert.NotNil(t, tr.Path(id))
+	tr.OnReceive(testNetB, testNetC, 3, msg, l)
+	ps = tr.Deliver(testNetB, testNetC, 3)
+	assert.Equal(t, 1, len(ps))
+	assert.False(t, ps[0].Complete())
+	assert.Equal(t, testNetD, tr.NextOf(testNetC, testNetD))
+	assert.Nil(t, tr.Path(id))
+
+	//pending hops are dropped by Unregister
+	msg = testMessage(testNetA, testNetB, 3)
+	id, _ = DecodeMessageID(msg)
+	tr.OnReceive(testNetA, testNetB, 7, msg, l)
+	tr.Unregister(testNetA, testNetB)
+	assert.False(t, tr.Serves(testNetA, testNetB))
+	assert.Equal(t, 0, len(tr.Deliver(testNetA, testNetB, 7)))
+}
+
+func TestMessageTracker_Limit(t *testing.T) {
+	tr := NewMessageTracker(2)
+	var ids []MessageID
+	for i := int64(0); i < 3; i++ {
+		msg := testMessage(testNetA, testNetB, i)
+		id, _ := DecodeMessageID(msg)
+		ids = append(ids, id)
+		_, err := tr.Receive(testNetA, testNetB, i+1, msg)
+		assert.NoError(t, err)
+	}
+	assert.Nil(t, tr.Path(ids[0]))
+	assert.NotNil(t, tr.Path(ids[1]))
+	ps := tr.Deliver(testNetA, testNetB, 3)
+	assert.Equal(t, 2, len(ps))
+	for _, p := range ps {
+		assert.True(t, p.Complete())
+	}
+	assert.Nil(t, tr.Path(ids[2]))
+}
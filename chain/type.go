@@ -84,6 +84,19 @@ type Sender interface {
 	TxSizeLimit() int
 }
 
+// FeeListener is called with the fee of every relay transaction which is confirmed.
+type FeeListener func(fee *big.Int)
+
+// FeeNotifier is optional interface of Sender which reports fees of transactions.
+type FeeNotifier interface {
+	SetFeeListener(l FeeListener)
+}
+
+// BalanceGetter is optional interface of Sender which returns the balance of the relay account.
+type BalanceGetter interface {
+	Balance() (*big.Int, error)
+}
+
 type ReceiveCallback func(bu *BlockUpdate, rps []*ReceiptProof)
 
 type Receiver interface {
@@ -91,6 +104,14 @@ type Receiver interface {
 	StopReceiveLoop()
 }
 
+// Closer is optional interface of Sender and Receiver, Close releases resources of the client
+// (ex: the watcher of endpoints) after Serve returns.
+type Closer interface {
+	Close()
+}
+
 type Chain interface {
 	Serve(sender Sender) error
+	// Stop stops Serve which returns nil, it's used to stop a link without exiting the process.
+	Stop()
 }
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/icon-project/btp/cmd/bridge/module"
+	"github.com/icon-project/btp/cmd/bridge/module/iconbridge/client"
+	"github.com/icon-project/btp/common/admin"
+	"github.com/icon-project/btp/common/errors"
+)
+
+var _ admin.Link = (*bridge)(nil)
+
+func (c *bridge) isPaused() bool {
+	return atomic.LoadInt32(&c.paused) == 1
+}
+
+func (c *bridge) Status() (*admin.LinkStatus, error) {
+	c.ssMtx.RLock()
+	defer c.ssMtx.RUnlock()
+	ls := &admin.LinkStatus{
+		Src:         c.src.String(),
+		Dst:         c.dst.String(),
+		Paused:      c.isPaused(),
+		Queued:      len(c.ss),
+		Quarantined: len(c.qs),
+	}
+	if bs := c.status(); bs != nil {
+		ls.TxSeq = strconv.FormatInt(bs.TxSeq, 10)
+		ls.RxSeq = strconv.FormatInt(bs.RxSeq, 10)
+		ls.VerifierHeight = bs.Verifier.Height
+		ls.CurrentHeight = bs.CurrentHeight
+	}
+	return ls, nil
+}
+
+func txHashOf(p module.GetResultParam) string {
+	switch v := p.(type) {
+	case ethcommon.Hash:
+		return v.Hex()
+	case *client.TransactionHashParam:
+		return string(v.Hash)
+	default:
+		return ""
+	}
+}
+
+// RelayMessages returns segments as relay messages, because bridge doesn't keep relay messages.
+// ID of relay message is EventSequence of the segment.
+func (c *bridge) RelayMessages() ([]*admin.RelayMessage, error) {
+	c.ssMtx.RLock()
+	defer c.ssMtx.RUnlock()
+	l := make([]*admin.RelayMessage, 0, len(c.ss))
+	for _, s := range c.ss {
+		seq := strconv.FormatInt(s.EventSequence, 10)
+		l = append(l, &admin.RelayMessage{
+			ID:     seq,
+			Height: s.Height,
+			Segments: []*admin.Segment{{
+				Height:        s.Height,
+				EventSequence: seq,
+				NumberOfEvent: s.NumberOfEvent,
+				TxHash:        txHashOf(s.GetResultParam),
+			}},
+		})
+	}
+	return l, nil
+}
+
+func (c *bridge) Pause() {
+	atomic.StoreInt32(&c.paused, 1)
+	c.l.Infof("paused")
+}
+
+func (c *bridge) Resume() {
+	if atomic.CompareAndSwapInt32(&c.paused, 1, 0) {
+		c.l.Infof("resumed")
+		go c.relay()
+	}
+}
+
+func (c *bridge) Refresh() error {
+	bs, err := c.s.GetStatus()
+	if err != nil {
+		return err
+	}
+	return c.OnBlockOfDst(bs)
+}
+
+func (c *bridge) Drop(id string) error {
+	seq, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return errors.IllegalArgumentError.Errorf("invalid id:%s", id)
+	}
+	c.ssMtx.Lock()
+	defer c.ssMtx.Unlock()
+	for i, s := range c.ss {
+		if s.EventSequence == seq {
+			c.ss = append(c.ss[:i], c.ss[i+1:]...)
+			c.m.Queued.Set(float64(len(c.ss)))
+			c.l.Warnf("drop segment height:%d,seq:%d,txh:%v", s.Height, s.EventSequence, s.GetResultParam)
+			return nil
+		}
+	}
+	return errors.NotFoundError.Errorf("not found segment id:%s", id)
+}
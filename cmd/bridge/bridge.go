@@ -19,15 +19,21 @@ package main
 import (
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"sync"
-	"unsafe"
+	"sync/atomic"
+	"time"
 
 	"github.com/icon-project/btp/cmd/bridge/module"
 	"github.com/icon-project/btp/cmd/bridge/module/evmbridge"
 	"github.com/icon-project/btp/cmd/bridge/module/iconbridge"
-	"github.com/icon-project/btp/common/codec"
 	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/health"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
+	"github.com/icon-project/btp/common/policy"
+	"github.com/icon-project/btp/common/segment"
+	"github.com/icon-project/btp/common/wallet"
 )
 
 type bridge struct {
@@ -38,14 +44,24 @@ type bridge struct {
 	src module.BtpAddress
 	dst module.BtpAddress
 
-	bs  *module.BMCLinkStatus //getstatus(dst.src)
-	l   log.Logger
-	cfg *module.Config
-
-	ssMtx  sync.RWMutex
-	ss     []*module.Segment
-	rm     *module.RelayMessage
-	rmSize int
+	bs    *module.BMCLinkStatus //getstatus(dst.src)
+	bsMtx sync.RWMutex
+	l     log.Logger
+	cfg   *module.Config
+
+	ssMtx sync.RWMutex
+	ss    []*module.Segment
+	qs    []*module.Segment
+	sg    *segment.Segmenter
+
+	paused    int32
+	pe        *policy.Engine
+	errCh     chan error
+	m         *metrics.LinkMetrics
+	wd        *health.Watchdog
+	restartCh chan error
+	loopGen   int32
+	lastSeq   int64
 }
 
 func (c *bridge) _log(prefix string, segment *module.Segment) {
@@ -57,9 +73,14 @@ func (c *bridge) _log(prefix string, segment *module.Segment) {
 		segment.GetResultParam)
 }
 
+// relay sends segments which are not sent yet, ssMtx is locked exclusively
+// because it's called concurrently and it updates segments.
 func (c *bridge) relay() {
-	c.ssMtx.RLock()
-	defer c.ssMtx.RUnlock()
+	if c.isPaused() {
+		return
+	}
+	c.ssMtx.Lock()
+	defer c.ssMtx.Unlock()
 
 	var err error
 	for _, s := range c.ss {
@@ -68,9 +89,15 @@ func (c *bridge) relay() {
 		if s.GetResultParam == nil {
 			s.TransactionResult = nil
 			if s.GetResultParam, err = c.s.Relay(s); err != nil {
-				c.l.Panicf("fail to Relay err:%+v", err)
+				c.l.Debugf("fail to Relay err:%+v", err)
+				d := c.pe.Decide(s, err)
+				c.m.Failed(d.Code)
+				//handle locks ssMtx for some actions
+				go c.handle(s, d)
+				return
 			}
 			c._log("after relay", s)
+			c.m.Sent.Inc()
 			go c.result(s)
 		}
 	}
@@ -80,46 +107,118 @@ func (c *bridge) result(s *module.Segment) {
 	var err error
 	s.TransactionResult, err = c.s.GetResult(s.GetResultParam)
 	if err != nil {
-		if ec, ok := errors.CoderOf(err); ok {
-			c.l.Debugf("fail to GetResult GetResultParam:%v ErrorCoder:%+v",
-				s.GetResultParam, ec)
-			switch ec.ErrorCode() {
-			default:
-				c.l.Panicf("fail to GetResult GetResultParam:%v ErrorCoder:%+v",
-					s.GetResultParam, ec)
+		c.l.Debugf("fail to GetResult GetResultParam:%v err:%+v",
+			s.GetResultParam, err)
+		d := c.pe.Decide(s, err)
+		c.m.Failed(d.Code)
+		c.handle(s, d)
+	} else {
+		c.m.Confirmed.Inc()
+		c.wd.OnRelay()
+		c.pe.Reset(s)
+	}
+}
+
+func (c *bridge) handle(s *module.Segment, d *policy.Decision) {
+	switch d.Action {
+	case policy.ActionRetry, policy.ActionResegment:
+		//segment of bridge could not be rebuilt, because events are not kept
+		c.resend(d.Delay, func() {
+			s.GetResultParam = nil
+		})
+	case policy.ActionRefresh:
+		if bs, err := c.s.GetStatus(); err != nil {
+			c.l.Warnf("fail to GetStatus err:%+v", err)
+		} else if err = c.OnBlockOfDst(bs); err != nil {
+			c.l.Warnf("fail to OnBlockOfDst err:%+v", err)
+		}
+		c.resend(d.Delay, func() {
+			s.GetResultParam = nil
+		})
+	case policy.ActionQuarantine:
+		c.ssMtx.Lock()
+		defer c.ssMtx.Unlock()
+		for i, v := range c.ss {
+			if v == s {
+				c.ss = append(c.ss[:i], c.ss[i+1:]...)
+				c.qs = append(c.qs, s)
+				c.l.Errorf("quarantine height:%d,seq:%d,txh:%v",
+					s.Height, s.EventSequence, s.GetResultParam)
+				c.shutdownOnGap(s.EventSequence, d.Err)
+				break
 			}
-		} else {
-			c.l.Panicf("fail to GetResult GetResultParam:%v err:%+v",
-				s.GetResultParam, err)
 		}
+	case policy.ActionShutdown:
+		c.shutdown(d.Err)
 	}
 }
 
-func (c *bridge) isOverLimit(size int) bool {
-	return c.s.TxSizeLimit() < size
+// resend calls reset with locked ssMtx after delay, then relay.
+func (c *bridge) resend(delay time.Duration, reset func()) {
+	time.AfterFunc(delay, func() {
+		if reset != nil {
+			c.ssMtx.Lock()
+			reset()
+			c.ssMtx.Unlock()
+		}
+		c.relay()
+	})
+}
+
+// shutdownOnGap stops the link after the segment up to seq is quarantined,
+// destination rejects following sequences, so it requires an operator to resolve the gap.
+func (c *bridge) shutdownOnGap(seq int64, err error) {
+	c.shutdown(errors.Wrapf(err, "stop relaying at the gap of quarantined seq:%d", seq))
+}
+
+func (c *bridge) shutdown(err error) {
+	c.l.Errorf("shutdown err:%+v", err)
+	select {
+	case c.errCh <- err:
+	default:
+	}
+}
+
+// fits returns true if the transaction data of RelayMessage of the size doesn't exceed the limit.
+func (c *bridge) fits(size int) bool {
+	return c.s.TxDataSize(size) <= c.s.TxDataLimit()
 }
 
 func (c *bridge) OnBlockOfDst(bs *module.BMCLinkStatus) error {
 	c.l.Tracef("OnBlockOfDst height:%d", bs.CurrentHeight)
-	if bs.Verifier.Height != c.bs.Verifier.Height || bs.RxSeq != c.bs.RxSeq {
+	c.wd.OnDst(bs.CurrentHeight)
+	//result goroutines also call it with refreshed status
+	c.bsMtx.Lock()
+	prev := c.bs
+	c.bs = bs
+	c.bsMtx.Unlock()
+	if prev == nil || bs.Verifier.Height != prev.Verifier.Height || bs.RxSeq != prev.RxSeq {
 		c.l.Debugf("OnBlockOfDst h:%d seq:%d monitorHeight:%d",
-			c.bs.Verifier.Height, c.bs.RxSeq, bs.CurrentHeight)
-		r := 0
-		for i, s := range c.ss {
-			if s.EventSequence <= bs.RxSeq {
-				r = i + 1
-			}
+			bs.Verifier.Height, bs.RxSeq, bs.CurrentHeight)
+		c.removeSegment(bs.RxSeq)
+	}
+	c.m.SetStatus(bs.CurrentHeight, bs.Verifier.Height, big.NewInt(bs.RxSeq))
+	if c.sg.Due() {
+		//pending events by latency
+		if err := c.sg.Commit(); err != nil {
+			return err
 		}
-		c.removeSegment(r)
+		c.relay()
 	}
-	c.bs = bs
 	return nil
 }
 
-func (c *bridge) removeSegment(offset int) {
+// removeSegment removes segments which are relayed up to rxSeq.
+func (c *bridge) removeSegment(rxSeq int64) {
 	c.ssMtx.Lock()
 	defer c.ssMtx.Unlock()
 
+	offset := 0
+	for i, s := range c.ss {
+		if s.EventSequence <= rxSeq {
+			offset = i + 1
+		}
+	}
 	if offset < 1 {
 		return
 	}
@@ -128,171 +227,184 @@ func (c *bridge) removeSegment(offset int) {
 		c.ss[0].EventSequence,
 		c.ss[offset-1].EventSequence)
 	c.ss = c.ss[offset:]
+	c.m.Queued.Set(float64(len(c.ss)))
 }
 
-type RelayMessage struct {
-	Receipts [][]byte
-}
-
-type Receipt struct {
-	Index  int64
-	Events []byte
-	Height int64
-}
-
-func (c *bridge) addSegment() error {
+func (c *bridge) addSegment(s *module.Segment) {
 	c.ssMtx.Lock()
 	defer c.ssMtx.Unlock()
 
-	rm := &RelayMessage{
-		Receipts: make([][]byte, 0),
-	}
-	var (
-		b   []byte
-		err error
-	)
-	numOfEvents := 0
-	for _, rp := range c.rm.ReceiptProofs {
-		if len(rp.Events) == 0 {
-			continue
-		}
-		numOfEvents += len(rp.Events)
-		if b, err = codec.RLP.MarshalToBytes(rp.Events); err != nil {
-			return err
-		}
-		r := &Receipt{
-			Index:  rp.Index,
-			Events: b,
-			Height: rp.Height,
-		}
-		if b, err = codec.RLP.MarshalToBytes(r); err != nil {
-			return err
-		}
-		rm.Receipts = append(rm.Receipts, b)
-	}
-	if b, err = codec.RLP.MarshalToBytes(rm); err != nil {
-		return err
-	}
-	lrp := c.rm.ReceiptProofs[len(c.rm.ReceiptProofs)-1]
-	le := lrp.Events[len(lrp.Events)-1]
-	s := &module.Segment{
-		TransactionParam: b,
-		//
-		From:          c.src,
-		Height:        lrp.Height,
-		EventSequence: le.Sequence,
-		NumberOfEvent: numOfEvents,
-	}
 	c.ss = append(c.ss, s)
-	lrp.Events = lrp.Events[:0]
-	c.rm.ReceiptProofs[0] = lrp
-	c.rm.ReceiptProofs = c.rm.ReceiptProofs[:1]
-	c.rmSize = 0
-	return nil
+	c.m.Queued.Set(float64(len(c.ss)))
 }
 
-func sizeOfEvent(rp *module.Event) int {
-	return int(unsafe.Sizeof(rp))
+// quarantineEvent keeps aside the event which doesn't fit in a transaction alone,
+// it's never accepted by destination. it returns the error to stop receiving following events.
+func (c *bridge) quarantineEvent(item *eventItem, err error) error {
+	c.l.Errorf("quarantine height:%d,seq:%d,size:%d err:%+v",
+		item.rp.Height, item.e.Sequence, item.size, err)
+	c.m.Failed(policy.CodeOversize)
+	p := &relayMessagePacker{src: c.src, onSegment: func(s *module.Segment) {
+		c.ssMtx.Lock()
+		defer c.ssMtx.Unlock()
+		c.qs = append(c.qs, s)
+	}}
+	if perr := p.Add(item); perr != nil {
+		return perr
+	}
+	if perr := p.Flush(); perr != nil {
+		return perr
+	}
+	c.shutdownOnGap(item.e.Sequence, err)
+	return err
 }
 
 func (c *bridge) OnBlockOfSrc(rps []*module.ReceiptProof) error {
 	c.l.Debugf("OnBlockOfSrc rps:%d", len(rps))
-	var err error
 	for _, rp := range rps {
-		trp := &module.ReceiptProof{
-			Index:  rp.Index,
-			Events: make([]*module.Event, 0),
-			Height: rp.Height,
+		c.m.SrcHeight.Set(float64(rp.Height))
+		if len(rp.Events) > 0 {
+			c.m.TxSeq.Set(float64(rp.Events[len(rp.Events)-1].Sequence))
 		}
-		c.rm.ReceiptProofs = append(c.rm.ReceiptProofs, trp)
-
 		for _, e := range rp.Events {
-			size := sizeOfEvent(e)
-			if c.isOverLimit(c.rmSize+size) && c.rmSize > 0 {
-				if err = c.addSegment(); err != nil {
-					return err
-				}
+			if e.Sequence <= atomic.LoadInt64(&c.lastSeq) {
+				//duplicated by restarted ReceiveLoop
+				continue
 			}
-			trp.Events = append(trp.Events, e)
-			c.rmSize += size
-		}
-
-		//last event
-		if c.isOverLimit(c.rmSize) {
-			if err = c.addSegment(); err != nil {
+			item, err := newEventItem(rp, e)
+			if err != nil {
 				return err
 			}
-		}
-
-		//remove last receipt if empty
-		if len(trp.Events) == 0 {
-			c.rm.ReceiptProofs = c.rm.ReceiptProofs[:len(c.rm.ReceiptProofs)-1]
-		}
-	}
-
-	if !c.cfg.MaxSizeTx {
-		//immediately relay
-		if c.rmSize > 0 {
-			if err = c.addSegment(); err != nil {
+			if err = c.sg.Add(item); err != nil {
+				if errors.Is(err, segment.ErrOversize) {
+					return c.quarantineEvent(item, err)
+				}
 				return err
 			}
+			atomic.StoreInt64(&c.lastSeq, e.Sequence)
 		}
+		//the receipt is skipped as duplicated after it's processed
+		c.wd.OnSrc(rp.Height)
+	}
+	if err := c.sg.Commit(); err != nil {
+		return err
 	}
 	c.relay()
 	return nil
 }
 
-func (c *bridge) ReceiveLoop(height, seq int64, errCh chan<- error) {
-	err := c.r.ReceiveLoop(
+func (c *bridge) ReceiveLoop(height, seq int64, notify func(err error)) {
+	notify(c.r.ReceiveLoop(
 		height,
 		seq,
 		c.OnBlockOfSrc,
 		func() {
 			c.l.Debugf("Source %s, height:%d, seq:%d",
 				c.src, height, seq)
-		})
-	select {
-	case errCh <- err:
-	default:
+		}))
+}
+
+// receiveFrom returns height and sequence to receive from, it resumes after the received events.
+func (c *bridge) receiveFrom(bs *module.BMCLinkStatus) (int64, int64) {
+	height, seq := bs.Verifier.Height, bs.RxSeq
+	if h := c.wd.SrcHeight(); h > height {
+		height = h
+	}
+	if v := atomic.LoadInt64(&c.lastSeq); v > seq {
+		seq = v
 	}
+	return height, seq
 }
 
-func (c *bridge) Serve() error {
-	errCh := make(chan error)
+// startLoops runs MonitorLoop, then ReceiveLoop with the first status of destination,
+// errors of the loops stopped by stopLoops are ignored.
+func (c *bridge) startLoops() {
+	gen := atomic.AddInt32(&c.loopGen, 1)
+	notify := func(err error) {
+		if atomic.LoadInt32(&c.loopGen) != gen {
+			return
+		}
+		select {
+		case c.errCh <- err:
+		default:
+		}
+	}
 	var once sync.Once
 	go func() {
-		err := c.s.MonitorLoop(func(bs *module.BMCLinkStatus) error {
+		notify(c.s.MonitorLoop(func(bs *module.BMCLinkStatus) error {
 			once.Do(func() {
-				c.bs = bs
 				c.l.Debugf("Destination %s, height:%d",
-					c.dst, c.bs.CurrentHeight)
-				go c.ReceiveLoop(c.bs.Verifier.Height, c.bs.RxSeq, errCh)
+					c.dst, bs.CurrentHeight)
+				height, seq := c.receiveFrom(bs)
+				go c.ReceiveLoop(height, seq, notify)
 			})
 			return c.OnBlockOfDst(bs)
-		})
-		select {
-		case errCh <- err:
-		default:
-		}
+		}))
 	}()
+}
 
+func (c *bridge) stopLoops() {
+	atomic.AddInt32(&c.loopGen, 1)
+	c.s.StopMonitorLoop()
+	c.r.StopReceiveLoop()
+}
+
+// onStall restarts loops in Serve, or shutdown if restart is false.
+func (c *bridge) onStall(err error, restart bool) {
+	if !restart {
+		c.shutdown(err)
+		return
+	}
+	select {
+	case c.restartCh <- err:
+	default:
+	}
+}
+
+// status returns the last BMCLinkStatus of destination.
+func (c *bridge) status() *module.BMCLinkStatus {
+	c.bsMtx.RLock()
+	defer c.bsMtx.RUnlock()
+	return c.bs
+}
+
+// pending returns whether there is segment to relay.
+func (c *bridge) pending() bool {
+	if c.isPaused() {
+		return false
+	}
+	c.ssMtx.RLock()
+	defer c.ssMtx.RUnlock()
+	return len(c.ss) > 0
+}
+
+func (c *bridge) Serve() error {
+	c.startLoops()
+	c.wd.Start()
+	defer c.wd.Stop()
 	for {
 		select {
-		case err := <-errCh:
+		case err := <-c.errCh:
+			c.stopLoops()
 			return err
+		case err := <-c.restartCh:
+			c.l.Warnf("restart loops err:%+v", err)
+			c.stopLoops()
+			c.startLoops()
+			c.resend(0, nil)
 		}
 	}
 }
 
 func NewBridge(cfg *module.Config, ks, pw []byte, l log.Logger) (*bridge, error) {
 	c := &bridge{
-		src: cfg.Src.Address,
-		dst: cfg.Dst.Address,
-		cfg: cfg,
-		rm: &module.RelayMessage{
-			ReceiptProofs: make([]*module.ReceiptProof, 0),
-		},
-		ss: make([]*module.Segment, 0),
+		src:       cfg.Src.Address,
+		dst:       cfg.Dst.Address,
+		cfg:       cfg,
+		ss:        make([]*module.Segment, 0),
+		errCh:     make(chan error),
+		m:         metrics.NewLinkMetrics(cfg.Src.Address.NetworkAddress(), cfg.Dst.Address.NetworkAddress()),
+		restartCh: make(chan error, 1),
 	}
 
 	var err error
@@ -309,6 +421,19 @@ func NewBridge(cfg *module.Config, ks, pw []byte, l log.Logger) (*bridge, error)
 	if c.s, err = NewSender(cfg, c.w, c.l); err != nil {
 		return nil, err
 	}
+	c.sg = newSegmenter(c.src, c.fits, cfg.Strategy(), c.addSegment)
+	if c.pe, err = policy.NewEngine(cfg.Policy, module.DefaultPolicyRules, c.l, module.ErrConnectFail); err != nil {
+		return nil, err
+	}
+	wc := health.Config{}
+	if cfg.Watchdog != nil {
+		wc = *cfg.Watchdog
+	}
+	if wc.SrcTimeout == 0 {
+		//receiver notifies only blocks having events, src check is disabled by default
+		wc.SrcTimeout = -1
+	}
+	c.wd = health.NewWatchdog(cfg.Src.Address.NetworkAddress(), &wc, c.pending, c.onStall, c.l)
 	return c, nil
 }
 
@@ -318,6 +443,20 @@ const (
 )
 
 func newWallet(cfg *module.Config, ks, pw []byte) (w module.Wallet, err error) {
+	if cfg.Signer.Enabled() {
+		var rw *wallet.RemoteWallet
+		if rw, err = wallet.NewRemoteWallet(cfg.Signer); err != nil {
+			return nil, err
+		}
+		return rw, nil
+	}
+	if cfg.PKCS11.Enabled() {
+		var hw *wallet.PKCS11Wallet
+		if hw, err = wallet.NewPKCS11Wallet(cfg.PKCS11); err != nil {
+			return nil, err
+		}
+		return hw, nil
+	}
 	switch cfg.Dst.Address.BlockChain() {
 	case chainNameIcon:
 		return iconbridge.NewWallet(ks, pw)
@@ -332,9 +471,9 @@ func newWallet(cfg *module.Config, ks, pw []byte) (w module.Wallet, err error) {
 func NewReceiver(cfg *module.Config, l log.Logger) (r module.Receiver, err error) {
 	switch cfg.Src.Address.BlockChain() {
 	case chainNameIcon:
-		r = iconbridge.NewReceiver(cfg.Src.Address, cfg.Dst.Address, cfg.Src.Endpoint, cfg.Src.Options, l)
+		r = iconbridge.NewReceiver(cfg.Src.Address, cfg.Dst.Address, cfg.Src.EndpointList(), cfg.Src.Options, l)
 	case chainNameBsc:
-		r = evmbridge.NewReceiver(cfg.Src.Address, cfg.Dst.Address, cfg.Src.Endpoint, cfg.Src.Options, l)
+		r = evmbridge.NewReceiver(cfg.Src.Address, cfg.Dst.Address, cfg.Src.EndpointList(), cfg.Src.Options, l)
 	default:
 		err = errors.Errorf("not supported receiver %s", cfg.Src.Address.BlockChain())
 	}
@@ -344,11 +483,20 @@ func NewReceiver(cfg *module.Config, l log.Logger) (r module.Receiver, err error
 func NewSender(cfg *module.Config, w module.Wallet, l log.Logger) (s module.Sender, err error) {
 	switch cfg.Dst.Address.BlockChain() {
 	case chainNameIcon:
-		s = iconbridge.NewSender(cfg.Src.Address, cfg.Dst.Address, w, cfg.Dst.Endpoint, cfg.Src.Options, l)
+		if s, err = iconbridge.NewSender(cfg.Src.Address, cfg.Dst.Address, w, cfg.Dst.EndpointList(), cfg.Src.Options, l); err != nil {
+			return
+		}
 	case chainNameBsc:
-		s = evmbridge.NewSender(cfg.Src.Address, cfg.Dst.Address, w, cfg.Dst.Endpoint, nil, l)
+		if s, err = evmbridge.NewSender(cfg.Src.Address, cfg.Dst.Address, w, cfg.Dst.EndpointList(), nil, l); err != nil {
+			return
+		}
 	default:
 		err = errors.Errorf("not supported sender %s", cfg.Dst.Address.BlockChain())
+		return
+	}
+	if cfg.DryRun != "" {
+		l.Warnf("dry-run mode, segments are recorded to %s instead of sending", cfg.DryRun)
+		s, err = newDryRunSender(cfg, s)
 	}
 	return
 }
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+
+	"github.com/icon-project/btp/cmd/bridge/module"
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/dryrun"
+)
+
+const (
+	bmcRelayMethod = "handleRelayMessage"
+)
+
+type eventRecord struct {
+	Next     string `json:"next"`
+	Sequence int64  `json:"sequence"`
+	Message  string `json:"message"`
+}
+
+type receiptRecord struct {
+	Index  int64          `json:"index"`
+	Height int64          `json:"height"`
+	Events []*eventRecord `json:"events"`
+}
+
+// decodeRelayMessage returns the readable form of RelayMessage, it's used by dry-run.
+func decodeRelayMessage(b []byte) (interface{}, error) {
+	rm := &RelayMessage{}
+	if _, err := codec.RLP.UnmarshalFromBytes(b, rm); err != nil {
+		return nil, err
+	}
+	rs := make([]*receiptRecord, 0, len(rm.Receipts))
+	for _, rb := range rm.Receipts {
+		r := &Receipt{}
+		if _, err := codec.RLP.UnmarshalFromBytes(rb, r); err != nil {
+			return nil, err
+		}
+		var evts []*module.Event
+		if _, err := codec.RLP.UnmarshalFromBytes(r.Events, &evts); err != nil {
+			return nil, err
+		}
+		rr := &receiptRecord{
+			Index:  r.Index,
+			Height: r.Height,
+			Events: make([]*eventRecord, 0, len(evts)),
+		}
+		for _, e := range evts {
+			rr.Events = append(rr.Events, &eventRecord{
+				Next:     e.Next,
+				Sequence: e.Sequence,
+				Message:  "0x" + hex.EncodeToString(e.Message),
+			})
+		}
+		rs = append(rs, rr)
+	}
+	return map[string]interface{}{"receipts": rs}, nil
+}
+
+// newDryRunSender returns Sender which records segments to cfg.DryRun instead of sending with s.
+func newDryRunSender(cfg *module.Config, s module.Sender) (module.Sender, error) {
+	output := cfg.DryRun
+	if output != dryrun.Stdout {
+		output = cfg.ResolveAbsolute(output)
+	}
+	r, err := dryrun.NewRecorder(output)
+	if err != nil {
+		return nil, err
+	}
+	statusPath := cfg.DryRunStatus
+	if statusPath != "" {
+		statusPath = cfg.ResolveAbsolute(statusPath)
+	}
+	t := dryrun.NewTracker(statusPath, cfg.Src.Address.NetworkAddress())
+	return module.NewDryRunSender(s, cfg.Src.Address, cfg.Dst.Address, bmcRelayMethod, decodeRelayMessage, r, t), nil
+}
@@ -29,10 +29,13 @@ import (
 	"github.com/icon-project/btp/cmd/bridge/module"
 	"github.com/spf13/cobra"
 
+	"github.com/icon-project/btp/common/admin"
 	"github.com/icon-project/btp/common/cli"
 	"github.com/icon-project/btp/common/crypto"
 	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/health"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
 	"github.com/icon-project/btp/common/wallet"
 )
 
@@ -55,9 +58,20 @@ type Config struct {
 	ConsoleLevel string               `json:"console_level"`
 	LogForwarder *log.ForwarderConfig `json:"log_forwarder,omitempty"`
 	LogWriter    *log.WriterConfig    `json:"log_writer,omitempty"`
+
+	MetricsAddress string `json:"metrics_address,omitempty"`
+	AdminAddress   string `json:"admin_address,omitempty"`
+	AdminReadOnly  bool   `json:"admin_read_only,omitempty"`
 }
 
+// Wallet returns the wallet, it requests signing to the signer or PKCS#11 token if it's configured.
 func (c *Config) Wallet() (wallet.Wallet, error) {
+	if c.Signer.Enabled() {
+		return wallet.NewRemoteWallet(c.Signer)
+	}
+	if c.PKCS11.Enabled() {
+		return wallet.NewPKCS11Wallet(c.PKCS11)
+	}
 	pw, err := c.resolvePassword()
 	if err != nil {
 		return nil, err
@@ -78,6 +92,12 @@ func (c *Config) resolvePassword() ([]byte, error) {
 }
 
 func (c *Config) EnsureWallet() error {
+	if c.Signer.Enabled() {
+		return nil
+	}
+	if c.PKCS11.Enabled() {
+		return wallet.EnsurePKCS11Key(c.PKCS11)
+	}
 	pw, err := c.resolvePassword()
 	if err != nil {
 		return err
@@ -152,9 +172,11 @@ func main() {
 	rootPFlags := rootCmd.PersistentFlags()
 	rootPFlags.String("src.address", "", "BTP Address of source blockchain (PROTOCOL://NID.BLOCKCHAIN/BMC)")
 	rootPFlags.String("src.endpoint", "", "Endpoint of source blockchain")
+	rootPFlags.StringSlice("src.endpoints", nil, "Additional endpoints of source blockchain for failover, comma-separated")
 	rootPFlags.StringToString("src.options", nil, "Options, comma-separated 'key=value'")
 	rootPFlags.String("dst.address", "", "BTP Address of destination blockchain (PROTOCOL://NID.BLOCKCHAIN/BMC)")
 	rootPFlags.String("dst.endpoint", "", "Endpoint of destination blockchain")
+	rootPFlags.StringSlice("dst.endpoints", nil, "Additional endpoints of destination blockchain for failover, comma-separated")
 	rootPFlags.StringToString("dst.options", nil, "Options, comma-separated 'key=value'")
 
 	//BTP2.0
@@ -162,13 +184,28 @@ func main() {
 	rootPFlags.Int64("nid", 1, "network id")
 	rootPFlags.Bool("proofFlag", false, "btp2.0 notification proof flag")
 	rootPFlags.Bool("maxSizeTx", false, "Send when the maximum transaction size is reached")
+	rootPFlags.Int("flush_latency", 0, "Milliseconds to send pending messages with maxSizeTx, disabled if zero")
+	rootPFlags.Int("max_events_tx", 0, "Max number of events in a transaction, unlimited if zero")
 
 	rootPFlags.Int64("offset", 0, "Offset of MTA")
 	rootPFlags.String("key_store", "", "KeyStore")
 	rootPFlags.String("key_password", "", "Password of KeyStore")
 	rootPFlags.String("key_secret", "", "Secret(password) file for KeyStore")
+	rootPFlags.String("signer.endpoint", "", "Signer endpoint (ex: unix:///tmp/signer.sock), KeyStore is not used if it's given")
+	rootPFlags.String("signer.address", "", "Account of signer, the only account of signer is used if empty")
+	rootPFlags.String("pkcs11.library", "", "PKCS#11 library (ex: /usr/lib/softhsm/libsofthsm2.so), KeyStore is not used if it's given")
+	rootPFlags.String("pkcs11.token_label", "", "PKCS#11 token label")
+	rootPFlags.String("pkcs11.key_label", "", "PKCS#11 key label")
+	rootPFlags.String("pkcs11.coin_type", "", "PKCS#11 key type (icx,evm)")
+	rootPFlags.String("pkcs11.pin", "", "PKCS#11 user PIN")
+	rootPFlags.String("pkcs11.pin_file", "", "PKCS#11 user PIN file")
 	//
 	rootPFlags.String("base_dir", "", "Base directory for data")
+	rootPFlags.String("dry_run", "", "Output path of dry-run records, '-' for standard output, transactions are not sent if it's given")
+	rootPFlags.String("dry_run_status", "", "Status file for dry-run confirmation, JSON object of {\"height\",\"rx_seq\"} keyed by network address of source")
+	rootPFlags.String("metrics_address", "", "Address of metrics and health probe endpoint (ex: 0.0.0.0:9090), disabled if empty")
+	rootPFlags.String(admin.FlagAddress, "", "Address of admin endpoint (ex: unix:///tmp/bridge.sock), disabled if empty")
+	rootPFlags.Bool(admin.FlagReadOnly, false, "Disallow admin requests which change state of link")
 	rootPFlags.StringP("config", "c", "", "Parsing configuration file")
 	//
 	rootPFlags.String("log_level", "debug", "Global log level (trace,debug,info,warn,error,fatal,panic)")
@@ -197,6 +234,9 @@ func main() {
 				return fmt.Errorf("fail to ensure src wallet err:%+v", err)
 			} else {
 				cfg.KeyStorePass = ""
+				if cfg.PKCS11 != nil {
+					cfg.PKCS11.PIN = ""
+				}
 			}
 			return nil
 		},
@@ -252,6 +292,10 @@ func main() {
 			if err != nil {
 				return err
 			}
+			metrics.Serve(cfg.MetricsAddress, func(err error) {
+				l.Errorf("fail to serve metrics address:%s err:%+v", cfg.MetricsAddress, err)
+			}, health.RegisterHandlers)
+			admin.Serve(cfg.AdminAddress, cfg.AdminReadOnly, l).Register(cfg.Src.Address.NetworkAddress(), c)
 			return c.Serve()
 		},
 	}
@@ -264,6 +308,9 @@ func main() {
 
 	cli.BindPFlags(rootVc, startFlags)
 
+	admin.NewCommand(rootCmd, rootVc)
+	wallet.NewKeyStoreCommand(rootCmd, rootVc)
+
 	genMdCmd := cli.NewGenerateMarkdownCommand(rootCmd, rootVc)
 	genMdCmd.Hidden = true
 
@@ -18,21 +18,46 @@ package module
 
 import (
 	"github.com/icon-project/btp/common/config"
+	"github.com/icon-project/btp/common/health"
+	"github.com/icon-project/btp/common/policy"
+	"github.com/icon-project/btp/common/segment"
+	"github.com/icon-project/btp/common/wallet"
 )
 
 type BaseConfig struct {
-	Address  BtpAddress             `json:"address"`
-	Endpoint string                 `json:"endpoint"`
-	Options  map[string]interface{} `json:"options,omitempty"`
+	Address   BtpAddress             `json:"address"`
+	Endpoint  string                 `json:"endpoint"`
+	Endpoints []string               `json:"endpoints,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+}
+
+// EndpointList returns Endpoint followed by Endpoints for failover, in order of preference.
+func (c *BaseConfig) EndpointList() []string {
+	l := make([]string, 0, len(c.Endpoints)+1)
+	if c.Endpoint != "" {
+		l = append(l, c.Endpoint)
+	}
+	for _, e := range c.Endpoints {
+		if e != c.Endpoint {
+			l = append(l, e)
+		}
+	}
+	return l
 }
 
 type Config struct {
 	config.FileConfig `json:",squash"` //instead of `mapstructure:",squash"`
-	Src               BaseConfig       `json:"src"`
-	Dst               BaseConfig       `json:"dst"`
-	Ntid              int64            `json:"ntid"`
-	Nid               int64            `json:"nid"`
-	MaxSizeTx         bool             `json:"maxSizeTx"`
-	ProofFlag         bool             `json:"proofFlag"`
-	Offset            int64            `json:"offset"`
+	segment.Config    `json:",squash"`
+	Src               BaseConfig           `json:"src"`
+	Dst               BaseConfig           `json:"dst"`
+	Ntid              int64                `json:"ntid"`
+	Nid               int64                `json:"nid"`
+	ProofFlag         bool                 `json:"proofFlag"`
+	Offset            int64                `json:"offset"`
+	Policy            *policy.Config       `json:"policy,omitempty"`
+	Watchdog          *health.Config       `json:"watchdog,omitempty"`
+	Signer            *wallet.RemoteConfig `json:"signer,omitempty"`         //KeyStore is not used if it's given
+	PKCS11            *wallet.PKCS11Config `json:"pkcs11,omitempty"`         //KeyStore is not used if it's given
+	DryRun            string               `json:"dry_run,omitempty"`        //output path of dry-run records, dry-run is disabled if empty
+	DryRunStatus      string               `json:"dry_run_status,omitempty"` //path of status file for dry-run confirmation
 }
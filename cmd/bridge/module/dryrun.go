@@ -0,0 +1,124 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package module
+
+import (
+	"fmt"
+
+	"github.com/icon-project/btp/common/dryrun"
+)
+
+// DecodeFunc returns the readable form of TransactionParam of the segment.
+type DecodeFunc func(b []byte) (interface{}, error)
+
+type dryRunSender struct {
+	Sender
+	src    BtpAddress
+	dst    BtpAddress
+	method string
+	decode DecodeFunc
+	r      *dryrun.Recorder
+	t      *dryrun.Tracker
+}
+
+type dryRunResult struct {
+	Height        int64
+	EventSequence int64
+}
+
+func (s *dryRunSender) Relay(segment *Segment) (GetResultParam, error) {
+	rec := &dryrun.Record{
+		Src:           s.src.String(),
+		Dst:           s.dst.String(),
+		Method:        s.method,
+		Height:        segment.Height,
+		EventSequence: segment.EventSequence,
+		NumberOfEvent: segment.NumberOfEvent,
+	}
+	if b, ok := segment.TransactionParam.([]byte); ok {
+		rec.Size = len(b)
+		if s.decode != nil {
+			var err error
+			if rec.Message, err = s.decode(b); err != nil {
+				rec.Error = err.Error()
+			}
+		}
+	}
+	if err := s.r.Record(rec); err != nil {
+		return nil, err
+	}
+	return &dryRunResult{Height: segment.Height, EventSequence: segment.EventSequence}, nil
+}
+
+// GetResult returns after the segment is reflected to the status of dryrun.Tracker.
+func (s *dryRunSender) GetResult(p GetResultParam) (TransactionResult, error) {
+	dr, ok := p.(*dryRunResult)
+	if !ok {
+		return nil, fmt.Errorf("fail to cast *dryRunResult %T", p)
+	}
+	s.t.Confirm(dr.Height, dr.EventSequence)
+	if err := s.t.Wait(dr.Height, dr.EventSequence); err != nil {
+		return nil, err
+	}
+	return dr, nil
+}
+
+func (s *dryRunSender) overlay(bs *BMCLinkStatus) (*BMCLinkStatus, error) {
+	s.t.Init(dryrun.Status{Height: bs.Verifier.Height, RxSeq: bs.RxSeq})
+	st, err := s.t.Status()
+	if err != nil {
+		return nil, err
+	}
+	ds := *bs
+	ds.Verifier.Height = st.Height
+	ds.RxSeq = st.RxSeq
+	return &ds, nil
+}
+
+// GetStatus returns the status of the destination with the progress of dryrun.Tracker.
+func (s *dryRunSender) GetStatus() (*BMCLinkStatus, error) {
+	bs, err := s.Sender.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+	return s.overlay(bs)
+}
+
+func (s *dryRunSender) MonitorLoop(cb MonitorCallback) error {
+	return s.Sender.MonitorLoop(func(bs *BMCLinkStatus) error {
+		ds, err := s.overlay(bs)
+		if err != nil {
+			return err
+		}
+		return cb(ds)
+	})
+}
+
+// NewDryRunSender returns Sender which records segments by r instead of sending with s,
+// the method is the name of BMC method to be called and decode is used to record readable message.
+func NewDryRunSender(s Sender, src, dst BtpAddress, method string, decode DecodeFunc,
+	r *dryrun.Recorder, t *dryrun.Tracker) Sender {
+	return &dryRunSender{
+		Sender: s,
+		src:    src,
+		dst:    dst,
+		method: method,
+		decode: decode,
+		r:      r,
+		t:      t,
+	}
+}
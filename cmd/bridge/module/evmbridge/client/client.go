@@ -21,7 +21,9 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -32,10 +34,14 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 
+	"github.com/icon-project/btp/common/endpoint"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
+	"github.com/icon-project/btp/common/wallet"
 )
 
 const (
+	MetricsClientName                          = "evm"
 	DefaultSendTransactionRetryInterval        = 3 * time.Second         //3sec
 	DefaultGetTransactionResultPollingInterval = 1500 * time.Millisecond //1.5sec
 	DefaultTimeout                             = 10 * time.Second        //
@@ -62,7 +68,39 @@ type Client struct {
 	ethClient    *ethclient.Client
 	rpcClient    *rpc.Client
 	chainID      *big.Int
-	stop         <-chan bool
+	mtx          sync.Mutex
+	stop         chan bool
+}
+
+// dial connects to the current endpoint of es, http requests are sent to the other endpoint on failure.
+// websocket is connected to the first available endpoint, it's not rotated after connected.
+func dial(es *endpoint.Selector) (*rpc.Client, error) {
+	if uri := es.Current(); strings.HasPrefix(uri, "http") {
+		hc := &http.Client{Transport: metrics.NewRPCTransport(MetricsClientName, es.Transport(nil))}
+		return rpc.DialHTTPWithClient(uri, hc)
+	}
+	var err error
+	for i := 0; i < es.Len(); i++ {
+		uri := es.Current()
+		var rc *rpc.Client
+		if rc, err = rpc.Dial(uri); err == nil {
+			return rc, nil
+		}
+		es.Fail(uri, err)
+	}
+	return nil, err
+}
+
+func blockNumberOf(uri string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), endpoint.DefaultCheckTimeout)
+	defer cancel()
+	rc, err := rpc.DialContext(ctx, uri)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	n, err := ethclient.NewClient(rc).BlockNumber(ctx)
+	return int64(n), err
 }
 
 func toBlockNumArg(number *big.Int) string {
@@ -87,12 +125,8 @@ func newTransaction(nonce uint64, to common.Address, amount *big.Int, gasLimit u
 	})
 }
 
-func (c *Client) NewTransactOpts(k *ecdsa.PrivateKey) (*bind.TransactOpts, error) {
-	txo, err := bind.NewKeyedTransactorWithChainID(k, c.chainID)
-	if err != nil {
-		return nil, err
-	}
-	txo.GasPrice, _ = c.ethClient.SuggestGasPrice(context.Background())
+func (c *Client) NewTransactOpts(w wallet.TransactionSigner) (*bind.TransactOpts, error) {
+	txo := wallet.NewTransactor(w, c.chainID)
 	txo.GasLimit = uint64(DefaultGasLimit)
 	return txo, nil
 }
@@ -199,8 +233,19 @@ func (c *Client) GetBlockNumber() (uint64, error) {
 	return c.ethClient.BlockNumber(ctx)
 }
 
+// stopCh returns the channel which is closed by CloseMonitor.
+func (c *Client) stopCh() <-chan bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.stop == nil {
+		c.stop = make(chan bool)
+	}
+	return c.stop
+}
+
 // Poll deprecated
 func (c *Client) Poll(cb func(bh *types.Header) error) error {
+	stop := c.stopCh()
 	n, err := c.GetBlockNumber()
 	if err != nil {
 		return err
@@ -209,7 +254,7 @@ func (c *Client) Poll(cb func(bh *types.Header) error) error {
 	var retry = BlockRetryLimit
 	for {
 		select {
-		case <-c.stop:
+		case <-stop:
 			return nil
 		default:
 			// Exhausted all error retries
@@ -290,6 +335,7 @@ func (c *Client) Monitor(cb func(bh *types.Header) error) error {
 		err error
 		ch  = make(chan *types.Header)
 	)
+	stop := c.stopCh()
 	if s, err = c.ethClient.SubscribeNewHead(context.Background(), ch); err != nil {
 		if rpc.ErrNotificationsUnsupported == err {
 			c.log.Infoln("%v, try polling", err)
@@ -297,8 +343,11 @@ func (c *Client) Monitor(cb func(bh *types.Header) error) error {
 		}
 		return err
 	}
+	defer s.Unsubscribe()
 	for {
 		select {
+		case <-stop:
+			return nil
 		case err = <-s.Err():
 			return err
 		case bh := <-ch:
@@ -311,11 +360,19 @@ func (c *Client) Monitor(cb func(bh *types.Header) error) error {
 	}
 }
 
+// CloseMonitor stops polling and subscription, the client is kept to be used by next Monitor.
 func (c *Client) CloseMonitor() {
-	c.log.Debugf("CloseMonitor %s", c.rpcClient)
-	c.subscription.Unsubscribe()
-	c.ethClient.Close()
-	c.rpcClient.Close()
+	c.log.Debugf("CloseMonitor %s", c.uri)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	if c.subscription != nil {
+		c.subscription.Unsubscribe()
+		c.subscription = nil
+	}
 }
 
 func (c *Client) CloseAllMonitor() {
@@ -327,19 +384,28 @@ func (c *Client) GetBackend() bind.ContractBackend {
 	return c.ethClient
 }
 
-func NewClient(uri string, l log.Logger) *Client {
+func (c *Client) GetRPCClient() *rpc.Client {
+	return c.rpcClient
+}
+
+// NewClient returns Client which uses endpoints in order, it rotates to the next endpoint on failure.
+func NewClient(endpoints []string, l log.Logger) *Client {
 	//TODO options {MaxRetrySendTx, MaxRetryGetResult, MaxIdleConnsPerHost, Debug, Dump} }
-	rpcClient, err := rpc.Dial(uri)
+	es := endpoint.NewSelector(endpoints, l)
+	rpcClient, err := dial(es)
 	if err != nil {
 		l.Fatal("Error creating client", err)
 	}
 	c := &Client{
-		uri:       uri,
+		uri:       es.Current(),
 		rpcClient: rpcClient,
 		ethClient: ethclient.NewClient(rpcClient),
 		log:       l,
 	}
 	c.chainID, _ = c.GetChainID()
+	if strings.HasPrefix(es.Current(), "http") {
+		es.Watch(endpoint.DefaultCheckInterval, blockNumberOf)
+	}
 	l.Tracef("Client Connected Chain ID: ", c.chainID)
 	if err != nil {
 		c.log.Error("Error creating tendermintLightclient system contract", err)
@@ -129,7 +129,7 @@ func (r *Receiver) StopReceiveLoop() {
 	r.c.CloseAllMonitor()
 }
 
-func NewReceiver(src, dst module.BtpAddress, endpoint string, opt map[string]interface{}, l log.Logger) module.Receiver {
+func NewReceiver(src, dst module.BtpAddress, endpoints []string, opt map[string]interface{}, l log.Logger) module.Receiver {
 	r := &Receiver{
 		src: src,
 		dst: dst,
@@ -142,6 +142,6 @@ func NewReceiver(src, dst module.BtpAddress, endpoint string, opt map[string]int
 	if err = json.Unmarshal(b, &r.opt); err != nil {
 		l.Panicf("fail to unmarshal opt:%#v err:%+v", opt, err)
 	}
-	r.c = client.NewClient(endpoint, l)
+	r.c = client.NewClient(endpoints, l)
 	return r
 }
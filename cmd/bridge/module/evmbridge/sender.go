@@ -17,22 +17,34 @@
 package evmbridge
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/icon-project/btp/cmd/bridge/module"
 	"github.com/icon-project/btp/cmd/bridge/module/evmbridge/client"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/gas"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
+	"github.com/icon-project/btp/common/nonce"
+	"github.com/icon-project/btp/common/revert"
+	"github.com/icon-project/btp/common/wallet"
 )
 
 const (
 	txMaxDataSize                 = 524288 //512 * 1024 // 512kB
 	txOverheadScale               = 0.37   //base64 encoding overhead 0.36, rlp and other fields 0.01
 	txSizeLimit                   = txMaxDataSize / (1 + txOverheadScale)
+	abiSelectorSize               = 4
+	abiWordSize                   = 32
 	DefaultGetRelayResultInterval = time.Second
 	DefaultRelayReSendInterval    = time.Second
 )
@@ -41,25 +53,46 @@ type sender struct {
 	c   *client.Client
 	src module.BtpAddress
 	dst module.BtpAddress
-	w   *EvmWallet
+	w   wallet.TransactionSigner
 	l   log.Logger
 	opt struct {
+		gas.Config
 	}
 
 	bmc *client.BMC
+	gp  *gas.Pricer
+	nm  *nonce.Manager
+	rd  *revert.Decoder
+	m   *metrics.LinkMetrics
 }
 
 func (s *sender) Relay(segment *module.Segment) (module.GetResultParam, error) {
 	p := segment.TransactionParam.([]byte)
 
-	t, err := s.c.NewTransactOpts(s.w.PrivateKey)
+	t, err := s.c.NewTransactOpts(s.w)
 	if err != nil {
 		return nil, err
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), client.DefaultTimeout)
+	defer cancel()
+	if t.GasPrice, err = s.gp.GasPrice(ctx); err != nil {
+		return nil, err
+	}
+	nonce, err := s.nm.Allocate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.Nonce = new(big.Int).SetUint64(nonce)
+	t.NoSend = true
 
 	var tx *types.Transaction
 	tx, err = s.bmc.HandleRelayMessage(t, s.src.String(), p[:])
 	if err != nil {
+		s.nm.Release(nonce, err)
+		s.l.Errorf("handleRelayMessage: ", err.Error())
+		return nil, err
+	}
+	if err = s.nm.Send(ctx, tx); err != nil {
 		s.l.Errorf("handleRelayMessage: ", err.Error())
 		return nil, err
 	}
@@ -67,22 +100,115 @@ func (s *sender) Relay(segment *module.Segment) (module.GetResultParam, error) {
 	return txh, nil
 }
 
+// transactionOf returns the mined one of the transaction and its replacements,
+// if all of them are pending, it returns the latest pending one.
+func (s *sender) transactionOf(hashes []common.Hash) (*types.Transaction, bool, error) {
+	var latest *types.Transaction
+	var err error
+	for i := len(hashes) - 1; i >= 0; i-- {
+		t, pending, e := s.c.GetTransaction(hashes[i])
+		if e != nil {
+			//replaced transaction could be dropped
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		if !pending {
+			return t, false, nil
+		}
+		if latest == nil {
+			latest = t
+		}
+	}
+	if latest == nil {
+		return nil, false, err
+	}
+	return latest, true, nil
+}
+
+// revertOf returns the error of the failed transaction which has the code of BMC, BMV or BSH.
+func (s *sender) revertOf(tx *types.Transaction) error {
+	ctx, cancel := context.WithTimeout(context.Background(), client.DefaultTimeout)
+	defer cancel()
+	return s.rd.ErrorOfTransaction(ctx, s.c.GetBackend(), tx, nil)
+}
+
+func (s *sender) recover() error {
+	ctx, cancel := context.WithTimeout(context.Background(), client.DefaultTimeout)
+	defer cancel()
+	return s.nm.Recover(ctx)
+}
+
+// replace sends the transaction which has the same nonce with tx and bumped gas price.
+func (s *sender) replace(tx *types.Transaction) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), client.DefaultTimeout)
+	defer cancel()
+	ntx, err := s.gp.Replacement(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.c.NewTransactOpts(s.w)
+	if err != nil {
+		return nil, err
+	}
+	if ntx, err = t.Signer(t.From, ntx); err != nil {
+		return nil, err
+	}
+	if err = s.nm.Send(ctx, ntx); err != nil {
+		return nil, err
+	}
+	return ntx, nil
+}
+
 func (s *sender) GetResult(p module.GetResultParam) (module.TransactionResult, error) {
 	if txh, ok := p.(common.Hash); ok {
+		hashes := []common.Hash{txh}
+		nonce, tracking := s.nm.NonceOf(txh)
+		sent := time.Now()
 		for {
-			_, pending, err := s.c.GetTransaction(txh)
+			t, pending, err := s.transactionOf(hashes)
+			if err == ethereum.NotFound && tracking {
+				//dropped transaction is resent, unless the nonce is used by another
+				if err = s.recover(); err != nil {
+					return nil, err
+				}
+				if !s.nm.Tracking(nonce) {
+					return nil, ethereum.NotFound
+				}
+				<-time.After(DefaultGetRelayResultInterval)
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
 			if pending {
+				if d := s.gp.ReplaceTimeout(); d > 0 && time.Since(sent) > d {
+					//transaction could be stuck by the gap of previous nonce
+					if err = s.recover(); err != nil {
+						s.l.Warnf("fail to recover nonce err:%+v", err)
+					}
+					if nt, err := s.replace(t); err != nil {
+						s.l.Warnf("fail to replace tx:%s err:%+v", t.Hash(), err)
+					} else {
+						s.l.Infof("replace tx:%s to tx:%s gasPrice:%s", t.Hash(), nt.Hash(), nt.GasPrice())
+						hashes = append(hashes, nt.Hash())
+					}
+					sent = time.Now()
+				}
 				<-time.After(DefaultGetRelayResultInterval)
 				continue
 			}
-			tx, err := s.c.GetTransactionReceipt(txh)
+			s.nm.Done(t.Nonce())
+			tx, err := s.c.GetTransactionReceipt(t.Hash())
 			if err != nil {
 				return nil, err
 			}
-			return tx, nil //mapErrorWithTransactionResult(&types.Receipt{}, err) // TODO: map transaction.js result error
+			s.m.Fee.Add(metrics.BigFloat(new(big.Int).Mul(t.GasPrice(), new(big.Int).SetUint64(tx.GasUsed))))
+			if tx.Status == types.ReceiptStatusFailed {
+				return tx, s.revertOf(t)
+			}
+			return tx, nil
 		}
 	} else {
 		return nil, fmt.Errorf("fail to casting TransactionHashParam %T", p)
@@ -130,23 +256,51 @@ func (s *sender) TxSizeLimit() int {
 	return int(math.Round(float64(txSizeLimit)))
 }
 
-func NewSender(src, dst module.BtpAddress, w module.Wallet, endpoint string, opt map[string]interface{}, l log.Logger) module.Sender {
+func abiSizeOf(size int) int {
+	return (size + abiWordSize - 1) / abiWordSize * abiWordSize
+}
+
+// TxDataSize returns the size of ABI encoded call of handleRelayMessage(string,bytes),
+// it's the selector, offsets of arguments and the arguments with length which are padded to the word.
+func (s *sender) TxDataSize(size int) int {
+	return abiSelectorSize + 2*abiWordSize +
+		abiWordSize + abiSizeOf(len(s.src.String())) +
+		abiWordSize + abiSizeOf(size)
+}
+
+func (s *sender) TxDataLimit() int {
+	return txMaxDataSize
+}
+
+func NewSender(src, dst module.BtpAddress, w module.Wallet, endpoints []string, opt map[string]interface{}, l log.Logger) (module.Sender, error) {
 	s := &sender{
 		src: src,
 		dst: dst,
-		w:   w.(*EvmWallet),
+		w:   w.(wallet.TransactionSigner),
 		l:   l,
+		m:   metrics.NewLinkMetrics(src.NetworkAddress(), dst.NetworkAddress()),
 	}
 	b, err := json.Marshal(opt)
 	if err != nil {
-		l.Panicf("fail to marshal opt:%#v err:%+v", opt, err)
+		return nil, errors.Wrapf(err, "fail to marshal opt:%#v", opt)
 	}
 	if err = json.Unmarshal(b, &s.opt); err != nil {
-		l.Panicf("fail to unmarshal opt:%#v err:%+v", opt, err)
+		return nil, errors.Wrapf(err, "fail to unmarshal opt:%#v", opt)
+	}
+	s.c = client.NewClient(endpoints, l)
+	if s.gp, err = gas.NewPricer(s.opt.Config, s.c.GetRPCClient()); err != nil {
+		return nil, errors.Wrap(err, "fail to create gas pricer")
+	}
+	t, err := s.c.NewTransactOpts(s.w)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to create transact opts")
+	}
+	s.nm = nonce.NewManager(ethclient.NewClient(s.c.GetRPCClient()), t.From, t.Signer, s.gp, l)
+	if s.rd, err = revert.NewDecoder(module.NewRevertError, client.BMCABI); err != nil {
+		return nil, errors.Wrap(err, "fail to create revert decoder")
 	}
-	s.c = client.NewClient(endpoint, l)
 
 	s.bmc, _ = client.NewBMC(common.HexToAddress(s.dst.ContractAddress()), s.c.GetBackend())
 
-	return s
+	return s, nil
 }
@@ -0,0 +1,140 @@
+package evmbridge
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/chain/bsc/bsctest"
+	"github.com/icon-project/btp/cmd/bridge/module"
+	"github.com/icon-project/btp/cmd/bridge/module/evmbridge/client"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/log"
+)
+
+const (
+	testIconAddress = module.BtpAddress("btp://0x1.icon/cx0000000000000000000000000000000000000001")
+	testBscAddress  = module.BtpAddress("btp://0x61.bsc/" + bsctest.DefaultBMCAddress)
+)
+
+func newTestWallet(t *testing.T) *EvmWallet {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	return &EvmWallet{&keystore.Key{Address: crypto.PubkeyToAddress(key.PublicKey), PrivateKey: key}}
+}
+
+func TestSender_Relay(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	node.AddLink(testIconAddress.String(), 0)
+	node.SetRelayHandler(func(l *bsctest.Link, msg []byte) int {
+		l.RxSeq++
+		return 0
+	})
+	s, err := NewSender(testIconAddress, testBscAddress, newTestWallet(t), []string{node.URL()}, nil, log.New())
+	assert.NoError(t, err)
+
+	msg := []byte("message0")
+	p, err := s.Relay(&module.Segment{TransactionParam: msg})
+	assert.NoError(t, err)
+	_, err = s.GetResult(p)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{msg}, node.Messages(testIconAddress.String()))
+
+	bs, err := s.GetStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), bs.RxSeq)
+	assert.Equal(t, node.Height(), bs.CurrentHeight)
+}
+
+func TestSender_TxDataSize(t *testing.T) {
+	bmc, err := abi.JSON(strings.NewReader(client.BMCABI))
+	assert.NoError(t, err)
+	s := &sender{src: testIconAddress}
+	for _, size := range []int{0, 1, 31, 32, 33, 1000} {
+		b, err := bmc.Pack("handleRelayMessage", s.src.String(), make([]byte, size))
+		assert.NoError(t, err)
+		assert.Equal(t, len(b), s.TxDataSize(size), "size:%d", size)
+	}
+}
+
+func TestSender_GetResult(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	node.AddLink(testIconAddress.String(), 0)
+	s, err := NewSender(testIconAddress, testBscAddress, newTestWallet(t), []string{node.URL()}, nil, log.New())
+	assert.NoError(t, err)
+
+	//reverted by BMV, then the code is decoded from revert data
+	node.InjectRevert(bsctest.BMVNotVerifiable)
+	p, err := s.Relay(&module.Segment{TransactionParam: []byte("message0")})
+	assert.NoError(t, err)
+	_, err = s.GetResult(p)
+	assert.Equal(t, module.BMVRevertNotVerifiable, errors.CodeOf(err))
+
+	node.SetRelayHandler(func(l *bsctest.Link, msg []byte) int {
+		return bsctest.BMCRevertUnauthorized
+	})
+	p, err = s.Relay(&module.Segment{TransactionParam: []byte("message1")})
+	assert.NoError(t, err)
+	_, err = s.GetResult(p)
+	assert.Equal(t, module.BMCRevertUnauthorized, errors.CodeOf(err))
+	assert.Equal(t, 0, len(node.Messages(testIconAddress.String())))
+}
+
+func TestReceiver_ReceiveLoop(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	r := NewReceiver(testBscAddress, testIconAddress, []string{node.WSURL()}, nil, log.New())
+	defer r.StopReceiveLoop()
+
+	msgs := [][]byte{[]byte("message0"), []byte("message1")}
+	height := node.SendMessages(testIconAddress.String(), msgs...)
+	node.SendMessages("btp://0x2.icon/cx0000000000000000000000000000000000000002", []byte("other"))
+	ch := make(chan []*module.ReceiptProof, 1)
+	go func() {
+		_ = r.ReceiveLoop(1, 0, func(rps []*module.ReceiptProof) error {
+			ch <- rps
+			return nil
+		}, func() {})
+	}()
+	//the new block is notified after subscription, then previous blocks are retrieved
+	for i := 0; i < 500 && node.Calls("eth_subscribe") == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	node.AddBlock()
+	select {
+	case rps := <-ch:
+		assert.Equal(t, len(msgs), len(rps))
+		for i, rp := range rps {
+			assert.Equal(t, int64(i), rp.Index)
+			assert.Equal(t, height, rp.Height)
+			assert.Equal(t, msgs[i], rp.Events[0].Message)
+			assert.Equal(t, int64(i+1), rp.Events[0].Sequence)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestSender_Replace(t *testing.T) {
+	node := bsctest.NewNode(bsctest.Config{})
+	defer node.Close()
+	node.AddLink(testIconAddress.String(), 0)
+	opt := map[string]interface{}{"gas_strategy": "fixed", "gas_price": "1gwei", "replace_timeout": "1ms"}
+	s, err := NewSender(testIconAddress, testBscAddress, newTestWallet(t), []string{node.URL()}, opt, log.New())
+	assert.NoError(t, err)
+
+	node.HoldTransactions(1)
+	p, err := s.Relay(&module.Segment{TransactionParam: []byte("message0")})
+	assert.NoError(t, err)
+	_, err = s.GetResult(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, node.Calls("eth_sendRawTransaction"))
+	assert.Equal(t, 1, len(node.Messages(testIconAddress.String())))
+}
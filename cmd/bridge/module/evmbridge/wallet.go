@@ -17,18 +17,29 @@
 package evmbridge
 
 import (
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/icon-project/btp/cmd/bridge/module"
+	"github.com/icon-project/btp/common/wallet"
 )
 
 type EvmWallet struct {
 	*keystore.Key
 }
 
+var _ wallet.TransactionSigner = (*EvmWallet)(nil)
+
 func (w *EvmWallet) Address() string {
 	return w.Key.Address.String()
 }
 
+// SignTransaction returns the transaction signed for the chain.
+func (w *EvmWallet) SignTransaction(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), w.PrivateKey)
+}
+
 func NewWallet(ks, pw []byte) (module.Wallet, error) {
 	k, err := keystore.DecryptKey(ks, string(pw))
 	if err != nil {
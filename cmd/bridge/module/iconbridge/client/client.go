@@ -33,20 +33,29 @@ import (
 	"github.com/icon-project/btp/cmd/bridge/module"
 	"github.com/icon-project/btp/cmd/bridge/module/iconbridge/wallet"
 	"github.com/icon-project/btp/common/crypto"
+	"github.com/icon-project/btp/common/endpoint"
 	"github.com/icon-project/btp/common/jsonrpc"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
 )
 
 const (
+	MetricsClientName                          = "icon"
 	DefaultSendTransactionRetryInterval        = 3 * time.Second         //3sec
 	DefaultGetTransactionResultPollingInterval = 1500 * time.Millisecond //1.5sec
+	DefaultReconnectDelay                      = time.Second
+	DefaultMaxReconnectDelay                   = time.Minute
 )
 
 type Client struct {
 	*jsonrpc.Client
 	conns map[string]*websocket.Conn
+	urls  map[string]bool
 	l     log.Logger
 	mtx   sync.Mutex
+	stop  chan struct{}
+	es    *endpoint.Selector
+	debug *jsonrpc.Client
 }
 
 var txSerializeExcludes = map[string]bool{"signature": true}
@@ -100,6 +109,30 @@ func (c *Client) WaitTransactionResult(p *TransactionHashParam) (*TransactionRes
 	}
 	return tr, nil
 }
+
+// EstimateStep returns the step which is required to execute the transaction by debug_estimateStep,
+// stepLimit and signature of p are not used.
+func (c *Client) EstimateStep(p *TransactionParam) (int64, error) {
+	ep := &EstimateStepParam{
+		Version:     p.Version,
+		FromAddress: p.FromAddress,
+		ToAddress:   p.ToAddress,
+		Value:       p.Value,
+		Timestamp:   p.Timestamp,
+		NetworkID:   p.NetworkID,
+		Nonce:       p.Nonce,
+		DataType:    p.DataType,
+		Data:        p.Data,
+	}
+	if ep.Timestamp == "" {
+		ep.Timestamp = NewHexInt(time.Now().UnixNano() / int64(time.Microsecond))
+	}
+	var result HexInt
+	if _, err := c.debug.Do("debug_estimateStep", ep, &result); err != nil {
+		return 0, err
+	}
+	return result.Value()
+}
 func (c *Client) Call(p *CallParam, r interface{}) error {
 	_, err := c.Do("icx_call", p, r)
 	return err
@@ -313,8 +346,10 @@ func (c *Client) Monitor(reqUrl string, reqPtr, respPtr interface{}, cb wsReadCa
 	if cb == nil {
 		return fmt.Errorf("callback function cannot be nil")
 	}
-	conn, err := c.wsConnect(reqUrl, nil)
+	url := c.es.Current()
+	conn, err := c.wsConnect(url, reqUrl, nil)
 	if err != nil {
+		c.es.Fail(url, err)
 		return module.ErrConnectFail
 	}
 	defer func() {
@@ -325,7 +360,12 @@ func (c *Client) Monitor(reqUrl string, reqPtr, respPtr interface{}, cb wsReadCa
 		return err
 	}
 	cb(conn, WSEventInit)
-	return c.wsReadJSONLoop(conn, respPtr, cb)
+	err = c.wsReadJSONLoop(conn, respPtr, cb)
+	if err != nil && c._hasWsConn(conn) {
+		//not closed by CloseAllMonitor
+		c.es.Fail(url, err)
+	}
+	return err
 }
 
 func (c *Client) CloseMonitor(conn *websocket.Conn) {
@@ -334,12 +374,61 @@ func (c *Client) CloseMonitor(conn *websocket.Conn) {
 }
 
 func (c *Client) CloseAllMonitor() {
+	c.mtx.Lock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	c.mtx.Unlock()
+	c.closeAllConn()
+}
+
+// closeAllConn closes all websocket connections, then monitors reconnect if they are not stopped.
+func (c *Client) closeAllConn() {
+	c.mtx.Lock()
+	conns := make([]*websocket.Conn, 0, len(c.conns))
 	for _, conn := range c.conns {
+		conns = append(conns, conn)
+	}
+	c.mtx.Unlock()
+	for _, conn := range conns {
 		c.l.Debugf("CloseAllMonitor %s", conn.LocalAddr().String())
 		c.wsClose(conn)
 	}
 }
 
+// MonitorWithReconnect calls monitor again with exponential backoff whenever it returns,
+// until CloseAllMonitor is called. monitor should call reset on connect to reset the backoff.
+func (c *Client) MonitorWithReconnect(monitor func(reset func()) error) error {
+	c.mtx.Lock()
+	if c.stop == nil {
+		c.stop = make(chan struct{})
+	}
+	stop := c.stop
+	c.mtx.Unlock()
+
+	delay := DefaultReconnectDelay
+	for {
+		err := monitor(func() {
+			delay = DefaultReconnectDelay
+		})
+		select {
+		case <-stop:
+			return err
+		default:
+		}
+		c.l.Warnf("monitor disconnected, reconnect after %v err:%+v", delay, err)
+		select {
+		case <-stop:
+			return err
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > DefaultMaxReconnectDelay {
+			delay = DefaultMaxReconnectDelay
+		}
+	}
+}
+
 type wsReadCallback func(*websocket.Conn, interface{})
 
 func (c *Client) _addWsConn(conn *websocket.Conn) {
@@ -350,6 +439,14 @@ func (c *Client) _addWsConn(conn *websocket.Conn) {
 	c.conns[la] = conn
 }
 
+func (c *Client) _hasWsConn(conn *websocket.Conn) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	_, ok := c.conns[conn.LocalAddr().String()]
+	return ok
+}
+
 func (c *Client) _removeWsConn(conn *websocket.Conn) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
@@ -366,8 +463,8 @@ type wsConnectError struct {
 	httpResp *http.Response
 }
 
-func (c *Client) wsConnect(reqUrl string, reqHeader http.Header) (*websocket.Conn, error) {
-	wsEndpoint := strings.Replace(c.Endpoint, "http", "ws", 1)
+func (c *Client) wsConnect(url, reqUrl string, reqHeader http.Header) (*websocket.Conn, error) {
+	wsEndpoint := strings.Replace(url, "http", "ws", 1)
 	conn, httpResp, err := websocket.DefaultDialer.Dial(wsEndpoint+reqUrl, reqHeader)
 	if err != nil {
 		wsErr := wsConnectError{error: err}
@@ -375,6 +472,10 @@ func (c *Client) wsConnect(reqUrl string, reqHeader http.Header) (*websocket.Con
 		return nil, wsErr
 	}
 	c._addWsConn(conn)
+	c.mtx.Lock()
+	metrics.WebsocketConnected(MetricsClientName, c.urls[reqUrl])
+	c.urls[reqUrl] = true
+	c.mtx.Unlock()
 	return conn, nil
 }
 
@@ -431,7 +532,7 @@ func (c *Client) wsReadJSONLoop(conn *websocket.Conn, respPtr interface{}, cb ws
 	for {
 		v := reflect.New(elem.Type())
 		ptr := v.Interface()
-		if _, ok := c.conns[conn.LocalAddr().String()]; !ok {
+		if !c._hasWsConn(conn) {
 			c.l.Debugf("wsReadJSONLoop c.conns[%s] is nil", conn.LocalAddr().String())
 			return nil
 		}
@@ -446,17 +547,61 @@ func (c *Client) wsReadJSONLoop(conn *websocket.Conn, respPtr interface{}, cb ws
 	}
 }
 
-func NewClient(uri string, l log.Logger) *Client {
+// NewClient returns Client which uses endpoints in order, it rotates to the next endpoint on failure.
+const (
+	apiPath      = "/api/v3"
+	debugApiPath = "/api/v3d"
+)
+
+// debugPath returns the path of debug API for the path of JSON-RPC API,
+// the path is not changed if it's not JSON-RPC API of goloop.
+func debugPath(path string) string {
+	if i := strings.Index(path, apiPath); i >= 0 && !strings.HasPrefix(path[i:], debugApiPath) {
+		return path[:i] + debugApiPath + path[i+len(apiPath):]
+	}
+	return path
+}
+
+// debugTransport sends the request to the debug API of the endpoint which is selected by endpoint.Selector.
+type debugTransport struct {
+	rt http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Path = debugPath(req.URL.Path)
+	return t.rt.RoundTrip(r)
+}
+
+func NewClient(endpoints []string, l log.Logger) *Client {
 	//TODO options {MaxRetrySendTx, MaxRetryGetResult, MaxIdleConnsPerHost, Debug, Dump}
 	tr := &http.Transport{MaxIdleConnsPerHost: 1000}
+	es := endpoint.NewSelector(endpoints, l)
 	c := &Client{
-		Client: jsonrpc.NewJsonRpcClient(&http.Client{Transport: tr}, uri),
+		Client: jsonrpc.NewJsonRpcClient(&http.Client{Transport: metrics.NewRPCTransport(MetricsClientName, es.Transport(tr))}, es.Current()),
 		conns:  make(map[string]*websocket.Conn),
+		urls:   make(map[string]bool),
 		l:      l,
+		es:     es,
 	}
+	es.OnChange(func(from, to string) {
+		c.closeAllConn()
+	})
+	hc := &http.Client{Transport: tr, Timeout: endpoint.DefaultCheckTimeout}
+	es.Watch(endpoint.DefaultCheckInterval, func(url string) (int64, error) {
+		blk := &struct {
+			Height int64 `json:"height"`
+		}{}
+		if _, err := jsonrpc.NewJsonRpcClient(hc, url).Do("icx_getLastBlock", nil, blk); err != nil {
+			return 0, err
+		}
+		return blk.Height, nil
+	})
 	opts := IconOptions{}
 	opts.SetBool(IconOptionsDebug, true)
 	c.CustomHeader[HeaderKeyIconOptions] = opts.ToHeaderValue()
+	c.debug = jsonrpc.NewJsonRpcClient(&http.Client{Transport: metrics.NewRPCTransport(MetricsClientName, es.Transport(&debugTransport{rt: tr}))}, es.Current())
+	c.debug.CustomHeader = c.CustomHeader
 
 	//c.Pre = func(req *http.Request) error {
 	//	b, err := req.GetBody()
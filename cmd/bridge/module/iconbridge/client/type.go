@@ -136,6 +136,20 @@ type TransactionParam struct {
 	Data        interface{} `json:"data,omitempty"`
 	TxHash      HexBytes    `json:"-"`
 }
+
+// EstimateStepParam is the parameter of debug_estimateStep, it's TransactionParam without stepLimit and signature.
+type EstimateStepParam struct {
+	Version     HexInt      `json:"version"`
+	FromAddress Address     `json:"from"`
+	ToAddress   Address     `json:"to"`
+	Value       HexInt      `json:"value,omitempty"`
+	Timestamp   HexInt      `json:"timestamp"`
+	NetworkID   HexInt      `json:"nid"`
+	Nonce       HexInt      `json:"nonce,omitempty"`
+	DataType    string      `json:"dataType,omitempty"`
+	Data        interface{} `json:"data,omitempty"`
+}
+
 type CallData struct {
 	Method string      `json:"method"`
 	Params interface{} `json:"params,omitempty"`
@@ -178,7 +178,7 @@ func (r *Receiver) monitorEvent(req *client.BlockRequest, seq int64,
 						if evt, err = proofToEvent(proofs[j+1]); err != nil {
 							return err
 						}
-						if evt.Sequence < seq {
+						if evt.Sequence <= seq {
 							continue EpLoop
 						}
 						evts = append(evts, evt)
@@ -227,6 +227,8 @@ func proofToEventLog(proof [][]byte) (*client.EventLog, error) {
 	return el, nil
 }
 
+// ReceiveLoop monitors events from height, it reconnects from the next height of
+// the last received events and skips the events replayed across the reconnect.
 func (r *Receiver) ReceiveLoop(height, seq int64,
 	cb module.ReceiveCallback, scb func()) error {
 	if height < 1 {
@@ -237,46 +239,63 @@ func (r *Receiver) ReceiveLoop(height, seq int64,
 		r.l.Debugf("onError %s err:%+v", conn.LocalAddr().String(), err)
 		_ = conn.Close()
 	}
-	if networkId, err := r.getBTPLinkNetworkId(); err == nil && networkId > 0 {
-		req := &client.BTPRequest{
-			Height:    client.HexInt(intconv.FormatInt(height)),
-			NetworkID: client.HexInt(intconv.FormatInt(networkId)),
+	//resume from the next height of the last events, events of the height are delivered at once
+	onEvents := func(rps []*module.ReceiptProof) error {
+		if err := cb(rps); err != nil {
+			return err
 		}
-		onConn := func(conn *websocket.Conn) {
-			r.l.Debugf("ReceiveLoop monitorBTPBlock height:%d seq:%d networkId:%d connected %s",
-				height, seq, networkId, conn.LocalAddr().String())
-			if scb != nil {
-				scb()
+		for _, rp := range rps {
+			if len(rp.Events) > 0 {
+				height = rp.Height + 1
+				seq = rp.Events[len(rp.Events)-1].Sequence
 			}
 		}
-		return r.monitorBTPBlock(req, seq, cb, onConn, onErr)
-	} else {
-		s := r.dst.String()
-		ef := &client.EventFilter{
-			Addr:      client.Address(r.src.Account()),
-			Signature: EventSignature,
-			Indexed:   []*string{&s},
-		}
-		req := &client.BlockRequest{
-			Height:       client.NewHexInt(height),
-			EventFilters: []*client.EventFilter{ef},
-		}
-		onConn := func(conn *websocket.Conn) {
-			r.l.Debugf("ReceiveLoop monitorEvent height:%d seq:%d connected %s",
-				height, seq, conn.LocalAddr().String())
-			if scb != nil {
-				scb()
+		return nil
+	}
+	return r.c.MonitorWithReconnect(func(reset func()) error {
+		if networkId, err := r.getBTPLinkNetworkId(); err == nil && networkId > 0 {
+			req := &client.BTPRequest{
+				Height:    client.HexInt(intconv.FormatInt(height)),
+				NetworkID: client.HexInt(intconv.FormatInt(networkId)),
+			}
+			onConn := func(conn *websocket.Conn) {
+				r.l.Debugf("ReceiveLoop monitorBTPBlock height:%d seq:%d networkId:%d connected %s",
+					height, seq, networkId, conn.LocalAddr().String())
+				reset()
+				if scb != nil {
+					scb()
+				}
 			}
+			return r.monitorBTPBlock(req, seq, onEvents, onConn, onErr)
+		} else {
+			s := r.dst.String()
+			ef := &client.EventFilter{
+				Addr:      client.Address(r.src.Account()),
+				Signature: EventSignature,
+				Indexed:   []*string{&s},
+			}
+			req := &client.BlockRequest{
+				Height:       client.NewHexInt(height),
+				EventFilters: []*client.EventFilter{ef},
+			}
+			onConn := func(conn *websocket.Conn) {
+				r.l.Debugf("ReceiveLoop monitorEvent height:%d seq:%d connected %s",
+					height, seq, conn.LocalAddr().String())
+				reset()
+				if scb != nil {
+					scb()
+				}
+			}
+			return r.monitorEvent(req, seq, onEvents, onConn, onErr)
 		}
-		return r.monitorEvent(req, seq, cb, onConn, onErr)
-	}
+	})
 }
 
 func (r *Receiver) StopReceiveLoop() {
 	r.c.CloseAllMonitor()
 }
 
-func NewReceiver(src, dst module.BtpAddress, endpoint string, opt map[string]interface{}, l log.Logger) module.Receiver {
+func NewReceiver(src, dst module.BtpAddress, endpoints []string, opt map[string]interface{}, l log.Logger) module.Receiver {
 	r := &Receiver{
 		src: src,
 		dst: dst,
@@ -289,6 +308,6 @@ func NewReceiver(src, dst module.BtpAddress, endpoint string, opt map[string]int
 	if err = json.Unmarshal(b, &r.opt); err != nil {
 		l.Panicf("fail to unmarshal opt:%#v err:%+v", opt, err)
 	}
-	r.c = client.NewClient(endpoint, l)
+	r.c = client.NewClient(endpoints, l)
 	return r
 }
@@ -23,6 +23,7 @@ import (
 	"math"
 	"net/url"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -31,8 +32,10 @@ import (
 
 	"github.com/icon-project/btp/cmd/bridge/module"
 	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/errors"
 	"github.com/icon-project/btp/common/jsonrpc"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
 )
 
 const (
@@ -41,6 +44,7 @@ const (
 	DefaultGetRelayResultInterval = time.Second
 	DefaultRelayReSendInterval    = time.Second
 	DefaultStepLimit              = 0x9502f900 //maxStepLimit(invoke), refer https://www.icondev.io/docs/step-estimation
+	DefaultStepMargin             = 10         //percent of estimated step
 )
 
 var (
@@ -54,8 +58,15 @@ type sender struct {
 	w   wallet.Wallet
 	l   log.Logger
 	opt struct {
+		//StepLimit is used if debug_estimateStep is unavailable
 		StepLimit int64
+		//StepMargin is percent which is added to estimated step, zero means DefaultStepMargin
+		StepMargin int64 `json:"step_margin,omitempty"`
+		//StepLimitCap is the max of estimated step limit, zero means StepLimit
+		StepLimitCap int64 `json:"step_limit_cap,omitempty"`
 	}
+	noEstimate int32
+	m          *metrics.LinkMetrics
 }
 
 func (s *sender) newTransactionParam(method string, params interface{}) *client.TransactionParam {
@@ -111,7 +122,40 @@ func (s *sender) Relay(segment *module.Segment) (module.GetResultParam, error) {
 	}
 }
 
+// estimateStepLimit sets stepLimit of p to estimated step with margin which doesn't exceed the cap.
+// the static limit is kept if debug_estimateStep is unavailable,
+// and it returns the revert error if the estimation fails by revert.
+func (s *sender) estimateStepLimit(p *client.TransactionParam) error {
+	step, err := s.c.EstimateStep(p)
+	if err != nil {
+		if je, ok := err.(*jsonrpc.Error); ok {
+			if re := revertErrorOf(je); re != nil {
+				return re
+			}
+		}
+		if atomic.CompareAndSwapInt32(&s.noEstimate, 0, 1) {
+			s.l.Warnf("fail to EstimateStep, use static step limit:%d err:%+v", s.opt.StepLimit, err)
+		} else {
+			s.l.Debugf("fail to EstimateStep, use static step limit:%d err:%+v", s.opt.StepLimit, err)
+		}
+		return nil
+	}
+	if atomic.CompareAndSwapInt32(&s.noEstimate, 1, 0) {
+		s.l.Infof("EstimateStep is available")
+	}
+	limit := step + step*s.opt.StepMargin/100
+	if limit > s.opt.StepLimitCap {
+		s.l.Warnf("estimated step limit:%d exceeds the cap:%d", limit, s.opt.StepLimitCap)
+		limit = s.opt.StepLimitCap
+	}
+	p.StepLimit = client.NewHexInt(limit)
+	return nil
+}
+
 func (s *sender) sendTransaction(p *client.TransactionParam) (module.GetResultParam, error) {
+	if err := s.estimateStepLimit(p); err != nil {
+		return nil, err
+	}
 	thp := &client.TransactionHashParam{}
 SignLoop:
 	for {
@@ -162,6 +206,9 @@ func (s *sender) GetResult(p module.GetResultParam) (module.TransactionResult, e
 					}
 				}
 			}
+			if txr != nil {
+				s.addFee(txr)
+			}
 			return txr, mapErrorWithTransactionResult(txr, err)
 		}
 	} else {
@@ -169,6 +216,18 @@ func (s *sender) GetResult(p module.GetResultParam) (module.TransactionResult, e
 	}
 }
 
+func (s *sender) addFee(txr *client.TransactionResult) {
+	used, err := txr.StepUsed.BigInt()
+	if err != nil {
+		return
+	}
+	price, err := txr.StepPrice.BigInt()
+	if err != nil {
+		return
+	}
+	s.m.Fee.Add(metrics.BigFloat(used.Mul(used, price)))
+}
+
 func (s *sender) GetStatus() (*module.BMCLinkStatus, error) {
 	p := &client.CallParam{
 		FromAddress: client.Address(s.w.Address()),
@@ -205,37 +264,46 @@ func (s *sender) GetStatus() (*module.BMCLinkStatus, error) {
 	return ls, nil
 }
 
+// MonitorLoop monitors blocks from the last block, it reconnects from the next height of
+// the last monitored block and skips the blocks replayed across the reconnect.
 func (s *sender) MonitorLoop(cb module.MonitorCallback) error {
 	blk, err := s.c.GetLastBlock()
 	if err != nil {
 		return err
 	}
-	br := &client.BlockRequest{
-		Height: client.NewHexInt(blk.Height),
-	}
-	return s.c.MonitorBlock(br,
-		func(conn *websocket.Conn, v *client.BlockNotification) error {
-			if h, err := v.Height.Value(); err != nil {
-				return err
-			} else {
-				if bs, err := s.GetStatus(); err != nil {
+	last := blk.Height - 1
+	return s.c.MonitorWithReconnect(func(reset func()) error {
+		br := &client.BlockRequest{
+			Height: client.NewHexInt(last + 1),
+		}
+		return s.c.MonitorBlock(br,
+			func(conn *websocket.Conn, v *client.BlockNotification) error {
+				if h, err := v.Height.Value(); err != nil {
 					return err
+				} else if h <= last {
+					return nil
 				} else {
-					if bs.CurrentHeight != h {
-						s.l.Warnf("mismatch bmcstatus.currentHeight(%d) != blockNotification.height(%d)",
-							bs.CurrentHeight, h)
+					if bs, err := s.GetStatus(); err != nil {
+						return err
+					} else {
+						if bs.CurrentHeight != h {
+							s.l.Warnf("mismatch bmcstatus.currentHeight(%d) != blockNotification.height(%d)",
+								bs.CurrentHeight, h)
+						}
+						last = h
+						return cb(bs)
 					}
-					return cb(bs)
 				}
-			}
-		},
-		func(conn *websocket.Conn) {
-			s.l.Debugf("MonitorLoop connected %s", conn.LocalAddr().String())
-		},
-		func(conn *websocket.Conn, err error) {
-			s.l.Debugf("onError %s err:%+v", conn.LocalAddr().String(), err)
-			_ = conn.Close()
-		})
+			},
+			func(conn *websocket.Conn) {
+				s.l.Debugf("MonitorLoop connected %s height:%d", conn.LocalAddr().String(), last+1)
+				reset()
+			},
+			func(conn *websocket.Conn, err error) {
+				s.l.Debugf("onError %s err:%+v", conn.LocalAddr().String(), err)
+				_ = conn.Close()
+			})
+	})
 }
 
 func (s *sender) StopMonitorLoop() {
@@ -246,25 +314,47 @@ func (s *sender) TxSizeLimit() int {
 	return txSizeLimit
 }
 
-func NewSender(src, dst module.BtpAddress, w module.Wallet, endpoint string, opt map[string]interface{}, l log.Logger) module.Sender {
+// TxDataSize returns the size of JSON data of handleRelayMessage, the message is encoded by base64.
+func (s *sender) TxDataSize(size int) int {
+	b, _ := json.Marshal(&client.CallData{
+		Method: client.BMCRelayMethod,
+		Params: &client.BMCRelayMethodParams{Prev: s.src.String()},
+	})
+	return len(b) + base64.URLEncoding.EncodedLen(size)
+}
+
+func (s *sender) TxDataLimit() int {
+	return txMaxDataSize
+}
+
+func NewSender(src, dst module.BtpAddress, w module.Wallet, endpoints []string, opt map[string]interface{}, l log.Logger) (module.Sender, error) {
 	s := &sender{
 		src: src,
 		dst: dst,
 		w:   w.(wallet.Wallet),
 		l:   l,
+		m:   metrics.NewLinkMetrics(src.NetworkAddress(), dst.NetworkAddress()),
 	}
 	b, err := json.Marshal(opt)
 	if err != nil {
-		l.Panicf("fail to marshal opt:%#v err:%+v", opt, err)
+		return nil, errors.Wrapf(err, "fail to marshal opt:%#v", opt)
 	}
 	if err = json.Unmarshal(b, &s.opt); err != nil {
-		l.Panicf("fail to unmarshal opt:%#v err:%+v", opt, err)
+		return nil, errors.Wrapf(err, "fail to unmarshal opt:%#v", opt)
 	}
 	if s.opt.StepLimit <= 0 {
 		s.opt.StepLimit = DefaultStepLimit
 	}
-	s.c = client.NewClient(endpoint, l)
-	return s
+	if s.opt.StepMargin < 0 {
+		return nil, errors.IllegalArgumentError.Errorf("invalid step_margin:%d", s.opt.StepMargin)
+	} else if s.opt.StepMargin == 0 {
+		s.opt.StepMargin = DefaultStepMargin
+	}
+	if s.opt.StepLimitCap <= 0 {
+		s.opt.StepLimitCap = s.opt.StepLimit
+	}
+	s.c = client.NewClient(endpoints, l)
+	return s, nil
 }
 
 func mapError(err error) error {
@@ -303,6 +393,15 @@ func mapError(err error) error {
 	return err
 }
 
+// revertErrorOf returns the revert error of SCORE error, debug_estimateStep returns the failure of execution as it.
+func revertErrorOf(je *jsonrpc.Error) error {
+	fc := int64(client.JsonrpcErrorCodeScore - je.Code)
+	if fc < client.ResultStatusFailureCodeRevert || fc > client.ResultStatusFailureCodeEnd {
+		return nil
+	}
+	return module.NewRevertError(int(fc - client.ResultStatusFailureCodeRevert))
+}
+
 func mapErrorWithTransactionResult(txr *client.TransactionResult, err error) error {
 	err = mapError(err)
 	if err == nil && txr != nil && txr.Status != client.ResultStatusSuccess {
@@ -66,10 +66,16 @@ type MonitorCallback func(*BMCLinkStatus) error
 type Sender interface {
 	Relay(segment *Segment) (GetResultParam, error)
 	GetResult(p GetResultParam) (TransactionResult, error)
+	GetStatus() (*BMCLinkStatus, error)
 	MonitorLoop(cb MonitorCallback) error
 	StopMonitorLoop()
 	//FinalizeLatency() int
 	TxSizeLimit() int
+	//TxDataSize returns the size of transaction data to relay the message of the size,
+	//it includes the overhead of encoding for the destination.
+	TxDataSize(size int) int
+	//TxDataLimit returns the max size of transaction data.
+	TxDataLimit() int
 }
 
 type ReceiveCallback func([]*ReceiptProof) error
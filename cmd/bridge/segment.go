@@ -0,0 +1,227 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"github.com/icon-project/btp/cmd/bridge/module"
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/segment"
+)
+
+type RelayMessage struct {
+	Receipts [][]byte
+}
+
+type Receipt struct {
+	Index  int64
+	Events []byte
+	Height int64
+}
+
+// rlpHeaderSize returns the size of RLP header of bytes or list which has the size of payload,
+// bytes of a single byte less than 0x80 has no header, but it's never used for receipts.
+func rlpHeaderSize(size int) int {
+	if size <= 55 {
+		return 1
+	}
+	n := 1
+	for l := size; l > 0xff; l >>= 8 {
+		n++
+	}
+	return 1 + n
+}
+
+func rlpSizeOf(v interface{}) (int, error) {
+	b, err := codec.RLP.MarshalToBytes(v)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// receiptSize returns the size of Receipt as an element of RelayMessage.Receipts,
+// events is the sum of the size of encoded events.
+func receiptSize(index, height int64, events int) (int, error) {
+	is, err := rlpSizeOf(index)
+	if err != nil {
+		return 0, err
+	}
+	hs, err := rlpSizeOf(height)
+	if err != nil {
+		return 0, err
+	}
+	es := rlpHeaderSize(events) + events
+	body := is + rlpHeaderSize(es) + es + hs
+	r := rlpHeaderSize(body) + body
+	return rlpHeaderSize(r) + r, nil
+}
+
+// relayMessageSize returns the size of RelayMessage,
+// receipts is the sum of the size of receipts including their headers.
+func relayMessageSize(receipts int) int {
+	l := rlpHeaderSize(receipts) + receipts
+	return rlpHeaderSize(l) + l
+}
+
+// eventItem is the item of segmentation for an event of the receipt.
+type eventItem struct {
+	rp   *module.ReceiptProof
+	e    *module.Event
+	size int
+}
+
+func newEventItem(rp *module.ReceiptProof, e *module.Event) (*eventItem, error) {
+	size, err := rlpSizeOf(e)
+	if err != nil {
+		return nil, err
+	}
+	return &eventItem{rp: rp, e: e, size: size}, nil
+}
+
+func (i *eventItem) Size() int {
+	return i.size
+}
+
+func (i *eventItem) Sequence() (int64, int64) {
+	return i.e.Sequence, i.e.Sequence
+}
+
+// relayMessagePacker packs events into RelayMessage, the size is computed incrementally
+// by the encoded size of each event. a receipt is split across segments
+// if its events don't fit in a segment.
+type relayMessagePacker struct {
+	src       module.BtpAddress
+	onSegment func(s *module.Segment)
+
+	rps    []*module.ReceiptProof
+	cur    *module.ReceiptProof //source of the last one of rps
+	size   int                  //size of receipts except the last one
+	events int                  //size of events of the last receipt
+	count  int
+}
+
+// newSegmenter returns Segmenter of events, onSegment is called with the segment of RelayMessage.
+func newSegmenter(src module.BtpAddress, fits func(size int) bool, st segment.Strategy,
+	onSegment func(s *module.Segment)) *segment.Segmenter {
+	return segment.New(&relayMessagePacker{src: src, onSegment: onSegment}, fits, st)
+}
+
+func (p *relayMessagePacker) SizeWith(item segment.Item) (int, error) {
+	if ei, ok := item.(*eventItem); ok {
+		return p.sizeWith(ei.rp, ei.size)
+	}
+	return p.sizeWith(nil, 0)
+}
+
+// sizeWith returns the size of RelayMessage if the event of rp which has the size is added,
+// rp is nil for the size of pending events.
+func (p *relayMessagePacker) sizeWith(rp *module.ReceiptProof, es int) (int, error) {
+	receipts := p.size
+	if n := len(p.rps); n > 0 {
+		lrp := p.rps[n-1]
+		events := p.events
+		if rp == p.cur {
+			events += es
+			rp = nil
+		}
+		rs, err := receiptSize(lrp.Index, lrp.Height, events)
+		if err != nil {
+			return 0, err
+		}
+		receipts += rs
+	}
+	if rp != nil {
+		rs, err := receiptSize(rp.Index, rp.Height, es)
+		if err != nil {
+			return 0, err
+		}
+		receipts += rs
+	}
+	return relayMessageSize(receipts), nil
+}
+
+func (p *relayMessagePacker) Add(item segment.Item) error {
+	ei := item.(*eventItem)
+	if n := len(p.rps); n == 0 || ei.rp != p.cur {
+		if n > 0 {
+			lrp := p.rps[n-1]
+			rs, err := receiptSize(lrp.Index, lrp.Height, p.events)
+			if err != nil {
+				return err
+			}
+			p.size += rs
+		}
+		p.rps = append(p.rps, &module.ReceiptProof{
+			Index:  ei.rp.Index,
+			Height: ei.rp.Height,
+		})
+		p.cur = ei.rp
+		p.events = 0
+	}
+	lrp := p.rps[len(p.rps)-1]
+	lrp.Events = append(lrp.Events, ei.e)
+	p.events += ei.size
+	p.count++
+	return nil
+}
+
+func (p *relayMessagePacker) Empty() bool {
+	return p.count == 0
+}
+
+// Flush builds the segment of pending events, the following events of the last receipt
+// are continued in the next segment.
+func (p *relayMessagePacker) Flush() error {
+	rm := &RelayMessage{
+		Receipts: make([][]byte, 0, len(p.rps)),
+	}
+	var (
+		b   []byte
+		err error
+	)
+	for _, rp := range p.rps {
+		if b, err = codec.RLP.MarshalToBytes(rp.Events); err != nil {
+			return err
+		}
+		r := &Receipt{
+			Index:  rp.Index,
+			Events: b,
+			Height: rp.Height,
+		}
+		if b, err = codec.RLP.MarshalToBytes(r); err != nil {
+			return err
+		}
+		rm.Receipts = append(rm.Receipts, b)
+	}
+	if b, err = codec.RLP.MarshalToBytes(rm); err != nil {
+		return err
+	}
+	lrp := p.rps[len(p.rps)-1]
+	le := lrp.Events[len(lrp.Events)-1]
+	p.onSegment(&module.Segment{
+		TransactionParam: b,
+		From:             p.src,
+		Height:           lrp.Height,
+		EventSequence:    le.Sequence,
+		NumberOfEvent:    p.count,
+	})
+	p.rps = nil
+	p.size = 0
+	p.events = 0
+	p.count = 0
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/cmd/bridge/module"
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/segment"
+)
+
+const testSrc = module.BtpAddress("btp://0x1.icon/cx0000000000000000000000000000000000000001")
+
+// randomReceipts returns receipts having events of random size, some messages are larger than maxMsg/2.
+func randomReceipts(r *rand.Rand, maxMsg int) []*module.ReceiptProof {
+	var seq int64
+	rps := make([]*module.ReceiptProof, r.Intn(8)+1)
+	for i := range rps {
+		rp := &module.ReceiptProof{
+			Index:  int64(r.Intn(1 << uint(r.Intn(24)+1))),
+			Height: r.Int63n(1 << uint(r.Intn(40)+1)),
+		}
+		for j := r.Intn(20) + 1; j > 0; j-- {
+			seq++
+			msg := make([]byte, r.Intn(maxMsg))
+			r.Read(msg)
+			rp.Events = append(rp.Events, &module.Event{
+				Next:     "btp://0x2.bsc/0x0000000000000000000000000000000000000002",
+				Sequence: seq,
+				Message:  msg,
+			})
+		}
+		rps[i] = rp
+	}
+	return rps
+}
+
+func decodeEvents(t *testing.T, b []byte) []*module.Event {
+	rm := &RelayMessage{}
+	_, err := codec.RLP.UnmarshalFromBytes(b, rm)
+	assert.NoError(t, err)
+	var evts []*module.Event
+	for _, rb := range rm.Receipts {
+		r := &Receipt{}
+		_, err = codec.RLP.UnmarshalFromBytes(rb, r)
+		assert.NoError(t, err)
+		var es []*module.Event
+		_, err = codec.RLP.UnmarshalFromBytes(r.Events, &es)
+		assert.NoError(t, err)
+		evts = append(evts, es...)
+	}
+	return evts
+}
+
+func sameEvent(a, b *module.Event) bool {
+	return a.Next == b.Next && a.Sequence == b.Sequence && bytes.Equal(a.Message, b.Message)
+}
+
+// segmentsOf returns segments of events in rps and the events in them,
+// the event which doesn't fit alone is rejected.
+func segmentsOf(t *testing.T, fits func(size int) bool, rps []*module.ReceiptProof) ([]*module.Segment, []*module.Event) {
+	var ss []*module.Segment
+	var evts []*module.Event
+	sg := newSegmenter(testSrc, fits, segment.Greedy, func(s *module.Segment) {
+		ss = append(ss, s)
+	})
+	for _, rp := range rps {
+		for _, e := range rp.Events {
+			item, err := newEventItem(rp, e)
+			assert.NoError(t, err)
+			if err = sg.Add(item); errors.Is(err, segment.ErrOversize) {
+				continue
+			}
+			assert.NoError(t, err)
+			evts = append(evts, e)
+		}
+	}
+	assert.NoError(t, sg.Commit())
+	return ss, evts
+}
+
+func TestRlpHeaderSize(t *testing.T) {
+	for _, size := range []int{0, 1, 55, 56, 255, 256, 65535, 65536, 1 << 24} {
+		b, err := codec.RLP.MarshalToBytes(make([]byte, size))
+		assert.NoError(t, err)
+		if size == 1 {
+			//single byte less than 0x80
+			continue
+		}
+		assert.Equal(t, len(b)-size, rlpHeaderSize(size), "size:%d", size)
+	}
+}
+
+func TestRelayMessagePacker_Size(t *testing.T) {
+	f := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		var s *module.Segment
+		p := &relayMessagePacker{src: testSrc, onSegment: func(v *module.Segment) { s = v }}
+		var size int
+		for _, rp := range randomReceipts(r, 300) {
+			for _, e := range rp.Events {
+				item, err := newEventItem(rp, e)
+				if !assert.NoError(t, err) {
+					return false
+				}
+				expected, err := p.SizeWith(item)
+				if !assert.NoError(t, err) || !assert.NoError(t, p.Add(item)) {
+					return false
+				}
+				if size, err = p.SizeWith(nil); !assert.NoError(t, err) || !assert.Equal(t, expected, size) {
+					return false
+				}
+			}
+		}
+		return assert.NoError(t, p.Flush()) &&
+			assert.Equal(t, len(s.TransactionParam.([]byte)), size) &&
+			assert.True(t, p.Empty())
+	}
+	assert.NoError(t, quick.Check(f, &quick.Config{MaxCount: 200}))
+}
+
+func TestRelayMessagePacker_Fits(t *testing.T) {
+	f := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		limit := r.Intn(4096) + 256
+		//events which don't fit alone are not relayed
+		ss, evts := segmentsOf(t, func(size int) bool { return size <= limit }, randomReceipts(r, limit))
+		var relayed []*module.Event
+		for _, s := range ss {
+			b := s.TransactionParam.([]byte)
+			es := decodeEvents(t, b)
+			if !assert.Equal(t, s.NumberOfEvent, len(es)) ||
+				!assert.Equal(t, es[len(es)-1].Sequence, s.EventSequence) {
+				return false
+			}
+			if !assert.True(t, len(b) <= limit, "size:%d limit:%d", len(b), limit) {
+				return false
+			}
+			relayed = append(relayed, es...)
+		}
+		if !assert.Equal(t, len(evts), len(relayed)) {
+			return false
+		}
+		for i, e := range evts {
+			if !assert.True(t, sameEvent(e, relayed[i]), "event:%d", i) {
+				return false
+			}
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(f, &quick.Config{MaxCount: 200}))
+}
+
+func TestRelayMessagePacker_SplitReceipt(t *testing.T) {
+	rp := &module.ReceiptProof{Index: 1, Height: 10}
+	for i := int64(1); i <= 10; i++ {
+		rp.Events = append(rp.Events, &module.Event{Sequence: i, Message: make([]byte, 100)})
+	}
+	limit := 350
+	ss, _ := segmentsOf(t, func(size int) bool { return size <= limit }, []*module.ReceiptProof{rp})
+	assert.True(t, len(ss) > 1)
+	var seq int64
+	for _, s := range ss {
+		assert.True(t, len(s.TransactionParam.([]byte)) <= limit)
+		assert.Equal(t, rp.Height, s.Height)
+		rm := &RelayMessage{}
+		_, err := codec.RLP.UnmarshalFromBytes(s.TransactionParam.([]byte), rm)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(rm.Receipts))
+		for _, e := range decodeEvents(t, s.TransactionParam.([]byte)) {
+			seq++
+			assert.Equal(t, seq, e.Sequence)
+		}
+	}
+	assert.Equal(t, int64(10), seq)
+}
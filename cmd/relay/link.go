@@ -25,111 +25,157 @@ import (
 	bscChain "github.com/icon-project/btp/chain/bsc"
 	"github.com/icon-project/btp/chain/icon"
 	iconChain "github.com/icon-project/btp/chain/icon"
+	"github.com/icon-project/btp/common/admin"
+	"github.com/icon-project/btp/common/dryrun"
 	"github.com/icon-project/btp/common/log"
 	"github.com/icon-project/btp/common/wallet"
 )
 
 func NewLink(cfg *Config, srcWallet wallet.Wallet, dstWallet wallet.Wallet, modLevels map[string]string) error {
-	var err error
-	linkErrCh := make(chan error)
+	as := admin.Serve(cfg.AdminAddress, cfg.AdminReadOnly, log.GlobalLogger())
+	return serveLink(cfg, srcWallet, dstWallet, func(w wallet.Wallet) log.Logger {
+		return setLogger(cfg, w, modLevels)
+	}, as, nil)
+}
 
+// serveLink serves directions of the link until one of them returns error or stop is closed,
+// then the other directions are stopped. It returns nil if it's stopped by stop.
+func serveLink(cfg *Config, srcWallet wallet.Wallet, dstWallet wallet.Wallet,
+	newLogger func(w wallet.Wallet) log.Logger, as *admin.Server, stop <-chan struct{}) error {
+	var (
+		dirs []chain.Config
+		ws   []wallet.Wallet
+	)
 	switch cfg.Direction {
 	case FrontDirection:
-		srcLog := setLogger(cfg, srcWallet, modLevels)
-		srcLog.Debugln(cfg.FilePath, cfg.BaseDir)
 		if cfg.BaseDir == "" {
 			cfg.BaseDir = path.Join(".", ".btp2", cfg.Src.Address.NetworkAddress())
 		}
-		if _, err = newChain(cfg.Src.Address.BlockChain(), cfg.Config, srcLog, srcWallet, linkErrCh); err != nil {
-			return err
-		}
-
+		dirs, ws = []chain.Config{cfg.Config}, []wallet.Wallet{srcWallet}
 	case ReverseDirection:
-		dstCfg := chain.Config{
-			Src: cfg.Dst,
-			Dst: cfg.Src,
-		}
-
-		dstLog := setLogger(cfg, dstWallet, modLevels)
-		dstLog.Debugln(cfg.FilePath, cfg.BaseDir)
 		if cfg.BaseDir == "" {
 			cfg.BaseDir = path.Join(".", ".btp2", cfg.Dst.Address.NetworkAddress())
 		}
-		if _, err = newChain(cfg.Dst.Address.BlockChain(), dstCfg, dstLog, dstWallet, linkErrCh); err != nil {
-			return err
-		}
+		dirs, ws = []chain.Config{reverseConfig(cfg.Config)}, []wallet.Wallet{dstWallet}
 	case BothDirection:
-		srcLog := setLogger(cfg, srcWallet, modLevels)
-		srcLog.Debugln(cfg.FilePath, cfg.BaseDir)
 		if cfg.BaseDir == "" {
 			cfg.BaseDir = path.Join(".", ".btp2", cfg.Src.Address.NetworkAddress())
 		}
-		if _, err = newChain(cfg.Src.Address.BlockChain(), cfg.Config, srcLog, srcWallet, linkErrCh); err != nil {
-			return err
-		}
+		dirs, ws = []chain.Config{cfg.Config, reverseConfig(cfg.Config)}, []wallet.Wallet{srcWallet, dstWallet}
+	default:
+		return fmt.Errorf("Not supported direction:%s", cfg.Direction)
+	}
 
-		dstCfg := chain.Config{
-			Src: cfg.Dst,
-			Dst: cfg.Src,
+	errCh := make(chan error, len(dirs))
+	chains := make([]chain.Chain, 0, len(dirs))
+	stopChains := func() {
+		for _, c := range chains {
+			c.Stop()
 		}
-
-		dstLog := setLogger(cfg, dstWallet, modLevels)
-		dstLog.Debugln(cfg.FilePath, cfg.BaseDir)
-		if cfg.BaseDir == "" {
-			cfg.BaseDir = path.Join(".", ".btp2", cfg.Dst.Address.NetworkAddress())
+		for range chains {
+			<-errCh
 		}
-		if _, err = newChain(cfg.Dst.Address.BlockChain(), dstCfg, dstLog, dstWallet, linkErrCh); err != nil {
+	}
+	for i, dc := range dirs {
+		l := newLogger(ws[i])
+		l.Debugln(cfg.FilePath, cfg.BaseDir)
+		c, err := newChain(dc.Src.Address.BlockChain(), dc, l)
+		if err != nil {
+			stopChains()
 			return err
 		}
-	default:
-		return fmt.Errorf("Not supported direction:%s", cfg.Direction)
+		name := dc.DirectionName()
+		if link, ok := c.(admin.Link); ok {
+			as.Register(name, link)
+			defer as.Unregister(name)
+		}
+		go func(c chain.Chain, dc chain.Config, w wallet.Wallet, l log.Logger) {
+			sender, err := newSender(dc.Src.Address.BlockChain(), dc, w, l)
+			if err == nil {
+				err = c.Serve(sender)
+				if cl, ok := sender.(chain.Closer); ok {
+					cl.Close()
+				}
+			}
+			errCh <- err
+		}(c, dc, ws[i], l)
+		chains = append(chains, c)
 	}
 
-	for {
-		select {
-		case err := <-linkErrCh:
-			if err != nil {
-				return err
-			}
+	var err error
+	select {
+	case err = <-errCh:
+		for _, c := range chains {
+			c.Stop()
+		}
+		for i := 1; i < len(chains); i++ {
+			<-errCh
 		}
+	case <-stop:
+		stopChains()
 	}
-	return nil
+	return err
+}
+
+// reverseConfig returns copy of cfg with swapped Src and Dst,
+// it keeps FilePath and BaseDir so that the database of the chain is located properly.
+func reverseConfig(cfg chain.Config) chain.Config {
+	cfg.Src, cfg.Dst = cfg.Dst, cfg.Src
+	return cfg
 }
 
-func newChain(name string, cfg chain.Config, l log.Logger, w wallet.Wallet, linkErrCh chan error) (chain.Chain, error) {
-	var chain chain.Chain
+func newChain(name string, cfg chain.Config, l log.Logger) (chain.Chain, error) {
 	switch name {
 	case ICON:
-		chain = iconChain.NewChain(&cfg, l)
+		return iconChain.NewChain(&cfg, l), nil
 	case ETH:
-		chain = bscChain.NewChain(&cfg, l)
+		return bscChain.NewChain(&cfg, l), nil
 	default:
 		return nil, fmt.Errorf("Not supported for chain:%s", name)
 	}
-
-	go func() {
-		err := chain.Serve(newSender(cfg.Src.Address.BlockChain(), cfg.Src, cfg.Dst, w, l))
-		select {
-		case linkErrCh <- err:
-		default:
-		}
-	}()
-
-	return chain, nil
 }
 
-func newSender(s string, srcCfg chain.BaseConfig, dstCfg chain.BaseConfig, w wallet.Wallet, l log.Logger) chain.Sender {
-	var sender chain.Sender
+func newSender(s string, cfg chain.Config, w wallet.Wallet, l log.Logger) (chain.Sender, error) {
+	var (
+		sender chain.Sender
+		method string
+		decode chain.DecodeFunc
+		err    error
+	)
+	srcCfg, dstCfg := cfg.Src, cfg.Dst
 
 	switch s {
 	case ICON:
-		sender = icon.NewSender(srcCfg.Address, dstCfg.Address, w, dstCfg.Endpoint, srcCfg.Options, l)
+		if sender, err = icon.NewSender(srcCfg.Address, dstCfg.Address, w, dstCfg.EndpointList(), srcCfg.Options, l); err != nil {
+			return nil, err
+		}
+		method, decode = icon.BMCRelayMethod, icon.DecodeRelayMessage
 	case ETH:
-		sender = bsc.NewSender(srcCfg.Address, dstCfg.Address, w, dstCfg.Endpoint, nil, l)
+		if sender, err = bsc.NewSender(srcCfg.Address, dstCfg.Address, w, dstCfg.EndpointList(), nil, l); err != nil {
+			return nil, err
+		}
+		method, decode = bsc.BMCRelayMethod, bsc.DecodeRelayMessage
 	default:
 		l.Fatalf("Not supported for chain:%s", s)
-		return nil
+		return nil, nil
 	}
 
-	return sender
+	if cfg.DryRun != "" {
+		output := cfg.DryRun
+		if output != dryrun.Stdout {
+			output = cfg.ResolveAbsolute(output)
+		}
+		r, err := dryrun.NewRecorder(output)
+		if err != nil {
+			return nil, err
+		}
+		statusPath := cfg.DryRunStatus
+		if statusPath != "" {
+			statusPath = cfg.ResolveAbsolute(statusPath)
+		}
+		t := dryrun.NewTracker(statusPath, srcCfg.Address.NetworkAddress())
+		l.Warnf("dry-run mode, segments are recorded to %s instead of sending", cfg.DryRun)
+		sender = chain.NewDryRunSender(sender, srcCfg.Address, dstCfg.Address, method, decode, r, t)
+	}
+	return sender, nil
 }
@@ -17,8 +17,8 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	stdlog "log"
 	"os"
@@ -29,10 +29,14 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/icon-project/btp/common/admin"
 	"github.com/icon-project/btp/common/cli"
+	"github.com/icon-project/btp/common/config"
 	"github.com/icon-project/btp/common/crypto"
 	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/health"
 	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
 	"github.com/icon-project/btp/common/wallet"
 )
 
@@ -56,14 +60,25 @@ type Config struct {
 	ConsoleLevel string               `json:"console_level"`
 	LogForwarder *log.ForwarderConfig `json:"log_forwarder,omitempty"`
 	LogWriter    *log.WriterConfig    `json:"log_writer,omitempty"`
+
+	MetricsAddress string `json:"metrics_address,omitempty"`
+	AdminAddress   string `json:"admin_address,omitempty"`
+	AdminReadOnly  bool   `json:"admin_read_only,omitempty"`
 }
 
-func (c *Config) Wallet(passwd, secret string, keyStore json.RawMessage) (wallet.Wallet, error) {
-	pw, err := c.resolvePassword(secret, passwd)
+// Wallet returns the wallet of the chain, it requests signing to the signer or PKCS#11 token if it's configured.
+func (c *Config) Wallet(bc *chain.BaseConfig) (wallet.Wallet, error) {
+	if bc.Signer.Enabled() {
+		return wallet.NewRemoteWallet(bc.Signer)
+	}
+	if bc.PKCS11.Enabled() {
+		return wallet.NewPKCS11Wallet(bc.PKCS11)
+	}
+	pw, err := c.resolvePassword(bc.KeySecret, bc.KeyStorePass)
 	if err != nil {
 		return nil, err
 	}
-	return wallet.DecryptKeyStore(keyStore, pw)
+	return wallet.DecryptKeyStore(bc.KeyStoreData, pw)
 }
 
 func (c *Config) resolvePassword(keySecret, keyStorePass string) ([]byte, error) {
@@ -78,39 +93,39 @@ func (c *Config) resolvePassword(keySecret, keyStorePass string) ([]byte, error)
 	}
 }
 
-func (c *Config) EnsureWallet() error {
-	srcPw, err := c.resolvePassword(c.Src.KeySecret, c.Src.KeyStorePass)
-	dstPw, err := c.resolvePassword(c.Dst.KeySecret, c.Dst.KeyStorePass)
+// ensureKeyStore generates KeyStore if it's empty, it does nothing if the signer is configured.
+// for PKCS#11 token, the key is generated in the token and only the reference is kept.
+func (c *Config) ensureKeyStore(bc *chain.BaseConfig) error {
+	if bc.Signer.Enabled() {
+		return nil
+	}
+	if bc.PKCS11.Enabled() {
+		return wallet.EnsurePKCS11Key(bc.PKCS11)
+	}
+	pw, err := c.resolvePassword(bc.KeySecret, bc.KeyStorePass)
 	if err != nil {
 		return err
 	}
-	if len(c.Src.KeyStoreData) < 1 {
-		src_prikey, _ := crypto.GenerateKeyPair()
-		if ks, err := wallet.EncryptKeyAsKeyStore(src_prikey, srcPw); err != nil {
+	if len(bc.KeyStoreData) < 1 {
+		priK, _ := crypto.GenerateKeyPair()
+		if ks, err := wallet.EncryptKeyAsKeyStore(priK, pw); err != nil {
 			return err
 		} else {
-			c.Src.KeyStoreData = ks
+			bc.KeyStoreData = ks
 		}
 	} else {
-		if _, err := wallet.DecryptKeyStore(c.Src.KeyStoreData, srcPw); err != nil {
+		if _, err := wallet.DecryptKeyStore(bc.KeyStoreData, pw); err != nil {
 			return errors.Errorf("fail to decrypt KeyStore err=%+v", err)
 		}
 	}
+	return nil
+}
 
-	if len(c.Dst.KeyStoreData) < 1 {
-		dst_prikey, _ := crypto.GenerateKeyPair()
-		if ks, err := wallet.EncryptKeyAsKeyStore(dst_prikey, dstPw); err != nil {
-			return err
-		} else {
-			c.Dst.KeyStoreData = ks
-		}
-	} else {
-		if _, err := wallet.DecryptKeyStore(c.Dst.KeyStoreData, dstPw); err != nil {
-			return errors.Errorf("fail to decrypt KeyStore err=%+v", err)
-		}
+func (c *Config) EnsureWallet() error {
+	if err := c.ensureKeyStore(&c.Src); err != nil {
+		return err
 	}
-
-	return nil
+	return c.ensureKeyStore(&c.Dst)
 }
 
 var logoLines = []string{
@@ -167,9 +182,11 @@ func main() {
 	rootPFlags := rootCmd.PersistentFlags()
 	rootPFlags.String("src.address", "", "BTP Address of source blockchain (PROTOCOL://NID.BLOCKCHAIN/BMC)")
 	rootPFlags.String("src.endpoint", "", "Endpoint of source blockchain")
+	rootPFlags.StringSlice("src.endpoints", nil, "Additional endpoints of source blockchain for failover, comma-separated")
 	rootPFlags.StringToString("src.options", nil, "Options, comma-separated 'key=value'")
 	rootPFlags.String("dst.address", "", "BTP Address of destination blockchain (PROTOCOL://NID.BLOCKCHAIN/BMC)")
 	rootPFlags.String("dst.endpoint", "", "Endpoint of destination blockchain")
+	rootPFlags.StringSlice("dst.endpoints", nil, "Additional endpoints of destination blockchain for failover, comma-separated")
 	rootPFlags.StringToString("dst.options", nil, "Options, comma-separated 'key=value'")
 
 	//BTP2.0
@@ -177,19 +194,43 @@ func main() {
 	rootPFlags.Int64("src.key_store", 1, "Source network id")
 	rootPFlags.Int64("src.key_password", 1, "Source password of keyStore")
 	rootPFlags.Int64("src.key_secret", 1, "Source Secret(password) file for keyStore")
+	rootPFlags.String("src.signer.endpoint", "", "Source signer endpoint (ex: unix:///tmp/signer.sock), keyStore is not used if it's given")
+	rootPFlags.String("src.signer.address", "", "Source account of signer, the only account of signer is used if empty")
+	rootPFlags.String("src.pkcs11.library", "", "Source PKCS#11 library (ex: /usr/lib/softhsm/libsofthsm2.so), keyStore is not used if it's given")
+	rootPFlags.String("src.pkcs11.token_label", "", "Source PKCS#11 token label")
+	rootPFlags.String("src.pkcs11.key_label", "", "Source PKCS#11 key label")
+	rootPFlags.String("src.pkcs11.coin_type", "", "Source PKCS#11 key type (icx,evm)")
+	rootPFlags.String("src.pkcs11.pin", "", "Source PKCS#11 user PIN")
+	rootPFlags.String("src.pkcs11.pin_file", "", "Source PKCS#11 user PIN file")
 
 	rootPFlags.Int64("dst.nid", 1, "Destination network id")
 	rootPFlags.Int64("dst.key_store", 1, "Destination network id")
 	rootPFlags.Int64("dst.key_password", 1, "Destination password of keyStore")
 	rootPFlags.Int64("dst.key_secret", 1, "Destination Secret(password) file for keyStore")
+	rootPFlags.String("dst.signer.endpoint", "", "Destination signer endpoint (ex: unix:///tmp/signer.sock), keyStore is not used if it's given")
+	rootPFlags.String("dst.signer.address", "", "Destination account of signer, the only account of signer is used if empty")
+	rootPFlags.String("dst.pkcs11.library", "", "Destination PKCS#11 library (ex: /usr/lib/softhsm/libsofthsm2.so), keyStore is not used if it's given")
+	rootPFlags.String("dst.pkcs11.token_label", "", "Destination PKCS#11 token label")
+	rootPFlags.String("dst.pkcs11.key_label", "", "Destination PKCS#11 key label")
+	rootPFlags.String("dst.pkcs11.coin_type", "", "Destination PKCS#11 key type (icx,evm)")
+	rootPFlags.String("dst.pkcs11.pin", "", "Destination PKCS#11 user PIN")
+	rootPFlags.String("dst.pkcs11.pin_file", "", "Destination PKCS#11 user PIN file")
 
 	rootPFlags.String("direction", "both", "btp2.0 network direction ( both, front, reverse)")
 	rootPFlags.Bool("maxSizeTx", false, "Send when the maximum transaction size is reached")
+	rootPFlags.Int("flush_latency", 0, "Milliseconds to send pending messages with maxSizeTx, disabled if zero")
+	rootPFlags.Int("max_events_tx", 0, "Max number of events in a transaction, unlimited if zero")
+	rootPFlags.Bool("verify", false, "Verify relay messages locally before sending, failed ones are held back")
+	rootPFlags.String("dry_run", "", "Output path of dry-run records, '-' for standard output, transactions are not sent if it's given (use separate base_dir)")
+	rootPFlags.String("dry_run_status", "", "Status file for dry-run confirmation, JSON object of {\"height\",\"rx_seq\"} keyed by network address of source")
 
 	rootPFlags.Int64("offset", 0, "Offset of MTA")
 
 	//
 	rootPFlags.String("base_dir", "", "Base directory for data")
+	rootPFlags.String("metrics_address", "", "Address of metrics and health probe endpoint (ex: 0.0.0.0:9090), disabled if empty")
+	rootPFlags.String(admin.FlagAddress, "", "Address of admin endpoint (ex: unix:///tmp/btp2.sock), disabled if empty")
+	rootPFlags.Bool(admin.FlagReadOnly, false, "Disallow admin requests which change state of link")
 	rootPFlags.StringP("config", "c", "", "Parsing configuration file")
 	//
 	rootPFlags.String("log_level", "debug", "Global log level (trace,debug,info,warn,error,fatal,panic)")
@@ -222,6 +263,12 @@ func main() {
 			} else {
 				cfg.Src.KeyStorePass = ""
 				cfg.Dst.KeyStorePass = ""
+				if cfg.Src.PKCS11 != nil {
+					cfg.Src.PKCS11.PIN = ""
+				}
+				if cfg.Dst.PKCS11 != nil {
+					cfg.Dst.PKCS11.PIN = ""
+				}
 			}
 			return nil
 		},
@@ -273,25 +320,18 @@ func main() {
 				srcWallet wallet.Wallet
 				dstWallet wallet.Wallet
 			)
-			if srcWallet, err = cfg.Wallet(cfg.Src.KeyStorePass, cfg.Src.KeySecret, cfg.Src.KeyStoreData); err != nil {
+			if srcWallet, err = cfg.Wallet(&cfg.Src); err != nil {
 				return err
 			}
 
-			if dstWallet, err = cfg.Wallet(cfg.Dst.KeyStorePass, cfg.Dst.KeySecret, cfg.Dst.KeyStoreData); err != nil {
-				return err
-			}
-
-			var srcKsData wallet.KeyStoreData
-			var dstKsData wallet.KeyStoreData
-			if err := json.Unmarshal(cfg.Src.KeyStoreData, &srcKsData); err != nil {
-				return err
-			}
-
-			if err := json.Unmarshal(cfg.Dst.KeyStoreData, &dstKsData); err != nil {
+			if dstWallet, err = cfg.Wallet(&cfg.Dst); err != nil {
 				return err
 			}
 			modLevels, _ := cmd.Flags().GetStringToString("mod_level")
 
+			metrics.Serve(cfg.MetricsAddress, func(err error) {
+				log.Errorf("fail to serve metrics address:%s err:%+v", cfg.MetricsAddress, err)
+			}, health.RegisterHandlers)
 			return NewLink(cfg, srcWallet, dstWallet, modLevels)
 		},
 	}
@@ -304,6 +344,11 @@ func main() {
 
 	cli.BindPFlags(rootVc, startFlags)
 
+	admin.NewCommand(rootCmd, rootVc)
+	admin.NewFeesCommand(rootCmd, rootVc)
+	wallet.NewKeyStoreCommand(rootCmd, rootVc)
+	NewMultiCommand(rootCmd, rootVc)
+
 	genMdCmd := cli.NewGenerateMarkdownCommand(rootCmd, rootVc)
 	genMdCmd.Hidden = true
 
@@ -322,48 +367,62 @@ func setLogger(cfg *Config, w wallet.Wallet, modLevels map[string]string) log.Lo
 		if cfg.LogWriter.Filename == "" {
 			log.Debugln("LogWriterConfig filename is empty string, will be ignore")
 		} else {
-			var lwCfg log.WriterConfig
-			lwCfg = *cfg.LogWriter
-			lwCfg.Filename = cfg.ResolveAbsolute(lwCfg.Filename)
-			w, err := log.NewWriter(&lwCfg)
+			w, err := newFileWriter(&cfg.FileConfig, cfg.LogWriter)
 			if err != nil {
-				log.Panicf("Fail to make writer err=%+v", err)
+				log.Panicf("%+v", err)
 			}
-			err = l.SetFileWriter(w)
-			if err != nil {
+			if err = l.SetFileWriter(w); err != nil {
 				log.Panicf("Fail to set file l err=%+v", err)
 			}
 		}
 	}
+	if err := setLogLevels(l, cfg.LogLevel, cfg.ConsoleLevel, modLevels); err != nil {
+		log.Panicf("%+v", err)
+	}
+
+	if cfg.LogForwarder != nil {
+		if cfg.LogForwarder.Vendor == "" && cfg.LogForwarder.Address == "" {
+			log.Debugln("LogForwarderConfig vendor and address is empty string, will be ignore")
+		} else {
+			if err := log.AddForwarder(cfg.LogForwarder); err != nil {
+				log.Fatalf("Invalid log_forwarder err:%+v", err)
+			}
+		}
+	}
+
+	return l
+}
+
+// newFileWriter returns the writer of log file, the filename is resolved from the configuration file.
+func newFileWriter(fc *config.FileConfig, wc *log.WriterConfig) (io.Writer, error) {
+	var lwCfg log.WriterConfig
+	lwCfg = *wc
+	lwCfg.Filename = fc.ResolveAbsolute(lwCfg.Filename)
+	w, err := log.NewWriter(&lwCfg)
+	if err != nil {
+		return nil, fmt.Errorf("Fail to make writer err=%+v", err)
+	}
+	return w, nil
+}
 
-	if lv, err := log.ParseLevel(cfg.LogLevel); err != nil {
-		log.Panicf("Invalid log_level=%s", cfg.LogLevel)
+func setLogLevels(l log.Logger, logLevel, consoleLevel string, modLevels map[string]string) error {
+	if lv, err := log.ParseLevel(logLevel); err != nil {
+		return fmt.Errorf("Invalid log_level=%s", logLevel)
 	} else {
 		l.SetLevel(lv)
 	}
-	if lv, err := log.ParseLevel(cfg.ConsoleLevel); err != nil {
-		log.Panicf("Invalid console_level=%s", cfg.ConsoleLevel)
+	if lv, err := log.ParseLevel(consoleLevel); err != nil {
+		return fmt.Errorf("Invalid console_level=%s", consoleLevel)
 	} else {
 		l.SetConsoleLevel(lv)
 	}
 
 	for mod, lvStr := range modLevels {
 		if lv, err := log.ParseLevel(lvStr); err != nil {
-			log.Panicf("Invalid mod_level mod=%s level=%s", mod, lvStr)
+			return fmt.Errorf("Invalid mod_level mod=%s level=%s", mod, lvStr)
 		} else {
 			l.SetModuleLevel(mod, lv)
 		}
 	}
-
-	if cfg.LogForwarder != nil {
-		if cfg.LogForwarder.Vendor == "" && cfg.LogForwarder.Address == "" {
-			log.Debugln("LogForwarderConfig vendor and address is empty string, will be ignore")
-		} else {
-			if err := log.AddForwarder(cfg.LogForwarder); err != nil {
-				log.Fatalf("Invalid log_forwarder err:%+v", err)
-			}
-		}
-	}
-
-	return l
+	return nil
 }
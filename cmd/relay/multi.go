@@ -0,0 +1,447 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	stdlog "log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/admin"
+	"github.com/icon-project/btp/common/cli"
+	"github.com/icon-project/btp/common/config"
+	"github.com/icon-project/btp/common/health"
+	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/metrics"
+	"github.com/icon-project/btp/common/wallet"
+)
+
+const (
+	DefaultRestartDelay    = 5 * time.Second
+	DefaultMaxRestartDelay = 5 * time.Minute
+	DefaultMultiBaseDir    = ".btp2"
+
+	FieldKeyLink = "link"
+)
+
+// MultiConfig is the configuration of the daemon which serves multiple links in a process.
+// logging options are used for links which don't have them, metrics and admin are shared by links.
+// only links and restart delays are applied on reload.
+type MultiConfig struct {
+	config.FileConfig `json:",squash"`
+	LogLevel          string               `json:"log_level,omitempty"`
+	ConsoleLevel      string               `json:"console_level,omitempty"`
+	LogForwarder      *log.ForwarderConfig `json:"log_forwarder,omitempty"`
+	LogWriter         *log.WriterConfig    `json:"log_writer,omitempty"`
+
+	MetricsAddress string `json:"metrics_address,omitempty"`
+	AdminAddress   string `json:"admin_address,omitempty"`
+	AdminReadOnly  bool   `json:"admin_read_only,omitempty"`
+
+	// RestartDelay is the delay to restart the failed link, it's doubled up to MaxRestartDelay on consecutive failures
+	RestartDelay    common.Duration `json:"restart_delay,omitempty"`
+	MaxRestartDelay common.Duration `json:"max_restart_delay,omitempty"`
+
+	Links []*LinkConfig `json:"links"`
+}
+
+// LinkConfig is the configuration of a link in MultiConfig, it's same as the configuration of btp2.
+// if File is given, the configuration is loaded from File which is saved by 'btp2 save',
+// and Name overrides the name in File.
+type LinkConfig struct {
+	Config `json:",squash"`
+	File   string `json:"file,omitempty"`
+}
+
+// LoadMultiConfig reads MultiConfig and configurations of links from file,
+// default values are filled and links are validated.
+func LoadMultiConfig(file string) (*MultiConfig, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	mc := &MultiConfig{}
+	if err = json.Unmarshal(b, mc); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal config file=%s err=%+v", file, err)
+	}
+	mc.FilePath, _ = filepath.Abs(file)
+	if mc.BaseDir == "" {
+		mc.BaseDir = DefaultMultiBaseDir
+	}
+	if mc.LogLevel == "" {
+		mc.LogLevel = "debug"
+	}
+	if mc.ConsoleLevel == "" {
+		mc.ConsoleLevel = "trace"
+	}
+	if mc.RestartDelay <= 0 {
+		mc.RestartDelay = common.Duration(DefaultRestartDelay)
+	}
+	if mc.MaxRestartDelay < mc.RestartDelay {
+		mc.MaxRestartDelay = common.Duration(DefaultMaxRestartDelay)
+		if mc.MaxRestartDelay < mc.RestartDelay {
+			mc.MaxRestartDelay = mc.RestartDelay
+		}
+	}
+	names := make(map[string]bool)
+	for i, lc := range mc.Links {
+		if err = mc.loadLink(lc); err != nil {
+			return nil, fmt.Errorf("invalid link index=%d err=%+v", i, err)
+		}
+		if names[lc.Name] {
+			return nil, fmt.Errorf("duplicated link name=%s", lc.Name)
+		}
+		names[lc.Name] = true
+	}
+	return mc, nil
+}
+
+func (mc *MultiConfig) loadLink(lc *LinkConfig) error {
+	if lc.File != "" {
+		file := mc.ResolveAbsolute(lc.File)
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		name := lc.Name
+		lc.Config = Config{}
+		if err = json.Unmarshal(b, &lc.Config); err != nil {
+			return fmt.Errorf("fail to unmarshal config file=%s err=%+v", file, err)
+		}
+		lc.FilePath = file
+		if name != "" {
+			lc.Name = name
+		}
+	} else {
+		lc.FilePath = mc.FilePath
+	}
+	if lc.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if lc.Direction == "" {
+		lc.Direction = BothDirection
+	}
+	if lc.BaseDir == "" {
+		//databases of links are isolated by name of link
+		lc.BaseDir = filepath.Join(mc.AbsBaseDir(), lc.Name)
+	}
+	if lc.LogLevel == "" {
+		lc.LogLevel = mc.LogLevel
+	}
+	if lc.ConsoleLevel == "" {
+		lc.ConsoleLevel = mc.ConsoleLevel
+	}
+	if _, err := log.ParseLevel(lc.LogLevel); err != nil {
+		return fmt.Errorf("Invalid log_level=%s", lc.LogLevel)
+	}
+	if _, err := log.ParseLevel(lc.ConsoleLevel); err != nil {
+		return fmt.Errorf("Invalid console_level=%s", lc.ConsoleLevel)
+	}
+	return nil
+}
+
+// link is a link served by linkManager, it's restarted until stop is closed.
+type link struct {
+	cfg  *LinkConfig
+	data []byte
+	l    log.Logger
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (k *link) stopped() bool {
+	select {
+	case <-k.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// linkManager serves links of MultiConfig, each link has its own logger, databases and wallets.
+// the failed link is restarted with backoff, and the other links are not affected.
+type linkManager struct {
+	mtx       sync.Mutex
+	cfg       *MultiConfig
+	links     map[string]*link
+	as        *admin.Server
+	fw        io.Writer
+	modLevels map[string]string
+	l         log.Logger
+
+	serve func(k *link) error
+}
+
+func newLinkManager(mc *MultiConfig, as *admin.Server, fw io.Writer, modLevels map[string]string, l log.Logger) *linkManager {
+	m := &linkManager{
+		cfg:       mc,
+		links:     make(map[string]*link),
+		as:        as,
+		fw:        fw,
+		modLevels: modLevels,
+		l:         l,
+	}
+	m.serve = m.serveLink
+	return m
+}
+
+// Apply starts links which are added or changed, and stops links which are removed or changed,
+// the links which are not changed keep running.
+func (m *linkManager) Apply(mc *MultiConfig) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.cfg = mc
+	next := make(map[string][]byte)
+	for _, lc := range mc.Links {
+		b, err := json.Marshal(lc)
+		if err != nil {
+			m.l.Errorf("fail to marshal link:%s err:%+v", lc.Name, err)
+			continue
+		}
+		next[lc.Name] = b
+	}
+	for name, k := range m.links {
+		if b, ok := next[name]; ok && string(b) == string(k.data) {
+			continue
+		}
+		m.l.Infof("stop link:%s", name)
+		close(k.stop)
+		<-k.done
+		delete(m.links, name)
+		m.as.UnregisterLogger(name)
+	}
+	for _, lc := range mc.Links {
+		b, ok := next[lc.Name]
+		if !ok {
+			continue
+		}
+		if _, ok = m.links[lc.Name]; ok {
+			continue
+		}
+		l, err := m.newLogger(&lc.Config)
+		if err != nil {
+			m.l.Errorf("fail to start link:%s err:%+v", lc.Name, err)
+			continue
+		}
+		m.l.Infof("start link:%s", lc.Name)
+		k := &link{
+			cfg:  lc,
+			data: b,
+			l:    l,
+			stop: make(chan struct{}),
+			done: make(chan struct{}),
+		}
+		m.links[lc.Name] = k
+		m.as.RegisterLogger(lc.Name, l)
+		go m.run(k, time.Duration(mc.RestartDelay), time.Duration(mc.MaxRestartDelay))
+	}
+}
+
+// Names returns names of running links.
+func (m *linkManager) Names() []string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	l := make([]string, 0, len(m.links))
+	for name := range m.links {
+		l = append(l, name)
+	}
+	return l
+}
+
+// Close stops all links.
+func (m *linkManager) Close() {
+	m.Apply(&MultiConfig{})
+}
+
+func (m *linkManager) run(k *link, delay, maxDelay time.Duration) {
+	defer close(k.done)
+	minDelay := delay
+	for {
+		started := time.Now()
+		err := m.serve(k)
+		if k.stopped() {
+			return
+		}
+		if time.Since(started) > maxDelay {
+			delay = minDelay
+		}
+		m.l.Errorf("link:%s is stopped, restart after %v err:%+v", k.cfg.Name, delay, err)
+		select {
+		case <-k.stop:
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// serveLink opens wallets and serves the link until it's stopped or failed.
+func (m *linkManager) serveLink(k *link) error {
+	cfg := k.cfg.Config
+	srcWallet, err := cfg.Wallet(&cfg.Src)
+	if err != nil {
+		return err
+	}
+	defer closeWallet(srcWallet)
+	dstWallet, err := cfg.Wallet(&cfg.Dst)
+	if err != nil {
+		return err
+	}
+	defer closeWallet(dstWallet)
+	return serveLink(&cfg, srcWallet, dstWallet, func(w wallet.Wallet) log.Logger {
+		return k.l.WithFields(log.Fields{log.FieldKeyWallet: w.Address()[2:]})
+	}, m.as, k.stop)
+}
+
+func closeWallet(w wallet.Wallet) {
+	if c, ok := w.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
+// newLogger returns the logger of the link which is not derived from the global logger,
+// the log file of the daemon is used if the link doesn't have log_writer.
+func (m *linkManager) newLogger(cfg *Config) (log.Logger, error) {
+	l := log.New()
+	if cfg.LogWriter != nil && cfg.LogWriter.Filename != "" {
+		w, err := newFileWriter(&cfg.FileConfig, cfg.LogWriter)
+		if err != nil {
+			return nil, err
+		}
+		if err = l.SetFileWriter(w); err != nil {
+			return nil, err
+		}
+	} else if m.fw != nil {
+		if err := l.SetFileWriter(m.fw); err != nil {
+			return nil, err
+		}
+	}
+	if err := setLogLevels(l, cfg.LogLevel, cfg.ConsoleLevel, m.modLevels); err != nil {
+		return nil, err
+	}
+	fc := cfg.LogForwarder
+	if fc == nil {
+		fc = m.cfg.LogForwarder
+	}
+	if fc != nil && (fc.Vendor != "" || fc.Address != "") {
+		if err := log.AddForwarderTo(l, fc); err != nil {
+			return nil, fmt.Errorf("Invalid log_forwarder err:%+v", err)
+		}
+	}
+	return l.WithFields(log.Fields{FieldKeyLink: cfg.Name}), nil
+}
+
+// setMultiLogger configures the global logger by MultiConfig, it returns the writer of log file if it's configured.
+func setMultiLogger(mc *MultiConfig, modLevels map[string]string) (io.Writer, error) {
+	l := log.GlobalLogger()
+	stdlog.SetOutput(l.WriterLevel(log.WarnLevel))
+	var fw io.Writer
+	if mc.LogWriter != nil && mc.LogWriter.Filename != "" {
+		var err error
+		if fw, err = newFileWriter(&mc.FileConfig, mc.LogWriter); err != nil {
+			return nil, err
+		}
+		if err = l.SetFileWriter(fw); err != nil {
+			return nil, err
+		}
+	}
+	if err := setLogLevels(l, mc.LogLevel, mc.ConsoleLevel, modLevels); err != nil {
+		return nil, err
+	}
+	if mc.LogForwarder != nil && (mc.LogForwarder.Vendor != "" || mc.LogForwarder.Address != "") {
+		if err := log.AddForwarder(mc.LogForwarder); err != nil {
+			return nil, fmt.Errorf("Invalid log_forwarder err:%+v", err)
+		}
+	}
+	return fw, nil
+}
+
+// NewMultiCommand returns multi command which serves multiple links in a process.
+func NewMultiCommand(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Command {
+	rootCmd, _ := cli.NewCommand(parentCmd, parentVc, "multi", "Serve multiple links in a process")
+	//configuration of single link is not used
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return nil
+	}
+
+	startCmd := &cobra.Command{
+		Use:   "start CONFIG",
+		Short: "Start server for links of CONFIG, links are reloaded from CONFIG by SIGHUP",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mc, err := LoadMultiConfig(args[0])
+			if err != nil {
+				return err
+			}
+			modLevels, _ := cmd.Flags().GetStringToString("mod_level")
+			fw, err := setMultiLogger(mc, modLevels)
+			if err != nil {
+				return err
+			}
+			for _, l := range logoLines {
+				log.Println(l)
+			}
+			log.Printf("Version : %s", version)
+			log.Printf("Build   : %s", build)
+
+			metrics.Serve(mc.MetricsAddress, func(err error) {
+				log.Errorf("fail to serve metrics address:%s err:%+v", mc.MetricsAddress, err)
+			}, health.RegisterHandlers)
+			as := admin.Serve(mc.AdminAddress, mc.AdminReadOnly, log.GlobalLogger())
+			m := newLinkManager(mc, as, fw, modLevels, log.GlobalLogger())
+			m.Apply(mc)
+
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, syscall.SIGHUP, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(sigCh)
+			for sig := range sigCh {
+				if sig == syscall.SIGHUP {
+					if nmc, err := LoadMultiConfig(args[0]); err != nil {
+						log.Errorf("fail to reload config, links are not changed err:%+v", err)
+					} else {
+						log.Infof("reload config file=%s", nmc.FilePath)
+						m.Apply(nmc)
+					}
+					continue
+				}
+				log.Infof("stop links by signal:%v", sig)
+				m.Close()
+				break
+			}
+			return nil
+		},
+	}
+	rootCmd.AddCommand(startCmd)
+	startFlags := startCmd.Flags()
+	startFlags.StringToString("mod_level", nil, "Set console log level for specific module ('mod'='level',...)")
+	startFlags.MarkHidden("mod_level")
+	return rootCmd
+}
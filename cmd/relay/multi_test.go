@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/log"
+)
+
+func writeTestFile(t *testing.T, dir, name, data string) string {
+	file := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(file, []byte(data), 0644))
+	return file
+}
+
+func TestLoadMultiConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multi")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeTestFile(t, dir, "bsc.json", `{"name":"saved","direction":"front","log_level":"info",
+		"src":{"address":"btp://0x1.icon/cx0000000000000000000000000000000000000001"}}`)
+	file := writeTestFile(t, dir, "multi.json", `{"log_level":"warn","restart_delay":"1s","links":[
+		{"name":"bsc","file":"bsc.json"},
+		{"name":"eth","src":{"address":"btp://0x1.icon/cx0000000000000000000000000000000000000001"}}]}`)
+	mc, err := LoadMultiConfig(file)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Duration(time.Second), mc.RestartDelay)
+	assert.Equal(t, common.Duration(DefaultMaxRestartDelay), mc.MaxRestartDelay)
+	assert.Equal(t, 2, len(mc.Links))
+
+	bsc := mc.Links[0]
+	assert.Equal(t, "bsc", bsc.Name)
+	assert.Equal(t, FrontDirection, bsc.Direction)
+	assert.Equal(t, "info", bsc.LogLevel)
+	assert.Equal(t, filepath.Join(dir, "bsc.json"), bsc.FilePath)
+
+	eth := mc.Links[1]
+	assert.Equal(t, BothDirection, eth.Direction)
+	assert.Equal(t, "warn", eth.LogLevel)
+	assert.Equal(t, "trace", eth.ConsoleLevel)
+	assert.NotEqual(t, bsc.AbsBaseDir(), eth.AbsBaseDir())
+	assert.Equal(t, filepath.Join(dir, DefaultMultiBaseDir, "eth"), eth.AbsBaseDir())
+
+	for _, invalid := range []string{
+		`{"links":[{"direction":"front"}]}`,
+		`{"links":[{"name":"a"},{"name":"a"}]}`,
+		`{"links":[{"name":"a","log_level":"invalid"}]}`,
+		`{"links":[{"name":"a","file":"notfound.json"}]}`,
+	} {
+		file = writeTestFile(t, dir, "invalid.json", invalid)
+		_, err = LoadMultiConfig(file)
+		assert.Error(t, err, invalid)
+	}
+}
+
+type testServer struct {
+	mtx     sync.Mutex
+	starts  map[string]int
+	fail    map[string]bool
+	started chan string
+}
+
+func (s *testServer) serve(k *link) error {
+	s.mtx.Lock()
+	s.starts[k.cfg.Name]++
+	fail := s.fail[k.cfg.Name]
+	delete(s.fail, k.cfg.Name)
+	s.mtx.Unlock()
+	s.started <- k.cfg.Name
+	if fail {
+		return errors.New("fail")
+	}
+	<-k.stop
+	return nil
+}
+
+func (s *testServer) count(name string) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.starts[name]
+}
+
+func (s *testServer) wait(t *testing.T, names ...string) {
+	for range names {
+		select {
+		case <-s.started:
+		case <-time.After(time.Second):
+			t.Fatalf("timeout to start links:%v", names)
+		}
+	}
+}
+
+func testMultiConfig(links ...*LinkConfig) *MultiConfig {
+	return &MultiConfig{
+		RestartDelay:    common.Duration(10 * time.Millisecond),
+		MaxRestartDelay: common.Duration(100 * time.Millisecond),
+		Links:           links,
+	}
+}
+
+func testLinkConfig(name, direction string) *LinkConfig {
+	lc := &LinkConfig{}
+	lc.Name = name
+	lc.Direction = direction
+	lc.LogLevel = "info"
+	lc.ConsoleLevel = "info"
+	return lc
+}
+
+func sortedNames(m *linkManager) []string {
+	l := m.Names()
+	sort.Strings(l)
+	return l
+}
+
+func TestLinkManager(t *testing.T) {
+	s := &testServer{
+		starts:  make(map[string]int),
+		fail:    map[string]bool{"b": true},
+		started: make(chan string, 10),
+	}
+	mc := testMultiConfig(testLinkConfig("a", FrontDirection), testLinkConfig("b", BothDirection))
+	m := newLinkManager(mc, nil, nil, nil, log.New())
+	m.serve = s.serve
+	m.Apply(mc)
+	//b is restarted after failure, a is not affected
+	s.wait(t, "a", "b", "b")
+	assert.Equal(t, []string{"a", "b"}, sortedNames(m))
+	assert.Equal(t, 1, s.count("a"))
+	assert.Equal(t, 2, s.count("b"))
+
+	//b is removed, c is added and a is restarted by change
+	m.Apply(testMultiConfig(testLinkConfig("a", BothDirection), testLinkConfig("c", BothDirection)))
+	s.wait(t, "a", "c")
+	assert.Equal(t, []string{"a", "c"}, sortedNames(m))
+	assert.Equal(t, 2, s.count("a"))
+	assert.Equal(t, 2, s.count("b"))
+
+	//unchanged links keep running
+	m.Apply(testMultiConfig(testLinkConfig("a", BothDirection), testLinkConfig("c", BothDirection)))
+	assert.Equal(t, 2, s.count("a"))
+	assert.Equal(t, 1, s.count("c"))
+
+	m.Close()
+	assert.Equal(t, 0, len(m.Names()))
+}
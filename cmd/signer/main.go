@@ -0,0 +1,153 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/cobra"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/cli"
+	"github.com/icon-project/btp/common/log"
+	"github.com/icon-project/btp/common/wallet"
+)
+
+var (
+	version = "unknown"
+	build   = "unknown"
+)
+
+const (
+	DefaultAddress = "unix:///tmp/signer.sock"
+	unixPrefix     = "unix://"
+)
+
+// checkAddress allows only unix socket, because requests are not authenticated,
+// access to the signer is limited by permission of the socket file.
+func checkAddress(address string) error {
+	if !strings.HasPrefix(address, unixPrefix) {
+		return fmt.Errorf("only unix socket is allowed address=%s", address)
+	}
+	return nil
+}
+
+// resolvePassword returns the password of i-th KeyStore, the last one is used for the rest.
+func resolvePassword(secrets, passwords []string, i int) ([]byte, error) {
+	if len(secrets) > 0 {
+		if i >= len(secrets) {
+			i = len(secrets) - 1
+		}
+		return ioutil.ReadFile(secrets[i])
+	}
+	if len(passwords) > 0 {
+		if i >= len(passwords) {
+			i = len(passwords) - 1
+		}
+		return []byte(passwords[i]), nil
+	}
+	return nil, fmt.Errorf("password of KeyStore is required")
+}
+
+func loadWallets(keyStores, secrets, passwords []string) ([]wallet.Wallet, error) {
+	ws := make([]wallet.Wallet, 0, len(keyStores))
+	for i, ks := range keyStores {
+		b, err := ioutil.ReadFile(ks)
+		if err != nil {
+			return nil, err
+		}
+		pw, err := resolvePassword(secrets, passwords, i)
+		if err != nil {
+			return nil, err
+		}
+		w, err := wallet.DecryptKeyStore(b, pw)
+		if err != nil {
+			return nil, fmt.Errorf("fail to decrypt KeyStore file=%s err=%+v", ks, err)
+		}
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
+
+func main() {
+	rootCmd, rootVc := cli.NewCommand(nil, nil, "signer", "BTP Signer")
+	rootCmd.Long = "Signer which keeps keys of relay out of the relay process, " +
+		"the relay requests signing by JSON-RPC over unix socket"
+	cli.SetEnvKeyReplacer(rootVc, strings.NewReplacer(".", "_"))
+	rootPFlags := rootCmd.PersistentFlags()
+	rootPFlags.String("address", DefaultAddress, "Unix socket to serve (ex: unix:///tmp/signer.sock)")
+	rootPFlags.StringSlice("key_store", nil, "KeyStore files, comma-separated")
+	rootPFlags.StringSlice("key_password", nil, "Passwords of KeyStore in order, comma-separated, the last one is used for the rest")
+	rootPFlags.StringSlice("key_secret", nil, "Secret(password) files for KeyStore in order, comma-separated, the last one is used for the rest")
+	cli.BindPFlags(rootVc, rootPFlags)
+
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start signer",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Printf("Version : %s", version)
+			log.Printf("Build   : %s", build)
+
+			address := rootVc.GetString("address")
+			if err := checkAddress(address); err != nil {
+				return err
+			}
+			ws, err := loadWallets(rootVc.GetStringSlice("key_store"),
+				rootVc.GetStringSlice("key_secret"), rootVc.GetStringSlice("key_password"))
+			if err != nil {
+				return err
+			}
+			if len(ws) == 0 {
+				return fmt.Errorf("KeyStore is required")
+			}
+			s, err := wallet.NewSigner(ws...)
+			if err != nil {
+				return err
+			}
+			defer s.Stop()
+			for _, a := range s.Accounts() {
+				log.Infof("signer account:%s", a)
+			}
+			hs := common.NewHttpServer(address, nil)
+			e := hs.Echo()
+			e.HideBanner = true
+			e.HidePort = true
+			e.POST("/", echo.WrapHandler(s))
+			log.Infof("serve signer address:%s", address)
+			if err = hs.Start(); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+	}
+	rootCmd.AddCommand(startCmd)
+	wallet.NewKeyStoreCommand(rootCmd, rootVc)
+
+	genMdCmd := cli.NewGenerateMarkdownCommand(rootCmd, rootVc)
+	genMdCmd.Hidden = true
+
+	rootCmd.SilenceUsage = true
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Printf("%+v", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,398 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/fee"
+	"github.com/icon-project/btp/common/log"
+)
+
+const (
+	ContextPath = "/admin"
+	UrlLinks    = "/links"
+	UrlLog      = "/log"
+	UrlFees     = "/fees"
+
+	ParamLink   = "link"
+	ParamID     = "id"
+	ParamFormat = "format"
+)
+
+// LinkStatus is status of a direction of link
+type LinkStatus struct {
+	Name           string `json:"name"`
+	Src            string `json:"src"`
+	Dst            string `json:"dst"`
+	Paused         bool   `json:"paused"`
+	TxSeq          string `json:"tx_seq"`
+	RxSeq          string `json:"rx_seq"`
+	VerifierHeight int64  `json:"verifier_height"`
+	CurrentHeight  int64  `json:"current_height"`
+	Queued         int    `json:"queued"`
+	Quarantined    int    `json:"quarantined"`
+	Balance        string `json:"balance,omitempty"`
+	LowBalance     bool   `json:"low_balance,omitempty"`
+}
+
+// RelayMessage is queued relay message, ID is used to drop it
+type RelayMessage struct {
+	ID       string     `json:"id"`
+	Height   int64      `json:"height"`
+	Segments []*Segment `json:"segments"`
+}
+
+type Segment struct {
+	Height        int64  `json:"height"`
+	EventSequence string `json:"event_sequence,omitempty"`
+	NumberOfEvent int    `json:"number_of_event"`
+	TxHash        string `json:"tx_hash,omitempty"`
+	//Fragments are transactions of the segment which is sent by fragments
+	Fragments []*Fragment `json:"fragments,omitempty"`
+}
+
+// Fragment is the transaction of a part of the segment, TxHash is empty if it's not sent yet.
+type Fragment struct {
+	Index  int    `json:"index"`
+	TxHash string `json:"tx_hash,omitempty"`
+	Done   bool   `json:"done"`
+}
+
+// FeeReport is fees spent by the relay account of a direction of link,
+// Balance is the last known balance which is empty before the first poll.
+type FeeReport struct {
+	Name    string          `json:"name"`
+	Balance string          `json:"balance,omitempty"`
+	Level   fee.Level       `json:"level"`
+	Count   int64           `json:"count"`
+	Total   string          `json:"total"`
+	Days    []*fee.DailyFee `json:"days"`
+}
+
+type LogLevel struct {
+	Module string `json:"module,omitempty"`
+	Level  string `json:"level"`
+}
+
+// Link is a direction of link which could be inspected and controlled
+type Link interface {
+	Status() (*LinkStatus, error)
+	RelayMessages() ([]*RelayMessage, error)
+	// Pause stops sending of relay messages, receiving is not affected
+	Pause()
+	Resume()
+	// Refresh refreshes BMCLinkStatus and removes relayed messages
+	Refresh() error
+	// Drop removes the relay message (or segment) of id from the queue
+	Drop(id string) error
+}
+
+// FeeReporter is optional interface of Link which reports fees from its ledger
+type FeeReporter interface {
+	Fees() (*FeeReport, error)
+}
+
+type Server struct {
+	*common.HttpServer
+	mtx     sync.RWMutex
+	names   []string
+	links   map[string]Link
+	loggers map[string]log.Logger
+	l       log.Logger
+}
+
+// Register adds the link with name, it does nothing if s is nil.
+func (s *Server) Register(name string, link Link) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.links[name]; !ok {
+		s.names = append(s.names, name)
+	}
+	s.links[name] = link
+}
+
+// RegisterLogger adds the logger of name which is not derived from the global logger,
+// log levels set by admin are applied to it as well. it does nothing if s is nil.
+func (s *Server) RegisterLogger(name string, l log.Logger) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.loggers[name] = l
+}
+
+// UnregisterLogger removes the logger of name, it does nothing if s is nil.
+func (s *Server) UnregisterLogger(name string) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.loggers, name)
+}
+
+// Unregister removes the link of name, it does nothing if s is nil.
+func (s *Server) Unregister(name string) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.links[name]; !ok {
+		return
+	}
+	delete(s.links, name)
+	for i, n := range s.names {
+		if n == name {
+			s.names = append(s.names[:i], s.names[i+1:]...)
+			break
+		}
+	}
+}
+
+func (s *Server) link(ctx echo.Context) (Link, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	name := ctx.Param(ParamLink)
+	if link, ok := s.links[name]; ok {
+		return link, nil
+	}
+	return nil, echo.NewHTTPError(http.StatusNotFound, errors.NotFoundError.Errorf("not found link:%s", name).Error())
+}
+
+func (s *Server) status(name string, link Link) (*LinkStatus, error) {
+	ls, err := link.Status()
+	if err != nil {
+		return nil, err
+	}
+	ls.Name = name
+	return ls, nil
+}
+
+// response writes v as JSON, or formats v by the template of ParamFormat.
+// fields of the template are keys of JSON.
+func response(ctx echo.Context, v interface{}) error {
+	if format := ctx.QueryParam(ParamFormat); format != "" {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var jv interface{}
+		if err = json.Unmarshal(b, &jv); err != nil {
+			return err
+		}
+		if err = common.DefaultJsonTemplate.Response(format, jv, ctx.Response()); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return nil
+	}
+	return ctx.JSON(http.StatusOK, v)
+}
+
+func (s *Server) getLinks(ctx echo.Context) error {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	l := make([]*LinkStatus, 0, len(s.names))
+	for _, name := range s.names {
+		ls, err := s.status(name, s.links[name])
+		if err != nil {
+			return err
+		}
+		l = append(l, ls)
+	}
+	return response(ctx, l)
+}
+
+func (s *Server) getLink(ctx echo.Context) error {
+	link, err := s.link(ctx)
+	if err != nil {
+		return err
+	}
+	ls, err := s.status(ctx.Param(ParamLink), link)
+	if err != nil {
+		return err
+	}
+	return response(ctx, ls)
+}
+
+func (s *Server) getRelayMessages(ctx echo.Context) error {
+	link, err := s.link(ctx)
+	if err != nil {
+		return err
+	}
+	rms, err := link.RelayMessages()
+	if err != nil {
+		return err
+	}
+	return response(ctx, rms)
+}
+
+func (s *Server) fees(name string, link Link) (*FeeReport, bool, error) {
+	fr, ok := link.(FeeReporter)
+	if !ok {
+		return nil, false, nil
+	}
+	r, err := fr.Fees()
+	if err != nil {
+		return nil, true, err
+	}
+	r.Name = name
+	return r, true, nil
+}
+
+func (s *Server) getFees(ctx echo.Context) error {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	l := make([]*FeeReport, 0, len(s.names))
+	for _, name := range s.names {
+		r, ok, err := s.fees(name, s.links[name])
+		if err != nil {
+			return err
+		}
+		if ok {
+			l = append(l, r)
+		}
+	}
+	return response(ctx, l)
+}
+
+func (s *Server) getLinkFees(ctx echo.Context) error {
+	link, err := s.link(ctx)
+	if err != nil {
+		return err
+	}
+	r, ok, err := s.fees(ctx.Param(ParamLink), link)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, errors.UnsupportedError.Errorf("fee is not supported link:%s", ctx.Param(ParamLink)).Error())
+	}
+	return response(ctx, r)
+}
+
+func (s *Server) control(f func(link Link) error) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		link, err := s.link(ctx)
+		if err != nil {
+			return err
+		}
+		if err = f(link); err != nil {
+			return err
+		}
+		s.l.Infof("admin %s %s", ctx.Request().Method, ctx.Request().URL.Path)
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+func (s *Server) dropRelayMessage(ctx echo.Context) error {
+	link, err := s.link(ctx)
+	if err != nil {
+		return err
+	}
+	id := ctx.Param(ParamID)
+	if err = link.Drop(id); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	s.l.Warnf("admin drop link:%s id:%s", ctx.Param(ParamLink), id)
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (s *Server) setLogLevel(ctx echo.Context) error {
+	p := &LogLevel{}
+	if err := ctx.Bind(p); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	lv, err := log.ParseLevel(p.Level)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	s.mtx.RLock()
+	loggers := []log.Logger{log.GlobalLogger()}
+	for _, l := range s.loggers {
+		loggers = append(loggers, l)
+	}
+	s.mtx.RUnlock()
+	for _, l := range loggers {
+		if p.Module == "" {
+			l.SetConsoleLevel(lv)
+		} else {
+			l.SetModuleLevel(p.Module, lv)
+		}
+	}
+	s.l.Infof("admin set log level module:%s level:%s", p.Module, p.Level)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// NewServer returns Server, if readOnly is true, requests which change state of link are not allowed.
+func NewServer(address string, readOnly bool, l log.Logger) *Server {
+	s := &Server{
+		HttpServer: common.NewHttpServer(address, nil),
+		links:      make(map[string]Link),
+		loggers:    make(map[string]log.Logger),
+		l:          l,
+	}
+	e := s.Echo()
+	e.HideBanner = true
+	e.HidePort = true
+	mw := common.Unauthorized(readOnly)
+	g := e.Group(ContextPath)
+	g.GET(UrlLinks, s.getLinks)
+	g.GET(UrlLinks+"/:"+ParamLink, s.getLink)
+	g.GET(UrlLinks+"/:"+ParamLink+"/messages", s.getRelayMessages)
+	g.GET(UrlLinks+"/:"+ParamLink+UrlFees, s.getLinkFees)
+	g.GET(UrlFees, s.getFees)
+	g.POST(UrlLinks+"/:"+ParamLink+"/pause", s.control(func(link Link) error {
+		link.Pause()
+		return nil
+	}), mw)
+	g.POST(UrlLinks+"/:"+ParamLink+"/resume", s.control(func(link Link) error {
+		link.Resume()
+		return nil
+	}), mw)
+	g.POST(UrlLinks+"/:"+ParamLink+"/refresh", s.control(func(link Link) error {
+		return link.Refresh()
+	}), mw)
+	g.DELETE(UrlLinks+"/:"+ParamLink+"/messages/:"+ParamID, s.dropRelayMessage, mw)
+	g.PUT(UrlLog, s.setLogLevel, mw)
+	return s
+}
+
+// Serve starts Server in background, if address is empty, it does nothing.
+func Serve(address string, readOnly bool, l log.Logger) *Server {
+	if address == "" {
+		return nil
+	}
+	s := NewServer(address, readOnly, l)
+	go func() {
+		if err := s.Start(); err != nil && err != http.ErrServerClosed {
+			l.Errorf("fail to serve admin address:%s err:%+v", address, err)
+		}
+	}()
+	return s
+}
@@ -0,0 +1,204 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/fee"
+	"github.com/icon-project/btp/common/log"
+)
+
+type testLink struct {
+	paused    bool
+	refreshed int
+	rms       []*RelayMessage
+}
+
+func (l *testLink) Status() (*LinkStatus, error) {
+	return &LinkStatus{Src: "src", Dst: "dst", Paused: l.paused, Queued: len(l.rms)}, nil
+}
+
+func (l *testLink) RelayMessages() ([]*RelayMessage, error) {
+	return l.rms, nil
+}
+
+func (l *testLink) Pause() {
+	l.paused = true
+}
+
+func (l *testLink) Resume() {
+	l.paused = false
+}
+
+func (l *testLink) Refresh() error {
+	l.refreshed++
+	return nil
+}
+
+func (l *testLink) Drop(id string) error {
+	for i, rm := range l.rms {
+		if rm.ID == id {
+			l.rms = append(l.rms[:i], l.rms[i+1:]...)
+			return nil
+		}
+	}
+	return errors.NotFoundError.Errorf("not found id:%s", id)
+}
+
+func newTestServer(readOnly bool) (*httptest.Server, *testLink) {
+	s := NewServer("", readOnly, log.New())
+	link := &testLink{
+		rms: []*RelayMessage{
+			{ID: "1", Height: 10, Segments: []*Segment{{Height: 10, TxHash: "0x01"}}},
+			{ID: "2", Height: 11},
+		},
+	}
+	s.Register("0x1.icon", link)
+	return httptest.NewServer(s.Echo()), link
+}
+
+func TestServer(t *testing.T) {
+	ts, link := newTestServer(false)
+	defer ts.Close()
+	c := NewClient(ts.URL)
+
+	ls, err := c.Links()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ls))
+	assert.Equal(t, "0x1.icon", ls[0].Name)
+	assert.Equal(t, 2, ls[0].Queued)
+
+	_, err = c.Link("0x2.bsc")
+	assert.Error(t, err)
+	he, ok := err.(*common.HttpError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, he.StatusCode())
+
+	rms, err := c.RelayMessages("0x1.icon")
+	assert.NoError(t, err)
+	assert.Equal(t, link.rms, rms)
+
+	s, err := c.Format(linkUrl("0x1.icon"), "{{.queued}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", s)
+
+	assert.NoError(t, c.Pause("0x1.icon"))
+	assert.True(t, link.paused)
+	assert.NoError(t, c.Resume("0x1.icon"))
+	assert.False(t, link.paused)
+	assert.NoError(t, c.Refresh("0x1.icon"))
+	assert.Equal(t, 1, link.refreshed)
+
+	assert.NoError(t, c.Drop("0x1.icon", "1"))
+	assert.Equal(t, 1, len(link.rms))
+	assert.Error(t, c.Drop("0x1.icon", "1"))
+
+	assert.NoError(t, c.SetLogLevel("icon", "info"))
+	assert.Error(t, c.SetLogLevel("icon", "invalid"))
+}
+
+func TestServer_Unregister(t *testing.T) {
+	s := NewServer("", false, log.New())
+	s.Register("0x1.icon@a", &testLink{})
+	s.Register("0x1.icon@b", &testLink{})
+	ts := httptest.NewServer(s.Echo())
+	defer ts.Close()
+	c := NewClient(ts.URL)
+
+	s.Unregister("0x1.icon@a")
+	s.Unregister("0x1.icon@c")
+	ls, err := c.Links()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ls))
+	assert.Equal(t, "0x1.icon@b", ls[0].Name)
+	_, err = c.Link("0x1.icon@a")
+	assert.Error(t, err)
+
+	var ns *Server
+	ns.Unregister("0x1.icon@b")
+}
+
+func TestServer_SetLogLevel(t *testing.T) {
+	s := NewServer("", false, log.New())
+	l := log.New()
+	s.RegisterLogger("0x1.icon", l)
+	ts := httptest.NewServer(s.Echo())
+	defer ts.Close()
+	c := NewClient(ts.URL)
+
+	//levels are applied to registered loggers as well as the global logger
+	assert.NoError(t, c.SetLogLevel("icon", "trace"))
+	assert.Equal(t, log.TraceLevel, l.GetModuleLevel("icon"))
+	assert.Equal(t, log.TraceLevel, log.GlobalLogger().GetModuleLevel("icon"))
+
+	s.UnregisterLogger("0x1.icon")
+	assert.NoError(t, c.SetLogLevel("icon", "info"))
+	assert.Equal(t, log.TraceLevel, l.GetModuleLevel("icon"))
+}
+
+func TestServer_ReadOnly(t *testing.T) {
+	ts, link := newTestServer(true)
+	defer ts.Close()
+	c := NewClient(ts.URL)
+
+	_, err := c.Links()
+	assert.NoError(t, err)
+	err = c.Pause("0x1.icon")
+	assert.Error(t, err)
+	he, ok := err.(*common.HttpError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, he.StatusCode())
+	assert.False(t, link.paused)
+	assert.Error(t, c.Drop("0x1.icon", "1"))
+	assert.Equal(t, 2, len(link.rms))
+}
+
+type testFeeLink struct {
+	testLink
+}
+
+func (l *testFeeLink) Fees() (*FeeReport, error) {
+	return &FeeReport{
+		Balance: "100",
+		Level:   fee.LevelWarn,
+		Count:   2,
+		Total:   "30",
+		Days:    []*fee.DailyFee{{Date: "2022-03-01", Count: 2, Fee: "30"}},
+	}, nil
+}
+
+func TestServer_Fees(t *testing.T) {
+	s := NewServer("", true, log.New())
+	s.Register("0x1.icon", &testFeeLink{})
+	s.Register("0x2.bsc", &testLink{})
+	ts := httptest.NewServer(s.Echo())
+	defer ts.Close()
+	c := NewClient(ts.URL)
+
+	//links without FeeReporter are omitted
+	l, err := c.Fees()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(l))
+	assert.Equal(t, "0x1.icon", l[0].Name)
+	assert.Equal(t, fee.LevelWarn, l[0].Level)
+	assert.Equal(t, "30", l[0].Days[0].Fee)
+
+	r, err := c.LinkFees("0x1.icon")
+	assert.NoError(t, err)
+	assert.Equal(t, l[0], r)
+
+	_, err = c.LinkFees("0x2.bsc")
+	assert.Error(t, err)
+	he, ok := err.(*common.HttpError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, he.StatusCode())
+
+	str, err := c.Format(linkUrl("0x1.icon")+UrlFees, "{{.total}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "30", str)
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/icon-project/btp/common"
+)
+
+type Client struct {
+	*common.HttpClient
+}
+
+func formatParams(format string) []*url.Values {
+	if format == "" {
+		return nil
+	}
+	return []*url.Values{{ParamFormat: []string{format}}}
+}
+
+func linkUrl(name string, paths ...string) string {
+	u := UrlLinks + "/" + url.PathEscape(name)
+	for _, p := range paths {
+		u += "/" + url.PathEscape(p)
+	}
+	return u
+}
+
+func (c *Client) Links() ([]*LinkStatus, error) {
+	l := make([]*LinkStatus, 0)
+	_, err := c.Get(UrlLinks, &l)
+	return l, err
+}
+
+func (c *Client) Link(name string) (*LinkStatus, error) {
+	ls := &LinkStatus{}
+	_, err := c.Get(linkUrl(name), ls)
+	return ls, err
+}
+
+func (c *Client) RelayMessages(name string) ([]*RelayMessage, error) {
+	l := make([]*RelayMessage, 0)
+	_, err := c.Get(linkUrl(name, "messages"), &l)
+	return l, err
+}
+
+func (c *Client) Fees() ([]*FeeReport, error) {
+	l := make([]*FeeReport, 0)
+	_, err := c.Get(UrlFees, &l)
+	return l, err
+}
+
+func (c *Client) LinkFees(name string) (*FeeReport, error) {
+	r := &FeeReport{}
+	_, err := c.Get(linkUrl(name)+UrlFees, r)
+	return r, err
+}
+
+// Format returns the response of GET reqUrl which is formatted by the template
+func (c *Client) Format(reqUrl, format string) (string, error) {
+	var s string
+	_, err := c.Get(reqUrl, &s, formatParams(format)...)
+	return s, err
+}
+
+func (c *Client) Pause(name string) error {
+	_, err := c.Post(linkUrl(name, "pause"), nil)
+	return err
+}
+
+func (c *Client) Resume(name string) error {
+	_, err := c.Post(linkUrl(name, "resume"), nil)
+	return err
+}
+
+func (c *Client) Refresh(name string) error {
+	_, err := c.Post(linkUrl(name, "refresh"), nil)
+	return err
+}
+
+func (c *Client) Drop(name, id string) error {
+	_, err := c.Delete(linkUrl(name, "messages", id), nil)
+	return err
+}
+
+func (c *Client) SetLogLevel(module, level string) error {
+	_, err := c.Do(http.MethodPut, UrlLog, &LogLevel{Module: module, Level: level}, nil)
+	return err
+}
+
+func NewClient(address string) *Client {
+	return &Client{common.NewHttpClient(address, ContextPath)}
+}
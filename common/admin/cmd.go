@@ -0,0 +1,172 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/icon-project/btp/common/cli"
+)
+
+const (
+	FlagAddress  = "admin_address"
+	FlagReadOnly = "admin_read_only"
+)
+
+// cmdContext has Client for the address of admin server and the format flag of the command.
+type cmdContext struct {
+	c      *Client
+	format string
+}
+
+// newCmdContext sets PersistentPreRunE of cmd which creates Client,
+// the address of admin server is resolved by FlagAddress of parentVc.
+func newCmdContext(cmd, parentCmd *cobra.Command, parentVc *viper.Viper) *cmdContext {
+	x := &cmdContext{}
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if parentCmd != nil && parentCmd.PersistentPreRunE != nil {
+			if err := parentCmd.PersistentPreRunE(cmd, args); err != nil {
+				return err
+			}
+		}
+		address := parentVc.GetString(FlagAddress)
+		if address == "" {
+			return fmt.Errorf("%s is not configured", FlagAddress)
+		}
+		x.c = NewClient(address)
+		return nil
+	}
+	cmd.PersistentFlags().StringVar(&x.format, "format", "", "Format the output using the given Go template")
+	return x
+}
+
+// show prints the response of get as JSON, or the response of reqUrl formatted by the template.
+func (x *cmdContext) show(reqUrl string, get func() (interface{}, error)) error {
+	if x.format != "" {
+		s, err := x.c.Format(reqUrl, x.format)
+		if err != nil {
+			return err
+		}
+		fmt.Println(s)
+		return nil
+	}
+	v, err := get()
+	if err != nil {
+		return err
+	}
+	return cli.JsonPrettyPrintln(os.Stdout, v)
+}
+
+// NewCommand returns admin command, address of admin server is resolved by FlagAddress of parentVc
+func NewCommand(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Command {
+	rootCmd, _ := cli.NewCommand(parentCmd, parentVc, "admin", "Inspect and control running relay")
+	x := newCmdContext(rootCmd, parentCmd, parentVc)
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "status [link]",
+		Short: "Print status of links",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return x.show(UrlLinks, func() (interface{}, error) {
+					return x.c.Links()
+				})
+			}
+			return x.show(linkUrl(args[0]), func() (interface{}, error) {
+				return x.c.Link(args[0])
+			})
+		},
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "messages LINK",
+		Short: "Print queued relay messages of the link",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return x.show(linkUrl(args[0], "messages"), func() (interface{}, error) {
+				return x.c.RelayMessages(args[0])
+			})
+		},
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "pause LINK",
+		Short: "Pause relaying of the link",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return x.c.Pause(args[0])
+		},
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "resume LINK",
+		Short: "Resume relaying of the link",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return x.c.Resume(args[0])
+		},
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "refresh LINK",
+		Short: "Refresh BMC link status of the link",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return x.c.Refresh(args[0])
+		},
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "drop LINK ID",
+		Short: "Drop the relay message from the queue of the link",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return x.c.Drop(args[0], args[1])
+		},
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "loglevel LEVEL [MODULE]",
+		Short: "Set console log level, or log level of the module",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.RangeArgs(1, 2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			module := ""
+			if len(args) > 1 {
+				module = args[1]
+			}
+			return x.c.SetLogLevel(module, args[0])
+		},
+	})
+	return rootCmd
+}
+
+// NewFeesCommand returns fees command which prints fees and balances of running relay,
+// address of admin server is resolved by FlagAddress of parentVc
+func NewFeesCommand(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Command {
+	feesCmd, _ := cli.NewCommand(parentCmd, parentVc, "fees [link]", "Print fees spent and balances of relay accounts")
+	x := newCmdContext(feesCmd, parentCmd, parentVc)
+	feesCmd.Args = cli.ArgsWithDefaultErrorFunc(cobra.MaximumNArgs(1))
+	feesCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return x.show(UrlFees, func() (interface{}, error) {
+				return x.c.Fees()
+			})
+		}
+		return x.show(linkUrl(args[0])+UrlFees, func() (interface{}, error) {
+			return x.c.LinkFees(args[0])
+		})
+	}
+	return feesCmd
+}
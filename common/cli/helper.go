@@ -266,6 +266,32 @@ func StringToStringConv(val string) (map[string]interface{}, error) {
 	return out, nil
 }
 
+var typeOfJsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// jsonUnmarshalerOf returns new value of t(or element of t) if its pointer implements json.Unmarshaler.
+func jsonUnmarshalerOf(t reflect.Type) (reflect.Value, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr || !reflect.PtrTo(t).Implements(typeOfJsonUnmarshaler) {
+		return reflect.Value{}, false
+	}
+	return reflect.New(t), true
+}
+
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// ViperDecodeOptJson decodes with json tags, values of types implementing json.Unmarshaler
+// like gas price are decoded by UnmarshalJSON from string or number.
 func ViperDecodeOptJson(c *mapstructure.DecoderConfig) {
 	c.TagName = "json"
 	c.DecodeHook = mapstructure.ComposeDecodeHookFunc(
@@ -280,6 +306,18 @@ func ViperDecodeOptJson(c *mapstructure.DecoderConfig) {
 						return json.RawMessage(nil), nil
 					}
 				}
+			} else if v, ok := jsonUnmarshalerOf(outValType); ok && isScalarKind(inputValType.Kind()) {
+				b, err := json.Marshal(input)
+				if err != nil {
+					return nil, err
+				}
+				if err = json.Unmarshal(b, v.Interface()); err != nil {
+					return nil, err
+				}
+				if outValType.Kind() == reflect.Ptr {
+					return v.Interface(), nil
+				}
+				return v.Elem().Interface(), nil
 			} else if inputValType.Kind() == reflect.String && outValType.Kind() == reflect.Map {
 				m, err := StringToStringConv(input.(string))
 				if outValType.Key().Kind() == reflect.String && outValType.Elem().Name() == "RawMessage" {
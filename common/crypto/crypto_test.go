@@ -90,7 +90,7 @@ func TestPrintSignature(t *testing.T) {
 func TestUri(t *testing.T) {
 	u, err:=url.Parse("btp://0x11.goloop/cx012325r")
 	if err != nil {
-		fmt.Errorf("err:%+v\n",err)
+		t.Errorf("err:%+v\n",err)
 		return
 	}
 	fmt.Println("uri",u.Scheme,u.Host,u.Port(), u.Path)
@@ -0,0 +1,182 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dryrun
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/icon-project/btp/common/errors"
+)
+
+const (
+	// Stdout is the output path for standard output
+	Stdout = "-"
+
+	DefaultPollInterval = time.Second
+)
+
+// Record is written for each segment which would be sent.
+type Record struct {
+	Time          time.Time   `json:"time"`
+	Src           string      `json:"src"`
+	Dst           string      `json:"dst"`
+	Method        string      `json:"method"`
+	Height        int64       `json:"height"`
+	EventSequence int64       `json:"event_sequence"`
+	NumberOfEvent int         `json:"number_of_event"`
+	Size          int         `json:"size"`
+	Fee           string      `json:"fee,omitempty"`
+	Message       interface{} `json:"message,omitempty"`
+	Error         string      `json:"error,omitempty"`
+}
+
+// Recorder writes Records as JSON lines.
+type Recorder struct {
+	mtx sync.Mutex
+	w   io.Writer
+	c   io.Closer
+}
+
+func (r *Recorder) Record(rec *Record) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	_, err = r.w.Write(append(b, '\n'))
+	return err
+}
+
+func (r *Recorder) Close() error {
+	if r.c == nil {
+		return nil
+	}
+	return r.c.Close()
+}
+
+// NewRecorder returns Recorder which appends to the file of path, or writes to standard output if path is Stdout.
+func NewRecorder(path string) (*Recorder, error) {
+	if path == "" || path == Stdout {
+		return &Recorder{w: os.Stdout}, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to open dry-run output path:%s", path)
+	}
+	return &Recorder{w: f, c: f}, nil
+}
+
+// Status is the progress of the source in the destination.
+type Status struct {
+	Height int64 `json:"height"`
+	RxSeq  int64 `json:"rx_seq"`
+}
+
+// Reached returns whether the progress of height and seq is reflected.
+func (s Status) Reached(height, seq int64) bool {
+	return s.Height >= height && s.RxSeq >= seq
+}
+
+// Tracker tracks Status instead of the destination which never receives transactions.
+// If the path of status file is given, Status is read from the file which is supplied by user,
+// otherwise Status is advanced by Confirm from the first status of the destination.
+// The status file is JSON object of Status keyed by network address of the source,
+// so that one file could be used for both directions.
+type Tracker struct {
+	mtx      sync.Mutex
+	path     string
+	key      string
+	st       Status
+	init     bool
+	interval time.Duration
+}
+
+// Init sets the first status of the destination, it's ignored if it's already initialized.
+func (t *Tracker) Init(st Status) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if !t.init {
+		t.st = st
+		t.init = true
+	}
+}
+
+func (t *Tracker) Status() (Status, error) {
+	if t.path != "" {
+		b, err := ioutil.ReadFile(t.path)
+		if err != nil {
+			return Status{}, errors.Wrapf(err, "fail to read dry-run status path:%s", t.path)
+		}
+		m := make(map[string]Status)
+		if err = json.Unmarshal(b, &m); err != nil {
+			return Status{}, errors.Wrapf(err, "fail to parse dry-run status path:%s", t.path)
+		}
+		return m[t.key], nil
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.st, nil
+}
+
+// Confirm advances Status to height and seq, it does nothing if Status is supplied by user.
+func (t *Tracker) Confirm(height, seq int64) {
+	if t.path != "" {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if height > t.st.Height {
+		t.st.Height = height
+	}
+	if seq > t.st.RxSeq {
+		t.st.RxSeq = seq
+	}
+}
+
+// Wait blocks until Status reaches height and seq.
+func (t *Tracker) Wait(height, seq int64) error {
+	for {
+		st, err := t.Status()
+		if err != nil {
+			return err
+		}
+		if st.Reached(height, seq) {
+			return nil
+		}
+		time.Sleep(t.interval)
+	}
+}
+
+// NewTracker returns Tracker, statusPath is the path of status file supplied by user, it could be empty.
+// key is the network address of the source to find Status in the file.
+func NewTracker(statusPath, key string) *Tracker {
+	return &Tracker{
+		path:     statusPath,
+		key:      key,
+		init:     statusPath != "",
+		interval: DefaultPollInterval,
+	}
+}
@@ -0,0 +1,99 @@
+package dryrun
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dryrun")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "records.jsonl")
+	r, err := NewRecorder(path)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Record(&Record{Height: 10, EventSequence: 1, NumberOfEvent: 1, Size: 100}))
+	assert.NoError(t, r.Record(&Record{Height: 11, Message: map[string]interface{}{"k": "v"}}))
+	assert.NoError(t, r.Close())
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	var recs []*Record
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		rec := &Record{}
+		assert.NoError(t, json.Unmarshal(sc.Bytes(), rec))
+		recs = append(recs, rec)
+	}
+	assert.Equal(t, 2, len(recs))
+	assert.Equal(t, int64(10), recs[0].Height)
+	assert.Equal(t, 100, recs[0].Size)
+	assert.False(t, recs[0].Time.IsZero())
+	assert.Equal(t, map[string]interface{}{"k": "v"}, recs[1].Message)
+}
+
+func TestTracker_Confirm(t *testing.T) {
+	tr := NewTracker("", "0x1.icon")
+	tr.Init(Status{Height: 10, RxSeq: 5})
+	//ignored after initialized
+	tr.Init(Status{Height: 1, RxSeq: 1})
+
+	st, err := tr.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, Status{Height: 10, RxSeq: 5}, st)
+
+	tr.Confirm(12, 7)
+	tr.Confirm(11, 6)
+	st, err = tr.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, Status{Height: 12, RxSeq: 7}, st)
+	assert.NoError(t, tr.Wait(12, 7))
+}
+
+func TestTracker_StatusFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dryrun")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "status.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"0x1.icon":{"height":10,"rx_seq":5}}`), 0644))
+	tr := NewTracker(path, "0x1.icon")
+	tr.interval = 10 * time.Millisecond
+	tr.Init(Status{Height: 1, RxSeq: 1})
+
+	st, err := tr.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, Status{Height: 10, RxSeq: 5}, st)
+
+	//status supplied by user is not changed by Confirm
+	tr.Confirm(12, 7)
+	st, err = tr.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, Status{Height: 10, RxSeq: 5}, st)
+
+	done := make(chan error)
+	go func() {
+		done <- tr.Wait(12, 7)
+	}()
+	select {
+	case <-done:
+		assert.Fail(t, "returned before status is reflected")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"0x1.icon":{"height":12,"rx_seq":7}}`), 0644))
+	select {
+	case err = <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "not returned after status is reflected")
+	}
+}
@@ -0,0 +1,382 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package endpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icon-project/btp/common/log"
+)
+
+const (
+	// DefaultMaxLag is the number of blocks, the endpoint is behind if it's lower than the highest by more than it.
+	DefaultMaxLag        = 10
+	DefaultCheckInterval = 30 * time.Second
+	DefaultCheckTimeout  = 10 * time.Second
+	MaxScore             = 100
+	FailPenalty          = 10
+)
+
+type Endpoint struct {
+	URL    string
+	Score  int
+	Height int64
+	Behind bool
+}
+
+func (e *Endpoint) String() string {
+	s := fmt.Sprintf("%s(score:%d,height:%d", e.URL, e.Score, e.Height)
+	if e.Behind {
+		s += ",behind"
+	}
+	return s + ")"
+}
+
+// HeightFunc returns the last block height of the endpoint.
+type HeightFunc func(url string) (int64, error)
+
+// Selector selects the endpoint to use from the ordered list,
+// it rotates to other endpoint on connection errors or falling behind.
+// Health score of the endpoint is increased by success and decreased by failure.
+type Selector struct {
+	mtx      sync.RWMutex
+	eps      []*Endpoint
+	cur      int
+	maxLag   int64
+	onChange []func(from, to string)
+	stop     chan struct{}
+	l        log.Logger
+}
+
+func (s *Selector) Current() string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.eps[s.cur].URL
+}
+
+func (s *Selector) Len() int {
+	return len(s.eps)
+}
+
+func (s *Selector) Endpoints() []Endpoint {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	l := make([]Endpoint, len(s.eps))
+	for i, e := range s.eps {
+		l[i] = *e
+	}
+	return l
+}
+
+func (s *Selector) SetMaxLag(maxLag int64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.maxLag = maxLag
+}
+
+// OnChange adds the callback which is called when the current endpoint is changed.
+func (s *Selector) OnChange(cb func(from, to string)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.onChange = append(s.onChange, cb)
+}
+
+func (s *Selector) indexOf(url string) int {
+	for i, e := range s.eps {
+		if e.URL == url {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Selector) Success(url string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if i := s.indexOf(url); i >= 0 && s.eps[i].Score < MaxScore {
+		s.eps[i].Score++
+	}
+}
+
+// Fail decreases score of the endpoint, then rotates if it's the current endpoint.
+func (s *Selector) Fail(url string, err error) {
+	s.mtx.Lock()
+	i := s.indexOf(url)
+	if i < 0 {
+		s.mtx.Unlock()
+		return
+	}
+	e := s.eps[i]
+	if e.Score -= FailPenalty; e.Score < 0 {
+		e.Score = 0
+	}
+	s.l.Debugf("endpoint %v fail err:%+v", e, err)
+	var cbs []func(from, to string)
+	if i == s.cur {
+		cbs = s._rotate(s._next(), err)
+	}
+	s.mtx.Unlock()
+	notify(cbs, url, s.Current())
+}
+
+// _next returns the index of the healthiest endpoint except current one, ties are broken by the order.
+func (s *Selector) _next() int {
+	n := s.cur
+	for i := 1; i < len(s.eps); i++ {
+		idx := (s.cur + i) % len(s.eps)
+		e := s.eps[idx]
+		if n == s.cur || better(e, s.eps[n]) {
+			n = idx
+		}
+	}
+	return n
+}
+
+func better(a, b *Endpoint) bool {
+	if a.Behind != b.Behind {
+		return !a.Behind
+	}
+	return a.Score > b.Score
+}
+
+func (s *Selector) _rotate(idx int, reason interface{}) []func(from, to string) {
+	if idx == s.cur {
+		return nil
+	}
+	s.l.Warnf("rotate endpoint %v -> %v reason:%v endpoints:%s",
+		s.eps[s.cur], s.eps[idx], reason, s._status())
+	s.cur = idx
+	return append([]func(from, to string){}, s.onChange...)
+}
+
+func notify(cbs []func(from, to string), from, to string) {
+	for _, cb := range cbs {
+		cb(from, to)
+	}
+}
+
+func (s *Selector) _status() string {
+	l := make([]string, len(s.eps))
+	for i, e := range s.eps {
+		l[i] = e.String()
+	}
+	return "[" + strings.Join(l, ",") + "]"
+}
+
+// Check updates heights of all endpoints by heightOf, then rotates if the current endpoint is behind,
+// or the preceding endpoint in the order is recovered.
+func (s *Selector) Check(heightOf HeightFunc) {
+	heights := make([]int64, len(s.eps))
+	errs := make([]error, len(s.eps))
+	for i, e := range s.Endpoints() {
+		heights[i], errs[i] = heightOf(e.URL)
+	}
+
+	s.mtx.Lock()
+	var max int64
+	for i, e := range s.eps {
+		if errs[i] != nil {
+			if e.Score -= FailPenalty; e.Score < 0 {
+				e.Score = 0
+			}
+			continue
+		}
+		if e.Score < MaxScore {
+			e.Score++
+		}
+		e.Height = heights[i]
+		if e.Height > max {
+			max = e.Height
+		}
+	}
+	for _, e := range s.eps {
+		e.Behind = max-e.Height > s.maxLag
+	}
+	s.l.Debugf("check endpoints:%s", s._status())
+
+	from := s.eps[s.cur].URL
+	var cbs []func(from, to string)
+	if c := s.eps[s.cur]; c.Behind || errs[s.cur] != nil {
+		cbs = s._rotate(s._next(), fmt.Sprintf("current is unhealthy, highest:%d", max))
+	} else {
+		for i := 0; i < s.cur; i++ {
+			if e := s.eps[i]; !e.Behind && e.Score >= c.Score {
+				cbs = s._rotate(i, "preceding is recovered")
+				break
+			}
+		}
+	}
+	s.mtx.Unlock()
+	notify(cbs, from, s.Current())
+}
+
+// Watch calls Check by interval in background until Stop, it does nothing for single endpoint.
+func (s *Selector) Watch(interval time.Duration, heightOf HeightFunc) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.eps) < 2 || s.stop != nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	s.stop = make(chan struct{})
+	go func(stop <-chan struct{}) {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				s.Check(heightOf)
+			}
+		}
+	}(s.stop)
+}
+
+func (s *Selector) Stop() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Transport returns http.RoundTripper which sends the request to the current endpoint.
+// The request is retried to the other endpoints on connection error unless it sends a transaction,
+// and the endpoint is failed on the response of 502, 503 and 504.
+func (s *Selector) Transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &transport{s: s, rt: rt}
+}
+
+type transport struct {
+	s  *Selector
+	rt http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for i := 0; ; i++ {
+		url := t.s.Current()
+		r, err := newRequest(req, url, i > 0)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.rt.RoundTrip(r)
+		if err == nil {
+			switch resp.StatusCode {
+			case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+				t.s.Fail(url, fmt.Errorf("http status %s", resp.Status))
+			default:
+				t.s.Success(url)
+			}
+			return resp, nil
+		}
+		t.s.Fail(url, err)
+		if i+1 >= t.s.Len() || req.GetBody == nil || req.Context().Err() != nil || !idempotent(req) {
+			return nil, err
+		}
+	}
+}
+
+// nonIdempotentMethods are JSON-RPC methods which are not retried to other endpoint,
+// the transaction could be broadcast twice if the request is delivered before the error.
+var nonIdempotentMethods = map[string]bool{
+	"icx_sendTransaction":        true,
+	"icx_sendTransactionAndWait": true,
+	"eth_sendTransaction":        true,
+	"eth_sendRawTransaction":     true,
+}
+
+// idempotent returns false if the request is JSON-RPC request (or batch) of nonIdempotentMethods.
+func idempotent(req *http.Request) bool {
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	defer body.Close()
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return false
+	}
+	type request struct {
+		Method string `json:"method"`
+	}
+	var reqs []request
+	if err = json.Unmarshal(b, &reqs); err != nil {
+		r := request{}
+		if err = json.Unmarshal(b, &r); err != nil {
+			//not JSON-RPC
+			return true
+		}
+		reqs = append(reqs, r)
+	}
+	for _, r := range reqs {
+		if nonIdempotentMethods[r.Method] {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequest(req *http.Request, url string, rewind bool) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.URL.String() != url {
+		u, err := req.URL.Parse(url)
+		if err != nil {
+			return nil, err
+		}
+		r.URL = u
+		r.Host = ""
+	}
+	if rewind && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// NewSelector returns Selector of urls, the first url is used at first.
+func NewSelector(urls []string, l log.Logger) *Selector {
+	s := &Selector{
+		eps:    make([]*Endpoint, 0, len(urls)),
+		maxLag: DefaultMaxLag,
+		l:      l,
+	}
+	for _, url := range urls {
+		if url = strings.TrimSpace(url); url != "" && s.indexOf(url) < 0 {
+			s.eps = append(s.eps, &Endpoint{URL: url, Score: MaxScore})
+		}
+	}
+	if len(s.eps) == 0 {
+		s.eps = append(s.eps, &Endpoint{Score: MaxScore})
+	}
+	return s
+}
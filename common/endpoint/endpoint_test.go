@@ -0,0 +1,146 @@
+package endpoint
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/log"
+)
+
+func TestSelector_Fail(t *testing.T) {
+	s := NewSelector([]string{"a", "b", "a", " ", "c"}, log.New())
+	assert.Equal(t, 3, s.Len())
+	assert.Equal(t, "a", s.Current())
+
+	var changes []string
+	s.OnChange(func(from, to string) {
+		changes = append(changes, from+">"+to)
+	})
+
+	//failure of not current endpoint doesn't rotate
+	s.Fail("b", fmt.Errorf("b"))
+	assert.Equal(t, "a", s.Current())
+	assert.Equal(t, MaxScore-FailPenalty, s.Endpoints()[1].Score)
+
+	//rotate to the healthiest
+	s.Fail("a", fmt.Errorf("a"))
+	assert.Equal(t, "c", s.Current())
+	s.Success("b")
+	s.Fail("c", fmt.Errorf("c"))
+	assert.Equal(t, "b", s.Current())
+	assert.Equal(t, []string{"a>c", "c>b"}, changes)
+}
+
+func TestSelector_Check(t *testing.T) {
+	s := NewSelector([]string{"a", "b", "c"}, log.New())
+	heights := map[string]int64{"a": 100, "b": 120, "c": 115}
+	heightOf := func(url string) (int64, error) {
+		if h, ok := heights[url]; ok {
+			return h, nil
+		}
+		return 0, fmt.Errorf("unavailable")
+	}
+
+	s.Check(heightOf)
+	eps := s.Endpoints()
+	assert.True(t, eps[0].Behind)
+	assert.False(t, eps[1].Behind)
+	assert.False(t, eps[2].Behind)
+	assert.Equal(t, "b", s.Current())
+
+	//stay on current endpoint within max lag
+	heights["b"] = 121
+	heights["c"] = 125
+	s.Check(heightOf)
+	assert.Equal(t, "b", s.Current())
+
+	//rotate on error
+	delete(heights, "b")
+	s.Check(heightOf)
+	assert.Equal(t, "c", s.Current())
+
+	//preceding endpoint is recovered
+	heights["a"] = 125
+	heights["b"] = 125
+	s.Check(heightOf)
+	assert.Equal(t, "a", s.Current())
+}
+
+func TestSelector_Transport(t *testing.T) {
+	var hits []string
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		hits = append(hits, "ok:"+string(b))
+		_, _ = w.Write([]byte("pong"))
+	}))
+	defer ok.Close()
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, "busy")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer busy.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	s := NewSelector([]string{down.URL, ok.URL, busy.URL}, log.New())
+	hc := &http.Client{Transport: s.Transport(nil)}
+
+	//retry to the next endpoint on connection error
+	resp, err := hc.Post(down.URL, "text/plain", bytes.NewBufferString("ping"))
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "pong", string(b))
+	assert.Equal(t, ok.URL, s.Current())
+	assert.Equal(t, []string{"ok:ping"}, hits)
+
+	//response of unavailable is returned, but rotates
+	s.Fail(ok.URL, fmt.Errorf("test"))
+	s.Fail(ok.URL, fmt.Errorf("test"))
+	assert.Equal(t, busy.URL, s.Current())
+	resp, err = hc.Post(down.URL, "text/plain", bytes.NewBufferString("ping"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.NotEqual(t, busy.URL, s.Current())
+}
+
+func TestSelector_TransportNonIdempotent(t *testing.T) {
+	var hits int
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer ok.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	s := NewSelector([]string{down.URL, ok.URL}, log.New())
+	hc := &http.Client{Transport: s.Transport(nil)}
+
+	//transaction is not sent again to other endpoint, but rotates
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","method":"icx_sendTransaction","id":1}`,
+		`[{"jsonrpc":"2.0","method":"eth_blockNumber","id":1},{"jsonrpc":"2.0","method":"eth_sendRawTransaction","id":2}]`,
+	} {
+		_, err := hc.Post(down.URL, "application/json", bytes.NewBufferString(body))
+		assert.Error(t, err)
+		assert.Equal(t, 0, hits)
+		assert.Equal(t, ok.URL, s.Current())
+		s.Fail(ok.URL, fmt.Errorf("test"))
+		assert.Equal(t, down.URL, s.Current())
+	}
+
+	//read is retried
+	resp, err := hc.Post(down.URL, "application/json",
+		bytes.NewBufferString(`{"jsonrpc":"2.0","method":"icx_getLastBlock","id":1}`))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, hits)
+}
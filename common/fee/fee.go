@@ -0,0 +1,234 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fee
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/log"
+)
+
+const (
+	DefaultInterval = time.Minute
+)
+
+// Amount is the balance or the fee in the smallest unit of the coin(loop or wei),
+// it's decoded from decimal or "0x" prefixed hex in JSON string or number.
+type Amount big.Int
+
+func NewAmount(v *big.Int) *Amount {
+	return (*Amount)(new(big.Int).Set(v))
+}
+
+func (a *Amount) Int() *big.Int {
+	if a == nil {
+		return nil
+	}
+	return new(big.Int).Set((*big.Int)(a))
+}
+
+func (a *Amount) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	return (*big.Int)(a).String()
+}
+
+func (a *Amount) SetString(s string) error {
+	var ok bool
+	if strings.HasPrefix(s, "0x") {
+		_, ok = (*big.Int)(a).SetString(s[2:], 16)
+	} else {
+		_, ok = (*big.Int)(a).SetString(s, 10)
+	}
+	if !ok || (*big.Int)(a).Sign() < 0 {
+		return fmt.Errorf("invalid amount %s", s)
+	}
+	return nil
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*big.Int)(&a).String())
+}
+
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+	return a.SetString(strings.TrimSpace(s))
+}
+
+// Config of Monitor for the relay account, nil threshold disables the level.
+// Stop should be less than Warn, relaying is paused while the balance is under Stop.
+type Config struct {
+	Interval time.Duration `json:"interval,omitempty"`
+	Warn     *Amount       `json:"warn,omitempty"`
+	Stop     *Amount       `json:"stop,omitempty"`
+}
+
+type Level string
+
+const (
+	LevelNormal Level = "normal"
+	LevelWarn   Level = "warn"
+	LevelStop   Level = "stop"
+)
+
+// LevelOf returns the level of the balance by thresholds of cfg, it's safe on nil.
+func (c *Config) LevelOf(balance *big.Int) Level {
+	if c == nil || balance == nil {
+		return LevelNormal
+	}
+	if c.Stop != nil && balance.Cmp((*big.Int)(c.Stop)) < 0 {
+		return LevelStop
+	}
+	if c.Warn != nil && balance.Cmp((*big.Int)(c.Warn)) < 0 {
+		return LevelWarn
+	}
+	return LevelNormal
+}
+
+// BalanceFunc returns the balance of the relay account on the chain.
+type BalanceFunc func() (*big.Int, error)
+
+// LevelFunc is called with the balance after every poll and spend,
+// prev is different from lv when the level is changed.
+type LevelFunc func(balance *big.Int, lv, prev Level)
+
+// Monitor polls the balance of the relay account, the balance is estimated
+// by Spend between polls, so relaying is paused before the next poll.
+type Monitor struct {
+	mtx     sync.Mutex
+	cfg     Config
+	f       BalanceFunc
+	cb      LevelFunc
+	l       log.Logger
+	balance *big.Int
+	lv      Level
+	stop    chan struct{}
+}
+
+// Balance returns the last known balance and its level, the balance is nil before the first poll.
+func (m *Monitor) Balance() (*big.Int, Level) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.balance == nil {
+		return nil, m.lv
+	}
+	return new(big.Int).Set(m.balance), m.lv
+}
+
+func (m *Monitor) update(balance *big.Int) {
+	m.mtx.Lock()
+	prev := m.lv
+	m.balance = balance
+	m.lv = m.cfg.LevelOf(balance)
+	lv := m.lv
+	m.mtx.Unlock()
+	if m.cb != nil {
+		m.cb(new(big.Int).Set(balance), lv, prev)
+	}
+}
+
+// Poll gets the balance and updates the level.
+func (m *Monitor) Poll() error {
+	balance, err := m.f()
+	if err != nil {
+		return err
+	}
+	if balance == nil {
+		return errors.InvalidStateError.New("nil balance")
+	}
+	m.update(balance)
+	return nil
+}
+
+// Spend subtracts the fee from the last known balance, it's ignored before the first poll.
+func (m *Monitor) Spend(fee *big.Int) {
+	m.mtx.Lock()
+	if m.balance == nil || fee == nil || fee.Sign() <= 0 {
+		m.mtx.Unlock()
+		return
+	}
+	balance := new(big.Int).Sub(m.balance, fee)
+	m.mtx.Unlock()
+	m.update(balance)
+}
+
+func (m *Monitor) poll() {
+	if err := m.Poll(); err != nil {
+		m.l.Warnf("fail to get balance err:%+v", err)
+	}
+}
+
+// Start polls the balance immediately, then polls for every interval until Stop.
+func (m *Monitor) Start() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+	go func(stop <-chan struct{}) {
+		m.poll()
+		t := time.NewTicker(m.cfg.Interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				m.poll()
+			}
+		}
+	}(m.stop)
+}
+
+func (m *Monitor) Stop() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+}
+
+// NewMonitor returns Monitor which calls cb with the balance from f, cfg could be nil to report only the balance.
+func NewMonitor(cfg *Config, f BalanceFunc, cb LevelFunc, l log.Logger) *Monitor {
+	m := &Monitor{
+		f:  f,
+		cb: cb,
+		l:  l,
+		lv: LevelNormal,
+	}
+	if cfg != nil {
+		m.cfg = *cfg
+	}
+	if m.cfg.Interval <= 0 {
+		m.cfg.Interval = DefaultInterval
+	}
+	return m
+}
@@ -0,0 +1,101 @@
+package fee
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/db"
+	"github.com/icon-project/btp/common/log"
+)
+
+func TestConfig_UnmarshalJSON(t *testing.T) {
+	var cfg Config
+	err := json.Unmarshal([]byte(`{"interval":30000000000,"warn":"0x64","stop":10}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.Interval)
+	assert.Equal(t, "100", cfg.Warn.String())
+	assert.Equal(t, "10", cfg.Stop.String())
+
+	assert.Equal(t, LevelNormal, cfg.LevelOf(big.NewInt(100)))
+	assert.Equal(t, LevelWarn, cfg.LevelOf(big.NewInt(99)))
+	assert.Equal(t, LevelStop, cfg.LevelOf(big.NewInt(9)))
+	assert.Equal(t, LevelNormal, (*Config)(nil).LevelOf(big.NewInt(0)))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"stop":"-1"}`), &cfg))
+	b, err := json.Marshal(cfg.Warn)
+	assert.NoError(t, err)
+	assert.Equal(t, `"100"`, string(b))
+}
+
+func TestMonitor(t *testing.T) {
+	balance := big.NewInt(1000)
+	var lvs []Level
+	m := NewMonitor(&Config{Warn: NewAmount(big.NewInt(500)), Stop: NewAmount(big.NewInt(100))},
+		func() (*big.Int, error) {
+			return new(big.Int).Set(balance), nil
+		}, func(b *big.Int, lv, prev Level) {
+			if lv != prev {
+				lvs = append(lvs, lv)
+			}
+		}, log.New())
+
+	//spend is ignored before the first poll
+	m.Spend(big.NewInt(10))
+	b, lv := m.Balance()
+	assert.Nil(t, b)
+	assert.Equal(t, LevelNormal, lv)
+
+	assert.NoError(t, m.Poll())
+	b, lv = m.Balance()
+	assert.Equal(t, int64(1000), b.Int64())
+	assert.Equal(t, LevelNormal, lv)
+
+	m.Spend(big.NewInt(600))
+	b, lv = m.Balance()
+	assert.Equal(t, int64(400), b.Int64())
+	assert.Equal(t, LevelWarn, lv)
+	m.Spend(big.NewInt(350))
+	_, lv = m.Balance()
+	assert.Equal(t, LevelStop, lv)
+
+	//recovered by poll after deposit
+	assert.NoError(t, m.Poll())
+	_, lv = m.Balance()
+	assert.Equal(t, LevelNormal, lv)
+	assert.Equal(t, []Level{LevelWarn, LevelStop, LevelNormal}, lvs)
+}
+
+func TestLedger(t *testing.T) {
+	database := db.NewMapDB()
+	l, err := NewLedger(database)
+	assert.NoError(t, err)
+
+	day1 := time.Date(2022, 3, 1, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+	assert.NoError(t, l.Add(day2, big.NewInt(30)))
+	assert.NoError(t, l.Add(day1, big.NewInt(10)))
+	assert.NoError(t, l.Add(day1, big.NewInt(20)))
+	assert.NoError(t, l.Add(day1, nil))
+
+	expected := []*DailyFee{
+		{Date: "2022-03-01", Count: 2, Fee: "30"},
+		{Date: "2022-03-02", Count: 1, Fee: "30"},
+	}
+	dfs, err := l.Days()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, dfs)
+	cnt, total := Total(dfs)
+	assert.Equal(t, int64(3), cnt)
+	assert.Equal(t, int64(60), total.Int64())
+
+	//restored from the database
+	l, err = NewLedger(database)
+	assert.NoError(t, err)
+	dfs, err = l.Days()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, dfs)
+}
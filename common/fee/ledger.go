@@ -0,0 +1,164 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fee
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/icon-project/btp/common/codec"
+	"github.com/icon-project/btp/common/db"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/intconv"
+)
+
+const (
+	LedgerBucket db.BucketID = "FeeLedger"
+
+	dateLayout = "2006-01-02"
+)
+
+var (
+	keyOfDayIndex = []byte("DayIndex")
+	prefixOfDay   = "d:"
+)
+
+type dayRecord struct {
+	Count int64
+	Fee   []byte
+}
+
+// DailyFee is the total of fees of transactions in the date of UTC.
+type DailyFee struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+	Fee   string `json:"fee"`
+}
+
+// Ledger accumulates fees of relay transactions per day in the database of the link.
+type Ledger struct {
+	mtx  sync.Mutex
+	bk   db.Bucket
+	days []string
+}
+
+func keyOfDay(date string) []byte {
+	return []byte(prefixOfDay + date)
+}
+
+func (l *Ledger) get(date string) (*dayRecord, error) {
+	r := &dayRecord{}
+	b, err := l.bk.Get(keyOfDay(date))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return r, nil
+	}
+	if _, err = codec.RLP.UnmarshalFromBytes(b, r); err != nil {
+		return nil, errors.Wrapf(err, "fail to unmarshal fee date:%s", date)
+	}
+	return r, nil
+}
+
+// Add records the fee of a transaction which is confirmed at t.
+func (l *Ledger) Add(t time.Time, fee *big.Int) error {
+	if fee == nil {
+		return nil
+	}
+	date := t.UTC().Format(dateLayout)
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	r, err := l.get(date)
+	if err != nil {
+		return err
+	}
+	var total big.Int
+	intconv.BigIntSetBytes(&total, r.Fee)
+	total.Add(&total, fee)
+	r.Count++
+	r.Fee = intconv.BigIntToBytes(&total)
+	b, err := codec.RLP.MarshalToBytes(r)
+	if err != nil {
+		return err
+	}
+	if err = l.bk.Set(keyOfDay(date), b); err != nil {
+		return err
+	}
+	idx := sort.SearchStrings(l.days, date)
+	if idx < len(l.days) && l.days[idx] == date {
+		return nil
+	}
+	l.days = append(l.days, "")
+	copy(l.days[idx+1:], l.days[idx:])
+	l.days[idx] = date
+	b, err = codec.RLP.MarshalToBytes(l.days)
+	if err != nil {
+		return err
+	}
+	return l.bk.Set(keyOfDayIndex, b)
+}
+
+// Days returns fees of all days in ascending order of the date.
+func (l *Ledger) Days() ([]*DailyFee, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	dfs := make([]*DailyFee, 0, len(l.days))
+	for _, date := range l.days {
+		r, err := l.get(date)
+		if err != nil {
+			return nil, err
+		}
+		var fee big.Int
+		intconv.BigIntSetBytes(&fee, r.Fee)
+		dfs = append(dfs, &DailyFee{Date: date, Count: r.Count, Fee: fee.String()})
+	}
+	return dfs, nil
+}
+
+// Total returns the number of transactions and the sum of fees of dfs.
+func Total(dfs []*DailyFee) (int64, *big.Int) {
+	var cnt int64
+	total := new(big.Int)
+	for _, df := range dfs {
+		cnt += df.Count
+		if v, ok := new(big.Int).SetString(df.Fee, 10); ok {
+			total.Add(total, v)
+		}
+	}
+	return cnt, total
+}
+
+func NewLedger(database db.Database) (*Ledger, error) {
+	bk, err := database.GetBucket(LedgerBucket)
+	if err != nil {
+		return nil, err
+	}
+	l := &Ledger{bk: bk, days: make([]string, 0)}
+	b, err := bk.Get(keyOfDayIndex)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > 0 {
+		if _, err = codec.RLP.UnmarshalFromBytes(b, &l.days); err != nil {
+			return nil, errors.Wrap(err, "fail to unmarshal index of fee ledger")
+		}
+	}
+	return l, nil
+}
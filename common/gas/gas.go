@@ -0,0 +1,217 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gas
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/errors"
+)
+
+type Strategy string
+
+const (
+	// StrategyOracle uses eth_gasPrice multiplied by gas_multiplier
+	StrategyOracle Strategy = "oracle"
+	// StrategyFixed uses gas_price
+	StrategyFixed Strategy = "fixed"
+	// StrategyEIP1559 uses base fee of the latest block with priority_fee, it's capped by max_fee
+	StrategyEIP1559 Strategy = "eip1559"
+)
+
+const (
+	DefaultStrategy       = StrategyOracle
+	DefaultReplaceTimeout = time.Minute
+	// DefaultBumpPercent is the minimum increase of gas price for replacement which is accepted by geth
+	DefaultBumpPercent = 10
+	// baseFeeScale allows base fee to be doubled before the transaction is included
+	baseFeeScale = 2
+)
+
+// Config of Pricer, it's decoded from options of the destination chain.
+// zero value of replace_timeout means default and negative value disables the replacement.
+type Config struct {
+	Strategy       Strategy        `json:"gas_strategy,omitempty"`
+	GasPrice       *Wei            `json:"gas_price,omitempty"`
+	MaxFee         *Wei            `json:"max_fee,omitempty"`
+	PriorityFee    *Wei            `json:"priority_fee,omitempty"`
+	Multiplier     Multiplier      `json:"gas_multiplier,omitempty"`
+	Ceiling        *Wei            `json:"gas_ceiling,omitempty"`
+	ReplaceTimeout common.Duration `json:"replace_timeout,omitempty"`
+}
+
+// Caller sends JSON-RPC request, *rpc.Client implements it.
+type Caller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// Pricer decides gas price of transactions to send.
+// go-ethereum of this module doesn't support dynamic fee transaction,
+// so the fee of StrategyEIP1559 is sent as gas price of legacy transaction which is accepted after London fork.
+type Pricer struct {
+	cfg Config
+	c   Caller
+}
+
+func (p *Pricer) suggestion(ctx context.Context) (*big.Int, error) {
+	var price hexutil.Big
+	if err := p.c.CallContext(ctx, &price, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return p.cfg.Multiplier.apply((*big.Int)(&price)), nil
+}
+
+// baseFee returns base fee of the latest block, it returns nil before London fork.
+func (p *Pricer) baseFee(ctx context.Context) (*big.Int, error) {
+	var head struct {
+		BaseFee *hexutil.Big `json:"baseFeePerGas"`
+	}
+	if err := p.c.CallContext(ctx, &head, "eth_getBlockByNumber", "latest", false); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(head.BaseFee), nil
+}
+
+func (p *Pricer) priorityFee(ctx context.Context) (*big.Int, error) {
+	if p.cfg.PriorityFee != nil {
+		return p.cfg.PriorityFee.Int(), nil
+	}
+	var tip hexutil.Big
+	if err := p.c.CallContext(ctx, &tip, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&tip), nil
+}
+
+func (p *Pricer) eip1559(ctx context.Context) (*big.Int, error) {
+	baseFee, err := p.baseFee(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if baseFee == nil {
+		return p.suggestion(ctx)
+	}
+	tip, err := p.priorityFee(ctx)
+	if err != nil {
+		return nil, err
+	}
+	price := new(big.Int).Mul(baseFee, big.NewInt(baseFeeScale))
+	price.Add(price, tip)
+	if p.cfg.MaxFee != nil && price.Cmp(p.cfg.MaxFee.Int()) > 0 {
+		price = p.cfg.MaxFee.Int()
+	}
+	return price, nil
+}
+
+func (p *Pricer) capped(price *big.Int) *big.Int {
+	if p.cfg.Ceiling != nil && price.Cmp(p.cfg.Ceiling.Int()) > 0 {
+		return p.cfg.Ceiling.Int()
+	}
+	return price
+}
+
+// GasPrice returns gas price for a new transaction, it doesn't exceed gas_ceiling.
+func (p *Pricer) GasPrice(ctx context.Context) (*big.Int, error) {
+	var price *big.Int
+	var err error
+	switch p.cfg.Strategy {
+	case StrategyFixed:
+		price = p.cfg.GasPrice.Int()
+	case StrategyEIP1559:
+		price, err = p.eip1559(ctx)
+	default:
+		price, err = p.suggestion(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.capped(price), nil
+}
+
+// Bump returns gas price to replace the transaction which has the price.
+// it's the larger one of DefaultBumpPercent increased price and current price.
+// it returns InvalidStateError if the price couldn't be increased under gas_ceiling.
+func (p *Pricer) Bump(ctx context.Context, price *big.Int) (*big.Int, error) {
+	bumped := new(big.Int).Mul(price, big.NewInt(100+DefaultBumpPercent))
+	bumped.Add(bumped, big.NewInt(99))
+	bumped.Div(bumped, big.NewInt(100))
+	current, err := p.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current.Cmp(bumped) > 0 {
+		bumped = current
+	}
+	if bumped = p.capped(bumped); bumped.Cmp(price) <= 0 {
+		return nil, errors.InvalidStateError.Errorf("fail to bump gas price %s ceiling:%s", price, p.cfg.Ceiling)
+	}
+	return bumped, nil
+}
+
+// Replacement returns unsigned transaction which has same nonce with tx and bumped gas price.
+func (p *Pricer) Replacement(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	price, err := p.Bump(ctx, tx.GasPrice())
+	if err != nil {
+		return nil, err
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    tx.Nonce(),
+		To:       tx.To(),
+		Value:    tx.Value(),
+		Gas:      tx.Gas(),
+		GasPrice: price,
+		Data:     tx.Data(),
+	}), nil
+}
+
+// ReplaceTimeout returns the duration which the transaction could be pending before replacement,
+// it returns zero if the replacement is disabled.
+func (p *Pricer) ReplaceTimeout() time.Duration {
+	switch d := time.Duration(p.cfg.ReplaceTimeout); {
+	case d < 0:
+		return 0
+	case d == 0:
+		return DefaultReplaceTimeout
+	default:
+		return d
+	}
+}
+
+// NewPricer returns Pricer which requests gas price via c.
+func NewPricer(cfg Config, c Caller) (*Pricer, error) {
+	switch cfg.Strategy {
+	case "":
+		cfg.Strategy = DefaultStrategy
+	case StrategyOracle, StrategyEIP1559:
+	case StrategyFixed:
+		if cfg.GasPrice == nil {
+			return nil, errors.IllegalArgumentError.Errorf("gas_price is required for %s", cfg.Strategy)
+		}
+	default:
+		return nil, errors.IllegalArgumentError.Errorf("unknown gas_strategy %s", cfg.Strategy)
+	}
+	if cfg.Multiplier < 0 {
+		return nil, errors.IllegalArgumentError.Errorf("invalid gas_multiplier %v", cfg.Multiplier)
+	}
+	return &Pricer{cfg: cfg, c: c}, nil
+}
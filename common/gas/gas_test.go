@@ -0,0 +1,133 @@
+package gas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common"
+)
+
+type testCaller map[string]interface{}
+
+func (c testCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	v, ok := c[method]
+	if !ok {
+		return fmt.Errorf("method not found %s", method)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, result)
+}
+
+func gwei(v int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(v), big.NewInt(1000000000))
+}
+
+func newTestPricer(t *testing.T, opt map[string]interface{}, c Caller) *Pricer {
+	b, err := json.Marshal(opt)
+	assert.NoError(t, err)
+	cfg := Config{}
+	assert.NoError(t, json.Unmarshal(b, &cfg))
+	p, err := NewPricer(cfg, c)
+	assert.NoError(t, err)
+	return p
+}
+
+func TestConfig_Unmarshal(t *testing.T) {
+	cfg := Config{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"gas_strategy":"eip1559","max_fee":"1.5gwei","priority_fee":"0x10",`+
+		`"gas_ceiling":100,"gas_multiplier":"1.2","replace_timeout":"30s"}`), &cfg))
+	assert.Equal(t, StrategyEIP1559, cfg.Strategy)
+	assert.Equal(t, big.NewInt(1500000000), cfg.MaxFee.Int())
+	assert.Equal(t, big.NewInt(16), cfg.PriorityFee.Int())
+	assert.Equal(t, big.NewInt(100), cfg.Ceiling.Int())
+	assert.Equal(t, Multiplier(1.2), cfg.Multiplier)
+	assert.Equal(t, common.Duration(30*time.Second), cfg.ReplaceTimeout)
+	assert.Nil(t, cfg.GasPrice)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"gas_price":"-1"}`), &cfg))
+	assert.Error(t, json.Unmarshal([]byte(`{"gas_price":"abc"}`), &cfg))
+
+	_, err := NewPricer(Config{Strategy: StrategyFixed}, testCaller{})
+	assert.Error(t, err)
+	_, err = NewPricer(Config{Strategy: "unknown"}, testCaller{})
+	assert.Error(t, err)
+}
+
+func TestPricer_GasPrice(t *testing.T) {
+	c := testCaller{
+		"eth_gasPrice":             (*hexutil.Big)(gwei(10)),
+		"eth_maxPriorityFeePerGas": (*hexutil.Big)(gwei(2)),
+		"eth_getBlockByNumber":     map[string]interface{}{"baseFeePerGas": (*hexutil.Big)(gwei(5))},
+	}
+	ctx := context.Background()
+
+	price, err := newTestPricer(t, nil, c).GasPrice(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(10), price)
+
+	price, err = newTestPricer(t, map[string]interface{}{"gas_multiplier": "1.5"}, c).GasPrice(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(15), price)
+
+	price, err = newTestPricer(t, map[string]interface{}{"gas_multiplier": "1.5", "gas_ceiling": "12gwei"}, c).GasPrice(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(12), price)
+
+	price, err = newTestPricer(t, map[string]interface{}{"gas_strategy": "fixed", "gas_price": "3gwei"}, c).GasPrice(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(3), price)
+
+	//base fee * 2 + priority fee
+	price, err = newTestPricer(t, map[string]interface{}{"gas_strategy": "eip1559"}, c).GasPrice(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(12), price)
+
+	price, err = newTestPricer(t, map[string]interface{}{"gas_strategy": "eip1559", "priority_fee": "1gwei"}, c).GasPrice(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(11), price)
+
+	price, err = newTestPricer(t, map[string]interface{}{"gas_strategy": "eip1559", "max_fee": "8gwei"}, c).GasPrice(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(8), price)
+
+	//block without base fee before London fork
+	c["eth_getBlockByNumber"] = map[string]interface{}{}
+	price, err = newTestPricer(t, map[string]interface{}{"gas_strategy": "eip1559"}, c).GasPrice(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(10), price)
+}
+
+func TestPricer_Bump(t *testing.T) {
+	c := testCaller{"eth_gasPrice": (*hexutil.Big)(gwei(10))}
+	ctx := context.Background()
+	p := newTestPricer(t, map[string]interface{}{"gas_ceiling": "20gwei"}, c)
+
+	price, err := p.Bump(ctx, gwei(5))
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(10), price)
+
+	price, err = p.Bump(ctx, gwei(15))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(16500000000), price)
+
+	price, err = p.Bump(ctx, gwei(19))
+	assert.NoError(t, err)
+	assert.Equal(t, gwei(20), price)
+
+	_, err = p.Bump(ctx, gwei(20))
+	assert.Error(t, err)
+
+	assert.Equal(t, DefaultReplaceTimeout, p.ReplaceTimeout())
+	assert.Equal(t, time.Duration(0),
+		newTestPricer(t, map[string]interface{}{"replace_timeout": "-1s"}, c).ReplaceTimeout())
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gas
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// unquote returns the string of JSON string or number, options of command line are given as string.
+func unquote(b []byte) (string, error) {
+	var s string
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(s), nil
+	}
+	return string(b), nil
+}
+
+// Wei is the amount in wei, it's decoded from decimal, "0x" prefixed hex or decimal with "gwei" suffix.
+type Wei big.Int
+
+func (w *Wei) Int() *big.Int {
+	if w == nil {
+		return nil
+	}
+	return new(big.Int).Set((*big.Int)(w))
+}
+
+func (w *Wei) String() string {
+	if w == nil {
+		return "<nil>"
+	}
+	return (*big.Int)(w).String()
+}
+
+func (w *Wei) SetString(s string) error {
+	if strings.HasSuffix(strings.ToLower(s), "gwei") {
+		f, ok := new(big.Float).SetString(strings.TrimSpace(s[:len(s)-4]))
+		if !ok || f.Sign() < 0 {
+			return fmt.Errorf("invalid wei %s", s)
+		}
+		f.Mul(f, big.NewFloat(params.GWei)).Int((*big.Int)(w))
+		return nil
+	}
+	var ok bool
+	if strings.HasPrefix(s, "0x") {
+		_, ok = (*big.Int)(w).SetString(s[2:], 16)
+	} else {
+		_, ok = (*big.Int)(w).SetString(s, 10)
+	}
+	if !ok || (*big.Int)(w).Sign() < 0 {
+		return fmt.Errorf("invalid wei %s", s)
+	}
+	return nil
+}
+
+func (w Wei) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*big.Int)(&w).String())
+}
+
+func (w *Wei) UnmarshalJSON(b []byte) error {
+	s, err := unquote(b)
+	if err != nil {
+		return err
+	}
+	return w.SetString(s)
+}
+
+// Multiplier is applied to suggested gas price, zero value means 1.
+type Multiplier float64
+
+func (m Multiplier) apply(v *big.Int) *big.Int {
+	if m == 0 || m == 1 {
+		return v
+	}
+	f := new(big.Float).SetInt(v)
+	r, _ := f.Mul(f, big.NewFloat(float64(m))).Int(nil)
+	return r
+}
+
+func (m *Multiplier) UnmarshalJSON(b []byte) error {
+	s, err := unquote(b)
+	if err != nil {
+		return err
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*m = Multiplier(f)
+	return nil
+}
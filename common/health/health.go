@@ -0,0 +1,344 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package health
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/log"
+)
+
+type Action string
+
+const (
+	// ActionNone only reports stall by probes
+	ActionNone Action = ""
+	// ActionRestart restarts receive and monitor loops of the stalled direction
+	ActionRestart Action = "restart"
+	// ActionExit stops the stalled direction, then the process exits with non-zero
+	ActionExit Action = "exit"
+)
+
+const (
+	DefaultInterval     = 10 * time.Second
+	DefaultSrcTimeout   = 5 * time.Minute
+	DefaultDstTimeout   = 5 * time.Minute
+	DefaultRelayTimeout = 10 * time.Minute
+	DefaultMaxRestart   = 3
+
+	UrlLive  = "/health/live"
+	UrlReady = "/health/ready"
+)
+
+const (
+	StalledSrc   = "src"
+	StalledDst   = "dst"
+	StalledRelay = "relay"
+)
+
+var (
+	ErrStalled = errors.NewBase(errors.TimeoutError, "Stalled")
+)
+
+// Config of Watchdog, zero value of timeout means default and negative value disables the check.
+type Config struct {
+	Action       Action          `json:"action,omitempty"`
+	Interval     common.Duration `json:"interval,omitempty"`
+	SrcTimeout   common.Duration `json:"src_timeout,omitempty"`
+	DstTimeout   common.Duration `json:"dst_timeout,omitempty"`
+	RelayTimeout common.Duration `json:"relay_timeout,omitempty"`
+	// MaxRestart is the number of consecutive restarts without progress, then ActionExit is used
+	MaxRestart int `json:"max_restart,omitempty"`
+}
+
+type Status struct {
+	Name      string    `json:"name"`
+	Ready     bool      `json:"ready"`
+	Stalled   string    `json:"stalled,omitempty"`
+	SrcHeight int64     `json:"src_height"`
+	LastSrc   time.Time `json:"last_src"`
+	DstHeight int64     `json:"dst_height"`
+	LastDst   time.Time `json:"last_dst"`
+	LastRelay time.Time `json:"last_relay"`
+	Restarts  int       `json:"restarts"`
+}
+
+// StallFunc is called when the direction is stalled, restart is false if it should be stopped.
+type StallFunc func(err error, restart bool)
+
+// Watchdog tracks progress of a direction of link and declares stall
+// when there is no progress over the timeout.
+type Watchdog struct {
+	mtx      sync.Mutex
+	cfg      Config
+	st       Status
+	pending  func() bool
+	onStall  StallFunc
+	stop     chan struct{}
+	l        log.Logger
+	progress bool
+	started  time.Time
+}
+
+func (w *Watchdog) OnSrc(height int64) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.st.SrcHeight = height
+	w.st.LastSrc = time.Now()
+	w._progress()
+}
+
+func (w *Watchdog) OnDst(height int64) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.st.DstHeight = height
+	w.st.LastDst = time.Now()
+	w._progress()
+}
+
+// OnRelay should be called when the relay is confirmed
+func (w *Watchdog) OnRelay() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.st.LastRelay = time.Now()
+	w.progress = true
+}
+
+func (w *Watchdog) _progress() {
+	w.st.Ready = (!w.st.LastSrc.IsZero() || w.cfg.SrcTimeout < 0) && !w.st.LastDst.IsZero()
+	if w.st.Stalled != "" {
+		w.l.Infof("recovered from stall of %s", w.st.Stalled)
+		w.st.Stalled = ""
+	}
+	w.progress = true
+}
+
+// SrcHeight returns last height of source, zero if it's not received.
+func (w *Watchdog) SrcHeight() int64 {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.st.SrcHeight
+}
+
+// DstHeight returns last height of destination, zero if it's not monitored.
+func (w *Watchdog) DstHeight() int64 {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.st.DstHeight
+}
+
+func (w *Watchdog) Status() *Status {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	st := w.st
+	return &st
+}
+
+// Check declares stall if there is no progress until now, it returns ErrStalled with reason.
+func (w *Watchdog) Check(now time.Time) error {
+	// pending may require lock of the chain which calls OnRelay with the lock
+	pending := w.pending != nil && w.pending()
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if !pending {
+		w.st.LastRelay = now
+	}
+	var reason string
+	var since time.Duration
+	switch {
+	case w.expired(now, w.st.LastSrc, time.Duration(w.cfg.SrcTimeout), &since):
+		reason = StalledSrc
+	case w.expired(now, w.st.LastDst, time.Duration(w.cfg.DstTimeout), &since):
+		reason = StalledDst
+	case w.expired(now, w.st.LastRelay, time.Duration(w.cfg.RelayTimeout), &since):
+		reason = StalledRelay
+	default:
+		return nil
+	}
+	w.st.Stalled = reason
+	w.st.Ready = false
+	return errors.Wrapf(ErrStalled, "stalled %s for %v", reason, since)
+}
+
+// expired returns whether timeout is elapsed since last, started time is used if last is zero.
+func (w *Watchdog) expired(now, last time.Time, timeout time.Duration, since *time.Duration) bool {
+	if timeout < 0 {
+		return false
+	}
+	if last.IsZero() {
+		last = w.started
+	}
+	*since = now.Sub(last)
+	return *since > timeout
+}
+
+func (w *Watchdog) check() {
+	err := w.Check(time.Now())
+	if err == nil {
+		w.mtx.Lock()
+		if w.progress {
+			w.st.Restarts = 0
+			w.progress = false
+		}
+		w.mtx.Unlock()
+		return
+	}
+	w.l.Warnf("watchdog %+v", err)
+	if w.cfg.Action == ActionNone || w.onStall == nil {
+		return
+	}
+	w.mtx.Lock()
+	restart := w.cfg.Action == ActionRestart && w.st.Restarts < w.cfg.MaxRestart
+	if restart {
+		w.st.Restarts++
+		w.started = time.Now()
+		w.st.LastSrc, w.st.LastDst, w.st.LastRelay = time.Time{}, time.Time{}, w.started
+	}
+	w.progress = false
+	w.mtx.Unlock()
+	w.onStall(err, restart)
+}
+
+func (w *Watchdog) Start() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	w.started = time.Now()
+	go func(stop <-chan struct{}) {
+		t := time.NewTicker(time.Duration(w.cfg.Interval))
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				w.check()
+			}
+		}
+	}(w.stop)
+}
+
+func (w *Watchdog) Stop() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// NewWatchdog returns Watchdog which is registered for probes,
+// pending returns whether there are messages to relay.
+func NewWatchdog(name string, cfg *Config, pending func() bool, onStall StallFunc, l log.Logger) *Watchdog {
+	w := &Watchdog{
+		st:      Status{Name: name},
+		pending: pending,
+		onStall: onStall,
+		l:       l,
+	}
+	if cfg != nil {
+		w.cfg = *cfg
+	}
+	w.started = time.Now()
+	if w.cfg.Interval <= 0 {
+		w.cfg.Interval = common.Duration(DefaultInterval)
+	}
+	if w.cfg.SrcTimeout == 0 {
+		w.cfg.SrcTimeout = common.Duration(DefaultSrcTimeout)
+	}
+	if w.cfg.DstTimeout == 0 {
+		w.cfg.DstTimeout = common.Duration(DefaultDstTimeout)
+	}
+	if w.cfg.RelayTimeout == 0 {
+		w.cfg.RelayTimeout = common.Duration(DefaultRelayTimeout)
+	}
+	if w.cfg.MaxRestart <= 0 {
+		w.cfg.MaxRestart = DefaultMaxRestart
+	}
+	register(w)
+	return w
+}
+
+var (
+	wdsMtx sync.Mutex
+	wds    = make(map[string]*Watchdog)
+)
+
+func register(w *Watchdog) {
+	wdsMtx.Lock()
+	defer wdsMtx.Unlock()
+	wds[w.st.Name] = w
+}
+
+// Unregister removes w from probes, it's used when the direction is removed without exiting the process.
+func Unregister(w *Watchdog) {
+	wdsMtx.Lock()
+	defer wdsMtx.Unlock()
+	if wds[w.st.Name] == w {
+		delete(wds, w.st.Name)
+	}
+}
+
+func statuses() ([]*Status, bool, bool) {
+	wdsMtx.Lock()
+	defer wdsMtx.Unlock()
+	l := make([]*Status, 0, len(wds))
+	live, ready := true, true
+	for _, w := range wds {
+		st := w.Status()
+		if st.Stalled != "" {
+			live = false
+		}
+		if !st.Ready {
+			ready = false
+		}
+		l = append(l, st)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Name < l[j].Name
+	})
+	return l, live, ready
+}
+
+func probe(ready bool) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		l, isLive, isReady := statuses()
+		code := http.StatusOK
+		if (ready && !isReady) || (!ready && !isLive) {
+			code = http.StatusServiceUnavailable
+		}
+		return ctx.JSON(code, l)
+	}
+}
+
+// RegisterHandlers adds liveness and readiness probes of all Watchdogs,
+// liveness fails if any direction is stalled,
+// readiness fails if any direction is stalled or has not received blocks of both chains.
+func RegisterHandlers(e *echo.Echo) {
+	e.GET(UrlLive, probe(false))
+	e.GET(UrlReady, probe(true))
+}
@@ -0,0 +1,141 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/log"
+)
+
+func TestWatchdog_Check(t *testing.T) {
+	pending := false
+	w := NewWatchdog("0x1.icon", &Config{
+		SrcTimeout:   common.Duration(time.Minute),
+		DstTimeout:   common.Duration(time.Minute),
+		RelayTimeout: common.Duration(20 * time.Second),
+	}, func() bool { return pending }, nil, log.New())
+	now := w.started
+
+	assert.False(t, w.Status().Ready)
+	assert.NoError(t, w.Check(now.Add(time.Second)))
+
+	err := w.Check(now.Add(2 * time.Minute))
+	assert.True(t, errors.Is(err, ErrStalled))
+	assert.Equal(t, StalledSrc, w.Status().Stalled)
+
+	w.OnSrc(10)
+	w.OnDst(20)
+	st := w.Status()
+	assert.True(t, st.Ready)
+	assert.Equal(t, "", st.Stalled)
+	assert.Equal(t, int64(10), w.SrcHeight())
+	assert.Equal(t, int64(20), w.DstHeight())
+
+	//relay is not stalled without pending
+	now = time.Now()
+	assert.NoError(t, w.Check(now.Add(30*time.Second)))
+	pending = true
+	assert.NoError(t, w.Check(now.Add(45*time.Second)))
+	err = w.Check(now.Add(55 * time.Second))
+	assert.True(t, errors.Is(err, ErrStalled))
+	assert.Equal(t, StalledRelay, w.Status().Stalled)
+	assert.False(t, w.Status().Ready)
+}
+
+func TestWatchdog_Disabled(t *testing.T) {
+	w := NewWatchdog("0x2.icon", &Config{SrcTimeout: -1, DstTimeout: common.Duration(time.Hour)}, nil, nil, log.New())
+	w.OnDst(1)
+	assert.True(t, w.Status().Ready)
+	assert.NoError(t, w.Check(time.Now().Add(DefaultSrcTimeout*2)))
+}
+
+func TestWatchdog_Restart(t *testing.T) {
+	var restarts []bool
+	w := NewWatchdog("0x3.icon", &Config{
+		Action:     ActionRestart,
+		DstTimeout: -1,
+		SrcTimeout: common.Duration(time.Nanosecond),
+		MaxRestart: 2,
+	}, nil, func(err error, restart bool) {
+		assert.True(t, errors.Is(err, ErrStalled))
+		restarts = append(restarts, restart)
+	}, log.New())
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		w.check()
+	}
+	assert.Equal(t, []bool{true, true, false}, restarts)
+
+	assert.Equal(t, 2, w.Status().Restarts)
+
+	//progress resets restarts
+	restarts = nil
+	w.mtx.Lock()
+	w.cfg.SrcTimeout = common.Duration(time.Minute)
+	w.mtx.Unlock()
+	w.OnSrc(1)
+	w.check()
+	assert.Equal(t, 0, len(restarts))
+	assert.Equal(t, 0, w.Status().Restarts)
+}
+
+func TestConfig_UnmarshalJSON(t *testing.T) {
+	var cfg Config
+	err := json.Unmarshal([]byte(`{"interval":"30s","src_timeout":"-1s","relay_timeout":600000000000}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Duration(30*time.Second), cfg.Interval)
+	assert.True(t, cfg.SrcTimeout < 0)
+	assert.Equal(t, common.Duration(0), cfg.DstTimeout)
+	assert.Equal(t, common.Duration(10*time.Minute), cfg.RelayTimeout)
+}
+
+func TestRegisterHandlers(t *testing.T) {
+	wdsMtx.Lock()
+	wds = make(map[string]*Watchdog)
+	wdsMtx.Unlock()
+	w := NewWatchdog("0x4.icon", nil, nil, nil, log.New())
+
+	e := echo.New()
+	RegisterHandlers(e)
+	get := func(url string) (int, []*Status) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		var l []*Status
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &l))
+		return rec.Code, l
+	}
+
+	code, l := get(UrlLive)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, len(l))
+	assert.Equal(t, "0x4.icon", l[0].Name)
+	code, _ = get(UrlReady)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	w.OnSrc(1)
+	w.OnDst(1)
+	code, _ = get(UrlReady)
+	assert.Equal(t, http.StatusOK, code)
+
+	assert.Error(t, w.Check(time.Now().Add(DefaultSrcTimeout*2)))
+	code, l = get(UrlLive)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StalledSrc, l[0].Stalled)
+	code, _ = get(UrlReady)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	//removed direction is not reported
+	Unregister(w)
+	code, l = get(UrlLive)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 0, len(l))
+}
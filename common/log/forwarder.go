@@ -141,6 +141,11 @@ func (h *HookWrapper) Fire(e *logrus.Entry) error {
 type HookCreater func(c *ForwarderConfig) (logrus.Hook, error)
 
 func AddForwarder(c *ForwarderConfig) error {
+	return AddForwarderTo(globalLogger, c)
+}
+
+// AddForwarderTo adds the forwarder to the logger which is not derived from the global logger.
+func AddForwarderTo(logger Logger, c *ForwarderConfig) error {
 	if c.Level == "" {
 		c.Level = "info"
 	}
@@ -164,7 +169,7 @@ func AddForwarder(c *ForwarderConfig) error {
 	if err != nil {
 		return err
 	}
-	globalLogger.addHook(h)
+	logger.addHook(h)
 	return nil
 }
 
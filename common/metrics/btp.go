@@ -0,0 +1,191 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+const (
+	LabelSrc    = "src"
+	LabelDst    = "dst"
+	LabelCode   = "code"
+	LabelClient = "client"
+	LabelMethod = "method"
+)
+
+var (
+	srcHeight      = NewGaugeVec("btp_src_height", "Last height of source received", LabelSrc, LabelDst)
+	dstHeight      = NewGaugeVec("btp_dst_height", "Last height of destination monitored", LabelSrc, LabelDst)
+	verifierHeight = NewGaugeVec("btp_verifier_height", "Height of BMCLinkStatus.Verifier", LabelSrc, LabelDst)
+	rxSeq          = NewGaugeVec("btp_rx_seq", "BMCLinkStatus.RxSeq of destination", LabelSrc, LabelDst)
+	txSeq          = NewGaugeVec("btp_tx_seq", "Last sequence of message received from source", LabelSrc, LabelDst)
+	queued         = NewGaugeVec("btp_queued_relay_messages", "Number of queued relay messages", LabelSrc, LabelDst)
+	segSent        = NewCounterVec("btp_segments_sent_total", "Number of segments sent", LabelSrc, LabelDst)
+	segConfirmed   = NewCounterVec("btp_segments_confirmed_total", "Number of segments confirmed", LabelSrc, LabelDst)
+	segFailed      = NewCounterVec("btp_segments_failed_total", "Number of segments failed by error code", LabelSrc, LabelDst, LabelCode)
+	feeSpent       = NewCounterVec("btp_fee_spent_total", "Transaction fee spent in the smallest unit of coin", LabelSrc, LabelDst)
+	balance        = NewGaugeVec("btp_balance", "Balance of the relay account on destination in the smallest unit of coin", LabelSrc, LabelDst)
+	msgUnserved    = NewCounterVec("btp_unserved_messages_total", "Number of messages for the next BMC which is not served by the relay", LabelSrc, LabelDst)
+	msgDelivered   = NewCounterVec("btp_delivered_messages_total", "Number of messages confirmed end-to-end across links of the relay", LabelSrc, LabelDst)
+
+	rpcDuration = NewHistogramVec("btp_rpc_duration_seconds", "Latency of RPC by method", nil, LabelClient, LabelMethod)
+	rpcErrors   = NewCounterVec("btp_rpc_errors_total", "Number of failed RPC by method", LabelClient, LabelMethod)
+	wsConnects  = NewCounterVec("btp_websocket_connects_total", "Number of websocket connects", LabelClient)
+	wsReconnect = NewCounterVec("btp_websocket_reconnects_total", "Number of websocket reconnects", LabelClient)
+)
+
+// LinkMetrics is set of metrics for a direction of link
+type LinkMetrics struct {
+	src, dst       string
+	SrcHeight      *Value
+	DstHeight      *Value
+	VerifierHeight *Value
+	RxSeq          *Value
+	TxSeq          *Value
+	Queued         *Value
+	Sent           *Value
+	Confirmed      *Value
+	Fee            *Value
+	Balance        *Value
+}
+
+func (m *LinkMetrics) Failed(code string) {
+	segFailed.With(m.src, m.dst, code).Inc()
+}
+
+func (m *LinkMetrics) SetStatus(currentHeight, verifierHeight int64, rxSeq *big.Int) {
+	m.DstHeight.Set(float64(currentHeight))
+	m.VerifierHeight.Set(float64(verifierHeight))
+	if rxSeq != nil {
+		m.RxSeq.Set(BigFloat(rxSeq))
+	}
+}
+
+func NewLinkMetrics(src, dst string) *LinkMetrics {
+	return &LinkMetrics{
+		src:            src,
+		dst:            dst,
+		SrcHeight:      srcHeight.With(src, dst),
+		DstHeight:      dstHeight.With(src, dst),
+		VerifierHeight: verifierHeight.With(src, dst),
+		RxSeq:          rxSeq.With(src, dst),
+		TxSeq:          txSeq.With(src, dst),
+		Queued:         queued.With(src, dst),
+		Sent:           segSent.With(src, dst),
+		Confirmed:      segConfirmed.With(src, dst),
+		Fee:            feeSpent.With(src, dst),
+		Balance:        balance.With(src, dst),
+	}
+}
+
+func BigFloat(v *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(v).Float64()
+	return f
+}
+
+// UnservedMessage counts the message from src for the next which is not served by the relay
+func UnservedMessage(src, next string) {
+	msgUnserved.With(src, next).Inc()
+}
+
+// DeliveredMessage counts the message which is delivered from src to dst across links of the relay
+func DeliveredMessage(src, dst string) {
+	msgDelivered.With(src, dst).Inc()
+}
+
+// ObserveRPC records latency and failure of RPC
+func ObserveRPC(client, method string, start time.Time, err error) {
+	rpcDuration.With(client, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		rpcErrors.With(client, method).Inc()
+	}
+}
+
+// WebsocketConnected counts connects of websocket, reconnect is counted if it's not first connect
+func WebsocketConnected(client string, reconnect bool) {
+	wsConnects.With(client).Inc()
+	if reconnect {
+		wsReconnect.With(client).Inc()
+	}
+}
+
+type rpcTransport struct {
+	client string
+	rt     http.RoundTripper
+}
+
+func (t *rpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := "unknown"
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+		method = methodOf(b)
+	}
+	start := time.Now()
+	resp, err := t.rt.RoundTrip(req)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		ObserveRPC(t.client, method, start, &httpStatusError{resp.StatusCode})
+	} else {
+		ObserveRPC(t.client, method, start, err)
+	}
+	return resp, err
+}
+
+type httpStatusError struct {
+	status int
+}
+
+func (e *httpStatusError) Error() string {
+	return http.StatusText(e.status)
+}
+
+func methodOf(b []byte) string {
+	req := &struct {
+		Method string `json:"method"`
+	}{}
+	if len(b) > 0 && b[0] == '[' {
+		reqs := make([]*struct {
+			Method string `json:"method"`
+		}, 0)
+		if err := json.Unmarshal(b, &reqs); err != nil || len(reqs) == 0 {
+			return "unknown"
+		}
+		return "batch:" + reqs[0].Method
+	}
+	if err := json.Unmarshal(b, req); err != nil || req.Method == "" {
+		return "unknown"
+	}
+	return req.Method
+}
+
+// NewRPCTransport returns http.RoundTripper which records latency of JSON-RPC by method
+func NewRPCTransport(client string, rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &rpcTransport{client: client, rt: rt}
+}
@@ -0,0 +1,348 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/icon-project/btp/common"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+	DefaultPath = "/metrics"
+)
+
+var (
+	DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	defaultReg     = NewRegistry()
+)
+
+type collector interface {
+	name() string
+	write(w io.Writer) error
+}
+
+// Registry keeps metrics and writes them in Prometheus text exposition format.
+type Registry struct {
+	mtx sync.RWMutex
+	cs  map[string]collector
+}
+
+func (r *Registry) register(c collector) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.cs[c.name()]; ok {
+		panic(fmt.Sprintf("duplicated metric name:%s", c.name()))
+	}
+	r.cs[c.name()] = c
+}
+
+func (r *Registry) Write(w io.Writer) error {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	names := make([]string, 0, len(r.cs))
+	for n := range r.cs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	bw := bufio.NewWriter(w)
+	for _, n := range names {
+		if err := r.cs[n].write(bw); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	if err := r.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func NewRegistry() *Registry {
+	return &Registry{cs: make(map[string]collector)}
+}
+
+// Handler returns http.Handler of default Registry
+func Handler() http.Handler {
+	return defaultReg
+}
+
+// NewHttpServer returns common.HttpServer which serves default Registry on DefaultPath
+func NewHttpServer(address string) *common.HttpServer {
+	s := common.NewHttpServer(address, nil)
+	s.Echo().HideBanner = true
+	s.Echo().HidePort = true
+	s.Echo().GET(DefaultPath, echo.WrapHandler(defaultReg))
+	return s
+}
+
+// Serve starts the server of NewHttpServer in background, if address is empty, it does nothing.
+// routes are called before start to serve additional handlers (ex: health probes).
+func Serve(address string, errFn func(err error), routes ...func(e *echo.Echo)) *common.HttpServer {
+	if address == "" {
+		return nil
+	}
+	s := NewHttpServer(address)
+	for _, route := range routes {
+		route(s.Echo())
+	}
+	go func() {
+		if err := s.Start(); err != nil && err != http.ErrServerClosed && errFn != nil {
+			errFn(err)
+		}
+	}()
+	return s
+}
+
+type vec struct {
+	mtx    sync.RWMutex
+	n      string
+	help   string
+	typ    string
+	labels []string
+	values map[string]interface{}
+	keys   map[string][]string
+	newFn  func() interface{}
+}
+
+func (v *vec) name() string {
+	return v.n
+}
+
+func (v *vec) with(lvs []string) interface{} {
+	if len(lvs) != len(v.labels) {
+		panic(fmt.Sprintf("invalid label values metric:%s expected:%d actual:%d",
+			v.n, len(v.labels), len(lvs)))
+	}
+	k := strings.Join(lvs, "\xff")
+	v.mtx.RLock()
+	m, ok := v.values[k]
+	v.mtx.RUnlock()
+	if ok {
+		return m
+	}
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+	if m, ok = v.values[k]; !ok {
+		m = v.newFn()
+		v.values[k] = m
+		v.keys[k] = append([]string{}, lvs...)
+	}
+	return m
+}
+
+func (v *vec) labelString(k string, extra ...string) string {
+	lvs := v.keys[k]
+	if len(lvs) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(lvs)+1)
+	for i, lv := range lvs {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", v.labels[i], strconv.Quote(lv)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", extra[i], strconv.Quote(extra[i+1])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (v *vec) write(w io.Writer) error {
+	v.mtx.RLock()
+	defer v.mtx.RUnlock()
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.n, v.help, v.n, v.typ); err != nil {
+		return err
+	}
+	ks := make([]string, 0, len(v.values))
+	for k := range v.values {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	for _, k := range ks {
+		switch m := v.values[k].(type) {
+		case *Value:
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", v.n, v.labelString(k), formatFloat(m.Get())); err != nil {
+				return err
+			}
+		case *Histogram:
+			if err := m.write(w, v, k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newVec(name, help, typ string, labels []string, newFn func() interface{}) *vec {
+	v := &vec{
+		n:      name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		values: make(map[string]interface{}),
+		keys:   make(map[string][]string),
+		newFn:  newFn,
+	}
+	defaultReg.register(v)
+	return v
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+// Value is a value of Counter or Gauge
+type Value struct {
+	mtx sync.Mutex
+	v   float64
+}
+
+func (m *Value) Add(f float64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.v += f
+}
+
+func (m *Value) Inc() {
+	m.Add(1)
+}
+
+func (m *Value) Set(f float64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.v = f
+}
+
+func (m *Value) Get() float64 {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.v
+}
+
+type CounterVec struct {
+	*vec
+}
+
+// With returns the counter for label values, Set of counter should not be used.
+func (v *CounterVec) With(lvs ...string) *Value {
+	return v.with(lvs).(*Value)
+}
+
+// NewCounterVec registers counter to default Registry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec(name, help, typeCounter, labels, func() interface{} {
+		return &Value{}
+	})}
+}
+
+type GaugeVec struct {
+	*vec
+}
+
+func (v *GaugeVec) With(lvs ...string) *Value {
+	return v.with(lvs).(*Value)
+}
+
+// NewGaugeVec registers gauge to default Registry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newVec(name, help, typeGauge, labels, func() interface{} {
+		return &Value{}
+	})}
+}
+
+type Histogram struct {
+	mtx     sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *Histogram) Observe(f float64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for i, b := range h.buckets {
+		if f <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += f
+}
+
+func (h *Histogram) write(w io.Writer, v *vec, k string) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for i, b := range h.buckets {
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n",
+			v.n, v.labelString(k, "le", formatFloat(b)), h.counts[i]); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+		v.n, v.labelString(k, "le", "+Inf"), h.count,
+		v.n, v.labelString(k), formatFloat(h.sum),
+		v.n, v.labelString(k), h.count); err != nil {
+		return err
+	}
+	return nil
+}
+
+type HistogramVec struct {
+	*vec
+}
+
+func (v *HistogramVec) With(lvs ...string) *Histogram {
+	return v.with(lvs).(*Histogram)
+}
+
+// NewHistogramVec registers histogram to default Registry, DefaultBuckets is used if buckets is empty
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	return &HistogramVec{newVec(name, help, typeHistogram, labels, func() interface{} {
+		return &Histogram{
+			buckets: buckets,
+			counts:  make([]uint64, len(buckets)),
+		}
+	})}
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	c := NewCounterVec("test_counter_total", "test counter", "a")
+	c.With("x").Inc()
+	c.With("x").Add(2)
+	c.With("y").Inc()
+	g := NewGaugeVec("test_gauge", "test gauge")
+	g.With().Set(1.5)
+	h := NewHistogramVec("test_seconds", "test histogram", []float64{1, 2}, "m")
+	h.With("get").Observe(0.5)
+	h.With("get").Observe(1.5)
+	h.With("get").Observe(3)
+
+	assert.Panics(t, func() { NewGaugeVec("test_gauge", "duplicated") })
+	assert.Panics(t, func() { c.With("x", "y") })
+
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, defaultReg.Write(buf))
+	out := buf.String()
+	for _, l := range []string{
+		"# TYPE test_counter_total counter",
+		`test_counter_total{a="x"} 3`,
+		`test_counter_total{a="y"} 1`,
+		"# TYPE test_gauge gauge",
+		"test_gauge 1.5",
+		"# TYPE test_seconds histogram",
+		`test_seconds_bucket{m="get",le="1"} 1`,
+		`test_seconds_bucket{m="get",le="2"} 2`,
+		`test_seconds_bucket{m="get",le="+Inf"} 3`,
+		`test_seconds_sum{m="get"} 5`,
+		`test_seconds_count{m="get"} 3`,
+	} {
+		assert.Contains(t, out, l+"\n")
+	}
+}
+
+func TestLinkMetrics(t *testing.T) {
+	m := NewLinkMetrics("0x1.icon", "0x2.bsc")
+	m.Sent.Inc()
+	m.Failed("25")
+	assert.Equal(t, float64(1), NewLinkMetrics("0x1.icon", "0x2.bsc").Sent.Get())
+
+	s := httptest.NewServer(Handler())
+	defer s.Close()
+	resp, err := http.Get(s.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+	buf := bytes.NewBuffer(nil)
+	_, err = buf.ReadFrom(resp.Body)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(buf.String(),
+		`btp_segments_failed_total{src="0x1.icon",dst="0x2.bsc",code="25"} 1`))
+}
+
+func TestRPCTransport(t *testing.T) {
+	assert.Equal(t, "icx_getBlockByHeight", methodOf([]byte(`{"jsonrpc":"2.0","method":"icx_getBlockByHeight"}`)))
+	assert.Equal(t, "batch:eth_getLogs", methodOf([]byte(`[{"method":"eth_getLogs"},{"method":"eth_getLogs"}]`)))
+	assert.Equal(t, "unknown", methodOf([]byte(`invalid`)))
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+	hc := &http.Client{Transport: NewRPCTransport("test", nil)}
+	resp, err := hc.Post(s.URL, "application/json", strings.NewReader(`{"method":"test_method"}`))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, defaultReg.Write(buf))
+	assert.Contains(t, buf.String(), `btp_rpc_duration_seconds_count{client="test",method="test_method"} 1`)
+}
@@ -0,0 +1,282 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nonce
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/icon-project/btp/common/gas"
+	"github.com/icon-project/btp/common/log"
+)
+
+const (
+	// FillerGasLimit is the gas of empty transaction which fills the gap of nonce
+	FillerGasLimit = 21000
+)
+
+// Backend is the part of chain client which is used by Manager, *ethclient.Client implements it.
+type Backend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+}
+
+// Manager allocates nonce of the account locally, so that transactions could be sent without waiting previous one.
+// it tracks sent transactions until mined, then resends dropped one and fills the gap by failure of sending.
+type Manager struct {
+	b      Backend
+	from   common.Address
+	signer bind.SignerFn
+	gp     *gas.Pricer
+	l      log.Logger
+
+	mtx     sync.Mutex
+	synced  bool
+	next    uint64
+	pending map[uint64]bool //allocated, but not sent or released yet
+	txs     map[uint64]*types.Transaction
+	nonces  map[common.Hash]uint64
+}
+
+// isNonceError returns whether the node rejects the transaction by nonce, the local nonce should be synchronized.
+func isNonceError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "nonce too high")
+}
+
+// isKnownError returns whether the transaction was already accepted.
+func isKnownError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+// Allocate returns the nonce for a new transaction, it should be sent by Send or returned by Release.
+func (m *Manager) Allocate(ctx context.Context) (uint64, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if !m.synced {
+		next, err := m.b.PendingNonceAt(ctx, m.from)
+		if err != nil {
+			return 0, err
+		}
+		//nonce of dropped transaction is kept, it's resent by Recover
+		for nonce := range m.txs {
+			if nonce >= next {
+				next = nonce + 1
+			}
+		}
+		for nonce := range m.pending {
+			if nonce >= next {
+				next = nonce + 1
+			}
+		}
+		m.next, m.synced = next, true
+	}
+	nonce := m.next
+	m.next++
+	m.pending[nonce] = true
+	return nonce, nil
+}
+
+// Release returns the allocated nonce which couldn't be sent by err.
+// if it's not the last allocated one, the gap is filled by Recover.
+func (m *Manager) Release(nonce uint64, err error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m._release(nonce, err)
+}
+
+func (m *Manager) _release(nonce uint64, err error) {
+	delete(m.pending, nonce)
+	if err != nil && isNonceError(err) {
+		m.synced = false
+		return
+	}
+	if m.synced && nonce+1 == m.next {
+		m.next--
+	}
+}
+
+func (m *Manager) _track(tx *types.Transaction) {
+	delete(m.pending, tx.Nonce())
+	m.txs[tx.Nonce()] = tx
+	m.nonces[tx.Hash()] = tx.Nonce()
+}
+
+// Send sends the transaction which has the allocated nonce or the nonce of tracking transaction for replacement,
+// then tracks it until Done. mtx is not locked while sending, so transactions are sent concurrently.
+func (m *Manager) Send(ctx context.Context, tx *types.Transaction) error {
+	m.mtx.Lock()
+	_, replace := m.txs[tx.Nonce()]
+	m.mtx.Unlock()
+	err := m.b.SendTransaction(ctx, tx)
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if err != nil {
+		if !replace {
+			m._release(tx.Nonce(), err)
+		}
+		return err
+	}
+	m._track(tx)
+	return nil
+}
+
+// NonceOf returns the nonce of the tracking transaction including replaced one.
+func (m *Manager) NonceOf(hash common.Hash) (uint64, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	nonce, ok := m.nonces[hash]
+	return nonce, ok
+}
+
+// Tracking returns whether the transaction with the nonce is tracked.
+func (m *Manager) Tracking(nonce uint64) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	_, ok := m.txs[nonce]
+	return ok
+}
+
+// Done stops tracking the transactions which have the nonce or lower, they are mined.
+func (m *Manager) Done(nonce uint64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m._done(nonce + 1)
+}
+
+// _done stops tracking the transactions which have lower nonce than next. mtx should be locked.
+func (m *Manager) _done(next uint64) {
+	for nonce := range m.txs {
+		if nonce < next {
+			delete(m.txs, nonce)
+		}
+	}
+	for hash, nonce := range m.nonces {
+		if nonce < next {
+			delete(m.nonces, hash)
+		}
+	}
+}
+
+func (m *Manager) filler(ctx context.Context, nonce uint64) (*types.Transaction, error) {
+	price, err := m.gp.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.signer(m.from, types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		To:       &m.from,
+		Value:    new(big.Int),
+		Gas:      FillerGasLimit,
+		GasPrice: price,
+	}))
+}
+
+// Recover resends the dropped transactions and fills the gaps with empty transactions,
+// so that the transactions after them could be mined.
+// the gaps are below the last tracking transaction, and allocated nonces which are not sent yet are skipped.
+func (m *Manager) Recover(ctx context.Context) error {
+	confirmed, err := m.b.NonceAt(ctx, m.from, nil)
+	if err != nil {
+		return err
+	}
+	m.mtx.Lock()
+	m._done(confirmed)
+	limit := confirmed
+	for nonce := range m.txs {
+		if nonce >= limit {
+			limit = nonce + 1
+		}
+	}
+	var gaps []uint64
+	var txs []*types.Transaction
+	for nonce := confirmed; nonce < limit; nonce++ {
+		if tx, ok := m.txs[nonce]; ok {
+			txs = append(txs, tx)
+		} else if !m.pending[nonce] {
+			//reserved while filling, concurrent Recover skips it
+			m.pending[nonce] = true
+			gaps = append(gaps, nonce)
+		}
+	}
+	m.mtx.Unlock()
+
+	for i, nonce := range gaps {
+		if err = m.fill(ctx, nonce); err != nil {
+			m.mtx.Lock()
+			for _, v := range gaps[i:] {
+				delete(m.pending, v)
+			}
+			m.mtx.Unlock()
+			return err
+		}
+	}
+	for _, tx := range txs {
+		if _, _, err = m.b.TransactionByHash(ctx, tx.Hash()); err == ethereum.NotFound {
+			if err = m.b.SendTransaction(ctx, tx); err != nil && !isKnownError(err) {
+				if isNonceError(err) {
+					continue
+				}
+				return err
+			}
+			m.l.Infof("resend dropped nonce:%d tx:%s", tx.Nonce(), tx.Hash())
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fill sends the empty transaction with the nonce reserved by Recover, then tracks it.
+func (m *Manager) fill(ctx context.Context, nonce uint64) error {
+	tx, err := m.filler(ctx, nonce)
+	if err != nil {
+		return err
+	}
+	if err = m.b.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	m.mtx.Lock()
+	m._track(tx)
+	m.mtx.Unlock()
+	m.l.Infof("fill the gap nonce:%d tx:%s", nonce, tx.Hash())
+	return nil
+}
+
+// NewManager returns Manager for the account, signer is used to sign the empty transaction for the gap.
+func NewManager(b Backend, from common.Address, signer bind.SignerFn, gp *gas.Pricer, l log.Logger) *Manager {
+	return &Manager{
+		b:       b,
+		from:    from,
+		signer:  signer,
+		gp:      gp,
+		l:       l,
+		pending: make(map[uint64]bool),
+		txs:     make(map[uint64]*types.Transaction),
+		nonces:  make(map[common.Hash]uint64),
+	}
+}
@@ -0,0 +1,197 @@
+package nonce
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/gas"
+	"github.com/icon-project/btp/common/log"
+)
+
+// testBackend accepts transactions into the pool, mined is the nonce of mined transactions.
+type testBackend struct {
+	mined uint64
+	pool  map[uint64]*types.Transaction
+	err   error
+}
+
+func (b *testBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	nonce := b.mined
+	for ; b.pool[nonce] != nil; nonce++ {
+	}
+	return nonce, nil
+}
+
+func (b *testBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return b.mined, nil
+}
+
+func (b *testBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.err; err != nil {
+		b.err = nil
+		return err
+	}
+	if tx.Nonce() < b.mined {
+		return fmt.Errorf("nonce too low")
+	}
+	b.pool[tx.Nonce()] = tx
+	return nil
+}
+
+func (b *testBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	for _, tx := range b.pool {
+		if tx.Hash() == hash {
+			return tx, true, nil
+		}
+	}
+	return nil, false, ethereum.NotFound
+}
+
+func (b *testBackend) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	*(result.(*hexutil.Big)) = hexutil.Big(*big.NewInt(1))
+	return nil
+}
+
+func newTestManager(t *testing.T, b *testBackend) *Manager {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	assert.NoError(t, err)
+	gp, err := gas.NewPricer(gas.Config{}, b)
+	assert.NoError(t, err)
+	return NewManager(b, opts.From, opts.Signer, gp, log.New())
+}
+
+func newTestTransaction(nonce uint64) *types.Transaction {
+	return types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(1), Gas: FillerGasLimit})
+}
+
+func allocateAndSend(t *testing.T, m *Manager) (*types.Transaction, error) {
+	nonce, err := m.Allocate(context.Background())
+	assert.NoError(t, err)
+	tx := newTestTransaction(nonce)
+	return tx, m.Send(context.Background(), tx)
+}
+
+func TestManager_Allocate(t *testing.T) {
+	b := &testBackend{mined: 3, pool: make(map[uint64]*types.Transaction)}
+	m := newTestManager(t, b)
+	ctx := context.Background()
+
+	for i := uint64(3); i < 6; i++ {
+		tx, err := allocateAndSend(t, m)
+		assert.NoError(t, err)
+		assert.Equal(t, i, tx.Nonce())
+		nonce, ok := m.NonceOf(tx.Hash())
+		assert.True(t, ok)
+		assert.Equal(t, i, nonce)
+	}
+
+	//the last nonce is reused on failure
+	b.err = fmt.Errorf("transient")
+	_, err := allocateAndSend(t, m)
+	assert.Error(t, err)
+	tx, err := allocateAndSend(t, m)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), tx.Nonce())
+
+	//nonce is synchronized on nonce error
+	b.mined, b.pool = 10, make(map[uint64]*types.Transaction)
+	_, err = allocateAndSend(t, m)
+	assert.Error(t, err)
+	m.Done(6)
+	tx, err = allocateAndSend(t, m)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), tx.Nonce())
+
+	//failure of replacement keeps the nonce
+	b.err = fmt.Errorf("replacement transaction underpriced")
+	assert.Error(t, m.Send(ctx, newTestTransaction(10)))
+	assert.True(t, m.Tracking(10))
+	nonce, err := m.Allocate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), nonce)
+}
+
+func TestManager_Recover(t *testing.T) {
+	b := &testBackend{pool: make(map[uint64]*types.Transaction)}
+	m := newTestManager(t, b)
+	ctx := context.Background()
+
+	txs := make([]*types.Transaction, 3)
+	for i := range txs {
+		tx, err := allocateAndSend(t, m)
+		assert.NoError(t, err)
+		txs[i] = tx
+	}
+	//failure of the middle makes a gap
+	n0, err := m.Allocate(ctx)
+	assert.NoError(t, err)
+	_, err = allocateAndSend(t, m)
+	assert.NoError(t, err)
+	m.Release(n0, fmt.Errorf("transient"))
+
+	//mined and dropped
+	b.mined = 1
+	delete(b.pool, 0)
+	delete(b.pool, 1)
+	assert.NoError(t, m.Recover(ctx))
+	assert.False(t, m.Tracking(0))
+	assert.Equal(t, txs[1].Hash(), b.pool[1].Hash())
+	assert.Equal(t, txs[2].Hash(), b.pool[2].Hash())
+	filler := b.pool[n0]
+	assert.NotNil(t, filler)
+	assert.Equal(t, m.from, *filler.To())
+	assert.Equal(t, 0, filler.Value().Sign())
+	assert.True(t, m.Tracking(n0))
+	assert.Equal(t, 5, len(b.pool)+int(b.mined))
+}
+
+func TestManager_RecoverPending(t *testing.T) {
+	b := &testBackend{pool: make(map[uint64]*types.Transaction)}
+	m := newTestManager(t, b)
+	ctx := context.Background()
+
+	//allocated one is not filled while it's being sent
+	n0, err := m.Allocate(ctx)
+	assert.NoError(t, err)
+	_, err = allocateAndSend(t, m)
+	assert.NoError(t, err)
+	assert.NoError(t, m.Recover(ctx))
+	assert.Nil(t, b.pool[n0])
+
+	tx := newTestTransaction(n0)
+	assert.NoError(t, m.Send(ctx, tx))
+	assert.Equal(t, tx.Hash(), b.pool[n0].Hash())
+
+	//released one below the last sent is filled
+	n2, err := m.Allocate(ctx)
+	assert.NoError(t, err)
+	_, err = allocateAndSend(t, m)
+	assert.NoError(t, err)
+	m.Release(n2, fmt.Errorf("transient"))
+	assert.NoError(t, m.Recover(ctx))
+	assert.True(t, m.Tracking(n2))
+	assert.Equal(t, m.from, *b.pool[n2].To())
+
+	//released one after the last sent is not filled
+	n4, err := m.Allocate(ctx)
+	assert.NoError(t, err)
+	n5, err := m.Allocate(ctx)
+	assert.NoError(t, err)
+	m.Release(n4, fmt.Errorf("transient"))
+	m.Release(n5, fmt.Errorf("transient"))
+	assert.NoError(t, m.Recover(ctx))
+	assert.Nil(t, b.pool[n4])
+	assert.False(t, m.Tracking(n4))
+}
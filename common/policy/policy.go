@@ -0,0 +1,315 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package policy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/log"
+)
+
+type Action string
+
+const (
+	// ActionRetry resends the segment after backoff
+	ActionRetry Action = "retry"
+	// ActionResegment rebuilds the segment from queued messages, then resends it
+	ActionResegment Action = "resegment"
+	// ActionRefresh refreshes BMCLinkStatus and trims verified messages, then resends remained
+	ActionRefresh Action = "refresh"
+	// ActionQuarantine removes the segment from relay queue and keeps it aside
+	ActionQuarantine Action = "quarantine"
+	// ActionShutdown stops the link with the error
+	ActionShutdown Action = "shutdown"
+)
+
+const (
+	// CodeTransport matches errors which are not coded and caused by connection
+	CodeTransport = "transport"
+	// CodeUnknown matches errors which are not coded and not caused by connection
+	CodeUnknown = "unknown"
+	// CodeAny matches all errors
+	CodeAny = "*"
+	// CodeUnverified is used for segments held back by local verification before sending
+	CodeUnverified = "unverified"
+	// CodeOversize is used for events which don't fit in a transaction alone
+	CodeOversize = "oversize"
+)
+
+const (
+	DefaultMaxRetry   = 5
+	DefaultBackoff    = time.Second
+	DefaultMaxBackoff = time.Minute
+)
+
+// Rule maps errors to Action.
+// Code is one of error code (ex: "25"), inclusive range of error code (ex: "10-24"),
+// CodeTransport, CodeUnknown or CodeAny.
+// When the number of decisions for same key exceeds MaxRetry,
+// Fallback is used instead of retryable Action.
+type Rule struct {
+	Code     string `json:"code"`
+	Action   Action `json:"action"`
+	MaxRetry int    `json:"max_retry,omitempty"`
+	Fallback Action `json:"fallback,omitempty"`
+}
+
+// Config overrides the default rules of the chain, Backoff and MaxBackoff are
+// the string like "1s" or the number of nanoseconds.
+type Config struct {
+	Rules      []Rule          `json:"rules,omitempty"`
+	MaxRetry   int             `json:"max_retry,omitempty"`
+	Backoff    common.Duration `json:"backoff,omitempty"`
+	MaxBackoff common.Duration `json:"max_backoff,omitempty"`
+}
+
+type Decision struct {
+	Action  Action
+	Code    string
+	Attempt int
+	Delay   time.Duration
+	Err     error
+}
+
+func (d *Decision) String() string {
+	return fmt.Sprintf("Decision{action:%s,code:%s,attempt:%d,delay:%v}",
+		d.Action, d.Code, d.Attempt, d.Delay)
+}
+
+type matcher struct {
+	Rule
+	from, to errors.Code
+	any      bool
+}
+
+func (m *matcher) match(code string, c errors.Code, coded bool) bool {
+	if m.any {
+		return true
+	}
+	if coded {
+		return m.Code != CodeTransport && m.Code != CodeUnknown && c >= m.from && c <= m.to
+	}
+	return m.Code == code
+}
+
+func newMatcher(r Rule) (*matcher, error) {
+	m := &matcher{Rule: r}
+	switch r.Action {
+	case ActionRetry, ActionResegment, ActionRefresh, ActionQuarantine, ActionShutdown:
+	default:
+		return nil, errors.Errorf("invalid action:%s code:%s", r.Action, r.Code)
+	}
+	switch r.Fallback {
+	case "", ActionQuarantine, ActionShutdown:
+	default:
+		return nil, errors.Errorf("invalid fallback:%s code:%s", r.Fallback, r.Code)
+	}
+	switch r.Code {
+	case CodeAny:
+		m.any = true
+	case CodeTransport, CodeUnknown:
+	default:
+		rs := strings.SplitN(r.Code, "-", 2)
+		from, err := strconv.Atoi(strings.TrimSpace(rs[0]))
+		if err != nil {
+			return nil, errors.Errorf("invalid code:%s", r.Code)
+		}
+		to := from
+		if len(rs) > 1 {
+			if to, err = strconv.Atoi(strings.TrimSpace(rs[1])); err != nil || to < from {
+				return nil, errors.Errorf("invalid code:%s", r.Code)
+			}
+		}
+		m.from, m.to = errors.Code(from), errors.Code(to)
+	}
+	return m, nil
+}
+
+// Engine decides Action for the error of relay.
+// Rules of Config take precedence over the default rules of the chain,
+// and ActionShutdown is used if there is no matched rule.
+type Engine struct {
+	mtx        sync.Mutex
+	ms         []*matcher
+	maxRetry   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	transports []error
+	attempts   map[interface{}]int
+	counts     map[Action]map[string]uint64
+	l          log.Logger
+}
+
+// Decide returns Decision for err, the key identifies the subject of retry.
+// It should be kept while the subject is resegmented (ex: id of relay message).
+func (e *Engine) Decide(key interface{}, err error) *Decision {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	d := &Decision{Err: err}
+	var c errors.Code
+	coder, coded := errors.CoderOf(err)
+	if coded {
+		c = coder.ErrorCode()
+		d.Code = strconv.Itoa(int(c))
+	} else if e.isTransport(err) {
+		d.Code = CodeTransport
+	} else {
+		d.Code = CodeUnknown
+	}
+
+	var m *matcher
+	for _, v := range e.ms {
+		if v.match(d.Code, c, coded) {
+			m = v
+			break
+		}
+	}
+	if m == nil {
+		d.Action = ActionShutdown
+	} else {
+		d.Action = m.Action
+	}
+
+	switch d.Action {
+	case ActionRetry, ActionResegment, ActionRefresh:
+		e.attempts[key]++
+		d.Attempt = e.attempts[key]
+		maxRetry := e.maxRetry
+		if m.MaxRetry > 0 {
+			maxRetry = m.MaxRetry
+		}
+		if d.Attempt > maxRetry {
+			d.Action = ActionShutdown
+			if m.Fallback != "" {
+				d.Action = m.Fallback
+			}
+			delete(e.attempts, key)
+		} else {
+			d.Delay = e.backoff << uint(d.Attempt-1)
+			if d.Delay > e.maxBackoff || d.Delay <= 0 {
+				d.Delay = e.maxBackoff
+			}
+		}
+	default:
+		delete(e.attempts, key)
+	}
+
+	cm, ok := e.counts[d.Action]
+	if !ok {
+		cm = make(map[string]uint64)
+		e.counts[d.Action] = cm
+	}
+	cm[d.Code]++
+	e.l.Warnf("policy %s err:%+v", d, err)
+	return d
+}
+
+// Reset clears the number of attempts for the key, it should be called when the subject is completed.
+func (e *Engine) Reset(key interface{}) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	delete(e.attempts, key)
+}
+
+// Counts returns the number of decisions by Action and code.
+func (e *Engine) Counts() map[Action]map[string]uint64 {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	r := make(map[Action]map[string]uint64)
+	for a, cm := range e.counts {
+		r[a] = make(map[string]uint64)
+		for c, n := range cm {
+			r[a][c] = n
+		}
+	}
+	return r
+}
+
+func (e *Engine) isTransport(err error) bool {
+	for _, t := range e.transports {
+		if errors.Is(err, t) {
+			return true
+		}
+	}
+	return IsTransportError(err)
+}
+
+// IsTransportError returns whether err is caused by connection to the node.
+func IsTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var ue *url.Error
+	if errors.AsValue(&ue, err) {
+		return true
+	}
+	var ne net.Error
+	if errors.AsValue(&ne, err) {
+		return true
+	}
+	var he *common.HttpError
+	return errors.AsValue(&he, err)
+}
+
+// NewEngine returns Engine with cfg and defaults.
+// transports are sentinel errors which should be treated as CodeTransport.
+func NewEngine(cfg *Config, defaults []Rule, l log.Logger, transports ...error) (*Engine, error) {
+	e := &Engine{
+		maxRetry:   DefaultMaxRetry,
+		backoff:    DefaultBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		transports: transports,
+		attempts:   make(map[interface{}]int),
+		counts:     make(map[Action]map[string]uint64),
+		l:          l,
+	}
+	rules := make([]Rule, 0)
+	if cfg != nil {
+		rules = append(rules, cfg.Rules...)
+		if cfg.MaxRetry > 0 {
+			e.maxRetry = cfg.MaxRetry
+		}
+		if cfg.Backoff > 0 {
+			e.backoff = time.Duration(cfg.Backoff)
+		}
+		if cfg.MaxBackoff > 0 {
+			e.maxBackoff = time.Duration(cfg.MaxBackoff)
+		}
+	}
+	rules = append(rules, defaults...)
+	for _, r := range rules {
+		m, err := newMatcher(r)
+		if err != nil {
+			return nil, err
+		}
+		e.ms = append(e.ms, m)
+	}
+	return e, nil
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/errors"
+	"github.com/icon-project/btp/common/log"
+)
+
+var testDefaults = []Rule{
+	{Code: "26", Action: ActionRefresh},
+	{Code: "10-24", Action: ActionRetry, MaxRetry: 2, Fallback: ActionQuarantine},
+	{Code: CodeTransport, Action: ActionRetry},
+	{Code: CodeAny, Action: ActionShutdown},
+}
+
+func TestEngine_Decide(t *testing.T) {
+	e, err := NewEngine(nil, testDefaults, log.New())
+	assert.NoError(t, err)
+
+	d := e.Decide(1, errors.NewBase(26, "BMVNotVerifiable"))
+	assert.Equal(t, ActionRefresh, d.Action)
+	assert.Equal(t, "26", d.Code)
+	assert.Equal(t, DefaultBackoff, d.Delay)
+
+	d = e.Decide(1, errors.NewBase(25, "BMVUnknown"))
+	assert.Equal(t, ActionShutdown, d.Action)
+
+	d = e.Decide(2, &url.Error{Op: "Post", URL: "http://localhost", Err: fmt.Errorf("refused")})
+	assert.Equal(t, ActionRetry, d.Action)
+	assert.Equal(t, CodeTransport, d.Code)
+
+	d = e.Decide(3, fmt.Errorf("unknown"))
+	assert.Equal(t, ActionShutdown, d.Action)
+	assert.Equal(t, CodeUnknown, d.Code)
+
+	counts := e.Counts()
+	assert.Equal(t, uint64(1), counts[ActionRefresh]["26"])
+	assert.Equal(t, uint64(1), counts[ActionShutdown]["25"])
+	assert.Equal(t, uint64(1), counts[ActionShutdown][CodeUnknown])
+}
+
+func TestEngine_Fallback(t *testing.T) {
+	cfg := &Config{
+		Backoff:    common.Duration(100 * time.Millisecond),
+		MaxBackoff: common.Duration(150 * time.Millisecond),
+	}
+	e, err := NewEngine(cfg, testDefaults, log.New())
+	assert.NoError(t, err)
+
+	err = errors.NewBase(11, "BMCRevertUnauthorized")
+	d := e.Decide(1, err)
+	assert.Equal(t, ActionRetry, d.Action)
+	assert.Equal(t, 1, d.Attempt)
+	assert.Equal(t, 100*time.Millisecond, d.Delay)
+	d = e.Decide(1, err)
+	assert.Equal(t, ActionRetry, d.Action)
+	assert.Equal(t, 2, d.Attempt)
+	assert.Equal(t, 150*time.Millisecond, d.Delay)
+	d = e.Decide(1, err)
+	assert.Equal(t, ActionQuarantine, d.Action)
+
+	e.Decide(2, err)
+	e.Reset(2)
+	d = e.Decide(2, err)
+	assert.Equal(t, 1, d.Attempt)
+}
+
+func TestEngine_ConfigRules(t *testing.T) {
+	sentinel := fmt.Errorf("fail to connect")
+	cfg := &Config{
+		Rules: []Rule{
+			{Code: "25", Action: ActionQuarantine},
+		},
+	}
+	e, err := NewEngine(cfg, testDefaults, log.New(), sentinel)
+	assert.NoError(t, err)
+
+	d := e.Decide(1, errors.NewBase(25, "BMVUnknown"))
+	assert.Equal(t, ActionQuarantine, d.Action)
+
+	d = e.Decide(2, errors.Wrap(sentinel, "wrapped"))
+	assert.Equal(t, CodeTransport, d.Code)
+	assert.Equal(t, ActionRetry, d.Action)
+
+	_, err = NewEngine(&Config{Rules: []Rule{{Code: "24-10", Action: ActionRetry}}}, nil, log.New())
+	assert.Error(t, err)
+	_, err = NewEngine(&Config{Rules: []Rule{{Code: "10", Action: "panic"}}}, nil, log.New())
+	assert.Error(t, err)
+}
+
+func TestConfig_JSON(t *testing.T) {
+	cfg := &Config{}
+	err := json.Unmarshal([]byte(`{"backoff":"2s","max_backoff":"1m","rules":[{"code":"unknown","action":"retry"}]}`), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Duration(2*time.Second), cfg.Backoff)
+	assert.Equal(t, common.Duration(time.Minute), cfg.MaxBackoff)
+	assert.Equal(t, []Rule{{Code: CodeUnknown, Action: ActionRetry}}, cfg.Rules)
+
+	b, err := json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"backoff":"2s"`)
+
+	//the number of nanoseconds is also accepted
+	assert.NoError(t, json.Unmarshal([]byte(`{"backoff":1000000}`), cfg))
+	assert.Equal(t, common.Duration(time.Millisecond), cfg.Backoff)
+	assert.Error(t, json.Unmarshal([]byte(`{"backoff":"1x"}`), cfg))
+}
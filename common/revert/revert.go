@@ -0,0 +1,229 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package revert decodes revert of EVM transaction, then maps it to the error which has the code of BTP.
+package revert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/icon-project/btp/common/errors"
+)
+
+const (
+	// CodeSeparator separates the code at the end of the reason, ex) "bmv: NotVerifiable|26"
+	CodeSeparator = "|"
+	// CodeArgument is the name of argument of the custom error for the code
+	CodeArgument = "code"
+
+	executionReverted = "execution reverted"
+)
+
+var (
+	errorSelector  = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector  = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+	stringType, _  = abi.NewType("string", "", nil)
+	uint256Type, _ = abi.NewType("uint256", "", nil)
+	errorArgs      = abi.Arguments{{Type: stringType}}
+	panicArgs      = abi.Arguments{{Type: uint256Type}}
+)
+
+type customError struct {
+	Name   string
+	Inputs abi.Arguments
+}
+
+// Decoder decodes revert data of Error(string), Panic(uint256) and the custom errors.
+type Decoder struct {
+	errs     map[string]*customError
+	newError func(code int) error
+}
+
+// Decode returns the reason and the code of revert data, the code is -1 if it doesn't have.
+func (d *Decoder) Decode(data []byte) (string, int, error) {
+	if len(data) < 4 {
+		return "", -1, fmt.Errorf("invalid revert data %x", data)
+	}
+	selector, payload := data[:4], data[4:]
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		v, err := errorArgs.Unpack(payload)
+		if err != nil {
+			return "", -1, err
+		}
+		reason := v[0].(string)
+		return reason, CodeOf(reason), nil
+	case bytes.Equal(selector, panicSelector):
+		v, err := panicArgs.Unpack(payload)
+		if err != nil {
+			return "", -1, err
+		}
+		return fmt.Sprintf("Panic(%#x)", v[0].(*big.Int)), -1, nil
+	}
+	ce, ok := d.errs[string(selector)]
+	if !ok {
+		return "", -1, fmt.Errorf("unknown revert selector %x", selector)
+	}
+	v, err := ce.Inputs.Unpack(payload)
+	if err != nil {
+		return "", -1, err
+	}
+	code := -1
+	args := make([]string, len(v))
+	for i, arg := range v {
+		args[i] = fmt.Sprint(arg)
+		if bi, ok := arg.(*big.Int); ok && bi.IsInt64() {
+			if code < 0 || ce.Inputs[i].Name == CodeArgument {
+				code = int(bi.Int64())
+			}
+		}
+	}
+	return fmt.Sprintf("%s(%s)", ce.Name, strings.Join(args, ",")), code, nil
+}
+
+// CodeOf returns the code which follows the last CodeSeparator of the reason, it returns -1 if there is no code.
+func CodeOf(reason string) int {
+	idx := strings.LastIndex(reason, CodeSeparator)
+	if idx < 0 {
+		return -1
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(reason[idx+len(CodeSeparator):]))
+	if err != nil || code < 0 {
+		return -1
+	}
+	return code
+}
+
+func (d *Decoder) errorOf(reason string, code int) error {
+	if code >= 0 {
+		if err := d.newError(code); err != nil {
+			return errors.Wrap(err, reason)
+		}
+	}
+	return errors.Errorf("reverted reason:%s", reason)
+}
+
+// ErrorOf returns the error for revert data.
+func (d *Decoder) ErrorOf(data []byte) error {
+	reason, code, err := d.Decode(data)
+	if err != nil {
+		return errors.Wrapf(err, "fail to decode revert")
+	}
+	return d.errorOf(reason, code)
+}
+
+// dataOf returns revert data of the error from JSON-RPC, the node which doesn't return data gives only message.
+func dataOf(err error) ([]byte, bool) {
+	var de rpc.DataError
+	if !errors.AsValue(&de, err) {
+		return nil, false
+	}
+	s, ok := de.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, e := hexutil.Decode(s)
+	return data, e == nil
+}
+
+// ErrorOfCall returns the error for the error of eth_call or eth_estimateGas,
+// it returns err as it is if it's not caused by revert.
+func (d *Decoder) ErrorOfCall(err error) error {
+	if err == nil {
+		return nil
+	}
+	if data, ok := dataOf(err); ok && len(data) > 0 {
+		return d.ErrorOf(data)
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, executionReverted) {
+		return err
+	}
+	reason := strings.TrimPrefix(strings.TrimPrefix(msg, executionReverted), ": ")
+	return d.errorOf(reason, CodeOf(reason))
+}
+
+// ErrorOfTransaction replays the failed transaction by eth_call at the block number, nil for the latest,
+// then returns the error for the revert.
+func (d *Decoder) ErrorOfTransaction(ctx context.Context, c ethereum.ContractCaller, tx *types.Transaction,
+	number *big.Int) error {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return err
+	}
+	_, err = c.CallContract(ctx, ethereum.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}, number)
+	if err == nil {
+		return errors.Errorf("reverted without reason tx:%s", tx.Hash())
+	}
+	return d.ErrorOfCall(err)
+}
+
+// NewDecoder returns Decoder with the custom errors in the ABI JSON,
+// newError returns the error for the code, it could return nil for unknown code.
+func NewDecoder(newError func(code int) error, abis ...string) (*Decoder, error) {
+	d := &Decoder{
+		errs:     make(map[string]*customError),
+		newError: newError,
+	}
+	for _, s := range abis {
+		var fields []struct {
+			Type   string
+			Name   string
+			Inputs []abi.ArgumentMarshaling
+		}
+		if err := json.Unmarshal([]byte(s), &fields); err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			if f.Type != "error" {
+				continue
+			}
+			ce := &customError{Name: f.Name}
+			types := make([]string, len(f.Inputs))
+			for i, input := range f.Inputs {
+				t, err := abi.NewType(input.Type, input.InternalType, input.Components)
+				if err != nil {
+					return nil, err
+				}
+				ce.Inputs = append(ce.Inputs, abi.Argument{Name: input.Name, Type: t, Indexed: input.Indexed})
+				types[i] = t.String()
+			}
+			sig := fmt.Sprintf("%s(%s)", f.Name, strings.Join(types, ","))
+			d.errs[string(crypto.Keccak256([]byte(sig))[:4])] = ce
+		}
+	}
+	return d, nil
+}
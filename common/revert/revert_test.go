@@ -0,0 +1,100 @@
+package revert
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/errors"
+)
+
+const testABI = `[{"type":"error","name":"BTPRevert","inputs":[{"name":"message","type":"string"},{"name":"code","type":"uint256"}]},
+{"type":"function","name":"handleRelayMessage","inputs":[{"name":"_prev","type":"string"},{"name":"_msg","type":"bytes"}],"outputs":[]}]`
+
+type testDataError struct {
+	msg  string
+	data string
+}
+
+func (e *testDataError) Error() string          { return e.msg }
+func (e *testDataError) ErrorData() interface{} { return e.data }
+
+func newTestError(code int) error {
+	if code < 10 {
+		return nil
+	}
+	return errors.NewBase(errors.Code(code), fmt.Sprintf("Revert[%d]", code))
+}
+
+func encode(t *testing.T, sig string, args abi.Arguments, values ...interface{}) []byte {
+	b, err := args.Pack(values...)
+	assert.NoError(t, err)
+	return append(crypto.Keccak256([]byte(sig))[:4], b...)
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	d, err := NewDecoder(newTestError, testABI)
+	assert.NoError(t, err)
+
+	reason, code, err := d.Decode(encode(t, "Error(string)", errorArgs, "bmv: NotVerifiable|26"))
+	assert.NoError(t, err)
+	assert.Equal(t, "bmv: NotVerifiable|26", reason)
+	assert.Equal(t, 26, code)
+
+	reason, code, err = d.Decode(encode(t, "Error(string)", errorArgs, "Ownable: caller is not the owner"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Ownable: caller is not the owner", reason)
+	assert.Equal(t, -1, code)
+
+	reason, code, err = d.Decode(encode(t, "Panic(uint256)", panicArgs, big.NewInt(0x11)))
+	assert.NoError(t, err)
+	assert.Equal(t, "Panic(0x11)", reason)
+	assert.Equal(t, -1, code)
+
+	args := abi.Arguments{{Name: "message", Type: stringType}, {Name: CodeArgument, Type: uint256Type}}
+	reason, code, err = d.Decode(encode(t, "BTPRevert(string,uint256)", args, "NotVerifiable", big.NewInt(26)))
+	assert.NoError(t, err)
+	assert.Equal(t, "BTPRevert(NotVerifiable,26)", reason)
+	assert.Equal(t, 26, code)
+
+	_, _, err = d.Decode(encode(t, "Unknown(uint256)", panicArgs, big.NewInt(1)))
+	assert.Error(t, err)
+	_, _, err = d.Decode([]byte{0x01})
+	assert.Error(t, err)
+}
+
+func TestDecoder_ErrorOfCall(t *testing.T) {
+	d, err := NewDecoder(newTestError)
+	assert.NoError(t, err)
+
+	data := hexutil.Encode(encode(t, "Error(string)", errorArgs, "bmc: Unauthorized|11"))
+	err = d.ErrorOfCall(&testDataError{msg: "execution reverted: bmc: Unauthorized|11", data: data})
+	assert.Equal(t, errors.Code(11), errors.CodeOf(err))
+
+	//node which doesn't return revert data
+	err = d.ErrorOfCall(fmt.Errorf("execution reverted: bmv: AlreadyVerified|27"))
+	assert.Equal(t, errors.Code(27), errors.CodeOf(err))
+
+	//reverted without BTP code
+	err = d.ErrorOfCall(fmt.Errorf("execution reverted"))
+	_, coded := errors.CoderOf(err)
+	assert.False(t, coded)
+	err = d.ErrorOfCall(fmt.Errorf("execution reverted: test|3"))
+	_, coded = errors.CoderOf(err)
+	assert.False(t, coded)
+
+	//not reverted
+	e := fmt.Errorf("connection refused")
+	assert.Equal(t, e, d.ErrorOfCall(e))
+	assert.NoError(t, d.ErrorOfCall(nil))
+
+	assert.Equal(t, 26, CodeOf("bmv: NotVerifiable|26"))
+	assert.Equal(t, 26, CodeOf("a|b|26"))
+	assert.Equal(t, -1, CodeOf("bmv: NotVerifiable"))
+	assert.Equal(t, -1, CodeOf("bmv: NotVerifiable|x"))
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package segment
+
+import (
+	"sync"
+	"time"
+
+	"github.com/icon-project/btp/common/errors"
+)
+
+var (
+	ErrOversize = errors.NewBase(errors.IllegalArgumentError, "Oversize")
+)
+
+// Item is the unit of segmentation which has the encoded size and the range of sequences.
+type Item interface {
+	// Size returns the encoded size of the item.
+	Size() int
+	// Sequence returns the range of sequences of events in the item,
+	// last is less than first if the item has no event (ex: block update).
+	Sequence() (first, last int64)
+}
+
+// NumberOfEvents returns the number of events in the item.
+func NumberOfEvents(item Item) int {
+	first, last := item.Sequence()
+	if last < first {
+		return 0
+	}
+	return int(last - first + 1)
+}
+
+// Packer builds a segment of pending items, the encoding of the segment is up to the implementation.
+type Packer interface {
+	// SizeWith returns the encoded size of the segment of pending items and the item,
+	// the size of pending items only if the item is nil.
+	SizeWith(item Item) (int, error)
+	// Add appends the item to pending items.
+	Add(item Item) error
+	// Empty returns true if there is no pending item.
+	Empty() bool
+	// Flush builds the segment of pending items, then pending items are cleared.
+	Flush() error
+}
+
+// Segmenter adds items to Packer and flushes pending items by the size limit and Strategy.
+// it's safe for concurrent use, but Packer is called with the lock.
+type Segmenter struct {
+	mtx      sync.Mutex
+	p        Packer
+	fits     func(size int) bool
+	st       Strategy
+	oversize bool
+	items    int
+	events   int
+	since    time.Time
+	now      func() time.Time
+}
+
+// New returns Segmenter, fits returns true if the segment of the size doesn't exceed the limit.
+func New(p Packer, fits func(size int) bool, st Strategy) *Segmenter {
+	return &Segmenter{
+		p:    p,
+		fits: fits,
+		st:   st,
+		now:  time.Now,
+	}
+}
+
+// AllowOversize makes the item which doesn't fit alone flushed as a segment over the limit,
+// it's for the sender which relays the segment by fragments.
+func (s *Segmenter) AllowOversize() *Segmenter {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.oversize = true
+	return s
+}
+
+// Add appends the item, pending items are flushed before
+// if the item doesn't fit in with them or Strategy says they are full.
+// the item which doesn't fit alone is not added and ErrOversize is returned,
+// unless AllowOversize is set.
+func (s *Segmenter) Add(item Item) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.p.Empty() {
+		full := s.st.Full(s.items, s.events, item)
+		if !full {
+			size, err := s.p.SizeWith(item)
+			if err != nil {
+				return err
+			}
+			full = !s.fits(size)
+		}
+		if full {
+			if err := s._flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if s.p.Empty() {
+		if !s.oversize {
+			size, err := s.p.SizeWith(item)
+			if err != nil {
+				return err
+			}
+			if !s.fits(size) {
+				return errors.Wrapf(ErrOversize, "oversize item size:%d", size)
+			}
+		}
+		s.since = s.now()
+	}
+	if err := s.p.Add(item); err != nil {
+		return err
+	}
+	s.items++
+	s.events += NumberOfEvents(item)
+	if s.items == 1 {
+		size, err := s.p.SizeWith(nil)
+		if err != nil {
+			return err
+		}
+		if !s.fits(size) {
+			return s._flush()
+		}
+	}
+	return nil
+}
+
+// Commit is called at the end of a batch of items (ex: a block) or periodically,
+// pending items are flushed if Strategy says so.
+func (s *Segmenter) Commit() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s._due() {
+		return s._flush()
+	}
+	return nil
+}
+
+// Due returns true if pending items would be flushed by Commit.
+func (s *Segmenter) Due() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s._due()
+}
+
+func (s *Segmenter) _due() bool {
+	return !s.p.Empty() && s.st.Flush(s.items, s.events, s.now().Sub(s.since))
+}
+
+// Flush flushes pending items regardless of Strategy.
+func (s *Segmenter) Flush() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s._flush()
+}
+
+func (s *Segmenter) _flush() error {
+	if s.p.Empty() {
+		return nil
+	}
+	if err := s.p.Flush(); err != nil {
+		return err
+	}
+	s.items = 0
+	s.events = 0
+	return nil
+}
+
+// Len returns the number of pending items which are added after the last flush.
+func (s *Segmenter) Len() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.items
+}
@@ -0,0 +1,215 @@
+package segment
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/errors"
+)
+
+type testItem struct {
+	size        int
+	first, last int64
+}
+
+func (i *testItem) Size() int {
+	return i.size
+}
+
+func (i *testItem) Sequence() (int64, int64) {
+	return i.first, i.last
+}
+
+// testPacker sums the size of items with the overhead of the segment.
+type testPacker struct {
+	pending  []Item
+	segments [][]Item
+}
+
+const testOverhead = 10
+
+func (p *testPacker) SizeWith(item Item) (int, error) {
+	size := testOverhead
+	for _, i := range p.pending {
+		size += i.Size()
+	}
+	if item != nil {
+		size += item.Size()
+	}
+	return size, nil
+}
+
+func (p *testPacker) Add(item Item) error {
+	p.pending = append(p.pending, item)
+	return nil
+}
+
+func (p *testPacker) Empty() bool {
+	return len(p.pending) == 0
+}
+
+func (p *testPacker) Flush() error {
+	p.segments = append(p.segments, p.pending)
+	p.pending = nil
+	return nil
+}
+
+func sizeOf(items []Item) int {
+	size := testOverhead
+	for _, i := range items {
+		size += i.Size()
+	}
+	return size
+}
+
+func eventsOf(items []Item) int {
+	n := 0
+	for _, i := range items {
+		n += NumberOfEvents(i)
+	}
+	return n
+}
+
+// randomItems returns items of random size, some of them have no event.
+func randomItems(r *rand.Rand, maxSize int) []Item {
+	var seq int64
+	items := make([]Item, r.Intn(100)+1)
+	for i := range items {
+		item := &testItem{size: r.Intn(maxSize) + 1, first: seq + 1}
+		seq += int64(r.Intn(4))
+		item.last = seq
+		items[i] = item
+	}
+	return items
+}
+
+func TestSegmenter_Greedy(t *testing.T) {
+	f := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		limit := r.Intn(1000) + testOverhead + 1
+		p := &testPacker{}
+		s := New(p, func(size int) bool { return size <= limit }, Greedy)
+		//all items fit alone, see TestSegmenter_MaxSize for oversized one
+		items := randomItems(r, limit-testOverhead)
+		for _, item := range items {
+			if !assert.NoError(t, s.Add(item)) {
+				return false
+			}
+		}
+		if !assert.NoError(t, s.Commit()) || !assert.True(t, p.Empty()) || !assert.Equal(t, 0, s.Len()) {
+			return false
+		}
+		var flushed []Item
+		for i, ss := range p.segments {
+			if !assert.True(t, sizeOf(ss) <= limit) {
+				return false
+			}
+			//the first item of the next segment didn't fit in
+			if i+1 < len(p.segments) &&
+				!assert.True(t, sizeOf(ss)+p.segments[i+1][0].Size() > limit) {
+				return false
+			}
+			flushed = append(flushed, ss...)
+		}
+		return assert.Equal(t, items, flushed)
+	}
+	assert.NoError(t, quick.Check(f, &quick.Config{MaxCount: 200}))
+}
+
+func TestSegmenter_MaxEvents(t *testing.T) {
+	f := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		limit := r.Intn(1000) + testOverhead
+		n := r.Intn(10) + 1
+		p := &testPacker{}
+		s := New(p, func(size int) bool { return size <= limit }, MaxEvents(n, MaxSize)).AllowOversize()
+		items := randomItems(r, limit)
+		for _, item := range items {
+			if !assert.NoError(t, s.Add(item)) {
+				return false
+			}
+		}
+		if !assert.NoError(t, s.Flush()) {
+			return false
+		}
+		var flushed []Item
+		for _, ss := range p.segments {
+			if len(ss) > 1 && (!assert.True(t, eventsOf(ss) <= n) || !assert.True(t, sizeOf(ss) <= limit)) {
+				return false
+			}
+			flushed = append(flushed, ss...)
+		}
+		return assert.Equal(t, items, flushed)
+	}
+	assert.NoError(t, quick.Check(f, &quick.Config{MaxCount: 200}))
+}
+
+func TestSegmenter_MaxSize(t *testing.T) {
+	p := &testPacker{}
+	s := New(p, func(size int) bool { return size <= 100 }, MaxSize)
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, s.Add(&testItem{size: 40, first: i, last: i}))
+		assert.NoError(t, s.Commit())
+	}
+	//pending items are kept by commit until the limit is reached
+	assert.Equal(t, 1, len(p.segments))
+	assert.Equal(t, 2, len(p.segments[0]))
+	assert.Equal(t, 1, s.Len())
+	assert.False(t, s.Due())
+
+	//oversized item is rejected after pending items are flushed
+	err := s.Add(&testItem{size: 200, first: 4, last: 4})
+	assert.True(t, errors.Is(err, ErrOversize))
+	assert.Equal(t, 2, len(p.segments))
+	assert.Equal(t, 0, s.Len())
+	assert.True(t, p.Empty())
+
+	//oversized item is flushed alone if it's allowed
+	s.AllowOversize()
+	assert.NoError(t, s.Add(&testItem{size: 200, first: 4, last: 4}))
+	assert.Equal(t, 3, len(p.segments))
+	assert.Equal(t, 1, len(p.segments[2]))
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestSegmenter_Latency(t *testing.T) {
+	now := time.Now()
+	p := &testPacker{}
+	s := New(p, func(size int) bool { return size <= 100 }, Latency(time.Second))
+	s.now = func() time.Time { return now }
+
+	assert.False(t, s.Due())
+	assert.NoError(t, s.Add(&testItem{size: 10, first: 1, last: 1}))
+	now = now.Add(500 * time.Millisecond)
+	assert.NoError(t, s.Add(&testItem{size: 10, first: 2, last: 2}))
+	assert.False(t, s.Due())
+	assert.NoError(t, s.Commit())
+	assert.Equal(t, 0, len(p.segments))
+
+	//elapsed from the first pending item
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, s.Due())
+	assert.NoError(t, s.Commit())
+	assert.Equal(t, 1, len(p.segments))
+	assert.Equal(t, 2, len(p.segments[0]))
+	assert.False(t, s.Due())
+}
+
+func TestConfig_Strategy(t *testing.T) {
+	assert.Equal(t, Greedy, (&Config{}).Strategy())
+	assert.Equal(t, MaxSize, (&Config{MaxSizeTx: true}).Strategy())
+	assert.Equal(t, Latency(time.Second), (&Config{MaxSizeTx: true, FlushLatency: 1000}).Strategy())
+	//latency is used only with MaxSizeTx
+	assert.Equal(t, Greedy, (&Config{FlushLatency: 1000}).Strategy())
+	assert.Equal(t, MaxEvents(3, Greedy), (&Config{MaxEventsTx: 3}).Strategy())
+}
+
+func TestNumberOfEvents(t *testing.T) {
+	assert.Equal(t, 0, NumberOfEvents(&testItem{first: 1, last: 0}))
+	assert.Equal(t, 1, NumberOfEvents(&testItem{first: 1, last: 1}))
+	assert.Equal(t, 3, NumberOfEvents(&testItem{first: 1, last: 3}))
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package segment
+
+import (
+	"fmt"
+	"time"
+)
+
+// Strategy decides when pending items are flushed, the size limit is applied regardless of it.
+type Strategy interface {
+	// Full returns true if the item could not be added to pending items.
+	Full(items, events int, item Item) bool
+	// Flush returns true if pending items are flushed on commit,
+	// elapsed is the duration since the first pending item was added.
+	Flush(items, events int, elapsed time.Duration) bool
+}
+
+type greedy struct{}
+
+func (greedy) Full(items, events int, item Item) bool {
+	return false
+}
+
+func (greedy) Flush(items, events int, elapsed time.Duration) bool {
+	return true
+}
+
+func (greedy) String() string {
+	return "greedy"
+}
+
+type maxSize struct{}
+
+func (maxSize) Full(items, events int, item Item) bool {
+	return false
+}
+
+func (maxSize) Flush(items, events int, elapsed time.Duration) bool {
+	return false
+}
+
+func (maxSize) String() string {
+	return "max_size"
+}
+
+var (
+	// Greedy fills items as many as the limit allows, then flushes them on every commit.
+	Greedy Strategy = greedy{}
+	// MaxSize flushes only when the limit is reached, it's maxSizeTx of the configuration.
+	MaxSize Strategy = maxSize{}
+)
+
+type latency struct {
+	d time.Duration
+}
+
+func (s latency) Full(items, events int, item Item) bool {
+	return false
+}
+
+func (s latency) Flush(items, events int, elapsed time.Duration) bool {
+	return elapsed >= s.d
+}
+
+func (s latency) String() string {
+	return fmt.Sprintf("latency(%v)", s.d)
+}
+
+// Latency works as MaxSize, but pending items are flushed on commit after d since the first one was added.
+func Latency(d time.Duration) Strategy {
+	return latency{d}
+}
+
+type maxEvents struct {
+	Strategy
+	n int
+}
+
+func (s maxEvents) Full(items, events int, item Item) bool {
+	return events+NumberOfEvents(item) > s.n || s.Strategy.Full(items, events, item)
+}
+
+func (s maxEvents) Flush(items, events int, elapsed time.Duration) bool {
+	return events >= s.n || s.Strategy.Flush(items, events, elapsed)
+}
+
+func (s maxEvents) String() string {
+	return fmt.Sprintf("%v,max_events(%d)", s.Strategy, s.n)
+}
+
+// MaxEvents limits the number of events in a segment to n on the base Strategy,
+// an item which has more events than n is not split, so it's flushed alone.
+func MaxEvents(n int, base Strategy) Strategy {
+	return maxEvents{Strategy: base, n: n}
+}
+
+// Config is the configuration of segmentation which is shared by relay and bridge.
+type Config struct {
+	MaxSizeTx    bool `json:"maxSizeTx"`
+	FlushLatency int  `json:"flush_latency,omitempty"` //milliseconds to flush pending items with MaxSizeTx, disabled if zero
+	MaxEventsTx  int  `json:"max_events_tx,omitempty"` //max number of events in a transaction, unlimited if zero
+}
+
+// Strategy returns Strategy of the configuration, it's Greedy by default.
+func (c *Config) Strategy() Strategy {
+	st := Greedy
+	if c.MaxSizeTx {
+		st = MaxSize
+		if c.FlushLatency > 0 {
+			st = Latency(time.Duration(c.FlushLatency) * time.Millisecond)
+		}
+	}
+	if c.MaxEventsTx > 0 {
+		st = MaxEvents(c.MaxEventsTx, st)
+	}
+	return st
+}
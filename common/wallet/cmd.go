@@ -0,0 +1,354 @@
+/*
+ * Copyright 2022 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/icon-project/btp/common/cli"
+	"github.com/icon-project/btp/common/crypto"
+	"github.com/icon-project/btp/common/errors"
+)
+
+// KeyStoreInfo is the result of inspection, it's printed without decryption.
+type KeyStoreInfo struct {
+	Address   string `json:"address"`
+	CoinType  string `json:"coinType"`
+	ID        string `json:"id,omitempty"`
+	Version   int    `json:"version"`
+	Cipher    string `json:"cipher"`
+	KDF       string `json:"kdf"`
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+// InspectKeyStore returns KeyStoreInfo of ICON or Ethereum KeyStore, the address of Ethereum is checksummed.
+func InspectKeyStore(data []byte) (*KeyStoreInfo, error) {
+	var ks struct {
+		Address  string `json:"address"`
+		ID       string `json:"id"`
+		Version  int    `json:"version"`
+		CoinType string `json:"coinType"`
+		Crypto   struct {
+			Cipher string `json:"cipher"`
+			KDF    string `json:"kdf"`
+		} `json:"crypto"`
+	}
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, err
+	}
+	info := &KeyStoreInfo{
+		Address:  ks.Address,
+		CoinType: ks.CoinType,
+		ID:       ks.ID,
+		Version:  ks.Version,
+		Cipher:   ks.Crypto.Cipher,
+		KDF:      ks.Crypto.KDF,
+	}
+	switch ks.CoinType {
+	case coinTypeICON:
+		addr, err := ReadAddressFromKeyStore(data)
+		if err != nil {
+			return nil, err
+		}
+		info.Address = addr.String()
+	case coinTypeEVM, "":
+		info.CoinType = coinTypeEVM
+		if !ethcommon.IsHexAddress(ks.Address) {
+			return nil, errors.Errorf("InvalidAddress(address=%s)", ks.Address)
+		}
+		info.Address = ethcommon.HexToAddress(ks.Address).Hex()
+	default:
+		return nil, errors.Errorf("InvalidCoinType(coin=%s)", ks.CoinType)
+	}
+	return info, nil
+}
+
+// parseSecret returns the raw private key from hex string with or without '0x' prefix.
+func parseSecret(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid hex of private key")
+	}
+	if _, err = ethcrypto.ToECDSA(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// readSecret reads the private key in raw 32 bytes or hex from key_file or standard input,
+// it's not taken from arguments which are exposed by shell history and process list.
+func readSecret(cmd *cobra.Command) ([]byte, error) {
+	var b []byte
+	var err error
+	if keyFile, _ := cmd.Flags().GetString("key_file"); keyFile != "" {
+		b, err = ioutil.ReadFile(keyFile)
+	} else if fd := int(os.Stdin.Fd()); cmd.InOrStdin() == os.Stdin && terminal.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Private key: ")
+		b, err = terminal.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+	} else {
+		b, err = ioutil.ReadAll(cmd.InOrStdin())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 32 {
+		if _, err = ethcrypto.ToECDSA(b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	return parseSecret(string(b))
+}
+
+func generateSecret(coinType string) ([]byte, error) {
+	switch coinType {
+	case coinTypeICON:
+		sk, _ := crypto.GenerateKeyPair()
+		return sk.Bytes(), nil
+	case coinTypeEVM:
+		sk, err := ethcrypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		return ethcrypto.FromECDSA(sk), nil
+	default:
+		return nil, errors.Errorf("InvalidCoinType(coin=%s)", coinType)
+	}
+}
+
+// resolvePassword returns the password from the secret file or the flag,
+// it prompts if both are empty and the input is terminal.
+func resolvePassword(fs interface {
+	GetString(name string) (string, error)
+}, secretFlag, passwordFlag, prompt string) ([]byte, error) {
+	if secret, _ := fs.GetString(secretFlag); secret != "" {
+		return ioutil.ReadFile(secret)
+	}
+	if pw, _ := fs.GetString(passwordFlag); pw != "" {
+		return []byte(pw), nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return nil, errors.Errorf("%s or %s is required", passwordFlag, secretFlag)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	pw, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(pw) == 0 {
+		return nil, errors.New("empty password")
+	}
+	return pw, nil
+}
+
+// writeKeyStore writes KeyStore to the file, or w if the file is empty.
+// the file is replaced by rename, so the previous one is never truncated on failure.
+func writeKeyStore(w io.Writer, file string, ks []byte) error {
+	if file == "" {
+		_, err := fmt.Fprintln(w, string(ks))
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, ks, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// NewKeyStoreCommand returns keystore command which manages KeyStore files of ICON and Ethereum.
+func NewKeyStoreCommand(parentCmd *cobra.Command, parentVc *viper.Viper) *cobra.Command {
+	rootCmd, _ := cli.NewCommand(parentCmd, parentVc, "keystore", "Manage KeyStore")
+	//configuration of parent is not required
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return nil
+	}
+
+	// encrypt writes KeyStore of the secret and prints the address.
+	encrypt := func(cmd *cobra.Command, coinType string, secret []byte) error {
+		pw, err := resolvePassword(cmd.Flags(), "new_secret", "new_password", "New password: ")
+		if err != nil {
+			return err
+		}
+		ks, err := EncryptSecretAsKeyStore(coinType, secret, pw)
+		if err != nil {
+			return err
+		}
+		out, _ := cmd.Flags().GetString("out")
+		if err = writeKeyStore(cmd.OutOrStdout(), out, ks); err != nil {
+			return err
+		}
+		info, err := InspectKeyStore(ks)
+		if err != nil {
+			return err
+		}
+		if out != "" {
+			fmt.Fprintln(cmd.OutOrStdout(), info.Address)
+		} else {
+			fmt.Fprintln(cmd.ErrOrStderr(), info.Address)
+		}
+		return nil
+	}
+	decrypt := func(cmd *cobra.Command, file string) ([]byte, string, error) {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, "", err
+		}
+		pw, err := resolvePassword(cmd.Flags(), "secret", "password", "Password: ")
+		if err != nil {
+			return nil, "", err
+		}
+		return DecryptKeyStoreAsSecret(b, pw)
+	}
+	outputFlags := func(cmd *cobra.Command) *cobra.Command {
+		cmd.Flags().String("out", "", "Output KeyStore file, standard output if empty")
+		cmd.Flags().String("new_password", "", "Password of output KeyStore")
+		cmd.Flags().String("new_secret", "", "Secret(password) file of output KeyStore")
+		return cmd
+	}
+	inputFlags := func(cmd *cobra.Command) *cobra.Command {
+		cmd.Flags().String("password", "", "Password of KeyStore")
+		cmd.Flags().String("secret", "", "Secret(password) file of KeyStore")
+		return cmd
+	}
+	typeFlag := func(cmd *cobra.Command, usage string) *cobra.Command {
+		cmd.Flags().String("type", coinTypeICON, usage)
+		return cmd
+	}
+
+	rootCmd.AddCommand(typeFlag(outputFlags(&cobra.Command{
+		Use:   "new",
+		Short: "Generate a key and write KeyStore",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			coinType, _ := cmd.Flags().GetString("type")
+			secret, err := generateSecret(coinType)
+			if err != nil {
+				return err
+			}
+			return encrypt(cmd, coinType, secret)
+		},
+	}), "Type of KeyStore (icx,evm)"))
+
+	importCmd := typeFlag(outputFlags(&cobra.Command{
+		Use:   "import",
+		Short: "Import a private key from key_file or standard input and write KeyStore",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			coinType, _ := cmd.Flags().GetString("type")
+			secret, err := readSecret(cmd)
+			if err != nil {
+				return err
+			}
+			return encrypt(cmd, coinType, secret)
+		},
+	}), "Type of KeyStore (icx,evm)")
+	importCmd.Flags().String("key_file", "", "File of private key in raw 32 bytes or hex, standard input if empty")
+	rootCmd.AddCommand(importCmd)
+
+	exportCmd := outputFlags(inputFlags(&cobra.Command{
+		Use:   "export KEYSTORE",
+		Short: "Convert KeyStore to the other type, ICON KeyStore to Ethereum KeyStore or vice versa",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			secret, srcType, err := decrypt(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			coinType, _ := cmd.Flags().GetString("type")
+			if coinType == "" {
+				coinType = coinTypeICON
+				if srcType == coinTypeICON {
+					coinType = coinTypeEVM
+				}
+			}
+			return encrypt(cmd, coinType, secret)
+		},
+	}))
+	exportCmd.Flags().String("type", "", "Type of output KeyStore (icx,evm), the other type of KEYSTORE if empty")
+	rootCmd.AddCommand(exportCmd)
+
+	inspectCmd := inputFlags(&cobra.Command{
+		Use:   "inspect KEYSTORE",
+		Short: "Print address and parameters of KeyStore, it's decrypted with verify flag",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			info, err := InspectKeyStore(b)
+			if err != nil {
+				return err
+			}
+			if verify, _ := cmd.Flags().GetBool("verify"); verify {
+				pw, err := resolvePassword(cmd.Flags(), "secret", "password", "Password: ")
+				if err != nil {
+					return err
+				}
+				w, err := DecryptKeyStore(b, pw)
+				if err != nil {
+					return err
+				}
+				info.PublicKey = hex.EncodeToString(w.PublicKey())
+			}
+			return cli.JsonPrettyPrintln(cmd.OutOrStdout(), info)
+		},
+	})
+	inspectCmd.Flags().Bool("verify", false, "Decrypt KeyStore and print public key")
+	rootCmd.AddCommand(inspectCmd)
+
+	changeCmd := outputFlags(inputFlags(&cobra.Command{
+		Use:   "change-password KEYSTORE",
+		Short: "Change password of KeyStore, the file is replaced if out is empty",
+		Args:  cli.ArgsWithDefaultErrorFunc(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			secret, coinType, err := decrypt(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			if out, _ := cmd.Flags().GetString("out"); out == "" {
+				if err = cmd.Flags().Set("out", args[0]); err != nil {
+					return err
+				}
+			}
+			return encrypt(cmd, coinType, secret)
+		},
+	}))
+	changeCmd.Flags().Lookup("out").Usage = "Output KeyStore file, KEYSTORE is replaced if empty"
+	rootCmd.AddCommand(changeCmd)
+	return rootCmd
+}
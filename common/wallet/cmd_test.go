@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func executeKeyStoreCommand(args ...string) (string, error) {
+	return executeKeyStoreCommandWithInput("", args...)
+}
+
+func executeKeyStoreCommandWithInput(in string, args ...string) (string, error) {
+	rootCmd := &cobra.Command{Use: "test"}
+	NewKeyStoreCommand(rootCmd, viper.New())
+	out := new(bytes.Buffer)
+	rootCmd.SetIn(strings.NewReader(in))
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(ioutil.Discard)
+	rootCmd.SetArgs(append([]string{"keystore"}, args...))
+	err := rootCmd.Execute()
+	return strings.TrimSpace(out.String()), err
+}
+
+func TestKeyStoreCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	icxFile := filepath.Join(dir, "icx.json")
+	evmFile := filepath.Join(dir, "evm.json")
+
+	//import ICON KeyStore
+	w := New()
+	secret := w.skey.Bytes()
+	addr, err := executeKeyStoreCommandWithInput("0x"+hex.EncodeToString(secret)+"\n", "import",
+		"--out", icxFile, "--new_password", "pw1")
+	assert.NoError(t, err)
+	assert.Equal(t, w.Address(), addr)
+
+	//private key is not taken from arguments
+	_, err = executeKeyStoreCommand("import", "0x"+hex.EncodeToString(secret), "--new_password", "pw1")
+	assert.Error(t, err)
+	keyFile := filepath.Join(dir, "key")
+	assert.NoError(t, ioutil.WriteFile(keyFile, secret, 0600))
+	addr, err = executeKeyStoreCommand("import", "--key_file", keyFile,
+		"--out", filepath.Join(dir, "key.json"), "--new_password", "pw1")
+	assert.NoError(t, err)
+	assert.Equal(t, w.Address(), addr)
+
+	out, err := executeKeyStoreCommand("inspect", icxFile, "--verify", "--password", "pw1")
+	assert.NoError(t, err)
+	assert.Contains(t, out, w.Address())
+	assert.Contains(t, out, hex.EncodeToString(w.PublicKey()))
+	_, err = executeKeyStoreCommand("inspect", icxFile, "--verify", "--password", "invalid")
+	assert.Error(t, err)
+
+	//convert to Ethereum KeyStore of the same key, the type is the other one by default
+	addr, err = executeKeyStoreCommand("export", icxFile,
+		"--password", "pw1", "--out", evmFile, "--new_password", "pw2")
+	assert.NoError(t, err)
+	b, err := ioutil.ReadFile(evmFile)
+	assert.NoError(t, err)
+	ew, err := DecryptKeyStore(b, []byte("pw2"))
+	assert.NoError(t, err)
+	assert.Equal(t, ew.Address(), addr)
+	esecret, coinType, err := DecryptKeyStoreAsSecret(b, []byte("pw2"))
+	assert.NoError(t, err)
+	assert.Equal(t, coinTypeEVM, coinType)
+	assert.Equal(t, secret, esecret)
+	out, err = executeKeyStoreCommand("export", evmFile, "--password", "pw2", "--new_password", "pw2")
+	assert.NoError(t, err)
+	info, err := InspectKeyStore([]byte(out))
+	assert.NoError(t, err)
+	assert.Equal(t, coinTypeICON, info.CoinType)
+	assert.Equal(t, string(w.Address()), info.Address)
+	_, err = executeKeyStoreCommand("export", icxFile, "--type", coinTypeICON,
+		"--password", "pw1", "--new_password", "pw2")
+	assert.NoError(t, err)
+
+	//rotate password in place
+	_, err = executeKeyStoreCommand("change-password", icxFile, "--password", "pw1", "--new_password", "pw3")
+	assert.NoError(t, err)
+	b, err = ioutil.ReadFile(icxFile)
+	assert.NoError(t, err)
+	_, err = DecryptKeyStore(b, []byte("pw1"))
+	assert.Error(t, err)
+	iw, err := DecryptKeyStore(b, []byte("pw3"))
+	assert.NoError(t, err)
+	assert.Equal(t, w.Address(), iw.Address())
+
+	//new key to standard output
+	out, err = executeKeyStoreCommand("new", "--new_password", "pw")
+	assert.NoError(t, err)
+	info, err = InspectKeyStore([]byte(out))
+	assert.NoError(t, err)
+	assert.Equal(t, coinTypeICON, info.CoinType)
+
+	_, err = executeKeyStoreCommand("new", "--type", "invalid", "--new_password", "pw")
+	assert.Error(t, err)
+	_, err = executeKeyStoreCommandWithInput("0x1234", "import", "--new_password", "pw")
+	assert.Error(t, err)
+}
@@ -76,7 +76,8 @@ func NewEncrypted(w Wallet, pubKey, b []byte) (*Encrypted, error) {
 		return nil, err
 	}
 	s.XORKeyStream(e.CipherText, b)
-	copy(e.Param[EncryptSaltSize:], w.PublicKey())
+	//public key of w could have different length with pubKey
+	e.Param = append(e.Param[:EncryptSaltSize], w.PublicKey()...)
 	return e, nil
 }
 
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/gofrs/uuid"
 	"golang.org/x/crypto/scrypt"
 	"golang.org/x/crypto/sha3"
@@ -232,6 +233,50 @@ func DecryptICONKeyStore(ksData *KeyStoreData, pw []byte) (*crypto.PrivateKey, e
 	return secret, nil
 }
 
+// DecryptKeyStoreAsSecret returns the raw private key and the coin type of KeyStore.
+func DecryptKeyStoreAsSecret(data, pw []byte) ([]byte, string, error) {
+	ksdata, err := NewKeyStoreData(data)
+	if err != nil {
+		return nil, "", err
+	}
+	switch ksdata.CoinType {
+	case coinTypeICON:
+		secret, err := DecryptICONKeyStore(ksdata, pw)
+		if err != nil {
+			return nil, "", err
+		}
+		return secret.Bytes(), coinTypeICON, nil
+	case coinTypeEVM, "":
+		key, err := DecryptEvmKeyStore(data, pw)
+		if err != nil {
+			return nil, "", err
+		}
+		return ethcrypto.FromECDSA(key), coinTypeEVM, nil
+	default:
+		return nil, "", errors.Errorf("InvalidCoinType(coin=%s)", ksdata.CoinType)
+	}
+}
+
+// EncryptSecretAsKeyStore returns KeyStore of the coin type for the raw private key.
+func EncryptSecretAsKeyStore(coinType string, secret, pw []byte) ([]byte, error) {
+	switch coinType {
+	case coinTypeICON:
+		sk, err := crypto.ParsePrivateKey(secret)
+		if err != nil {
+			return nil, err
+		}
+		return EncryptKeyAsKeyStore(sk, pw)
+	case coinTypeEVM:
+		sk, err := ethcrypto.ToECDSA(secret)
+		if err != nil {
+			return nil, err
+		}
+		return EncryptEvmKeyAsKeyStore(sk, pw)
+	default:
+		return nil, errors.Errorf("InvalidCoinType(coin=%s)", coinType)
+	}
+}
+
 func NewKeyStoreData(data []byte) (*KeyStoreData, error) {
 	var ksData KeyStoreData
 	if err := json.Unmarshal(data, &ksData); err != nil {
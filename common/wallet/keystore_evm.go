@@ -4,6 +4,8 @@ import (
 	"crypto/ecdsa"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
 )
 
 func DecryptEvmKeyStore(ksData, pw []byte) (*ecdsa.PrivateKey, error) {
@@ -13,3 +15,17 @@ func DecryptEvmKeyStore(ksData, pw []byte) (*ecdsa.PrivateKey, error) {
 	}
 	return key.PrivateKey, nil
 }
+
+// EncryptEvmKeyAsKeyStore returns KeyStore in the format of Ethereum(geth), it doesn't have coinType.
+func EncryptEvmKeyAsKeyStore(sk *ecdsa.PrivateKey, pw []byte) ([]byte, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	key := &keystore.Key{
+		Id:         id,
+		Address:    ethcrypto.PubkeyToAddress(sk.PublicKey),
+		PrivateKey: sk,
+	}
+	return keystore.EncryptKey(key, string(pw), keystore.StandardScryptN, keystore.StandardScryptP)
+}
@@ -0,0 +1,155 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/icon-project/btp/common"
+	"github.com/icon-project/btp/common/crypto"
+	"github.com/icon-project/btp/common/errors"
+)
+
+var (
+	secp256k1N     = ethcrypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+	// secp256k1OID is DER encoded object identifier of secp256k1 for CKA_EC_PARAMS, 1.3.132.0.10
+	secp256k1OID = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}
+)
+
+// PKCS11Config is the reference of the key in PKCS#11 token, it's saved instead of KeyStore.
+// the token is selected by Slot or TokenLabel, the private and public keys have the same KeyLabel.
+// CoinType is 'icx' or 'evm' for the format of address and public key.
+type PKCS11Config struct {
+	Library    string `json:"library"`
+	Slot       *uint  `json:"slot,omitempty"`
+	TokenLabel string `json:"token_label,omitempty"`
+	KeyLabel   string `json:"key_label"`
+	CoinType   string `json:"coin_type"`
+	PIN        string `json:"pin,omitempty"`
+	PINFile    string `json:"pin_file,omitempty"`
+}
+
+// Enabled returns true if the library of PKCS#11 is given, it's safe on nil.
+func (c *PKCS11Config) Enabled() bool {
+	return c != nil && c.Library != ""
+}
+
+func (c *PKCS11Config) pin() (string, error) {
+	if c.PINFile != "" {
+		b, err := ioutil.ReadFile(c.PINFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return c.PIN, nil
+}
+
+func (c *PKCS11Config) validate() error {
+	if !c.Enabled() {
+		return errors.IllegalArgumentError.New("empty library of PKCS#11")
+	}
+	if c.Slot == nil && c.TokenLabel == "" {
+		return errors.IllegalArgumentError.New("slot or token_label is required")
+	}
+	if c.KeyLabel == "" {
+		return errors.IllegalArgumentError.New("key_label is required")
+	}
+	switch c.CoinType {
+	case coinTypeICON, coinTypeEVM:
+		return nil
+	default:
+		return errors.IllegalArgumentError.Errorf("InvalidCoinType(coin=%s)", c.CoinType)
+	}
+}
+
+// ecPointOf returns the uncompressed point from CKA_EC_POINT,
+// it's DER encoded OCTET STRING though some tokens return the raw point.
+func ecPointOf(b []byte) ([]byte, error) {
+	var p []byte
+	if rest, err := asn1.Unmarshal(b, &p); err != nil || len(rest) > 0 {
+		p = b
+	}
+	if _, err := ethcrypto.UnmarshalPubkey(p); err != nil {
+		return nil, errors.Wrap(err, "invalid EC point")
+	}
+	return p, nil
+}
+
+// rsvOf returns the signature in [R|S|V] format from [R|S] signed by CKM_ECDSA,
+// S is normalized to the lower half of the order and V is found by recovering the public key.
+func rsvOf(rs, hash, pubKey []byte) ([]byte, error) {
+	if len(rs) != 64 {
+		return nil, errors.Errorf("invalid signature length:%d", len(rs))
+	}
+	s := new(big.Int).SetBytes(rs[32:])
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s.Sub(secp256k1N, s)
+	}
+	sig := make([]byte, 65)
+	copy(sig, rs[:32])
+	sb := s.Bytes()
+	copy(sig[64-len(sb):64], sb)
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		if pk, err := ethcrypto.Ecrecover(hash, sig); err == nil && bytes.Equal(pk, pubKey) {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("fail to recover public key from signature")
+}
+
+// pkcs11Key is the public part of the key in PKCS#11 token.
+type pkcs11Key struct {
+	coinType string
+	pubKey   []byte
+}
+
+func (k *pkcs11Key) Address() string {
+	if k.coinType == coinTypeICON {
+		pk, _ := crypto.ParsePublicKey(k.pubKey)
+		return common.NewAccountAddressFromPublicKey(pk).String()
+	}
+	pk, _ := ethcrypto.UnmarshalPubkey(k.pubKey)
+	return ethcrypto.PubkeyToAddress(*pk).Hex()
+}
+
+// PublicKey returns the public key in compressed format for ICON, uncompressed format for EVM.
+func (k *pkcs11Key) PublicKey() []byte {
+	if k.coinType == coinTypeICON {
+		pk, _ := ethcrypto.UnmarshalPubkey(k.pubKey)
+		return ethcrypto.CompressPubkey(pk)
+	}
+	return k.pubKey
+}
+
+func signTransaction(w Wallet, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	sig, err := w.Sign(signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// EnsurePKCS11Key checks the key in the token, the key is generated if it doesn't exist.
+func EnsurePKCS11Key(cfg *PKCS11Config) error {
+	w, err := NewPKCS11Wallet(cfg)
+	if errors.NotFoundError.Equals(err) {
+		if err = GeneratePKCS11Key(cfg); err != nil {
+			return err
+		}
+		w, err = NewPKCS11Wallet(cfg)
+	}
+	if err != nil {
+		return err
+	}
+	w.Close()
+	return nil
+}
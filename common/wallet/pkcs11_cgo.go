@@ -0,0 +1,215 @@
+//go:build cgo
+// +build cgo
+
+package wallet
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/miekg/pkcs11"
+
+	"github.com/icon-project/btp/common/errors"
+)
+
+// PKCS11Wallet is Wallet which signs by the key in PKCS#11 token, the private key never leaves the token.
+type PKCS11Wallet struct {
+	pkcs11Key
+	mtx  sync.Mutex
+	ctx  *pkcs11.Ctx
+	sh   pkcs11.SessionHandle
+	skey pkcs11.ObjectHandle
+}
+
+var _ Wallet = (*PKCS11Wallet)(nil)
+var _ TransactionSigner = (*PKCS11Wallet)(nil)
+
+// Sign returns the signature of the 32 bytes hash in [R|S|V] format, V is 0 or 1.
+func (w *PKCS11Wallet) Sign(data []byte) ([]byte, error) {
+	if len(data) != 32 {
+		return nil, errors.IllegalArgumentError.Errorf("invalid hash length:%d", len(data))
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if err := w.ctx.SignInit(w.sh, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, w.skey); err != nil {
+		return nil, errors.Wrap(err, "fail to SignInit")
+	}
+	rs, err := w.ctx.Sign(w.sh, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to Sign")
+	}
+	return rsvOf(rs, data, w.pubKey)
+}
+
+// SignTransaction returns the transaction signed for the chain.
+func (w *PKCS11Wallet) SignTransaction(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signTransaction(w, tx, chainID)
+}
+
+// ECDH is not supported, the derived key of CKM_ECDH1_DERIVE has only X coordinate of the shared point.
+func (w *PKCS11Wallet) ECDH(pubKey []byte) ([]byte, error) {
+	return nil, errors.UnsupportedError.New("ECDH is not supported by PKCS11Wallet")
+}
+
+// Close logs out and releases the token.
+func (w *PKCS11Wallet) Close() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	_ = w.ctx.Logout(w.sh)
+	_ = w.ctx.CloseSession(w.sh)
+	_ = w.ctx.Finalize()
+	w.ctx.Destroy()
+}
+
+func findSlot(ctx *pkcs11.Ctx, cfg *PKCS11Config) (uint, error) {
+	if cfg.Slot != nil {
+		return *cfg.Slot, nil
+	}
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+	for _, slot := range slots {
+		ti, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, err
+		}
+		if ti.Label == cfg.TokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, errors.NotFoundError.Errorf("token not found label:%s", cfg.TokenLabel)
+}
+
+func findObject(ctx *pkcs11.Ctx, sh pkcs11.SessionHandle, class uint, label string) (pkcs11.ObjectHandle, error) {
+	if err := ctx.FindObjectsInit(sh, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}); err != nil {
+		return 0, err
+	}
+	objs, _, err := ctx.FindObjects(sh, 2)
+	if fErr := ctx.FindObjectsFinal(sh); err == nil {
+		err = fErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	switch len(objs) {
+	case 0:
+		return 0, errors.NotFoundError.Errorf("key not found class:%d label:%s", class, label)
+	case 1:
+		return objs[0], nil
+	default:
+		return 0, errors.InvalidStateError.Errorf("duplicated key class:%d label:%s", class, label)
+	}
+}
+
+// openPKCS11 returns the session of the token which is logged in as user.
+func openPKCS11(cfg *PKCS11Config) (*pkcs11.Ctx, pkcs11.SessionHandle, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, 0, err
+	}
+	pin, err := cfg.pin()
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx := pkcs11.New(cfg.Library)
+	if ctx == nil {
+		return nil, 0, errors.NotFoundError.Errorf("fail to load PKCS#11 library:%s", cfg.Library)
+	}
+	if err = ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, 0, errors.Wrap(err, "fail to Initialize")
+	}
+	var sh pkcs11.SessionHandle
+	if slot, err := findSlot(ctx, cfg); err != nil {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+		return nil, 0, err
+	} else if sh, err = ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION); err != nil {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+		return nil, 0, errors.Wrap(err, "fail to OpenSession")
+	}
+	if err = ctx.Login(sh, pkcs11.CKU_USER, pin); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		_ = ctx.CloseSession(sh)
+		_ = ctx.Finalize()
+		ctx.Destroy()
+		return nil, 0, errors.Wrap(err, "fail to Login")
+	}
+	return ctx, sh, nil
+}
+
+// NewPKCS11Wallet returns the wallet for the key in the token, the session is kept until Close.
+func NewPKCS11Wallet(cfg *PKCS11Config) (*PKCS11Wallet, error) {
+	ctx, sh, err := openPKCS11(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w := &PKCS11Wallet{ctx: ctx, sh: sh}
+	w.coinType = cfg.CoinType
+	if err = w.load(cfg.KeyLabel); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *PKCS11Wallet) load(label string) error {
+	var err error
+	if w.skey, err = findObject(w.ctx, w.sh, pkcs11.CKO_PRIVATE_KEY, label); err != nil {
+		return err
+	}
+	pkey, err := findObject(w.ctx, w.sh, pkcs11.CKO_PUBLIC_KEY, label)
+	if err != nil {
+		return err
+	}
+	attrs, err := w.ctx.GetAttributeValue(w.sh, pkey, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return errors.Wrap(err, "fail to get CKA_EC_POINT")
+	}
+	w.pubKey, err = ecPointOf(attrs[0].Value)
+	return err
+}
+
+// GeneratePKCS11Key generates secp256k1 key pair in the token with the label,
+// the private key is not extractable.
+func GeneratePKCS11Key(cfg *PKCS11Config) error {
+	ctx, sh, err := openPKCS11(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = ctx.Logout(sh)
+		_ = ctx.CloseSession(sh)
+		_ = ctx.Finalize()
+		ctx.Destroy()
+	}()
+	if _, err = findObject(ctx, sh, pkcs11.CKO_PRIVATE_KEY, cfg.KeyLabel); err == nil {
+		return errors.InvalidStateError.Errorf("key already exists label:%s", cfg.KeyLabel)
+	}
+	_, _, err = ctx.GenerateKeyPair(sh,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1OID),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, cfg.KeyLabel),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, cfg.KeyLabel),
+		})
+	if err != nil {
+		return errors.Wrap(err, "fail to GenerateKeyPair")
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+//go:build cgo
+// +build cgo
+
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/crypto"
+)
+
+const (
+	testTokenLabel = "btp"
+	testSOPIN      = "1234"
+	testUserPIN    = "5678"
+)
+
+// softHSMLibraries are well-known paths of SoftHSM, SOFTHSM2_LIB is used if it's given.
+var softHSMLibraries = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+// newTestSoftHSM initializes the token of SoftHSM in the temporary directory, it skips the test if there is no SoftHSM.
+func newTestSoftHSM(t *testing.T) (string, func()) {
+	lib := os.Getenv("SOFTHSM2_LIB")
+	for _, p := range softHSMLibraries {
+		if lib != "" {
+			break
+		}
+		if _, err := os.Stat(p); err == nil {
+			lib = p
+		}
+	}
+	if lib == "" {
+		t.Skip("SoftHSM is not installed, set SOFTHSM2_LIB")
+	}
+	dir, err := ioutil.TempDir("", "softhsm")
+	assert.NoError(t, err)
+	tokenDir := filepath.Join(dir, "tokens")
+	assert.NoError(t, os.Mkdir(tokenDir, 0700))
+	conf := filepath.Join(dir, "softhsm2.conf")
+	assert.NoError(t, ioutil.WriteFile(conf,
+		[]byte(fmt.Sprintf("directories.tokendir = %s\nobjectstore.backend = file\nlog.level = ERROR\n", tokenDir)), 0600))
+	prev, hasPrev := os.LookupEnv("SOFTHSM2_CONF")
+	os.Setenv("SOFTHSM2_CONF", conf)
+
+	ctx := pkcs11.New(lib)
+	assert.NotNil(t, ctx)
+	assert.NoError(t, ctx.Initialize())
+	slots, err := ctx.GetSlotList(true)
+	assert.NoError(t, err)
+	assert.NoError(t, ctx.InitToken(slots[0], testSOPIN, testTokenLabel))
+	slots, err = ctx.GetSlotList(true)
+	assert.NoError(t, err)
+	for _, slot := range slots {
+		if ti, err := ctx.GetTokenInfo(slot); err == nil && ti.Label == testTokenLabel {
+			sh, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+			assert.NoError(t, err)
+			assert.NoError(t, ctx.Login(sh, pkcs11.CKU_SO, testSOPIN))
+			assert.NoError(t, ctx.InitPIN(sh, testUserPIN))
+			assert.NoError(t, ctx.Logout(sh))
+			assert.NoError(t, ctx.CloseSession(sh))
+		}
+	}
+	assert.NoError(t, ctx.Finalize())
+	ctx.Destroy()
+	return lib, func() {
+		if hasPrev {
+			os.Setenv("SOFTHSM2_CONF", prev)
+		} else {
+			os.Unsetenv("SOFTHSM2_CONF")
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPKCS11Wallet_SoftHSM(t *testing.T) {
+	lib, cleanup := newTestSoftHSM(t)
+	defer cleanup()
+
+	cfg := &PKCS11Config{
+		Library:    lib,
+		TokenLabel: testTokenLabel,
+		KeyLabel:   "relay",
+		CoinType:   coinTypeEVM,
+		PIN:        testUserPIN,
+	}
+	_, err := NewPKCS11Wallet(cfg)
+	assert.Error(t, err)
+	assert.NoError(t, EnsurePKCS11Key(cfg))
+	assert.Error(t, GeneratePKCS11Key(cfg))
+
+	//EVM
+	w, err := NewPKCS11Wallet(cfg)
+	assert.NoError(t, err)
+	hash := crypto.SHA3Sum256([]byte("message"))
+	for i := 0; i < 8; i++ {
+		sig, err := w.Sign(hash)
+		assert.NoError(t, err)
+		pk, err := ethcrypto.Ecrecover(hash, sig)
+		assert.NoError(t, err)
+		assert.Equal(t, w.PublicKey(), pk)
+		assert.True(t, new(big.Int).SetBytes(sig[32:64]).Cmp(secp256k1HalfN) <= 0)
+	}
+	chainID := big.NewInt(97)
+	to := common.HexToAddress("0x0000000000000000000000000000000000001000")
+	tx, err := w.SignTransaction(types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(0)}), chainID)
+	assert.NoError(t, err)
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	assert.NoError(t, err)
+	assert.Equal(t, w.Address(), from.Hex())
+	evmPubKey := w.PublicKey()
+	w.Close()
+
+	//ICON with the same key
+	cfg.CoinType = coinTypeICON
+	w, err = NewPKCS11Wallet(cfg)
+	assert.NoError(t, err)
+	defer w.Close()
+	assert.Equal(t, ethcrypto.CompressPubkey(mustUnmarshalPubkey(t, evmPubKey)), w.PublicKey())
+	sig, err := w.Sign(hash)
+	assert.NoError(t, err)
+	s, err := crypto.ParseSignature(sig)
+	assert.NoError(t, err)
+	pk, err := s.RecoverPublicKey(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, w.PublicKey(), pk.SerializeCompressed())
+	assert.Equal(t, w.Address(), (&softwareWallet{pkey: pk}).Address())
+}
+
+func mustUnmarshalPubkey(t *testing.T, b []byte) *ecdsa.PublicKey {
+	pk, err := ethcrypto.UnmarshalPubkey(b)
+	assert.NoError(t, err)
+	return pk
+}
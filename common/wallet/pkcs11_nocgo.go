@@ -0,0 +1,42 @@
+//go:build !cgo
+// +build !cgo
+
+package wallet
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/icon-project/btp/common/errors"
+)
+
+// PKCS11Wallet requires cgo to load PKCS#11 library, every function returns UnsupportedError.
+type PKCS11Wallet struct {
+	pkcs11Key
+}
+
+var errPKCS11NotSupported = errors.UnsupportedError.New("PKCS#11 is not supported, build with CGO_ENABLED=1")
+
+func (w *PKCS11Wallet) Sign(data []byte) ([]byte, error) {
+	return nil, errPKCS11NotSupported
+}
+
+func (w *PKCS11Wallet) SignTransaction(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, errPKCS11NotSupported
+}
+
+func (w *PKCS11Wallet) ECDH(pubKey []byte) ([]byte, error) {
+	return nil, errPKCS11NotSupported
+}
+
+func (w *PKCS11Wallet) Close() {
+}
+
+func NewPKCS11Wallet(cfg *PKCS11Config) (*PKCS11Wallet, error) {
+	return nil, errPKCS11NotSupported
+}
+
+func GeneratePKCS11Key(cfg *PKCS11Config) error {
+	return errPKCS11NotSupported
+}
@@ -0,0 +1,83 @@
+package wallet
+
+import (
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/crypto"
+)
+
+func TestRsvOf(t *testing.T) {
+	w := newTestEvmWallet(t)
+	hash := crypto.SHA3Sum256([]byte("message"))
+	sig, err := w.Sign(hash)
+	assert.NoError(t, err)
+
+	//[R|S] without V
+	rsv, err := rsvOf(sig[:64], hash, w.PublicKey())
+	assert.NoError(t, err)
+	assert.Equal(t, sig, rsv)
+
+	//S in the upper half of the order is normalized
+	hs := new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(sig[32:64])).Bytes()
+	rs := append(append([]byte{}, sig[:32]...), make([]byte, 32-len(hs))...)
+	rs = append(rs, hs...)
+	rsv, err = rsvOf(rs, hash, w.PublicKey())
+	assert.NoError(t, err)
+	assert.Equal(t, sig, rsv)
+
+	//compatible with common/crypto
+	s, err := crypto.ParseSignature(rsv)
+	assert.NoError(t, err)
+	pk, err := s.RecoverPublicKey(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, w.CompressedPublicKey(), pk.SerializeCompressed())
+
+	_, err = rsvOf(sig[:64], hash, newTestEvmWallet(t).PublicKey())
+	assert.Error(t, err)
+	_, err = rsvOf(sig, hash, w.PublicKey())
+	assert.Error(t, err)
+}
+
+func TestPKCS11Key(t *testing.T) {
+	w := newTestEvmWallet(t)
+	der, err := asn1.Marshal(w.PublicKey())
+	assert.NoError(t, err)
+	for _, b := range [][]byte{der, w.PublicKey()} {
+		p, err := ecPointOf(b)
+		assert.NoError(t, err)
+		assert.Equal(t, w.PublicKey(), p)
+	}
+	_, err = ecPointOf(w.CompressedPublicKey())
+	assert.Error(t, err)
+
+	k := &pkcs11Key{coinType: coinTypeEVM, pubKey: w.PublicKey()}
+	assert.Equal(t, w.Address(), k.Address())
+	assert.Equal(t, w.PublicKey(), k.PublicKey())
+
+	pk, err := crypto.ParsePublicKey(w.PublicKey())
+	assert.NoError(t, err)
+	iw := &softwareWallet{pkey: pk}
+	k = &pkcs11Key{coinType: coinTypeICON, pubKey: w.PublicKey()}
+	assert.Equal(t, iw.Address(), k.Address())
+	assert.Equal(t, iw.PublicKey(), k.PublicKey())
+	assert.Equal(t, ethcrypto.CompressPubkey(w.Pkey), k.PublicKey())
+}
+
+func TestPKCS11Config_validate(t *testing.T) {
+	slot := uint(0)
+	cfgs := []*PKCS11Config{
+		nil,
+		{Library: "lib.so", KeyLabel: "key", CoinType: coinTypeEVM},
+		{Library: "lib.so", Slot: &slot, CoinType: coinTypeEVM},
+		{Library: "lib.so", Slot: &slot, KeyLabel: "key", CoinType: "btc"},
+	}
+	for _, cfg := range cfgs {
+		assert.Error(t, cfg.validate())
+	}
+	assert.NoError(t, (&PKCS11Config{Library: "lib.so", TokenLabel: "token", KeyLabel: "key", CoinType: coinTypeICON}).validate())
+}
@@ -0,0 +1,217 @@
+package wallet
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/icon-project/btp/common/errors"
+)
+
+// JSON-RPC methods of the signer, eth_ methods are compatible with web3signer.
+// btp_signHash signs the 32 bytes hash as it is, ICON transaction hash is signed by it.
+const (
+	RemoteMethodAccounts        = "eth_accounts"
+	RemoteMethodSign            = "eth_sign"
+	RemoteMethodSignTransaction = "eth_signTransaction"
+	RemoteMethodSignHash        = "btp_signHash"
+	RemoteMethodPublicKey       = "btp_publicKey"
+	RemoteMethodECDH            = "btp_ecdh"
+
+	DefaultRemoteTimeout = 10 * time.Second
+	unixNetworkPrefix    = "unix://"
+)
+
+// RemoteConfig is the configuration of the signer which keeps the key,
+// Endpoint is 'http(s)://host:port' or 'unix:///path/to/socket'.
+// Address could be empty if the signer has only one account.
+type RemoteConfig struct {
+	Endpoint string `json:"endpoint"`
+	Address  string `json:"address,omitempty"`
+}
+
+// Enabled returns true if the endpoint of signer is given, it's safe on nil.
+func (c *RemoteConfig) Enabled() bool {
+	return c != nil && c.Endpoint != ""
+}
+
+// SignTxArgs is the parameter of eth_signTransaction.
+type SignTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to,omitempty"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+	ChainID  *hexutil.Big    `json:"chainId"`
+}
+
+// NewSignTxArgs returns the parameter of eth_signTransaction for the transaction.
+func NewSignTxArgs(from common.Address, tx *types.Transaction, chainID *big.Int) *SignTxArgs {
+	return &SignTxArgs{
+		From:     from,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+		ChainID:  (*hexutil.Big)(chainID),
+	}
+}
+
+// Transaction returns the unsigned transaction of args.
+func (a *SignTxArgs) Transaction() *types.Transaction {
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    uint64(a.Nonce),
+		GasPrice: a.GasPrice.ToInt(),
+		Gas:      uint64(a.Gas),
+		To:       a.To,
+		Value:    a.Value.ToInt(),
+		Data:     a.Data,
+	})
+}
+
+// RemoteWallet is Wallet which requests signing to the signer,
+// the private key is never resident in the process.
+type RemoteWallet struct {
+	c       *rpc.Client
+	address string
+	pubKey  []byte
+}
+
+var _ Wallet = (*RemoteWallet)(nil)
+var _ TransactionSigner = (*RemoteWallet)(nil)
+
+func (w *RemoteWallet) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultRemoteTimeout)
+	defer cancel()
+	if err := w.c.CallContext(ctx, result, method, args...); err != nil {
+		return errors.Wrapf(err, "fail to request signer method:%s", method)
+	}
+	return nil
+}
+
+func (w *RemoteWallet) Address() string {
+	return w.address
+}
+
+// Sign returns the signature of the 32 bytes hash in [R|S|V] format, V is 0 or 1.
+func (w *RemoteWallet) Sign(data []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := w.call(&sig, RemoteMethodSignHash, w.address, hexutil.Bytes(data)); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// SignPersonalMessage returns the signature of the message which is hashed by EIP-191, V is 27 or 28.
+func (w *RemoteWallet) SignPersonalMessage(msg []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := w.call(&sig, RemoteMethodSign, w.address, hexutil.Bytes(msg)); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// SignTransaction returns the transaction signed by the signer,
+// it fails if the signer changes the transaction or signs with other account.
+func (w *RemoteWallet) SignTransaction(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	from := common.HexToAddress(w.address)
+	var raw hexutil.Bytes
+	if err := w.call(&raw, RemoteMethodSignTransaction, NewSignTxArgs(from, tx, chainID)); err != nil {
+		return nil, err
+	}
+	stx := new(types.Transaction)
+	if err := stx.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(stx) != signer.Hash(tx) {
+		return nil, errors.InvalidStateError.New("signed transaction is different")
+	}
+	if sender, err := types.Sender(signer, stx); err != nil {
+		return nil, err
+	} else if sender != from {
+		return nil, errors.InvalidStateError.Errorf("signed by other account %s", sender.Hex())
+	}
+	return stx, nil
+}
+
+func (w *RemoteWallet) PublicKey() []byte {
+	return w.pubKey
+}
+
+func (w *RemoteWallet) ECDH(pubKey []byte) ([]byte, error) {
+	var shared hexutil.Bytes
+	if err := w.call(&shared, RemoteMethodECDH, w.address, hexutil.Bytes(pubKey)); err != nil {
+		return nil, err
+	}
+	return shared, nil
+}
+
+func (w *RemoteWallet) Close() {
+	w.c.Close()
+}
+
+func dialSigner(endpoint string) (*rpc.Client, error) {
+	if strings.HasPrefix(endpoint, unixNetworkPrefix) {
+		sockPath := strings.TrimPrefix(endpoint, unixNetworkPrefix)
+		hc := &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", sockPath)
+				},
+			},
+		}
+		return rpc.DialHTTPWithClient("http://localhost", hc)
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	return rpc.DialHTTP(endpoint)
+}
+
+// NewRemoteWallet returns RemoteWallet for the account of the signer,
+// if the address is not given, the signer should have only one account.
+func NewRemoteWallet(cfg *RemoteConfig) (*RemoteWallet, error) {
+	if !cfg.Enabled() {
+		return nil, errors.IllegalArgumentError.New("empty endpoint of signer")
+	}
+	c, err := dialSigner(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	w := &RemoteWallet{c: c}
+	var accounts []string
+	if err = w.call(&accounts, RemoteMethodAccounts); err != nil {
+		c.Close()
+		return nil, err
+	}
+	for _, a := range accounts {
+		if cfg.Address == "" && len(accounts) == 1 || strings.EqualFold(a, cfg.Address) {
+			w.address = a
+		}
+	}
+	if w.address == "" {
+		c.Close()
+		return nil, errors.NotFoundError.Errorf("account not found address:%s accounts:%v", cfg.Address, accounts)
+	}
+	var pubKey hexutil.Bytes
+	if err = w.call(&pubKey, RemoteMethodPublicKey, w.address); err != nil {
+		c.Close()
+		return nil, err
+	}
+	w.pubKey = pubKey
+	return w, nil
+}
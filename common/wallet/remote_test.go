@@ -0,0 +1,138 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/crypto"
+)
+
+// newTestSigner returns the endpoint of Signer for the wallets over HTTP.
+func newTestSigner(t *testing.T, ws ...Wallet) *httptest.Server {
+	s, err := NewSigner(ws...)
+	assert.NoError(t, err)
+	return httptest.NewServer(s)
+}
+
+func TestRemoteWallet(t *testing.T) {
+	ew, iw := newTestEvmWallet(t), New()
+	srv := newTestSigner(t, ew, iw)
+	defer srv.Close()
+
+	//EVM account
+	rw, err := NewRemoteWallet(&RemoteConfig{Endpoint: srv.URL, Address: ew.Address()})
+	assert.NoError(t, err)
+	defer rw.Close()
+	assert.Equal(t, ew.Address(), rw.Address())
+	assert.Equal(t, ew.PublicKey(), rw.PublicKey())
+
+	hash := crypto.SHA3Sum256([]byte("message"))
+	sig, err := rw.Sign(hash)
+	assert.NoError(t, err)
+	pk, err := ethcrypto.Ecrecover(hash, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, ew.PublicKey(), pk)
+
+	sig, err = rw.SignPersonalMessage([]byte("message"))
+	assert.NoError(t, err)
+	esig, err := ew.SignPersonalMessage([]byte("message"))
+	assert.NoError(t, err)
+	assert.Equal(t, esig, sig)
+
+	chainID := big.NewInt(97)
+	to := common.HexToAddress("0x0000000000000000000000000000000000001000")
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(0)})
+	stx, err := NewTransactor(rw, chainID).Signer(common.HexToAddress(ew.Address()), tx)
+	assert.NoError(t, err)
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), stx)
+	assert.NoError(t, err)
+	assert.Equal(t, ew.Address(), from.Hex())
+	assert.Equal(t, tx.Nonce(), stx.Nonce())
+
+	//ICON account, the hash is signed as it is
+	rw, err = NewRemoteWallet(&RemoteConfig{Endpoint: srv.URL, Address: iw.Address()})
+	assert.NoError(t, err)
+	defer rw.Close()
+	assert.Equal(t, iw.Address(), rw.Address())
+	sig, err = rw.Sign(hash)
+	assert.NoError(t, err)
+	s, err := crypto.ParseSignature(sig)
+	assert.NoError(t, err)
+	cpk, err := s.RecoverPublicKey(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, iw.PublicKey(), cpk.SerializeCompressed())
+
+	shared, err := rw.ECDH(ew.PublicKey())
+	assert.NoError(t, err)
+	expected, err := ew.ECDH(iw.PublicKey())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, shared)
+
+	_, err = rw.SignTransaction(tx, chainID)
+	assert.Error(t, err)
+	_, err = rw.SignPersonalMessage([]byte("message"))
+	assert.Error(t, err)
+}
+
+func TestNewRemoteWallet(t *testing.T) {
+	ew, iw := newTestEvmWallet(t), New()
+
+	//the only account is used without address
+	srv := newTestSigner(t, ew)
+	defer srv.Close()
+	rw, err := NewRemoteWallet(&RemoteConfig{Endpoint: srv.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, ew.Address(), rw.Address())
+	rw.Close()
+	_, err = NewRemoteWallet(&RemoteConfig{Endpoint: srv.URL, Address: iw.Address()})
+	assert.Error(t, err)
+
+	//address is required for multiple accounts
+	srv2 := newTestSigner(t, ew, iw)
+	defer srv2.Close()
+	_, err = NewRemoteWallet(&RemoteConfig{Endpoint: srv2.URL})
+	assert.Error(t, err)
+
+	_, err = NewRemoteWallet(&RemoteConfig{})
+	assert.Error(t, err)
+	_, err = NewSigner(ew, ew)
+	assert.Error(t, err)
+}
+
+func TestRemoteWallet_Unix(t *testing.T) {
+	w := newTestEvmWallet(t)
+	s, err := NewSigner(w)
+	assert.NoError(t, err)
+	dir, err := ioutil.TempDir("", "signer")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sockPath := filepath.Join(dir, "signer.sock")
+	l, err := net.Listen("unix", sockPath)
+	assert.NoError(t, err)
+	defer l.Close()
+	go func() {
+		_ = http.Serve(l, s)
+	}()
+
+	rw, err := NewRemoteWallet(&RemoteConfig{Endpoint: "unix://" + sockPath})
+	assert.NoError(t, err)
+	defer rw.Close()
+	assert.Equal(t, w.Address(), rw.Address())
+	hash := crypto.SHA3Sum256([]byte("message"))
+	sig, err := rw.Sign(hash)
+	assert.NoError(t, err)
+	pk, err := ethcrypto.Ecrecover(hash, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, w.PublicKey(), pk)
+}
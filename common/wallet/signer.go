@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/icon-project/btp/common/errors"
+)
+
+// Signer serves JSON-RPC methods of RemoteWallet for the wallets, so the keys are kept only in the process of Signer.
+// it's the reference implementation of the signer for testing, requests are not authenticated,
+// so it should be served only on unix socket which is protected by file permission.
+type Signer struct {
+	s     *rpc.Server
+	ws    map[string]Wallet
+	addrs []string
+}
+
+func (s *Signer) wallet(address string) (Wallet, error) {
+	if w, ok := s.ws[strings.ToLower(address)]; ok {
+		return w, nil
+	}
+	return nil, errors.NotFoundError.Errorf("account not found address:%s", address)
+}
+
+// Accounts returns addresses of wallets.
+func (s *Signer) Accounts() []string {
+	return append([]string{}, s.addrs...)
+}
+
+func (s *Signer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.s.ServeHTTP(w, r)
+}
+
+func (s *Signer) Stop() {
+	s.s.Stop()
+}
+
+type personalSigner interface {
+	SignPersonalMessage(msg []byte) ([]byte, error)
+}
+
+type signerEthService struct {
+	s *Signer
+}
+
+func (e *signerEthService) Accounts() []string {
+	return e.s.Accounts()
+}
+
+func (e *signerEthService) Sign(address string, data hexutil.Bytes) (hexutil.Bytes, error) {
+	w, err := e.s.wallet(address)
+	if err != nil {
+		return nil, err
+	}
+	ps, ok := w.(personalSigner)
+	if !ok {
+		return nil, errors.UnsupportedError.Errorf("not supported wallet address:%s", address)
+	}
+	return ps.SignPersonalMessage(data)
+}
+
+func (e *signerEthService) SignTransaction(args SignTxArgs) (hexutil.Bytes, error) {
+	w, err := e.s.wallet(args.From.Hex())
+	if err != nil {
+		return nil, err
+	}
+	ts, ok := w.(TransactionSigner)
+	if !ok {
+		return nil, errors.UnsupportedError.Errorf("not supported wallet address:%s", args.From.Hex())
+	}
+	if args.GasPrice == nil || args.ChainID == nil {
+		return nil, errors.IllegalArgumentError.New("gasPrice and chainId are required")
+	}
+	if args.Value == nil {
+		args.Value = new(hexutil.Big)
+	}
+	tx, err := ts.SignTransaction(args.Transaction(), args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	return tx.MarshalBinary()
+}
+
+type signerBtpService struct {
+	s *Signer
+}
+
+func (b *signerBtpService) SignHash(address string, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	w, err := b.s.wallet(address)
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != common.HashLength {
+		return nil, errors.IllegalArgumentError.Errorf("invalid hash length:%d", len(hash))
+	}
+	return w.Sign(hash)
+}
+
+func (b *signerBtpService) PublicKey(address string) (hexutil.Bytes, error) {
+	w, err := b.s.wallet(address)
+	if err != nil {
+		return nil, err
+	}
+	return w.PublicKey(), nil
+}
+
+func (b *signerBtpService) Ecdh(address string, pubKey hexutil.Bytes) (hexutil.Bytes, error) {
+	w, err := b.s.wallet(address)
+	if err != nil {
+		return nil, err
+	}
+	return w.ECDH(pubKey)
+}
+
+// NewSigner returns Signer for the wallets, Signer could be served by net/http.
+func NewSigner(ws ...Wallet) (*Signer, error) {
+	s := &Signer{
+		s:  rpc.NewServer(),
+		ws: make(map[string]Wallet),
+	}
+	for _, w := range ws {
+		k := strings.ToLower(w.Address())
+		if _, ok := s.ws[k]; ok {
+			return nil, errors.IllegalArgumentError.Errorf("duplicated wallet address:%s", w.Address())
+		}
+		s.ws[k] = w
+		s.addrs = append(s.addrs, w.Address())
+	}
+	if err := s.s.RegisterName("eth", &signerEthService{s}); err != nil {
+		return nil, err
+	}
+	if err := s.s.RegisterName("btp", &signerBtpService{s}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
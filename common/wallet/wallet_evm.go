@@ -1,35 +1,110 @@
 package wallet
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
+const (
+	// personalSignV is added to V of the signature for personal message, it follows eth_sign
+	personalSignV = 27
+)
+
 type EvmWallet struct {
 	Skey *ecdsa.PrivateKey
 	Pkey *ecdsa.PublicKey
 }
 
+var _ Wallet = (*EvmWallet)(nil)
+var _ TransactionSigner = (*EvmWallet)(nil)
+
+// TransactionSigner signs EVM transaction without exposing the private key,
+// it's implemented by EvmWallet and RemoteWallet.
+type TransactionSigner interface {
+	Address() string
+	SignTransaction(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// NewTransactor returns bind.TransactOpts which signs the transaction by s.
+func NewTransactor(s TransactionSigner, chainID *big.Int) *bind.TransactOpts {
+	from := common.HexToAddress(s.Address())
+	return &bind.TransactOpts{
+		From: from,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.SignTransaction(tx, chainID)
+		},
+		Context: context.Background(),
+	}
+}
+
 func (w *EvmWallet) Address() string {
 	pubBytes := w.PublicKey()
 	return common.BytesToAddress(crypto.Keccak256(pubBytes[1:])[12:]).Hex()
 }
 
+// Sign returns the signature of the 32 bytes hash in [R|S|V] format, V is 0 or 1.
 func (w *EvmWallet) Sign(data []byte) ([]byte, error) {
-	//TODO: Not implemented yet
-	return nil, errors.New("Not implemented yet")
+	if len(data) != crypto.DigestLength {
+		return nil, errors.New("invalid hash length")
+	}
+	return crypto.Sign(data, w.Skey)
 }
 
+// SignPersonalMessage returns the signature of the message which is hashed by EIP-191,
+// V is 27 or 28 as same as eth_sign.
+func (w *EvmWallet) SignPersonalMessage(msg []byte) ([]byte, error) {
+	sig, err := crypto.Sign(accounts.TextHash(msg), w.Skey)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += personalSignV
+	return sig, nil
+}
+
+// SignTransaction returns the transaction signed for the chain.
+func (w *EvmWallet) SignTransaction(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), w.Skey)
+}
+
+// PublicKey returns the public key in uncompressed format.
 func (w *EvmWallet) PublicKey() []byte {
 	return crypto.FromECDSAPub(w.Pkey)
 }
 
+// CompressedPublicKey returns the public key in compressed format.
+func (w *EvmWallet) CompressedPublicKey() []byte {
+	return crypto.CompressPubkey(w.Pkey)
+}
+
+// ECDH returns the shared point in compressed format, it's compatible with common/crypto.
+// pubKey could be compressed or uncompressed.
 func (w *EvmWallet) ECDH(pubKey []byte) ([]byte, error) {
-	//TODO: Not implemented yet
-	return nil, nil
+	var pk *ecdsa.PublicKey
+	var err error
+	if len(pubKey) == 33 {
+		pk, err = crypto.DecompressPubkey(pubKey)
+	} else {
+		pk, err = crypto.UnmarshalPubkey(pubKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	x, y := crypto.S256().ScalarMult(pk.X, pk.Y, w.Skey.D.Bytes())
+	if x == nil || (x.Sign() == 0 && y.Sign() == 0) {
+		return nil, errors.New("invalid shared point")
+	}
+	return crypto.CompressPubkey(&ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y}), nil
 }
 
 func NewEvmWalletFromPrivateKey(sk *ecdsa.PrivateKey) (*EvmWallet, error) {
@@ -0,0 +1,88 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/icon-project/btp/common/crypto"
+)
+
+func newTestEvmWallet(t *testing.T) *EvmWallet {
+	sk, err := ethcrypto.GenerateKey()
+	assert.NoError(t, err)
+	w, err := NewEvmWalletFromPrivateKey(sk)
+	assert.NoError(t, err)
+	return w
+}
+
+func TestEvmWallet_Sign(t *testing.T) {
+	w := newTestEvmWallet(t)
+	hash := crypto.SHA3Sum256([]byte("message"))
+
+	sig, err := w.Sign(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, 65, len(sig))
+
+	//recovered by go-ethereum
+	pk, err := ethcrypto.Ecrecover(hash, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, w.PublicKey(), pk)
+
+	//recovered by common/crypto
+	s, err := crypto.ParseSignature(sig)
+	assert.NoError(t, err)
+	cpk, err := s.RecoverPublicKey(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, w.PublicKey(), cpk.SerializeUncompressed())
+	assert.Equal(t, w.CompressedPublicKey(), cpk.SerializeCompressed())
+
+	_, err = w.Sign([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestEvmWallet_SignPersonalMessage(t *testing.T) {
+	w := newTestEvmWallet(t)
+	msg := []byte("message")
+
+	sig, err := w.SignPersonalMessage(msg)
+	assert.NoError(t, err)
+	assert.True(t, sig[64] == 27 || sig[64] == 28)
+
+	sig[64] -= 27
+	pk, err := ethcrypto.SigToPub(accounts.TextHash(msg), sig)
+	assert.NoError(t, err)
+	assert.Equal(t, w.Address(), ethcrypto.PubkeyToAddress(*pk).Hex())
+}
+
+func TestEvmWallet_ECDH(t *testing.T) {
+	w := newTestEvmWallet(t)
+	other := newTestEvmWallet(t)
+	iw := New()
+
+	//compatible with common/crypto
+	secret, err := w.ECDH(iw.PublicKey())
+	assert.NoError(t, err)
+	isecret, err := iw.ECDH(w.PublicKey())
+	assert.NoError(t, err)
+	assert.Equal(t, isecret, secret)
+
+	secret, err = w.ECDH(other.PublicKey())
+	assert.NoError(t, err)
+	osecret, err := other.ECDH(w.CompressedPublicKey())
+	assert.NoError(t, err)
+	assert.Equal(t, osecret, secret)
+
+	_, err = w.ECDH([]byte("invalid"))
+	assert.Error(t, err)
+
+	//Encrypted between EVM wallet and ICON wallet
+	b := []byte("secret")
+	e, err := NewEncrypted(w, iw.PublicKey(), b)
+	assert.NoError(t, err)
+	d, err := e.Decrypt(iw)
+	assert.NoError(t, err)
+	assert.Equal(t, b, d)
+}
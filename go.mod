@@ -12,10 +12,12 @@ require (
 	github.com/evalphobia/logrus_fluent v0.5.4
 	github.com/fluent/fluent-logger-golang v1.4.0 // indirect
 	github.com/gofrs/uuid v3.3.0+incompatible
+	github.com/google/uuid v1.1.5
 	github.com/gorilla/websocket v1.4.2
 	github.com/haltingstate/secp256k1-go v0.0.0-20151224084235-572209b26df6
 	github.com/jroimartin/gocui v0.4.0
 	github.com/labstack/echo/v4 v4.1.10
+	github.com/miekg/pkcs11 v1.1.1
 	github.com/mitchellh/mapstructure v1.1.2
 	github.com/nsf/termbox-go v0.0.0-20190325093121-288510b9734e // indirect
 	github.com/pkg/errors v0.9.1